@@ -0,0 +1,45 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/k8sclient"
+)
+
+// capacityHandler serves the effective capacity of the nodes given by the
+// repeated node query parameter, or of all the nodes if none is given, so
+// that external tools can reconcile it with the kubelet's view.
+func capacityHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nodes := r.URL.Query()["node"]
+		capacities, err := k8sclient.NodeCapacities(nodes...)
+		if err == k8sclient.ErrUnknownNode {
+			http.Error(w, fmt.Sprintf("nodes %v: %v", nodes, err), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(capacities)
+	})
+}
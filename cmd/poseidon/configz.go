@@ -0,0 +1,62 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/config"
+	"github.com/kubernetes-sigs/poseidon/pkg/features"
+	"github.com/kubernetes-sigs/poseidon/pkg/runinfo"
+	"github.com/kubernetes-sigs/poseidon/pkg/version"
+	apimachineryversion "k8s.io/apimachinery/pkg/version"
+)
+
+// configz is what a deployment of Poseidon is actually running.
+type configz struct {
+	Version   apimachineryversion.Info `json:"version"`
+	RunID     string                   `json:"runId"`
+	StartTime time.Time                `json:"startTime"`
+	// ConfigHash identifies Config, the effective configuration once the
+	// flags, the config file and the defaults are merged.
+	ConfigHash string          `json:"configHash"`
+	Config     json.RawMessage `json:"config"`
+	// Policies are the scheduling policies and features Config enables.
+	Policies []string `json:"policies"`
+	// FeatureGates tells whether each experimental feature is enabled.
+	FeatureGates map[string]bool `json:"featureGates"`
+}
+
+// configzHandler serves the build information, the effective configuration,
+// the policies it enables and the feature gates, so that support can check what a deployment
+// runs.
+func configzHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(&configz{
+			Version:      version.Get(),
+			RunID:        runinfo.ID,
+			StartTime:    runinfo.StartTime,
+			ConfigHash:   config.Hash(),
+			Config:       config.Effective(),
+			Policies:     config.ActivePolicies(),
+			FeatureGates: features.DefaultGate.Map(),
+		})
+	})
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/k8sclient"
+)
+
+// defaultEvictionTarget is the utilization the eviction advice brings a node
+// under when the request does not set one.
+const defaultEvictionTarget = 0.8
+
+// evictionHandler serves the pods to evict to relieve the node given by the
+// node query parameter, down to the utilization given by the target query
+// parameter, for the descheduler or the operators' automation.
+func evictionHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		node := r.URL.Query().Get("node")
+		if node == "" {
+			http.Error(w, "the node parameter is required", http.StatusBadRequest)
+			return
+		}
+		target := defaultEvictionTarget
+		if value := r.URL.Query().Get("target"); value != "" {
+			var err error
+			if target, err = strconv.ParseFloat(value, 64); err != nil {
+				http.Error(w, fmt.Sprintf("invalid target parameter: %v", err), http.StatusBadRequest)
+				return
+			}
+		}
+		advice, err := k8sclient.AdviseEvictions(node, target)
+		if err == k8sclient.ErrUnknownNode {
+			http.Error(w, fmt.Sprintf("node %s: %v", node, err), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(advice)
+	})
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/history"
+	"github.com/kubernetes-sigs/poseidon/pkg/k8sclient"
+)
+
+const explainUsage = "usage: poseidon explain pod <namespace>/<name> [--adminAddress=<host:port>]"
+
+// podExplanation is the scheduling state of a pod served by the admin
+// server.
+type podExplanation struct {
+	*k8sclient.Explanation
+	// LastDecision is the last decision applied to the pod if the
+	// placement history is enabled.
+	LastDecision *history.Record `json:"lastDecision,omitempty"`
+}
+
+// explainHandler serves the scheduling state of the pod given by the pod
+// namespace/name query parameter.
+func explainHandler(placements *history.Store) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pod := r.URL.Query().Get("pod")
+		parts := strings.Split(pod, "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			http.Error(w, "the pod parameter must be <namespace>/<name>", http.StatusBadRequest)
+			return
+		}
+		explanation, ok := k8sclient.Explain(k8sclient.PodIdentifier{Namespace: parts[0], Name: parts[1]})
+		if !ok {
+			http.Error(w, fmt.Sprintf("pod %s is not known to the scheduler: it is not claimed by it, already completed or does not exist", pod),
+				http.StatusNotFound)
+			return
+		}
+		response := podExplanation{Explanation: explanation}
+		if placements != nil {
+			if records := placements.Query(history.Query{Pod: pod, Limit: 1}); len(records) > 0 {
+				response.LastDecision = &records[0]
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	})
+}
+
+// runExplain implements the explain command: it asks the admin server of the
+// running scheduler for the scheduling state of a pod and prints it.
+func runExplain(args []string, adminAddress string, out io.Writer) error {
+	if len(args) != 2 || args[0] != "pod" {
+		return errors.New(explainUsage)
+	}
+	host, port, err := net.SplitHostPort(adminAddress)
+	if err != nil {
+		return fmt.Errorf("invalid admin address %s: %v", adminAddress, err)
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(fmt.Sprintf("http://%s/explain?pod=%s", net.JoinHostPort(host, port), url.QueryEscape(args[1])))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("%s", strings.TrimSpace(string(body)))
+	}
+	var explanation podExplanation
+	if err := json.NewDecoder(resp.Body).Decode(&explanation); err != nil {
+		return fmt.Errorf("invalid response of the scheduler: %v", err)
+	}
+	printExplanation(out, &explanation)
+	return nil
+}
+
+// printExplanation prints the scheduling state of a pod in a human readable
+// form.
+func printExplanation(out io.Writer, explanation *podExplanation) {
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	defer w.Flush()
+	fmt.Fprintf(w, "Pod:\t%s\n", explanation.Pod)
+	switch explanation.State {
+	case k8sclient.ExplainPending:
+		fmt.Fprintf(w, "State:\twaiting for a placement for %d scheduling cycles\n", explanation.PendingCycles)
+	case k8sclient.ExplainDeferred:
+		fmt.Fprintf(w, "State:\tsubmission to the solver deferred\n")
+	default:
+		fmt.Fprintf(w, "State:\t%s\n", explanation.State)
+	}
+	fmt.Fprintf(w, "Requests:\t%d millicores, %d KB of memory\n", explanation.CPURequest, explanation.MemRequestKb)
+	fmt.Fprintf(w, "Priority:\t%d\n", explanation.Priority)
+	if len(explanation.Constraints) == 0 {
+		fmt.Fprintf(w, "Constraints:\tnone\n")
+	}
+	for i, constraint := range explanation.Constraints {
+		label := ""
+		if i == 0 {
+			label = "Constraints:"
+		}
+		fmt.Fprintf(w, "%s\t%s\n", label, constraint)
+	}
+	fmt.Fprintf(w, "Candidate nodes:\t%d of %d nodes match the constraints, %d of them have the capacity for the requests\n",
+		explanation.MatchingNodes, explanation.Nodes, explanation.CandidateNodes)
+	if decision := explanation.LastDecision; decision != nil {
+		fmt.Fprintf(w, "Last decision:\t%s on node %s at %s\n", decision.Type, decision.Node, decision.Time.Format(time.RFC3339))
+	} else {
+		fmt.Fprintf(w, "Last decision:\tnone recorded\n")
+	}
+	if failure := explanation.LastFailure; failure != nil {
+		fmt.Fprintf(w, "Last failure:\t%s at %s\n", failure.Reason, failure.Time.Format(time.RFC3339))
+	}
+}
+
+// explainMain runs the explain command and exits.
+func explainMain(args []string, adminAddress string) {
+	if err := runExplain(args, adminAddress, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
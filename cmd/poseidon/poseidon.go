@@ -17,87 +17,1116 @@ limitations under the License.
 package main
 
 import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/kubernetes-sigs/poseidon/pkg/config"
+	"github.com/kubernetes-sigs/poseidon/pkg/features"
 	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+	"github.com/kubernetes-sigs/poseidon/pkg/history"
 	"github.com/kubernetes-sigs/poseidon/pkg/k8sclient"
+	"github.com/kubernetes-sigs/poseidon/pkg/metrics"
+	"github.com/kubernetes-sigs/poseidon/pkg/runinfo"
+	"github.com/kubernetes-sigs/poseidon/pkg/sampling"
+	"github.com/kubernetes-sigs/poseidon/pkg/scheduler"
+	"github.com/kubernetes-sigs/poseidon/pkg/seed"
 	"github.com/kubernetes-sigs/poseidon/pkg/stats"
+	"github.com/kubernetes-sigs/poseidon/pkg/version"
 
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
 	"k8s.io/apimachinery/pkg/util/wait"
 
 	"github.com/golang/glog"
 )
 
-func schedule(fc firmament.FirmamentSchedulerClient) {
+// schedulerShard is a Firmament instance running its own scheduling cycles.
+type schedulerShard struct {
+	name string
+	// solver is the Firmament instance of the shard, and scope restricts the
+	// bookkeeping of its cycles to its nodes and tasks if Firmament is
+	// sharded.
+	solver     firmament.FirmamentSchedulerClient
+	scope      *k8sclient.ShardScope
+	connection *firmament.ConnectionMonitor
+	health     *firmament.HealthMonitor
+	// burst wakes up the cycles of the shard when its tasks are submitted.
+	burst *scheduler.Burst
+	// applyMux serializes the application of the deltas of the shard. The
+	// shards own disjoint nodes and tasks, their deltas are applied
+	// concurrently.
+	applyMux sync.Mutex
+	// all are the shards of the scheduler, whose applyMux are taken in
+	// order while the zones spanning them are balanced.
+	all []*schedulerShard
+}
+
+// lockShards takes the applyMux of the shards in order, and returns the
+// function releasing them.
+func lockShards(shards []*schedulerShard) func() {
+	for _, shard := range shards {
+		shard.applyMux.Lock()
+	}
+	return func() {
+		for i := len(shards) - 1; i >= 0; i-- {
+			shards[i].applyMux.Unlock()
+		}
+	}
+}
+
+// schedule runs the scheduling cycles of a shard until the scheduler is
+// drained. The scheduler run in progress is given up on once ctx is done.
+// The nodes and tasks are updated through fc.
+func schedule(ctx context.Context, fc firmament.FirmamentSchedulerClient, shard *schedulerShard, interval *scheduler.Interval,
+	drain *scheduler.Drain, caps *scheduler.NodeCaps, placements, preemptions *history.Store, sampler *sampling.Sampler, cycles *scheduler.CycleLog,
+	status *statusReporter, fallback *k8sclient.FallbackPolicy) {
+	connection, health, burst := shard.connection, shard.health, shard.burst
+	burstRun := false
+	resyncNeeded := false
+	// The watchers carried on after seenErrors errors when Firmament was
+	// last resynchronized with all the nodes and tasks.
+	seenErrors, forceResync := k8sclient.RecoverableErrors(), false
 	for {
-		deltas := firmament.Schedule(fc)
-		glog.Infof("Scheduler returned %d deltas", len(deltas.GetDeltas()))
-		for _, delta := range deltas.GetDeltas() {
+		if drain.IsRequested() {
+			// The deltas of the previous cycle are applied, it is safe to terminate.
+			glog.Infof("Scheduler drained, no further scheduling cycles will run, %d deferred deltas dropped", caps.NumDeferred())
+			return
+		}
+		if n := k8sclient.RecoverableErrors(); n != seenErrors {
+			// Firmament may have missed some nodes or tasks.
+			seenErrors, forceResync = n, true
+		}
+		if connection.ConnectionLost() || resyncNeeded || forceResync {
+			// Firmament may have restarted, its state must be restored before
+			// it schedules again.
+			waitForFirmament(health, fallback, interval.Next(), drain, placements)
+			resync := k8sclient.ResyncFirmament
+			if forceResync {
+				resync = k8sclient.ForceResyncFirmament
+			}
+			resynced, err := resync(fc, shard.scope)
+			resyncNeeded = err != nil
+			if err != nil {
+				glog.Errorf("Failed to resync Firmament: %v", err)
+				status.publish(caps, k8sclient.FirmamentUnavailable)
+				burstRun = burst.Wait(interval.Next(), drain.Requested())
+				continue
+			}
+			forceResync = false
+			if resynced {
+				// The deferred deltas were computed by the previous Firmament.
+				caps.Reset()
+			}
+		}
+		if shard.name == "" {
+			// The zones span the shards, they are balanced by the cycles of
+			// the default shard while no shard applies its deltas.
+			unlock := lockShards(shard.all)
+			balanceZones(fc, placements, preemptions)
+			unlock()
+		}
+		solveStart := time.Now()
+		deltas, err := solve(ctx, shard.solver, burstRun)
+		if err != nil {
+			if ctx.Err() != nil {
+				// The loop stops at the drain check.
+				glog.Infof("Scheduler run cancelled after %v", time.Since(solveStart))
+				metrics.AbandonedSchedulerRuns.Inc("cancelled")
+				continue
+			}
+			code := firmament.Code(err)
+			if code == codes.Unavailable || code == codes.DeadlineExceeded {
+				health.Observe(false)
+			}
+			if fallback != nil && code == codes.Unavailable {
+				// The pods are placed by the fallback scheduler until
+				// Firmament is available again.
+				glog.Warningf("Firmament unavailable: %v", err)
+				resyncNeeded = true
+				status.publish(caps, k8sclient.FirmamentUnavailable)
+				continue
+			}
+			if !burstRun && !firmament.IsRetryable(err) {
+				glog.Fatalf("%v.Schedule(_) = _, %v: ", shard.solver, err)
+			}
+			reason := "timeout"
+			if code != codes.DeadlineExceeded {
+				// Firmament may restart while it is unavailable.
+				reason = "unavailable"
+				resyncNeeded = true
+			}
+			// The pending tasks will be placed by the next batch run.
+			glog.Warningf("Scheduler run failed (burst run: %v): %v", burstRun, err)
+			metrics.AbandonedSchedulerRuns.Inc(reason)
+			status.publish(caps, k8sclient.FirmamentUnavailable)
+			burstRun = burst.Wait(interval.Next(), drain.Requested())
+			continue
+		}
+		health.Observe(true)
+		solveDuration := time.Since(solveStart)
+		glog.Infof("Scheduler%s returned %d deltas in %v (burst run: %v)", shardSuffix(shard), len(deltas.GetDeltas()), solveDuration, burstRun)
+		shard.applyMux.Lock()
+		bindStart := time.Now()
+		cycles.Begin(solveStart, burstRun)
+		k8sclient.ReleaseExpiredGangs(fc, bindStart)
+		// The pods of the terminating nodes are migrated like Firmament's.
+		cycleDeltas := caps.Start(append(k8sclient.TerminationMigrations(shard.scope), deltas.GetDeltas()...))
+		placed := placedPods(cycleDeltas)
+		for _, delta := range cycleDeltas {
 			switch delta.GetType() {
 			case firmament.SchedulingDelta_PLACE:
-				k8sclient.PodMux.RLock()
-				podIdentifier, ok := k8sclient.TaskIDToPod[delta.GetTaskId()]
-				k8sclient.PodMux.RUnlock()
+				podIdentifier, ok := k8sclient.State().PodOfTask(delta.GetTaskId())
 				if !ok {
+					if caps.IsCarriedOver(delta) {
+						glog.V(2).Infof("Dropping deferred placement of removed task %d", delta.GetTaskId())
+						continue
+					}
 					glog.Fatalf("Placed task %d without pod pairing", delta.GetTaskId())
 				}
-				k8sclient.NodeMux.RLock()
-				nodeName, ok := k8sclient.ResIDToNode[delta.GetResourceId()]
-				k8sclient.NodeMux.RUnlock()
+				if k8sclient.IsPreemptionRefused(delta.GetTaskId()) {
+					glog.V(2).Infof("Ignoring placement of pod %v, it kept running after a refused preemption", podIdentifier)
+					k8sclient.UnpinTask(fc, delta.GetTaskId())
+					countDelta(cycles, delta, podIdentifier, "", k8sclient.DeltaSuperseded)
+					continue
+				}
+				if k8sclient.IsResyncedPlacement(delta.GetTaskId()) {
+					glog.V(2).Infof("Ignoring placement of pod %v, it was bound before Firmament restarted", podIdentifier)
+					k8sclient.UnpinTask(fc, delta.GetTaskId())
+					logDelta(cycles, delta, podIdentifier, "", "resynced")
+					continue
+				}
+				nodeName, ok := k8sclient.State().NodeOfResource(delta.GetResourceId())
 				if !ok {
+					if caps.IsCarriedOver(delta) {
+						glog.V(2).Infof("Dropping deferred placement of task %d on removed resource %s", delta.GetTaskId(), delta.GetResourceId())
+						continue
+					}
 					glog.Fatalf("Placed task %d on resource %s without node pairing", delta.GetTaskId(), delta.GetResourceId())
 				}
-				k8sclient.BindPodToNode(podIdentifier.Name, podIdentifier.Namespace, nodeName)
+				if k8sclient.IsNodeQuarantined(nodeName) {
+					// Firmament is told the node has no capacity left.
+					countDelta(cycles, delta, podIdentifier, nodeName, k8sclient.PlacementFailed(fc, delta.GetTaskId(), k8sclient.ErrNodeQuarantined))
+					continue
+				}
+				if !caps.Admit(delta, nodeName) {
+					glog.V(2).Infof("Deferring placement of pod %v, node %s reached its placement cap", podIdentifier, nodeName)
+					logDelta(cycles, delta, podIdentifier, nodeName, "deferred")
+					continue
+				}
+				if k8sclient.IsShadowMode() {
+					k8sclient.RecordShadowPlacement(podIdentifier, nodeName)
+					logDelta(cycles, delta, podIdentifier, nodeName, "shadow")
+					k8sclient.MarkTaskPlaced(delta.GetTaskId())
+					continue
+				}
+				if k8sclient.IsPodGroupBackedOff(delta.GetTaskId(), bindStart) {
+					// Firmament is told the placement failed, so that the
+					// group does not reserve capacity.
+					countDelta(cycles, delta, podIdentifier, nodeName, k8sclient.PlacementFailed(fc, delta.GetTaskId(), k8sclient.ErrPodGroupBackedOff))
+					continue
+				}
+				gangPlacements := k8sclient.HoldGangPlacement(delta, podIdentifier, nodeName, bindStart)
+				if len(gangPlacements) == 0 {
+					glog.V(2).Infof("Holding placement of pod %v until its pod group reaches its minAvailable", podIdentifier)
+					logDelta(cycles, delta, podIdentifier, nodeName, "held")
+					continue
+				}
+				for _, placement := range gangPlacements {
+					bindPlacement(fc, placements, sampler, cycles, placement)
+				}
 			case firmament.SchedulingDelta_PREEMPT, firmament.SchedulingDelta_MIGRATE:
-				k8sclient.PodMux.RLock()
-				podIdentifier, ok := k8sclient.TaskIDToPod[delta.GetTaskId()]
-				k8sclient.PodMux.RUnlock()
+				podIdentifier, ok := k8sclient.State().PodOfTask(delta.GetTaskId())
 				if !ok {
+					if caps.IsCarriedOver(delta) {
+						glog.V(2).Infof("Dropping deferred preemption of removed task %d", delta.GetTaskId())
+						continue
+					}
 					glog.Fatalf("Preempted task %d without pod pairing", delta.GetTaskId())
 				}
+				nodeName, ok := k8sclient.State().NodeOfResource(delta.GetResourceId())
+				if !ok {
+					nodeName, _ = k8sclient.TerminatingNodeName(delta.GetResourceId())
+				}
+				if k8sclient.EvictGangPlacement(delta, nodeName) {
+					// The pod is not bound, its held placement is updated.
+					glog.V(2).Infof("Updating the held placement of pod %v", podIdentifier)
+					logDelta(cycles, delta, podIdentifier, nodeName, "held")
+					continue
+				}
+				// The migrations off the terminating nodes are not Firmament's.
+				_, terminating := k8sclient.TerminatingNodeName(delta.GetResourceId())
+				if k8sclient.RefusePreemption(delta.GetTaskId()) {
+					glog.Infof("Not preempting pod %v, its priority is not preemptible", podIdentifier)
+					if !terminating {
+						k8sclient.KeepTaskOnNode(fc, delta.GetTaskId(), nodeName)
+					}
+					logDelta(cycles, delta, podIdentifier, nodeName, "refused")
+					continue
+				}
+				if delta.GetType() == firmament.SchedulingDelta_MIGRATE && !terminating && k8sclient.RefuseMigration(delta.GetTaskId()) {
+					glog.V(2).Infof("Not migrating pod %v, the %s feature is disabled", podIdentifier, features.Migrations)
+					k8sclient.KeepTaskOnNode(fc, delta.GetTaskId(), nodeName)
+					logDelta(cycles, delta, podIdentifier, nodeName, "refused")
+					continue
+				}
+				if delta.GetType() == firmament.SchedulingDelta_MIGRATE && !terminating && k8sclient.SuppressMigration(delta.GetTaskId()) {
+					glog.V(2).Infof("Not migrating pod %v, its owner exhausted its churn budget", podIdentifier)
+					k8sclient.KeepTaskOnNode(fc, delta.GetTaskId(), nodeName)
+					logDelta(cycles, delta, podIdentifier, nodeName, "suppressed")
+					continue
+				}
+				if !caps.Admit(delta, nodeName) {
+					glog.V(2).Infof("Deferring preemption of pod %v, node %s reached its preemption cap", podIdentifier, nodeName)
+					logDelta(cycles, delta, podIdentifier, nodeName, "deferred")
+					continue
+				}
+				if k8sclient.IsShadowMode() {
+					glog.V(2).Infof("Shadow mode, not preempting pod %v", podIdentifier)
+					logDelta(cycles, delta, podIdentifier, nodeName, "shadow")
+					continue
+				}
 				// XXX(ionel): HACK! Kubernetes does not yet have support for preemption.
 				// However, preemption can be achieved by deleting the preempted pod
 				// and relying on the controller mechanism (e.g., job, replica set)
 				// to submit another instance of this pod.
-				k8sclient.DeletePod(podIdentifier.Name, podIdentifier.Namespace)
+				if err := k8sclient.DeletePod(podIdentifier.Name, podIdentifier.Namespace); err != nil {
+					result := k8sclient.PreemptionFailed(delta.GetTaskId(), err)
+					if result == k8sclient.DeltaFailed && !terminating {
+						k8sclient.KeepTaskOnNode(fc, delta.GetTaskId(), nodeName)
+					}
+					countDelta(cycles, delta, podIdentifier, nodeName, result)
+					continue
+				}
+				countDelta(cycles, delta, podIdentifier, nodeName, k8sclient.DeltaApplied)
+				k8sclient.RecordChurn(delta.GetTaskId(), delta.GetType() == firmament.SchedulingDelta_MIGRATE)
+				recordType, outcome := history.Preempt, sampling.Preempted
+				if delta.GetType() == firmament.SchedulingDelta_MIGRATE {
+					recordType, outcome = history.Migrate, sampling.Migrated
+				}
+				sampleDecision(sampler, outcome, delta.GetTaskId(), podIdentifier, nodeName)
+				recordPreemption(placements, preemptions, history.Record{
+					Type:       recordType,
+					Pod:        podIdentifier.UniqueName(),
+					Node:       nodeName,
+					Reason:     preemptionReason(delta),
+					OnBehalfOf: placed[nodeName],
+				})
 			case firmament.SchedulingDelta_NOOP:
 			default:
 				glog.Fatalf("Unexpected SchedulingDelta type %v", delta.GetType())
 			}
 		}
+		cycles.End(solveDuration, time.Since(bindStart))
+		k8sclient.RecordSchedulingCycle(shard.scope, solveStart)
+		k8sclient.RecordCapacityMetrics()
+		k8sclient.RecordChurnMetrics()
+		k8sclient.RecordCycleLoad(solveDuration, time.Since(bindStart))
+		status.cycleDone(solveStart)
+		status.publish(caps, k8sclient.FirmamentServing)
+		shard.applyMux.Unlock()
+		if !burstRun {
+			// Burst runs only place a few tasks and would skew the interval.
+			interval.Observe(solveDuration, time.Since(bindStart))
+		}
 		// TODO(ionel): Temporary sleep statement because we currently call the scheduler even if there's no work do to.
-		time.Sleep(time.Duration(config.GetSchedulingInterval()) * time.Second)
+		glog.V(2).Infof("Next scheduler run in %v", interval.Next())
+		burstRun = burst.Wait(interval.Next(), drain.Requested())
+	}
+}
+
+// shardSuffix names the shard in the logs of its cycles if Firmament is
+// sharded.
+func shardSuffix(shard *schedulerShard) string {
+	if shard.scope == nil {
+		return ""
+	}
+	if shard.name == "" {
+		return " of the default shard"
+	}
+	return fmt.Sprintf(" of shard %s", shard.name)
+}
+
+// bindPlacement binds a placed pod to its node.
+func bindPlacement(fc firmament.FirmamentSchedulerClient, placements *history.Store, sampler *sampling.Sampler, cycles *scheduler.CycleLog,
+	placement k8sclient.GangPlacement) {
+	delta, podIdentifier, nodeName := placement.Delta, placement.Pod, placement.Node
+	if err := k8sclient.BindPodToNode(podIdentifier.Name, podIdentifier.Namespace, nodeName); err != nil {
+		sampleDecision(sampler, sampling.BindFailed, delta.GetTaskId(), podIdentifier, nodeName)
+		countDelta(cycles, delta, podIdentifier, nodeName, k8sclient.PlacementFailed(fc, delta.GetTaskId(), err))
+		return
+	}
+	sampleDecision(sampler, sampling.Placed, delta.GetTaskId(), podIdentifier, nodeName)
+	countDelta(cycles, delta, podIdentifier, nodeName, k8sclient.DeltaApplied)
+	metrics.PodsBound.Inc(podIdentifier.Namespace)
+	recordPlacement(placements, history.Place, podIdentifier, nodeName)
+	k8sclient.MarkTaskPlaced(delta.GetTaskId())
+}
+
+// solve runs the solver and returns its deltas. Burst runs are bounded by
+// the burst schedule timeout, and batch runs by the schedule timeout if set.
+func solve(ctx context.Context, fc firmament.FirmamentSchedulerClient, burstRun bool) (*firmament.SchedulingDeltas, error) {
+	timeout := time.Duration(config.GetScheduleTimeout()) * time.Second
+	if burstRun {
+		timeout = time.Duration(config.GetBurstScheduleTimeout()) * time.Millisecond
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return firmament.ScheduleWithContext(ctx, fc)
+}
+
+// countDelta counts and logs the result of the application of a scheduling
+// delta about a pod on a node.
+func countDelta(cycles *scheduler.CycleLog, delta *firmament.SchedulingDelta, podIdentifier k8sclient.PodIdentifier, nodeName string,
+	result k8sclient.DeltaResult) {
+	metrics.DeltaResults.Inc(strings.ToLower(delta.GetType().String()), string(result))
+	if result != k8sclient.DeltaApplied {
+		glog.Warningf("Scheduling delta %v of task %d %s", delta.GetType(), delta.GetTaskId(), result)
+	}
+	logDelta(cycles, delta, podIdentifier, nodeName, string(result))
+}
+
+// logDelta records the result of a scheduling delta in the cycle log.
+func logDelta(cycles *scheduler.CycleLog, delta *firmament.SchedulingDelta, podIdentifier k8sclient.PodIdentifier, nodeName, result string) {
+	cycles.Record(scheduler.DeltaRecord{
+		Type:   strings.ToLower(delta.GetType().String()),
+		TaskID: delta.GetTaskId(),
+		Pod:    podIdentifier.UniqueName(),
+		Node:   nodeName,
+		Result: result,
+	})
+}
+
+// statusReporter publishes the scheduler status after each cycle if enabled.
+type statusReporter struct {
+	publisher        *k8sclient.StatusPublisher
+	leader           string
+	lastCycle        time.Time
+	lastCycleLatency time.Duration
+}
+
+// newStatusReporter returns the status reporter as configured, nil if the
+// status is not published.
+func newStatusReporter() *statusReporter {
+	if config.GetStatusConfigMap() == "" {
+		return nil
+	}
+	publisher, err := k8sclient.NewStatusPublisher(config.GetKubeConfig(), config.GetStatusConfigMap())
+	if err != nil {
+		glog.Fatalf("Failed to publish the scheduler status: %v", err)
+	}
+	// Poseidon runs as a single instance, identified by its pod name.
+	leader, err := os.Hostname()
+	if err != nil {
+		glog.Warningf("Failed to get the hostname: %v", err)
+	}
+	return &statusReporter{publisher: publisher, leader: leader}
+}
+
+func (r *statusReporter) cycleDone(start time.Time) {
+	if r == nil {
+		return
+	}
+	r.lastCycle = start
+	r.lastCycleLatency = time.Since(start)
+}
+
+func (r *statusReporter) publish(caps *scheduler.NodeCaps, firmamentHealth string) {
+	if r == nil {
+		return
+	}
+	backpressure, _ := k8sclient.Backpressure()
+	r.publisher.Publish(k8sclient.Status{
+		Leader:           r.leader,
+		RunID:            runinfo.ID,
+		PendingTasks:     k8sclient.NumPendingTasks(),
+		DeferredDeltas:   caps.NumDeferred(),
+		LastCycleTime:    r.lastCycle,
+		LastCycleLatency: r.lastCycleLatency,
+		FirmamentHealth:  firmamentHealth,
+		Backpressure:     backpressure,
+	})
+}
+
+// recordPlacement persists a scheduling decision if the placement history is enabled.
+func recordPlacement(placements *history.Store, recordType history.RecordType, podIdentifier k8sclient.PodIdentifier, nodeName string) {
+	if placements == nil {
+		return
+	}
+	err := placements.Append(history.Record{
+		Time:  time.Now(),
+		Type:  recordType,
+		Pod:   podIdentifier.UniqueName(),
+		Node:  nodeName,
+		RunID: runinfo.ID,
+	})
+	if err != nil {
+		glog.Errorf("Failed to record %s of pod %v in the placement history: %v", recordType, podIdentifier, err)
 	}
 }
 
-// WaitForFirmamentService blocks till the Firmament service is available
-func WaitForFirmamentService(fc firmament.FirmamentSchedulerClient) {
+// recordPreemption logs a preempted or migrated pod, and persists it in the
+// placement history if enabled.
+func recordPreemption(placements, preemptions *history.Store, record history.Record) {
+	record.Time = time.Now()
+	record.RunID = runinfo.ID
+	for _, store := range []*history.Store{placements, preemptions} {
+		if store == nil {
+			continue
+		}
+		if err := store.Append(record); err != nil {
+			glog.Errorf("Failed to record %s of pod %s: %v", record.Type, record.Pod, err)
+		}
+	}
+}
 
-	serviceReq := new(firmament.HealthCheckRequest)
-	err := wait.PollImmediate(2*time.Second, 10*time.Minute, func() (bool, error) {
-		ok, _ := firmament.Check(fc, serviceReq)
+// balanceZones updates the zones the pending replicas must avoid, and
+// deletes the replicas migrated to balance their ReplicaSet.
+func balanceZones(fc firmament.FirmamentSchedulerClient, placements, preemptions *history.Store) {
+	for _, migration := range k8sclient.BalanceZones(fc) {
+		if k8sclient.IsShadowMode() {
+			glog.V(2).Infof("Shadow mode, not migrating pod %v out of zone %s", migration.Pod, migration.Zone)
+			continue
+		}
+		// The budget accounts the migrations already applied by this loop.
+		if k8sclient.SuppressZoneMigration(migration.TaskID) {
+			glog.V(2).Infof("Not migrating pod %v out of zone %s, its owner exhausted its churn budget", migration.Pod, migration.Zone)
+			continue
+		}
+		if err := k8sclient.DeletePod(migration.Pod.Name, migration.Pod.Namespace); err != nil {
+			glog.Errorf("Failed to migrate pod %v out of zone %s: %v", migration.Pod, migration.Zone, err)
+			continue
+		}
+		glog.Infof("Migrated pod %v out of zone %s to balance its ReplicaSet", migration.Pod, migration.Zone)
+		k8sclient.RecordChurn(migration.TaskID, true)
+		recordPreemption(placements, preemptions, history.Record{
+			Type:   history.Migrate,
+			Pod:    migration.Pod.UniqueName(),
+			Node:   migration.Node,
+			Reason: "zone balancing",
+		})
+	}
+}
+
+// preemptionReason returns why a pod is preempted or migrated.
+func preemptionReason(delta *firmament.SchedulingDelta) string {
+	if delta.GetType() == firmament.SchedulingDelta_PREEMPT {
+		return "preempted by Firmament"
+	}
+	if _, ok := k8sclient.TerminatingNodeName(delta.GetResourceId()); ok {
+		return "node terminating"
+	}
+	return "migrated by Firmament"
+}
+
+// placedPods returns the pods placed by the deltas by node, on behalf of
+// which the pods of the node are preempted or migrated.
+func placedPods(deltas []*firmament.SchedulingDelta) map[string][]string {
+	placed := make(map[string][]string)
+	for _, delta := range deltas {
+		if delta.GetType() != firmament.SchedulingDelta_PLACE {
+			continue
+		}
+		podIdentifier, ok := k8sclient.State().PodOfTask(delta.GetTaskId())
 		if !ok {
-			return false, nil
+			continue
+		}
+		if nodeName, ok := k8sclient.State().NodeOfResource(delta.GetResourceId()); ok {
+			placed[nodeName] = append(placed[nodeName], podIdentifier.UniqueName())
+		}
+	}
+	return placed
+}
+
+// sampleDecision records a scheduling decision if the decision sampling is
+// enabled and the decision is sampled.
+func sampleDecision(sampler *sampling.Sampler, outcome sampling.Outcome, taskID uint64, podIdentifier k8sclient.PodIdentifier,
+	nodeName string) {
+	if sampler == nil || !sampler.Sample() {
+		return
+	}
+	features, ok := k8sclient.DecisionFeatures(taskID, nodeName)
+	if !ok {
+		return
+	}
+	err := sampler.Record(sampling.Sample{
+		Time:     time.Now(),
+		RunID:    runinfo.ID,
+		Pod:      sampler.Anonymize(podIdentifier.UniqueName()),
+		Node:     sampler.Anonymize(nodeName),
+		Outcome:  outcome,
+		Features: features,
+	})
+	if err != nil {
+		glog.Errorf("Failed to record the sampled %s decision of pod %v: %v", outcome, podIdentifier, err)
+	}
+}
+
+// newDecisionSampler returns the decision sampler as configured, nil if the
+// decisions are not sampled.
+func newDecisionSampler() *sampling.Sampler {
+	if config.GetDecisionSamplingPath() == "" {
+		return nil
+	}
+	var salt string
+	if config.GetDecisionSamplingSaltFile() != "" {
+		data, err := ioutil.ReadFile(config.GetDecisionSamplingSaltFile())
+		if err != nil {
+			glog.Fatalf("Failed to read the decision sampling salt: %v", err)
+		}
+		salt = strings.TrimSpace(string(data))
+	}
+	sampler, err := sampling.Open(config.GetDecisionSamplingPath(), config.GetDecisionSamplingPercentage(), salt)
+	if err != nil {
+		glog.Fatalf("Failed to open decision sampling file %s: %v", config.GetDecisionSamplingPath(), err)
+	}
+	return sampler
+}
+
+// seedKnowledgeBase loads the historical usage of the nodes and pods into the
+// knowledge base of Firmament once the nodes and pods listed at startup are
+// synchronized. The history of Prometheus is a best effort, the scheduler
+// starts cold without it.
+func seedKnowledgeBase(fc firmament.FirmamentSchedulerClient) {
+	if !features.Enabled(features.KnowledgeBaseSeed) {
+		if config.GetKnowledgeBaseSeedFile() != "" || config.GetKnowledgeBasePrometheus() != "" {
+			glog.Warningf("Not seeding the knowledge base, the %s feature gate is disabled", features.KnowledgeBaseSeed)
+		}
+		return
+	}
+	usage := &seed.Seed{}
+	if config.GetKnowledgeBaseSeedFile() != "" {
+		loaded, err := seed.LoadCSV(config.GetKnowledgeBaseSeedFile())
+		if err != nil {
+			glog.Fatalf("Failed to load the knowledge base seed %s: %v", config.GetKnowledgeBaseSeedFile(), err)
+		}
+		usage.Merge(loaded)
+	}
+	if config.GetKnowledgeBasePrometheus() != "" {
+		queried, err := seed.QueryPrometheus(config.GetKnowledgeBasePrometheus(), seed.DefaultPrometheusQueries, 30*time.Second)
+		if err != nil {
+			glog.Warningf("Failed to query the historical usage from Prometheus %s: %v", config.GetKnowledgeBasePrometheus(), err)
+		} else {
+			usage.Merge(queried)
+		}
+	}
+	if len(usage.Nodes) == 0 && len(usage.Pods) == 0 {
+		return
+	}
+	go func() {
+		k8sclient.WaitForStartupSync()
+		nodes, pods := seed.Apply(fc, k8sclient.State(), usage)
+		glog.Infof("Seeded the knowledge base with the historical usage of %d of %d nodes and %d of %d pods", nodes,
+			len(usage.Nodes), pods, len(usage.Pods))
+	}()
+}
+
+// newSchedulingInterval returns the interval between scheduler runs as configured.
+func newSchedulingInterval() *scheduler.Interval {
+	schedulingInterval := time.Duration(config.GetSchedulingInterval()) * time.Second
+	if !config.GetAdaptiveSchedulingInterval() {
+		return scheduler.NewFixedInterval(schedulingInterval)
+	}
+	minInterval := time.Duration(config.GetMinSchedulingInterval()) * time.Second
+	maxInterval := time.Duration(config.GetMaxSchedulingInterval()) * time.Second
+	if minInterval <= 0 || minInterval > maxInterval {
+		glog.Fatalf("Incorrect adaptive scheduling interval bounds --minSchedulingInterval %d --maxSchedulingInterval %d",
+			config.GetMinSchedulingInterval(), config.GetMaxSchedulingInterval())
+	}
+	return scheduler.NewAdaptiveInterval(schedulingInterval, minInterval, maxInterval)
+}
+
+// serveAdmin starts the admin HTTP server exposing metrics, the readiness,
+// drain, explain, eviction advice, node removal, node capacity and configz
+// endpoints, the preemption log, and the placement history and cycle log if
+// enabled.
+func serveAdmin(address string, drain *scheduler.Drain, health *firmament.HealthMonitor, placements, preemptions *history.Store,
+	cycles *scheduler.CycleLog) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		// The scheduler is ready while Firmament serves, even if it failed
+		// its last requests.
+		if !health.Serving() {
+			http.Error(w, fmt.Sprintf("Firmament is %s", health.State()), http.StatusServiceUnavailable)
+			return
 		}
-		return true, nil
+		fmt.Fprintf(w, "Firmament is %s\n", health.State())
+	})
+	mux.Handle("/drain", drain)
+	mux.Handle("/explain", explainHandler(placements))
+	mux.Handle("/evictions", evictionHandler())
+	mux.Handle("/removals", removalHandler())
+	mux.Handle("/capacity", capacityHandler())
+	mux.Handle("/configz", configzHandler())
+	mux.Handle("/preemptions", preemptions)
+	if placements != nil {
+		mux.Handle("/placements", placements)
+	}
+	if cycles != nil {
+		mux.Handle("/cycles", cycles)
+	}
+	mux.HandleFunc("/state", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(k8sclient.State().Snapshot())
+	})
+	glog.Info("Starting admin server on ", address)
+	glog.Fatal(http.ListenAndServe(address, mux))
+}
+
+// serveValidationWebhook serves the validating webhook of the pods of the
+// scheduler over TLS, on /validate.
+func serveValidationWebhook(address, certFile, keyFile, schedulerName string) {
+	mux := http.NewServeMux()
+	mux.Handle("/validate", &k8sclient.ValidatingWebhook{SchedulerName: schedulerName})
+	glog.Info("Starting validating webhook server on ", address)
+	glog.Fatal(http.ListenAndServeTLS(address, certFile, keyFile, mux))
+}
+
+// drainGracePeriod bounds the time the scheduler waits to be drained when
+// asked to terminate, below the default termination grace period of pods.
+const drainGracePeriod = 25 * time.Second
+
+// drainOnTermination drains the scheduler when it is asked to terminate and
+// exits once it is drained, or after the drain grace period.
+func drainOnTermination(drain *scheduler.Drain) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+	sig := <-signals
+	glog.Infof("Received %v, draining the scheduler", sig)
+	drain.Request()
+	err := wait.PollImmediate(100*time.Millisecond, drainGracePeriod, func() (bool, error) {
+		return drain.Status().Drained, nil
 	})
 	if err != nil {
-		glog.Fatalf("Timed-out waiting for firmament service %v", err)
+		glog.Warningf("Scheduler not drained after %v, terminating", drainGracePeriod)
+	}
+	glog.Flush()
+	os.Exit(0)
+}
+
+// waitForFirmament blocks until Firmament is Ready. Once it is unavailable
+// for longer than the fallback policy allows, the pending pods are placed by
+// the fallback scheduler at every scheduling interval, without fallback
+// policy the scheduler exits once Firmament is Down. It returns early if the
+// scheduler is drained.
+func waitForFirmament(health *firmament.HealthMonitor, fallback *k8sclient.FallbackPolicy, interval time.Duration,
+	drain *scheduler.Drain, placements *history.Store) {
+	if fallback == nil {
+		if !health.WaitReady(drain.Requested()) && !drain.IsRequested() {
+			glog.Fatalf("Firmament is %s", health.State())
+		}
+		return
+	}
+	outageStart := time.Now()
+	var lastFallback time.Time
+	for !drain.IsRequested() {
+		if health.State() == firmament.HealthReady {
+			if !lastFallback.IsZero() {
+				glog.Infof("Firmament available again after %v, stopping the fallback scheduler", time.Since(outageStart))
+			}
+			return
+		}
+		if time.Since(outageStart) >= fallback.After && time.Since(lastFallback) >= interval {
+			if lastFallback.IsZero() {
+				glog.Warningf("Firmament unavailable for %v, placing the pending pods with the %s fallback scheduler",
+					time.Since(outageStart), fallback.Strategy)
+			}
+			runFallback(fallback, placements)
+			lastFallback = time.Now()
+		}
+		time.Sleep(2 * time.Second)
+	}
+}
+
+// runFallback binds the placements of the fallback scheduler.
+func runFallback(fallback *k8sclient.FallbackPolicy, placements *history.Store) {
+	fallbackPlacements := fallback.PlaceWithoutFirmament()
+	bound := 0
+	for _, placement := range fallbackPlacements {
+		if err := k8sclient.BindFallbackPlacement(placement); err != nil {
+			metrics.FallbackPlacements.Inc("failed")
+			continue
+		}
+		bound++
+		metrics.FallbackPlacements.Inc("applied")
+		metrics.PodsBound.Inc(placement.Pod.Namespace)
+		recordPlacement(placements, history.Place, placement.Pod, placement.Node)
+	}
+	glog.Infof("Fallback scheduler bound %d of %d placements", bound, len(fallbackPlacements))
+}
+
+// firmamentHealthCheckInterval is the interval between the health checks of
+// Firmament.
+const firmamentHealthCheckInterval = 2 * time.Second
+
+// newFirmamentHealthMonitor starts monitoring the health of Firmament,
+// exported as the firmament_health metric.
+func newFirmamentHealthMonitor(fc firmament.FirmamentSchedulerClient) *firmament.HealthMonitor {
+	return firmament.NewHealthMonitor(fc, firmamentHealthCheckInterval, time.Duration(config.GetFirmamentDownAfter())*time.Second,
+		func(state firmament.HealthState) {
+			for _, s := range firmament.HealthStates {
+				value := 0.0
+				if s == state {
+					value = 1
+				}
+				metrics.FirmamentHealth.Set(value, string(s))
+			}
+		})
+}
+
+// newShardedFirmament connects to the Firmament shards as configured, and
+// returns the client routing the nodes and tasks to them and the shards,
+// the default one first.
+func newShardedFirmament(defaultShard *schedulerShard) (*firmament.ShardedClient, []*schedulerShard) {
+	clients := map[string]firmament.FirmamentSchedulerClient{"": defaultShard.solver}
+	shards := []*schedulerShard{defaultShard}
+	for _, spec := range config.GetFirmamentShards() {
+		parts := strings.SplitN(spec, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			glog.Fatalf("Invalid Firmament shard %q, expected <label value>=<address>", spec)
+		}
+		if _, ok := clients[parts[0]]; ok {
+			glog.Fatalf("Duplicate Firmament shard %s", parts[0])
+		}
+		// The connections of the shards are kept until Poseidon exits.
+		fc, conn, err := firmament.New(parts[1])
+		if err != nil {
+			glog.Fatalf("Failed to connect to the Firmament of shard %s: %v", parts[0], err)
+		}
+		// The firmament_health metric is that of the default shard.
+		health := firmament.NewHealthMonitor(fc, firmamentHealthCheckInterval, time.Duration(config.GetFirmamentDownAfter())*time.Second, nil)
+		if !health.WaitReady(nil) {
+			glog.Fatalf("Firmament of shard %s is %s after %ds", parts[0], health.State(), config.GetFirmamentDownAfter())
+		}
+		clients[parts[0]] = fc
+		shards = append(shards, &schedulerShard{name: parts[0], solver: fc, connection: firmament.NewConnectionMonitor(conn), health: health})
+	}
+	sharded, err := firmament.NewShardedClient(config.GetFirmamentShardLabel(), clients)
+	if err != nil {
+		glog.Fatalf("Invalid Firmament shards: %v", err)
+	}
+	for _, shard := range shards {
+		shard.scope = &k8sclient.ShardScope{Shards: sharded, Name: shard.name}
+	}
+	return sharded, shards
+}
+
+// logStartupReport logs the state of the cluster and of Firmament, and the
+// configuration of the current run.
+func logStartupReport() {
+	report := runinfo.NewReport()
+	report.ConfigHash = config.Hash()
+	report.FirmamentAddress = config.GetFirmamentAddress()
+	// Firmament was checked to be serving before.
+	report.FirmamentStatus = firmament.ServingStatus_SERVING.String()
+	var err error
+	report.Nodes, report.Pods, err = k8sclient.ClusterSize(config.GetKubeConfig())
+	if err != nil {
+		glog.Warningf("Failed to get the cluster size: %v", err)
 	}
+	glog.Infof("Startup report: %s", report)
 }
 
 func main() {
-	glog.Info("Starting Poseidon...", config.GetFirmamentAddress())
+	if args := config.GetArgs(); len(args) > 0 && args[0] == "explain" {
+		explainMain(args[1:], config.GetAdminAddress())
+	}
+	if args := config.GetArgs(); len(args) > 0 && args[0] == "replay" {
+		replayMain(args[1:], config.GetKubeConfig(), config.GetReplayDryRun(), config.GetReplayMaxDeltas())
+	}
+	glog.Infof("Starting Poseidon %s run %s... %s", version.Get().GitVersion, runinfo.ID, config.GetFirmamentAddress())
+	metrics.RunInfo.Set(1, runinfo.ID)
+	err := metrics.SetCardinalityLimits(metrics.CardinalityLimits{
+		MaxSeries: config.GetMetricsMaxSeries(),
+		Labels:    config.GetMetricsHighCardinalityLabels(),
+		Policy:    metrics.OverflowPolicy(config.GetMetricsOverflowPolicy()),
+	})
+	if err != nil {
+		glog.Fatalf("Invalid metrics cardinality limits: %v", err)
+	}
+	if err := features.DefaultGate.Set(config.GetFeatureGates()); err != nil {
+		glog.Fatalf("Invalid --feature-gates: %v", err)
+	}
 	fc, conn, err := firmament.New(config.GetFirmamentAddress())
 	if err != nil {
 		panic(err)
 	}
 	defer conn.Close()
 	// Check if firmament grpc service is available and then proceed
-	WaitForFirmamentService(fc)
-	go schedule(fc)
-	go stats.StartgRPCStatsServer(config.GetStatsServerAddress(), config.GetFirmamentAddress())
+	health := newFirmamentHealthMonitor(fc)
+	if !health.WaitReady(nil) {
+		glog.Fatalf("Firmament is %s after %ds", health.State(), config.GetFirmamentDownAfter())
+	}
+	shards := []*schedulerShard{{solver: fc, connection: firmament.NewConnectionMonitor(conn), health: health}}
+	var sharded *firmament.ShardedClient
+	if len(config.GetFirmamentShards()) > 0 {
+		sharded, shards = newShardedFirmament(shards[0])
+		fc = sharded
+		glog.Infof("Firmament sharded by node label %s over %d shards", config.GetFirmamentShardLabel(), len(shards))
+	}
+	for _, shard := range shards {
+		scope := shard.scope
+		shard.all = shards
+		shard.burst = scheduler.NewBurst(config.GetBurstMaxPendingTasks(), k8sclient.TaskSubmittedNotify(scope),
+			k8sclient.CriticalTaskSubmittedNotify(scope), func() int { return k8sclient.NumShardPendingTasks(scope) })
+	}
+	drain := scheduler.NewDrain(k8sclient.StopClaimingPods)
+	var placements *history.Store
+	if config.GetPlacementHistoryPath() != "" {
+		placements, err = history.Open(config.GetPlacementHistoryPath(), config.GetPlacementHistoryMaxRecords())
+		if err != nil {
+			glog.Fatalf("Failed to open placement history %s: %v", config.GetPlacementHistoryPath(), err)
+		}
+		defer placements.Close()
+	}
+	if config.GetPlacementDecisionTTL() > 0 {
+		if placements == nil {
+			// The decisions are forwarded by the placement history, only
+			// kept in memory if it is not persisted.
+			placements, err = history.Open("", config.GetPlacementHistoryMaxRecords())
+			if err != nil {
+				glog.Fatalf("Failed to open placement history: %v", err)
+			}
+		}
+		sink := k8sclient.NewPlacementDecisionSink(time.Duration(config.GetPlacementDecisionTTL()) * time.Second)
+		placements.AddSink(sink)
+		go sink.Run(nil)
+	}
+	preemptions, err := history.Open(config.GetPreemptionLogPath(), config.GetPreemptionLogMaxRecords())
+	if err != nil {
+		glog.Fatalf("Failed to open preemption log %s: %v", config.GetPreemptionLogPath(), err)
+	}
+	defer preemptions.Close()
+	sampler := newDecisionSampler()
+	if sampler != nil {
+		defer sampler.Close()
+	}
+	caps := scheduler.NewNodeCaps(config.GetMaxPlacementsPerNode(), config.GetMaxPreemptionsPerNode())
+	// The scheduler run in progress is cancelled by a drain, so that the
+	// scheduler stops without waiting for the solver.
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-drain.Requested()
+		cancel()
+	}()
+	go drainOnTermination(drain)
+	cycles := scheduler.NewCycleLog(config.GetCycleLogSize())
+	fallback, err := k8sclient.NewFallbackPolicy(time.Duration(config.GetFallbackAfter())*time.Second, config.GetFallbackStrategy(),
+		uint32(config.GetFallbackMinPriority()))
+	if err != nil {
+		glog.Fatalf("Invalid fallback scheduler policy: %v", err)
+	}
+	seedKnowledgeBase(fc)
+	// The cycles of the shards run concurrently, each with its own interval
+	// and placement caps. A task submission wakes up the cycles of its shard
+	// for a burst cycle. The status is that of the default shard, and only
+	// the default shard falls back on the fallback scheduler.
+	var loops sync.WaitGroup
+	status := newStatusReporter()
+	for i, shard := range shards {
+		shardCaps, shardStatus, shardFallback, shardCycles := caps, status, fallback, cycles
+		if i > 0 {
+			shardCaps = scheduler.NewNodeCaps(config.GetMaxPlacementsPerNode(), config.GetMaxPreemptionsPerNode())
+			shardStatus, shardFallback, shardCycles = nil, nil, cycles.ForShard(shard.name)
+		}
+		loops.Add(1)
+		go func(shard *schedulerShard, caps *scheduler.NodeCaps, status *statusReporter, fallback *k8sclient.FallbackPolicy, cycles *scheduler.CycleLog) {
+			defer loops.Done()
+			schedule(ctx, fc, shard, newSchedulingInterval(), drain, caps, placements, preemptions, sampler, cycles, status, fallback)
+		}(shard, shardCaps, shardStatus, shardFallback, shardCycles)
+	}
+	go func() {
+		loops.Wait()
+		drain.MarkDrained()
+	}()
+	go serveAdmin(config.GetAdminAddress(), drain, health, placements, preemptions, cycles)
+	go stats.StartgRPCStatsServer(config.GetStatsServerAddresses(), config.GetFirmamentAddress(), stats.ServerOptions{
+		Validate:          config.GetStatsValidation(),
+		TLSCertFile:       config.GetStatsServerTLSCertFile(),
+		TLSKeyFile:        config.GetStatsServerTLSKeyFile(),
+		ClientCAFile:      config.GetStatsServerClientCAFile(),
+		Authentication:    stats.Authentication(config.GetStatsAuthentication()),
+		TrustedIdentities: config.GetStatsTrustedIdentities(),
+		KubeConfig:        config.GetKubeConfig(),
+		Shards:            sharded,
+	})
 	kubeMajorVer, kubeMinorVer := config.GetKubeVersion()
-	k8sclient.New(config.GetSchedulerName(), config.GetKubeConfig(), kubeMajorVer, kubeMinorVer, config.GetFirmamentAddress())
+	var priorities *k8sclient.PriorityMapping
+	if config.GetPriorityMappingFile() != "" {
+		priorities, err = k8sclient.LoadPriorityMapping(config.GetPriorityMappingFile())
+		if err != nil {
+			glog.Fatalf("Failed to load the priority mapping: %v", err)
+		}
+	}
+	var tenantLimits *k8sclient.TenantLimits
+	if config.GetTenantRateLimitFile() != "" {
+		tenantLimits, err = k8sclient.LoadTenantLimits(config.GetTenantRateLimitFile())
+		if err != nil {
+			glog.Fatalf("Failed to load the tenant rate limits: %v", err)
+		}
+	}
+	var fairShares *k8sclient.FairSharePolicy
+	if config.GetFairSharePolicyFile() != "" && !features.Enabled(features.FairShareScheduling) {
+		glog.Warningf("Ignoring --fairSharePolicyFile, the %s feature gate is disabled", features.FairShareScheduling)
+	} else if config.GetFairSharePolicyFile() != "" {
+		fairShares, err = k8sclient.LoadFairSharePolicy(config.GetFairSharePolicyFile())
+		if err != nil {
+			glog.Fatalf("Failed to load the fair share policy: %v", err)
+		}
+	}
+	var nodePools *k8sclient.NodePoolPolicy
+	if config.GetNodePoolPolicyFile() != "" {
+		nodePools, err = k8sclient.LoadNodePoolPolicy(config.GetNodePoolPolicyFile())
+		if err != nil {
+			glog.Fatalf("Failed to load the node pool policy: %v", err)
+		}
+	}
+	deletion, err := k8sclient.NewDeletionPolicy(config.GetPreemptionGracePeriod(), config.GetPreemptionPropagationPolicy())
+	if err != nil {
+		glog.Fatalf("Invalid preemption deletion policy: %v", err)
+	}
+	var quarantine *k8sclient.QuarantinePolicy
+	if config.GetQuarantineBindFailures() > 0 {
+		quarantine = &k8sclient.QuarantinePolicy{
+			Failures: config.GetQuarantineBindFailures(),
+			Window:   time.Duration(config.GetQuarantineWindow()) * time.Second,
+			Duration: time.Duration(config.GetQuarantineDuration()) * time.Second,
+		}
+	}
+	var devices *k8sclient.DeviceHealthPolicy
+	if config.GetDeviceHealthGating() {
+		devices = &k8sclient.DeviceHealthPolicy{MaxUnhealthyFraction: float64(config.GetMaxUnhealthyDevicePercent()) / 100}
+	}
+	var zones *k8sclient.ZoneBalancePolicy
+	if config.GetZoneBalanceMaxSkew() > 0 {
+		zones = &k8sclient.ZoneBalancePolicy{MaxSkew: config.GetZoneBalanceMaxSkew(), Migrate: config.GetZoneBalanceMigrations()}
+	}
+	var oversized *k8sclient.OversizedPodPolicy
+	switch config.GetOversizedPods() {
+	case "":
+	case "report":
+		oversized = &k8sclient.OversizedPodPolicy{ScaleUpHint: config.GetOversizedPodScaleUpHint()}
+	case "reject":
+		oversized = &k8sclient.OversizedPodPolicy{Reject: true, ScaleUpHint: config.GetOversizedPodScaleUpHint()}
+	default:
+		glog.Fatalf("Invalid --oversizedPods %q, expected report or reject", config.GetOversizedPods())
+	}
+	var backpressure *k8sclient.BackpressurePolicy
+	if config.GetBackpressureMaxSolveTime() > 0 || config.GetBackpressureMaxBindTime() > 0 {
+		if config.GetBackpressureMinRate() <= 0 {
+			glog.Fatalf("Invalid --backpressureMinRate %d, expected a positive rate", config.GetBackpressureMinRate())
+		}
+		backpressure = &k8sclient.BackpressurePolicy{
+			MaxSolveDuration:  time.Duration(config.GetBackpressureMaxSolveTime()) * time.Millisecond,
+			MaxBindDuration:   time.Duration(config.GetBackpressureMaxBindTime()) * time.Millisecond,
+			MinSubmissionRate: float64(config.GetBackpressureMinRate()),
+		}
+	}
+	var admission *k8sclient.AdmissionPolicy
+	if config.GetAdmissionLabel() != "" {
+		if config.GetAdmittedAnnotation() == "" {
+			glog.Fatal("--admittedAnnotation must be set with --admissionLabel")
+		}
+		admission = &k8sclient.AdmissionPolicy{
+			Label:              config.GetAdmissionLabel(),
+			AdmittedAnnotation: config.GetAdmittedAnnotation(),
+			UnplacedCycles:     config.GetAdmissionUnplacedCycles(),
+		}
+	}
+	var memoryQoS *k8sclient.MemoryQoSPolicy
+	if config.GetMemoryQoS() {
+		if config.GetMemoryThrottlingPercent() < 0 || config.GetMemoryThrottlingPercent() > 100 {
+			glog.Fatalf("Invalid --memoryThrottlingPercent %d, expected 0 to 100", config.GetMemoryThrottlingPercent())
+		}
+		memoryQoS = &k8sclient.MemoryQoSPolicy{ThrottlingFactor: float64(config.GetMemoryThrottlingPercent()) / 100}
+	}
+	schedulerName := config.GetSchedulerName()
+	if config.GetShadowMode() {
+		schedulerName = config.GetShadowSchedulerName()
+		glog.Info("Running in shadow mode for scheduler ", schedulerName)
+	}
+	if config.GetValidationWebhookAddress() != "" {
+		if config.GetShadowMode() {
+			// The pods are scheduled by another scheduler.
+			glog.Warning("Not serving the validating webhook in shadow mode")
+		} else {
+			go serveValidationWebhook(config.GetValidationWebhookAddress(), config.GetValidationWebhookTLSCertFile(),
+				config.GetValidationWebhookTLSKeyFile(), schedulerName)
+		}
+	}
+	if config.GetPermissionSelfCheck() {
+		podConditions := config.GetRejectUnresolvableConstraints() || config.GetOversizedPods() != "" || admission != nil ||
+			config.GetClaimPercentage() < 100
+		options := k8sclient.PermissionOptions{
+			Shadow:                 config.GetShadowMode(),
+			DaemonSetOverhead:      config.GetDaemonSetOverhead(),
+			NamespaceNodeSelectors: config.GetNamespaceNodeSelectors(),
+			StatsTokenReview:       stats.Authentication(config.GetStatsAuthentication()) == stats.TokenAuthentication,
+			PodConditions:          podConditions && !config.GetShadowMode(),
+			PlacementDecisions:     config.GetPlacementDecisionTTL() > 0,
+		}
+		if config.GetStatusConfigMap() != "" {
+			options.StatusNamespace, _, err = k8sclient.ParseStatusConfigMap(config.GetStatusConfigMap())
+			if err != nil {
+				glog.Fatalf("Invalid --statusConfigMap: %v", err)
+			}
+		}
+		if err := k8sclient.CheckPermissions(config.GetKubeConfig(), options); err != nil {
+			glog.Fatalf("Permission self-check failed: %v", err)
+		}
+	}
+	logStartupReport()
+	k8sclient.New(k8sclient.Options{
+		SchedulerName:          schedulerName,
+		KubeConfig:             config.GetKubeConfig(),
+		KubeVersionMajor:       kubeMajorVer,
+		KubeVersionMinor:       kubeMinorVer,
+		FirmamentAddress:       config.GetFirmamentAddress(),
+		Shards:                 sharded,
+		MaxFirmamentBacklog:    config.GetMaxFirmamentBacklog(),
+		ClaimPercentage:        config.GetClaimPercentage(),
+		Shadow:                 config.GetShadowMode(),
+		NodeWarmUp:             time.Duration(config.GetNodeWarmUpPeriod()) * time.Second,
+		WarmUpCompleteLabel:    config.GetNodeWarmUpCompleteLabel(),
+		NotReadyGrace:          time.Duration(config.GetNodeNotReadyGracePeriod()) * time.Second,
+		Priorities:             priorities,
+		MigrateFromTerminating: config.GetMigrateFromTerminatingNodes(),
+		DaemonSetOverhead:      config.GetDaemonSetOverhead(),
+		TenantLimits:           tenantLimits,
+		CacheTemplates:         config.GetCacheTemplateConstraints(),
+		AnnotateZone:           config.GetAnnotateTopologyZone(),
+		Quarantine:             quarantine,
+		NamespaceSelectors:     config.GetNamespaceNodeSelectors(),
+		NodePools:              nodePools,
+		Deletion:               deletion,
+		NodeWorkers:            config.GetNodeWorkers(),
+		PodWorkers:             config.GetPodWorkers(),
+		Gangs: &k8sclient.GangPolicy{
+			Timeout: time.Duration(config.GetPodGroupTimeout()) * time.Second,
+			Backoff: time.Duration(config.GetPodGroupBackoff()) * time.Second,
+		},
+		Devices:            devices,
+		MemoryQoS:          memoryQoS,
+		Zones:              zones,
+		RejectUnresolvable: config.GetRejectUnresolvableConstraints(),
+		Oversized:          oversized,
+		Admission:          admission,
+		Backpressure:       backpressure,
+		FairShares:         fairShares,
+		Churn: &k8sclient.ChurnPolicy{
+			Window: time.Duration(config.GetChurnWindow()) * time.Second,
+			Budget: config.GetChurnBudget(),
+		},
+	})
 }
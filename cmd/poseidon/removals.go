@@ -0,0 +1,49 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/k8sclient"
+)
+
+// removalHandler serves where the pods would move if the nodes given by the
+// repeated node query parameter were removed, and whether they all still
+// fit, for the cluster autoscaler or the operators' scale-down automation.
+func removalHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nodes := r.URL.Query()["node"]
+		if len(nodes) == 0 {
+			http.Error(w, "the node parameter is required", http.StatusBadRequest)
+			return
+		}
+		simulation, err := k8sclient.SimulateNodeRemoval(nodes)
+		if err == k8sclient.ErrUnknownNode {
+			http.Error(w, fmt.Sprintf("nodes %v: %v", nodes, err), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(simulation)
+	})
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/k8sclient"
+	"github.com/kubernetes-sigs/poseidon/pkg/scheduler"
+)
+
+const replayUsage = "usage: poseidon replay <cycles.json> [--kubeConfig=<path>] [--replayDryRun=false] [--replayMaxDeltas=<n>]"
+
+// runReplay implements the replay command: it re-applies the deltas of the
+// cycles saved from /cycles against the cluster of kubeConfig, pinning the
+// pods to their recorded nodes, and prints the result of each delta.
+func runReplay(args []string, kubeConfig string, dryRun bool, maxDeltas int, out io.Writer) error {
+	if len(args) != 1 {
+		return errors.New(replayUsage)
+	}
+	file, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	cycles, err := scheduler.LoadCycles(file)
+	if err != nil {
+		return err
+	}
+	cluster, err := k8sclient.NewReplayCluster(kubeConfig, dryRun)
+	if err != nil {
+		return err
+	}
+	results := scheduler.Replay(cluster, scheduler.ReplayDeltas(cycles, maxDeltas))
+	return printReplay(out, results, dryRun)
+}
+
+// printReplay prints the replayed deltas and their results, and returns an
+// error if any of them failed.
+func printReplay(out io.Writer, results []scheduler.ReplayResult, dryRun bool) error {
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	ok := "applied"
+	if dryRun {
+		ok = "applicable"
+	}
+	var failed int
+	for i, result := range results {
+		status := ok
+		if result.Err != nil {
+			status = fmt.Sprintf("failed: %v", result.Err)
+			failed++
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", i+1, result.Delta.Type, result.Delta.Pod, result.Delta.Node, status)
+	}
+	w.Flush()
+	if failed > 0 {
+		return fmt.Errorf("%d of %d deltas failed", failed, len(results))
+	}
+	return nil
+}
+
+// replayMain runs the replay command and exits.
+func replayMain(args []string, kubeConfig string, dryRun bool, maxDeltas int) {
+	if err := runReplay(args, kubeConfig, dryRun, maxDeltas, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
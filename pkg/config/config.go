@@ -17,11 +17,15 @@ limitations under the License.
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/json"
 	"flag"
+	"fmt"
 	"strconv"
 	"strings"
 
 	"github.com/golang/glog"
+	"github.com/kubernetes-sigs/poseidon/pkg/features"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
@@ -29,14 +33,164 @@ import (
 var config poseidonConfig
 
 type poseidonConfig struct {
-	SchedulerName      string `json:"schedulerName,omitempty"`
-	FirmamentAddress   string `json:"firmamentAddress,omitempty"`
-	KubeConfig         string `json:"kubeConfig,omitempty"`
-	KubeVersion        string `json:"kubeVersion,omitempty"`
-	StatsServerAddress string `json:"statsServerAddress,omitempty"`
-	SchedulingInterval int    `json:"schedulingInterval,omitempty"`
-	FirmamentPort      string `json:"firmamentPort,omitempty"`
-	ConfigPath         string `json:"configPath,omitempty"`
+	SchedulerName                string `json:"schedulerName,omitempty"`
+	FirmamentAddress             string `json:"firmamentAddress,omitempty"`
+	KubeConfig                   string `json:"kubeConfig,omitempty"`
+	KubeVersion                  string `json:"kubeVersion,omitempty"`
+	StatsServerAddress           string `json:"statsServerAddress,omitempty"`
+	SchedulingInterval           int    `json:"schedulingInterval,omitempty"`
+	FirmamentPort                string `json:"firmamentPort,omitempty"`
+	FirmamentSRVRecord           string `json:"firmamentSRVRecord,omitempty"`
+	ConfigPath                   string `json:"configPath,omitempty"`
+	AdaptiveSchedulingInterval   bool   `json:"adaptiveSchedulingInterval,omitempty"`
+	MinSchedulingInterval        int    `json:"minSchedulingInterval,omitempty"`
+	MaxSchedulingInterval        int    `json:"maxSchedulingInterval,omitempty"`
+	BurstMaxPendingTasks         int    `json:"burstMaxPendingTasks,omitempty"`
+	BurstScheduleTimeout         int    `json:"burstScheduleTimeout,omitempty"`
+	MaxFirmamentBacklog          int    `json:"maxFirmamentBacklog,omitempty"`
+	AdminAddress                 string `json:"adminAddress,omitempty"`
+	ClaimPercentage              int    `json:"claimPercentage,omitempty"`
+	ShadowMode                   bool   `json:"shadowMode,omitempty"`
+	ShadowSchedulerName          string `json:"shadowSchedulerName,omitempty"`
+	PlacementHistoryPath         string `json:"placementHistoryPath,omitempty"`
+	PlacementHistoryMaxRecords   int    `json:"placementHistoryMaxRecords,omitempty"`
+	MaxPlacementsPerNode         int    `json:"maxPlacementsPerNode,omitempty"`
+	MaxPreemptionsPerNode        int    `json:"maxPreemptionsPerNode,omitempty"`
+	NodeWarmUpPeriod             int    `json:"nodeWarmUpPeriod,omitempty"`
+	NodeWarmUpCompleteLabel      string `json:"nodeWarmUpCompleteLabel,omitempty"`
+	PriorityMappingFile          string `json:"priorityMappingFile,omitempty"`
+	MetricsMaxSeries             int    `json:"metricsMaxSeries,omitempty"`
+	MetricsHighCardinalityLabels string `json:"metricsHighCardinalityLabels,omitempty"`
+	MetricsOverflowPolicy        string `json:"metricsOverflowPolicy,omitempty"`
+	PermissionSelfCheck          bool   `json:"permissionSelfCheck,omitempty"`
+	MigrateFromTerminatingNodes  bool   `json:"migrateFromTerminatingNodes,omitempty"`
+	DaemonSetOverhead            bool   `json:"daemonSetOverhead,omitempty"`
+	StatusConfigMap              string `json:"statusConfigMap,omitempty"`
+	TenantRateLimitFile          string `json:"tenantRateLimitFile,omitempty"`
+	CacheTemplateConstraints     bool   `json:"cacheTemplateConstraints,omitempty"`
+	AnnotateTopologyZone         bool   `json:"annotateTopologyZone,omitempty"`
+	QuarantineBindFailures       int    `json:"quarantineBindFailures,omitempty"`
+	QuarantineWindow             int    `json:"quarantineWindow,omitempty"`
+	QuarantineDuration           int    `json:"quarantineDuration,omitempty"`
+	NamespaceNodeSelectors       bool   `json:"namespaceNodeSelectors,omitempty"`
+	NodePoolPolicyFile           string `json:"nodePoolPolicyFile,omitempty"`
+	ScheduleTimeout              int    `json:"scheduleTimeout,omitempty"`
+	DecisionSamplingPath         string `json:"decisionSamplingPath,omitempty"`
+	DecisionSamplingPercentage   int    `json:"decisionSamplingPercentage,omitempty"`
+	DecisionSamplingSaltFile     string `json:"decisionSamplingSaltFile,omitempty"`
+	CycleLogSize                 int    `json:"cycleLogSize,omitempty"`
+	StatsValidation              bool   `json:"statsValidation,omitempty"`
+	StatsServerTLSCertFile       string `json:"statsServerTLSCertFile,omitempty"`
+	StatsServerTLSKeyFile        string `json:"statsServerTLSKeyFile,omitempty"`
+	StatsServerClientCAFile      string `json:"statsServerClientCAFile,omitempty"`
+	StatsAuthentication          string `json:"statsAuthentication,omitempty"`
+	StatsTrustedIdentities       string `json:"statsTrustedIdentities,omitempty"`
+	PreemptionGracePeriod        int    `json:"preemptionGracePeriod,omitempty"`
+	PreemptionPropagationPolicy  string `json:"preemptionPropagationPolicy,omitempty"`
+	NodeWorkers                  int    `json:"nodeWorkers,omitempty"`
+	PodWorkers                   int    `json:"podWorkers,omitempty"`
+	PodGroupTimeout              int    `json:"podGroupTimeout,omitempty"`
+	PodGroupBackoff              int    `json:"podGroupBackoff,omitempty"`
+	FallbackAfter                int    `json:"fallbackAfter,omitempty"`
+	FallbackStrategy             string `json:"fallbackStrategy,omitempty"`
+	FallbackMinPriority          int    `json:"fallbackMinPriority,omitempty"`
+	ReplayDryRun                 bool   `json:"replayDryRun,omitempty"`
+	ReplayMaxDeltas              int    `json:"replayMaxDeltas,omitempty"`
+	DeviceHealthGating           bool   `json:"deviceHealthGating,omitempty"`
+	MaxUnhealthyDevicePercent    int    `json:"maxUnhealthyDevicePercent,omitempty"`
+	MemoryQoS                    bool   `json:"memoryQoS,omitempty"`
+	MemoryThrottlingPercent      int    `json:"memoryThrottlingPercent,omitempty"`
+	FirmamentDownAfter           int    `json:"firmamentDownAfter,omitempty"`
+	PreemptionLogPath            string `json:"preemptionLogPath,omitempty"`
+	PreemptionLogMaxRecords      int    `json:"preemptionLogMaxRecords,omitempty"`
+	ZoneBalanceMaxSkew           int    `json:"zoneBalanceMaxSkew,omitempty"`
+	ZoneBalanceMigrations        bool   `json:"zoneBalanceMigrations,omitempty"`
+	RejectUnresolvable           bool   `json:"rejectUnresolvableConstraints,omitempty"`
+	OversizedPods                string `json:"oversizedPods,omitempty"`
+	OversizedPodScaleUpHint      bool   `json:"oversizedPodScaleUpHint,omitempty"`
+	PlacementDecisionTTL         int    `json:"placementDecisionTTL,omitempty"`
+	AdmissionLabel               string `json:"admissionLabel,omitempty"`
+	AdmittedAnnotation           string `json:"admittedAnnotation,omitempty"`
+	AdmissionUnplacedCycles      int    `json:"admissionUnplacedCycles,omitempty"`
+	BackpressureMaxSolveTime     int    `json:"backpressureMaxSolveTime,omitempty"`
+	BackpressureMaxBindTime      int    `json:"backpressureMaxBindTime,omitempty"`
+	BackpressureMinRate          int    `json:"backpressureMinRate,omitempty"`
+	KnowledgeBaseSeedFile        string `json:"knowledgeBaseSeedFile,omitempty"`
+	KnowledgeBasePrometheus      string `json:"knowledgeBasePrometheus,omitempty"`
+	FirmamentShardLabel          string `json:"firmamentShardLabel,omitempty"`
+	FirmamentShards              string `json:"firmamentShards,omitempty"`
+	ValidationWebhookAddress     string `json:"validationWebhookAddress,omitempty"`
+	ValidationWebhookTLSCertFile string `json:"validationWebhookTLSCertFile,omitempty"`
+	ValidationWebhookTLSKeyFile  string `json:"validationWebhookTLSKeyFile,omitempty"`
+	FairSharePolicyFile          string `json:"fairSharePolicyFile,omitempty"`
+	FeatureGates                 string `json:"featureGates,omitempty"`
+	NodeNotReadyGracePeriod      int    `json:"nodeNotReadyGracePeriod,omitempty"`
+	ChurnWindow                  int    `json:"churnWindow,omitempty"`
+	ChurnBudget                  int    `json:"churnBudget,omitempty"`
+}
+
+// Effective returns the effective configuration as JSON
+func Effective() json.RawMessage {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// ActivePolicies returns the names of the scheduling policies and features
+// the effective configuration enables
+func ActivePolicies() []string {
+	enabled := []struct {
+		name string
+		on   bool
+	}{
+		{"adaptiveSchedulingInterval", config.AdaptiveSchedulingInterval},
+		{"shadowMode", config.ShadowMode},
+		{"canary", config.ClaimPercentage < 100},
+		{"nodeWarmUp", config.NodeWarmUpPeriod > 0},
+		{"nodeNotReadyGracePeriod", config.NodeNotReadyGracePeriod > 0},
+		{"priorityMapping", config.PriorityMappingFile != ""},
+		{"migrateFromTerminatingNodes", config.MigrateFromTerminatingNodes},
+		{"daemonSetOverhead", config.DaemonSetOverhead},
+		{"tenantRateLimits", config.TenantRateLimitFile != ""},
+		{"fairShare", config.FairSharePolicyFile != ""},
+		{"cacheTemplateConstraints", config.CacheTemplateConstraints},
+		{"annotateTopologyZone", config.AnnotateTopologyZone},
+		{"quarantine", config.QuarantineBindFailures > 0},
+		{"namespaceNodeSelectors", config.NamespaceNodeSelectors},
+		{"nodePools", config.NodePoolPolicyFile != ""},
+		{"decisionSampling", config.DecisionSamplingPath != ""},
+		{"statsValidation", config.StatsValidation},
+		{"fallback", config.FallbackAfter > 0},
+		{"deviceHealthGating", config.DeviceHealthGating},
+		{"memoryQoS", config.MemoryQoS},
+		{"zoneBalance", config.ZoneBalanceMaxSkew > 0},
+		{"rejectUnresolvableConstraints", config.RejectUnresolvable},
+		{"oversizedPods", config.OversizedPods != ""},
+		{"admission", config.AdmissionLabel != ""},
+		{"backpressure", config.BackpressureMaxSolveTime > 0 || config.BackpressureMaxBindTime > 0},
+		{"knowledgeBaseSeed", config.KnowledgeBaseSeedFile != "" || config.KnowledgeBasePrometheus != ""},
+		{"firmamentShards", config.FirmamentShards != ""},
+		{"validationWebhook", config.ValidationWebhookAddress != ""},
+		{"churnBudget", config.ChurnBudget > 0},
+	}
+	policies := []string{}
+	for _, policy := range enabled {
+		if policy.on {
+			policies = append(policies, policy.name)
+		}
+	}
+	return policies
+}
+
+// Hash returns a hash identifying the effective configuration
+func Hash() string {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(data))[:16]
 }
 
 // GetSchedulerName returns the SchedulerName from config
@@ -46,6 +200,10 @@ func GetSchedulerName() string {
 
 // GetFirmamentAddress returns the FirmamentAddress from config
 func GetFirmamentAddress() string {
+	if config.FirmamentSRVRecord != "" {
+		// The endpoints are discovered and re-resolved by the SRV resolver.
+		return "srv:///" + config.FirmamentSRVRecord
+	}
 	// join the firmament address and port with a colon separator
 	// Passing the firmament address with port and colon separator throws an error
 	// for conversion from yaml to json
@@ -80,11 +238,461 @@ func GetStatsServerAddress() string {
 	return config.StatsServerAddress
 }
 
+// GetStatsServerAddresses returns the non empty addresses the stats server listens on from config
+func GetStatsServerAddresses() []string {
+	var addresses []string
+	for _, address := range strings.Split(config.StatsServerAddress, ",") {
+		if address = strings.TrimSpace(address); address != "" {
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses
+}
+
 // GetSchedulingInterval return the scheduling interval from config
 func GetSchedulingInterval() int {
 	return config.SchedulingInterval
 }
 
+// GetAdaptiveSchedulingInterval returns if the scheduling interval adapts to the cycle durations
+func GetAdaptiveSchedulingInterval() bool {
+	return config.AdaptiveSchedulingInterval
+}
+
+// GetMinSchedulingInterval returns the lower bound of the adaptive scheduling interval from config
+func GetMinSchedulingInterval() int {
+	return config.MinSchedulingInterval
+}
+
+// GetMaxSchedulingInterval returns the upper bound of the adaptive scheduling interval from config
+func GetMaxSchedulingInterval() int {
+	return config.MaxSchedulingInterval
+}
+
+// GetBurstMaxPendingTasks returns the max number of pending tasks for which a burst scheduler run is triggered
+func GetBurstMaxPendingTasks() int {
+	return config.BurstMaxPendingTasks
+}
+
+// GetBurstScheduleTimeout returns the timeout of burst scheduler runs (in milliseconds) from config
+func GetBurstScheduleTimeout() int {
+	return config.BurstScheduleTimeout
+}
+
+// GetMaxFirmamentBacklog returns the number of unplaced tasks above which new submissions are deferred
+func GetMaxFirmamentBacklog() int {
+	return config.MaxFirmamentBacklog
+}
+
+// GetAdminAddress returns the address of the admin HTTP server from config
+func GetAdminAddress() string {
+	return config.AdminAddress
+}
+
+// GetClaimPercentage returns the percentage of eligible pods claimed by Poseidon from config
+func GetClaimPercentage() int {
+	return config.ClaimPercentage
+}
+
+// GetShadowMode returns if Poseidon only shadows the placements of another scheduler
+func GetShadowMode() bool {
+	return config.ShadowMode
+}
+
+// GetShadowSchedulerName returns the name of the shadowed scheduler from config
+func GetShadowSchedulerName() string {
+	return config.ShadowSchedulerName
+}
+
+// GetPlacementHistoryPath returns the path of the placement history store from config
+func GetPlacementHistoryPath() string {
+	return config.PlacementHistoryPath
+}
+
+// GetPlacementHistoryMaxRecords returns the max number of records kept in the placement history from config
+func GetPlacementHistoryMaxRecords() int {
+	return config.PlacementHistoryMaxRecords
+}
+
+// GetMaxPlacementsPerNode returns the max number of placements on a node per scheduling cycle from config
+func GetMaxPlacementsPerNode() int {
+	return config.MaxPlacementsPerNode
+}
+
+// GetMaxPreemptionsPerNode returns the max number of preemptions on a node per scheduling cycle from config
+func GetMaxPreemptionsPerNode() int {
+	return config.MaxPreemptionsPerNode
+}
+
+// GetNodeWarmUpPeriod returns the period over which the capacity of new nodes ramps up from config
+func GetNodeWarmUpPeriod() int {
+	return config.NodeWarmUpPeriod
+}
+
+// GetNodeWarmUpCompleteLabel returns the node label ending the warm-up from config
+func GetNodeWarmUpCompleteLabel() string {
+	return config.NodeWarmUpCompleteLabel
+}
+
+// GetPriorityMappingFile returns the path of the pod to Firmament priority mapping from config
+func GetPriorityMappingFile() string {
+	return config.PriorityMappingFile
+}
+
+// GetMetricsMaxSeries returns the max number of series per metric from config
+func GetMetricsMaxSeries() int {
+	return config.MetricsMaxSeries
+}
+
+// GetMetricsHighCardinalityLabels returns the metric labels limited by the max number of series from config
+func GetMetricsHighCardinalityLabels() []string {
+	if config.MetricsHighCardinalityLabels == "" {
+		return nil
+	}
+	return strings.Split(config.MetricsHighCardinalityLabels, ",")
+}
+
+// GetMetricsOverflowPolicy returns the policy applied to the series above the limit from config
+func GetMetricsOverflowPolicy() string {
+	return config.MetricsOverflowPolicy
+}
+
+// GetPermissionSelfCheck returns true if the API permissions must be checked at startup from config
+func GetPermissionSelfCheck() bool {
+	return config.PermissionSelfCheck
+}
+
+// GetMigrateFromTerminatingNodes returns true if the pods of the terminating nodes must be migrated from config
+func GetMigrateFromTerminatingNodes() bool {
+	return config.MigrateFromTerminatingNodes
+}
+
+// GetDaemonSetOverhead returns true if the DaemonSet requests must be discounted from the node capacity from config
+func GetDaemonSetOverhead() bool {
+	return config.DaemonSetOverhead
+}
+
+// GetStatusConfigMap returns the namespace/name of the ConfigMap publishing the scheduler status from config
+func GetStatusConfigMap() string {
+	return config.StatusConfigMap
+}
+
+// GetTenantRateLimitFile returns the path of the per namespace submission rate limits file from config
+func GetTenantRateLimitFile() string {
+	return config.TenantRateLimitFile
+}
+
+// GetCacheTemplateConstraints returns true if the pod constraints must be cached by pod template from config
+func GetCacheTemplateConstraints() bool {
+	return config.CacheTemplateConstraints
+}
+
+// GetAnnotateTopologyZone returns true if the bound pods must be annotated with the zone of their node from config
+func GetAnnotateTopologyZone() bool {
+	return config.AnnotateTopologyZone
+}
+
+// GetQuarantineBindFailures returns the number of failed binds after which a node is quarantined from config
+func GetQuarantineBindFailures() int {
+	return config.QuarantineBindFailures
+}
+
+// GetQuarantineWindow returns the window in seconds in which the failed binds of a node are counted from config
+func GetQuarantineWindow() int {
+	return config.QuarantineWindow
+}
+
+// GetQuarantineDuration returns the duration in seconds of the quarantine of a node from config
+func GetQuarantineDuration() int {
+	return config.QuarantineDuration
+}
+
+// GetNamespaceNodeSelectors returns true if the node selectors of the namespaces must be honored from config
+func GetNamespaceNodeSelectors() bool {
+	return config.NamespaceNodeSelectors
+}
+
+// GetNodePoolPolicyFile returns the path of the priority class to node pool policy from config
+func GetNodePoolPolicyFile() string {
+	return config.NodePoolPolicyFile
+}
+
+// GetScheduleTimeout returns the deadline of the scheduler runs (in seconds) from config
+func GetScheduleTimeout() int {
+	return config.ScheduleTimeout
+}
+
+// GetDecisionSamplingPath returns the path of the file recording the sampled scheduling decisions from config
+func GetDecisionSamplingPath() string {
+	return config.DecisionSamplingPath
+}
+
+// GetDecisionSamplingPercentage returns the percentage of the scheduling decisions sampled from config
+func GetDecisionSamplingPercentage() int {
+	return config.DecisionSamplingPercentage
+}
+
+// GetDecisionSamplingSaltFile returns the path of the file holding the salt of the anonymized names from config
+func GetDecisionSamplingSaltFile() string {
+	return config.DecisionSamplingSaltFile
+}
+
+// GetCycleLogSize returns the number of scheduling cycles whose deltas are served on /cycles from config
+func GetCycleLogSize() int {
+	return config.CycleLogSize
+}
+
+// GetStatsValidation returns whether the inconsistent stats are rejected from config
+func GetStatsValidation() bool {
+	return config.StatsValidation
+}
+
+// GetStatsServerTLSCertFile returns the certificate file of the stats server from config
+func GetStatsServerTLSCertFile() string {
+	return config.StatsServerTLSCertFile
+}
+
+// GetStatsServerTLSKeyFile returns the key file of the stats server from config
+func GetStatsServerTLSKeyFile() string {
+	return config.StatsServerTLSKeyFile
+}
+
+// GetStatsServerClientCAFile returns the CA file verifying the client certificates of the stats server from config
+func GetStatsServerClientCAFile() string {
+	return config.StatsServerClientCAFile
+}
+
+// GetStatsAuthentication returns the way the stats senders are authenticated from config
+func GetStatsAuthentication() string {
+	return config.StatsAuthentication
+}
+
+// GetStatsTrustedIdentities returns the identities allowed to send the stats of any node from config
+func GetStatsTrustedIdentities() []string {
+	if config.StatsTrustedIdentities == "" {
+		return nil
+	}
+	return strings.Split(config.StatsTrustedIdentities, ",")
+}
+
+// GetPreemptionGracePeriod returns the grace period (in seconds) of the preempted pods from config
+func GetPreemptionGracePeriod() int {
+	return config.PreemptionGracePeriod
+}
+
+// GetPreemptionPropagationPolicy returns the propagation policy of the deletion of the preempted pods from config
+func GetPreemptionPropagationPolicy() string {
+	return config.PreemptionPropagationPolicy
+}
+
+// GetNodeWorkers returns the number of workers synchronizing the nodes with Firmament from config
+func GetNodeWorkers() int {
+	return config.NodeWorkers
+}
+
+// GetPodWorkers returns the number of workers synchronizing the pods with Firmament from config
+func GetPodWorkers() int {
+	return config.PodWorkers
+}
+
+// GetPodGroupTimeout returns the time in seconds the placements of a pod group wait for its minAvailable pods from config
+func GetPodGroupTimeout() int {
+	return config.PodGroupTimeout
+}
+
+// GetPodGroupBackoff returns the backoff in seconds of the timed out pod groups from config
+func GetPodGroupBackoff() int {
+	return config.PodGroupBackoff
+}
+
+// GetFallbackAfter returns the Firmament outage in seconds after which the fallback scheduler places the pods from config
+func GetFallbackAfter() int {
+	return config.FallbackAfter
+}
+
+// GetFallbackStrategy returns the placement strategy of the fallback scheduler from config
+func GetFallbackStrategy() string {
+	return config.FallbackStrategy
+}
+
+// GetFallbackMinPriority returns the lowest Firmament priority of the tasks placed by the fallback scheduler from config
+func GetFallbackMinPriority() int {
+	return config.FallbackMinPriority
+}
+
+// GetReplayDryRun returns whether the replay command only checks the recorded deltas could be applied from config
+func GetReplayDryRun() bool {
+	return config.ReplayDryRun
+}
+
+// GetReplayMaxDeltas returns the number of recorded deltas applied by the replay command from config
+func GetReplayMaxDeltas() int {
+	return config.ReplayMaxDeltas
+}
+
+// GetDeviceHealthGating returns whether the pods requesting devices are kept off the nodes with degraded devices from config
+func GetDeviceHealthGating() bool {
+	return config.DeviceHealthGating
+}
+
+// GetMaxUnhealthyDevicePercent returns the largest percentage of unhealthy devices of a node still accepting the pods requesting them from config
+func GetMaxUnhealthyDevicePercent() int {
+	return config.MaxUnhealthyDevicePercent
+}
+
+// GetMemoryQoS returns whether the memory QoS of the cgroup v2 nodes is accounted from config
+func GetMemoryQoS() bool {
+	return config.MemoryQoS
+}
+
+// GetMemoryThrottlingPercent returns the memory throttling factor of the kubelets of the cgroup v2 nodes in percent from config
+func GetMemoryThrottlingPercent() int {
+	return config.MemoryThrottlingPercent
+}
+
+// GetFirmamentDownAfter returns the time in seconds after which a failing Firmament is down from config
+func GetFirmamentDownAfter() int {
+	return config.FirmamentDownAfter
+}
+
+// GetPreemptionLogPath returns the path of the preemption log from config
+func GetPreemptionLogPath() string {
+	return config.PreemptionLogPath
+}
+
+// GetPreemptionLogMaxRecords returns the max number of records kept in the preemption log from config
+func GetPreemptionLogMaxRecords() int {
+	return config.PreemptionLogMaxRecords
+}
+
+// GetZoneBalanceMaxSkew returns the largest difference between the replicas of a ReplicaSet in two zones from config
+func GetZoneBalanceMaxSkew() int {
+	return config.ZoneBalanceMaxSkew
+}
+
+// GetZoneBalanceMigrations returns whether replicas are deleted to balance their ReplicaSet across zones from config
+func GetZoneBalanceMigrations() bool {
+	return config.ZoneBalanceMigrations
+}
+
+// GetRejectUnresolvableConstraints returns whether the pods whose constraints no node satisfies are marked unschedulable from config
+func GetRejectUnresolvableConstraints() bool {
+	return config.RejectUnresolvable
+}
+
+// GetOversizedPods returns the handling of the pods exceeding the allocatable of every node from config
+func GetOversizedPods() string {
+	return config.OversizedPods
+}
+
+// GetOversizedPodScaleUpHint returns whether the oversized pods are marked unschedulable for the cluster autoscaler from config
+func GetOversizedPodScaleUpHint() bool {
+	return config.OversizedPodScaleUpHint
+}
+
+// GetPlacementDecisionTTL returns the time in seconds the PlacementDecision custom resources are kept from config
+func GetPlacementDecisionTTL() int {
+	return config.PlacementDecisionTTL
+}
+
+// GetAdmissionLabel returns the label key of the pods managed by an external admission system from config
+func GetAdmissionLabel() string {
+	return config.AdmissionLabel
+}
+
+// GetAdmittedAnnotation returns the annotation set on the admitted pods by the admission system from config
+func GetAdmittedAnnotation() string {
+	return config.AdmittedAnnotation
+}
+
+// GetAdmissionUnplacedCycles returns the number of cycles after which the unplaced admitted pods are reported from config
+func GetAdmissionUnplacedCycles() int {
+	return config.AdmissionUnplacedCycles
+}
+
+// GetBackpressureMaxSolveTime returns the solver run duration in milliseconds above which the scheduler is saturated from config
+func GetBackpressureMaxSolveTime() int {
+	return config.BackpressureMaxSolveTime
+}
+
+// GetBackpressureMaxBindTime returns the bind duration in milliseconds above which the scheduler is saturated from config
+func GetBackpressureMaxBindTime() int {
+	return config.BackpressureMaxBindTime
+}
+
+// GetBackpressureMinRate returns the lowest task submission rate while the scheduler is saturated from config
+func GetBackpressureMinRate() int {
+	return config.BackpressureMinRate
+}
+
+// GetKnowledgeBaseSeedFile returns the path of the CSV file of the historical usage seeding Firmament from config
+func GetKnowledgeBaseSeedFile() string {
+	return config.KnowledgeBaseSeedFile
+}
+
+// GetKnowledgeBasePrometheus returns the address of the Prometheus server of the historical usage seeding Firmament from config
+func GetKnowledgeBasePrometheus() string {
+	return config.KnowledgeBasePrometheus
+}
+
+// GetFirmamentShardLabel returns the node label partitioning the nodes between the Firmament shards from config
+func GetFirmamentShardLabel() string {
+	return config.FirmamentShardLabel
+}
+
+// GetFirmamentShards returns the Firmament shards, as <label value>=<address> pairs, from config
+func GetFirmamentShards() []string {
+	if config.FirmamentShards == "" {
+		return nil
+	}
+	return strings.Split(config.FirmamentShards, ",")
+}
+
+// GetValidationWebhookAddress returns the address of the validating webhook server from config
+func GetValidationWebhookAddress() string {
+	return config.ValidationWebhookAddress
+}
+
+// GetValidationWebhookTLSCertFile returns the certificate file of the validating webhook server from config
+func GetValidationWebhookTLSCertFile() string {
+	return config.ValidationWebhookTLSCertFile
+}
+
+// GetValidationWebhookTLSKeyFile returns the key file of the validating webhook server from config
+func GetValidationWebhookTLSKeyFile() string {
+	return config.ValidationWebhookTLSKeyFile
+}
+
+// GetFairSharePolicyFile returns the path of the fair share policy file from config
+func GetFairSharePolicyFile() string {
+	return config.FairSharePolicyFile
+}
+
+// GetFeatureGates returns the feature gates from config
+func GetFeatureGates() string {
+	return config.FeatureGates
+}
+
+// GetNodeNotReadyGracePeriod returns the time in seconds a node stays not ready before it is failed from config
+func GetNodeNotReadyGracePeriod() int {
+	return config.NodeNotReadyGracePeriod
+}
+
+// GetChurnWindow returns the window in seconds over which the churn of the pod owners is counted from config
+func GetChurnWindow() int {
+	return config.ChurnWindow
+}
+
+// GetChurnBudget returns the largest number of disruptions of the pods of an owner within the churn window from config
+func GetChurnBudget() int {
+	return config.ChurnBudget
+}
+
+// GetArgs returns the command line arguments left after the flags
+func GetArgs() []string {
+	return pflag.Args()
+}
+
 // GetConfigPath returns the config path from  config
 func GetConfigPath() string {
 	return config.ConfigPath
@@ -114,10 +722,173 @@ func ReadFromCommandLineFlags() {
 	pflag.StringVar(&config.SchedulerName, "schedulerName", "poseidon", "The scheduler name with which pods are labeled")
 	pflag.StringVar(&config.FirmamentAddress, "firmamentAddress", "firmament-service.kube-system", "Firmament scheduler service port")
 	pflag.StringVar(&config.FirmamentPort, "firmamentPort", "9090", "Firmament scheduler service port")
+	pflag.StringVar(&config.FirmamentSRVRecord, "firmamentSRVRecord", "",
+		"DNS SRV record of the Firmament endpoints, e.g. _grpc._tcp.firmament-service.kube-system.svc.cluster.local for a headless service, overrides firmamentAddress and firmamentPort")
 	pflag.StringVar(&config.KubeConfig, "kubeConfig", "kubeconfig.cfg", "Path to the kubeconfig file")
 	pflag.StringVar(&config.KubeVersion, "kubeVersion", "1.6", "Kubernetes version")
-	pflag.StringVar(&config.StatsServerAddress, "statsServerAddress", "0.0.0.0:9091", "Address on which the stats server listens")
+	pflag.StringVar(&config.StatsServerAddress, "statsServerAddress", "0.0.0.0:9091", "Comma separated addresses on which the stats server listens, the stats are sharded between as many Firmament connections")
 	pflag.IntVar(&config.SchedulingInterval, "schedulingInterval", 10, "Time between scheduler runs (in seconds)")
+	pflag.BoolVar(&config.AdaptiveSchedulingInterval, "adaptiveSchedulingInterval", false,
+		"Adapt the time between scheduler runs to the measured solver and bind durations, within the min and max bounds")
+	pflag.IntVar(&config.MinSchedulingInterval, "minSchedulingInterval", 1, "Lower bound of the adaptive scheduling interval (in seconds)")
+	pflag.IntVar(&config.MaxSchedulingInterval, "maxSchedulingInterval", 60, "Upper bound of the adaptive scheduling interval (in seconds)")
+	pflag.IntVar(&config.BurstMaxPendingTasks, "burstMaxPendingTasks", 0,
+		"Run the scheduler immediately when a pod is submitted and at most this many pods are pending (0 disables burst runs)")
+	pflag.IntVar(&config.BurstScheduleTimeout, "burstScheduleTimeout", 1000, "Deadline for burst scheduler runs (in milliseconds)")
+	pflag.IntVar(&config.MaxFirmamentBacklog, "maxFirmamentBacklog", 0,
+		"Defer new pod submissions while this many submitted pods are not placed by Firmament (0 disables the limit)")
+	pflag.StringVar(&config.AdminAddress, "adminAddress", "0.0.0.0:9092", "Address on which the admin HTTP server serving metrics and debug endpoints listens")
+	pflag.IntVar(&config.ClaimPercentage, "claimPercentage", 100,
+		"Percentage of eligible pods claimed by Poseidon, selected by hashing the pod owner (canary rollout)")
+	pflag.BoolVar(&config.ShadowMode, "shadowMode", false,
+		"Compute placements for the pods of the shadowed scheduler without binding them and export agreement metrics")
+	pflag.StringVar(&config.ShadowSchedulerName, "shadowSchedulerName", "default-scheduler", "The scheduler name of the pods shadowed in shadow mode")
+	pflag.StringVar(&config.PlacementHistoryPath, "placementHistoryPath", "",
+		"Path of the file recording every placement and preemption, served on /placements (empty disables the history)")
+	pflag.IntVar(&config.PlacementHistoryMaxRecords, "placementHistoryMaxRecords", 100000, "Max number of records kept in the placement history")
+	pflag.IntVar(&config.MaxPlacementsPerNode, "maxPlacementsPerNode", 0,
+		"Max number of pods placed on a node per scheduling cycle, the others are deferred to the next cycles (0 means no limit)")
+	pflag.IntVar(&config.MaxPreemptionsPerNode, "maxPreemptionsPerNode", 0,
+		"Max number of pods preempted or migrated from a node per scheduling cycle, the others are deferred to the next cycles (0 means no limit)")
+	pflag.IntVar(&config.NodeWarmUpPeriod, "nodeWarmUpPeriod", 0,
+		"Time in seconds over which the capacity of newly added nodes ramps up to their full capacity (0 disables the ramp)")
+	pflag.StringVar(&config.NodeWarmUpCompleteLabel, "nodeWarmUpCompleteLabel", "",
+		"Node label which ends the warm-up of the node once set to true, e.g. when its CNI and image cache are ready")
+	pflag.StringVar(&config.PriorityMappingFile, "priorityMappingFile", "",
+		"Path of the JSON file mapping pod priority classes and values to Firmament task priorities and preemption eligibility")
+	pflag.IntVar(&config.MetricsMaxSeries, "metricsMaxSeries", 0,
+		"Max number of series of the metrics partitioned by high cardinality labels (0 means no limit)")
+	pflag.StringVar(&config.MetricsHighCardinalityLabels, "metricsHighCardinalityLabels", "namespace,pod",
+		"Comma separated metric labels whose series are limited by --metricsMaxSeries")
+	pflag.StringVar(&config.MetricsOverflowPolicy, "metricsOverflowPolicy", "aggregate",
+		"Policy for the series above --metricsMaxSeries: aggregate them into an __other__ series (gauges excepted) or drop them")
+	pflag.BoolVar(&config.PermissionSelfCheck, "permissionSelfCheck", true,
+		"Check at startup that all the required API permissions are granted and exit with a report of the missing ones otherwise")
+	pflag.BoolVar(&config.MigrateFromTerminatingNodes, "migrateFromTerminatingNodes", false,
+		"Migrate the running pods off the nodes tainted for removal by the cluster autoscaler or the cloud provider before they are removed")
+	pflag.BoolVar(&config.DaemonSetOverhead, "daemonSetOverhead", false,
+		"Discount the requests of the DaemonSets from the capacity of the nodes matching their node selector, even before their pods run")
+	pflag.StringVar(&config.StatusConfigMap, "statusConfigMap", "",
+		"The namespace/name of a ConfigMap updated after each scheduling cycle with the scheduler status, disabled if empty")
+	pflag.StringVar(&config.TenantRateLimitFile, "tenantRateLimitFile", "",
+		"The path of a JSON file limiting the rate at which the pods of each namespace are submitted to Firmament, with the surplus rate shared by weighted fair queuing")
+	pflag.BoolVar(&config.CacheTemplateConstraints, "cacheTemplateConstraints", false,
+		"Compute the requests and node constraints of the pods once per pod-template-hash. Must not be set if admission webhooks set different requests on the replicas of a template")
+	pflag.BoolVar(&config.AnnotateTopologyZone, "annotateTopologyZone", false,
+		"Annotate the pods with the topology zone of their node when binding them, as a hint for topology aware routing")
+	pflag.IntVar(&config.QuarantineBindFailures, "quarantineBindFailures", 0,
+		"Quarantine a node from new placements after this many binds of pods to it failed within --quarantineWindow, 0 disables the quarantine")
+	pflag.IntVar(&config.QuarantineWindow, "quarantineWindow", 60,
+		"The window in seconds in which the failed binds of a node are counted")
+	pflag.IntVar(&config.QuarantineDuration, "quarantineDuration", 300,
+		"The duration in seconds of the quarantine of a node")
+	pflag.BoolVar(&config.NamespaceNodeSelectors, "namespaceNodeSelectors", false,
+		"Merge the node selector annotation of the namespaces into the node selector of their pods, add their default label selectors and node preferences annotations to the constraints of their pods, and hold back the pods selecting labels out of the node selector whitelist annotation of their namespace")
+	pflag.StringVar(&config.NodePoolPolicyFile, "nodePoolPolicyFile", "",
+		"Path of the JSON file restricting the pods of priority classes to node pools, and dedicating node pools to priority classes")
+	pflag.IntVar(&config.ScheduleTimeout, "scheduleTimeout", 0,
+		"Deadline for scheduler runs (in seconds), 0 disables it. The tasks placed by a run which exceeds it are not bound, so it must be well above the solver runtime")
+	pflag.StringVar(&config.DecisionSamplingPath, "decisionSamplingPath", "",
+		"Path of the file recording the features and outcomes of a sample of the scheduling decisions, with anonymized pod and node names (empty disables the sampling)")
+	pflag.IntVar(&config.DecisionSamplingPercentage, "decisionSamplingPercentage", 1, "Percentage of the scheduling decisions sampled")
+	pflag.StringVar(&config.DecisionSamplingSaltFile, "decisionSamplingSaltFile", "",
+		"Path of the file holding the salt of the anonymized names, so that they can be joined across runs (empty uses a random salt per run)")
+	pflag.IntVar(&config.CycleLogSize, "cycleLogSize", 10,
+		"Number of most recent scheduling cycles whose deltas and their results are served on /cycles (0 disables the cycle log)")
+	pflag.BoolVar(&config.StatsValidation, "statsValidation", false,
+		"Reject the inconsistent node and pod stats, and the stats of pods not reported by the node they are bound to")
+	pflag.StringVar(&config.StatsServerTLSCertFile, "statsServerTLSCertFile", "", "Certificate file of the stats server (empty disables TLS)")
+	pflag.StringVar(&config.StatsServerTLSKeyFile, "statsServerTLSKeyFile", "", "Key file of the stats server")
+	pflag.StringVar(&config.StatsServerClientCAFile, "statsServerClientCAFile", "",
+		"CA file verifying the client certificates of the stats server (empty does not request client certificates)")
+	pflag.StringVar(&config.StatsAuthentication, "statsAuthentication", "",
+		"Authentication of the stats senders: tls (client certificate) or token (TokenReview of the bearer token), empty disables it. "+
+			"The nodes (system:node:<name>) may only send their own stats")
+	pflag.StringVar(&config.StatsTrustedIdentities, "statsTrustedIdentities", "",
+		"Comma separated identities allowed to send the stats of any node, e.g. the service account of the metrics pipeline")
+	pflag.IntVar(&config.PreemptionGracePeriod, "preemptionGracePeriod", -1,
+		"Grace period (in seconds) overriding the termination grace period of the preempted and migrated pods, 0 deletes them immediately "+
+			"(negative keeps the grace period of the pods)")
+	pflag.StringVar(&config.PreemptionPropagationPolicy, "preemptionPropagationPolicy", "",
+		"Propagation policy of the deletion of the preempted and migrated pods: Orphan, Background or Foreground (empty uses the API server default)")
+	pflag.IntVar(&config.NodeWorkers, "nodeWorkers", 10,
+		"Number of workers synchronizing the nodes with Firmament, which bounds the startup time on large clusters")
+	pflag.IntVar(&config.PodWorkers, "podWorkers", 10,
+		"Number of workers synchronizing the pods with Firmament, which bounds the startup time on large clusters")
+	pflag.IntVar(&config.PodGroupTimeout, "podGroupTimeout", 300,
+		"Time (in seconds) the placed pods of a pod group are held waiting for its minAvailable pods to be placed, before their capacity is released "+
+			"(0 holds them until the group fits)")
+	pflag.IntVar(&config.PodGroupBackoff, "podGroupBackoff", 60,
+		"Time (in seconds) during which the placements of a timed out pod group are released right away")
+	pflag.IntVar(&config.FallbackAfter, "fallbackAfter", 0,
+		"Time (in seconds) Firmament must be unavailable for before the pending pods are placed greedily by the fallback scheduler (0 disables it)")
+	pflag.StringVar(&config.FallbackStrategy, "fallbackStrategy", "first-fit", "Placement strategy of the fallback scheduler: first-fit or best-fit")
+	pflag.IntVar(&config.FallbackMinPriority, "fallbackMinPriority", 0,
+		"Lowest Firmament priority of the tasks placed by the fallback scheduler, to restrict it to the critical pods")
+	pflag.BoolVar(&config.ReplayDryRun, "replayDryRun", true,
+		"Only check the deltas replayed by the replay command could be applied, set it to false to bind and delete the pods")
+	pflag.IntVar(&config.ReplayMaxDeltas, "replayMaxDeltas", 0,
+		"Number of recorded deltas applied by the replay command, to bisect the deltas leading to an anomaly (0 applies all of them)")
+	pflag.BoolVar(&config.DeviceHealthGating, "deviceHealthGating", false,
+		"Keep the pods requesting devices, e.g. GPUs, off the nodes whose device plugins report unhealthy devices of the requested resources")
+	pflag.IntVar(&config.MaxUnhealthyDevicePercent, "maxUnhealthyDevicePercent", 0,
+		"Largest percentage of unhealthy devices of a resource for which a node still accepts the pods requesting it (0 excludes the nodes with any unhealthy device)")
+	pflag.BoolVar(&config.MemoryQoS, "memoryQoS", false,
+		"Discount from the memory headroom of the nodes labeled poseidon.k8s.io/cgroup-v2=true the memory their Burstable pods may use above their requests before memory.high throttles them")
+	pflag.IntVar(&config.MemoryThrottlingPercent, "memoryThrottlingPercent", 80,
+		"The memoryThrottlingFactor of the kubelets of the cgroup v2 nodes in percent, memory.high is the request plus this percentage of the gap to the limit")
+	pflag.IntVar(&config.FirmamentDownAfter, "firmamentDownAfter", 600,
+		"Time in seconds after which Firmament failing its health checks is down, the scheduler exits if it is down at startup or without fallback scheduler")
+	pflag.StringVar(&config.PreemptionLogPath, "preemptionLogPath", "",
+		"Path of the file persisting the log of the preempted and migrated pods served on /preemptions, the log is only kept in memory if empty")
+	pflag.IntVar(&config.PreemptionLogMaxRecords, "preemptionLogMaxRecords", 10000, "Max number of records kept in the preemption log")
+	pflag.IntVar(&config.ZoneBalanceMaxSkew, "zoneBalanceMaxSkew", 0,
+		"Largest difference between the number of replicas of a ReplicaSet in two zones, the pending replicas avoid the zones which would exceed it (0 disables the zone balancing)")
+	pflag.BoolVar(&config.ZoneBalanceMigrations, "zoneBalanceMigrations", false,
+		"Delete a replica of the most loaded zone of the ReplicaSets exceeding --zoneBalanceMaxSkew at every cycle, so that it is replaced in another zone")
+	pflag.BoolVar(&config.RejectUnresolvable, "rejectUnresolvableConstraints", false,
+		"Mark the pending pods whose constraints no node satisfies unschedulable instead of submitting them to Firmament, they are retried until a matching node is added")
+	pflag.StringVar(&config.OversizedPods, "oversizedPods", "",
+		"Handling of the pending pods whose requests exceed the allocatable of every node: report them with an event and a condition, or reject them until a large enough node is added (empty submits them as the other pods)")
+	pflag.BoolVar(&config.OversizedPodScaleUpHint, "oversizedPodScaleUpHint", false,
+		"Also mark the oversized pods unschedulable so that the cluster autoscaler adds a larger node, with --oversizedPods")
+	pflag.IntVar(&config.PlacementDecisionTTL, "placementDecisionTTL", 0,
+		"Time in seconds the decisions are kept as PlacementDecision custom resources in the namespace of their pod, the CRD of deploy/placementdecision-crd.yaml must be installed (0 disables them)")
+	pflag.StringVar(&config.AdmissionLabel, "admissionLabel", "",
+		"Label key of the pods managed by an external admission system, e.g. kueue.x-k8s.io/queue-name, they are only claimed once admitted (empty claims the pods regardless of their admission)")
+	pflag.StringVar(&config.AdmittedAnnotation, "admittedAnnotation", "poseidon.k8s.io/admitted",
+		"Annotation the admission system sets to \"true\" on the pods it admits, with --admissionLabel")
+	pflag.IntVar(&config.AdmissionUnplacedCycles, "admissionUnplacedCycles", 0,
+		"Number of scheduling cycles after which an admitted pod left unplaced is reported on its poseidon.k8s.io/Placed condition, with --admissionLabel (0 only reports the placements)")
+	pflag.IntVar(&config.BackpressureMaxSolveTime, "backpressureMaxSolveTime", 0,
+		"Duration of the solver runs (in milliseconds) above which the task submissions are delayed until the solver catches up (0 disables the backpressure on the solver)")
+	pflag.IntVar(&config.BackpressureMaxBindTime, "backpressureMaxBindTime", 0,
+		"Duration of the application of the deltas of a cycle (in milliseconds) above which the task submissions are delayed until the bind path catches up (0 disables the backpressure on the bind path)")
+	pflag.IntVar(&config.BackpressureMinRate, "backpressureMinRate", 10,
+		"Lowest number of task submissions per second while the solver or the bind path is saturated, with --backpressureMaxSolveTime or --backpressureMaxBindTime")
+	pflag.StringVar(&config.KnowledgeBaseSeedFile, "knowledgeBaseSeedFile", "",
+		"Path of a CSV file of historical node and pod usage loaded into the knowledge base of Firmament at startup, see pkg/seed for its format")
+	pflag.StringVar(&config.KnowledgeBasePrometheus, "knowledgeBasePrometheus", "",
+		"Address of a Prometheus server queried for the node and pod usage of the last day, loaded into the knowledge base of Firmament at startup")
+	pflag.StringVar(&config.FirmamentShardLabel, "firmamentShardLabel", "",
+		"Node label, e.g. the node pool label, whose values are scheduled by the Firmament instances of --firmamentShards")
+	pflag.StringVar(&config.FirmamentShards, "firmamentShards", "",
+		"Comma separated <label value>=<address> Firmament instances scheduling the nodes with the given value of --firmamentShardLabel, and the pods restricted to them, in concurrent cycles. The other nodes and pods are scheduled by --firmamentAddress")
+	pflag.StringVar(&config.ValidationWebhookAddress, "validationWebhookAddress", "",
+		"Address of the validating webhook server rejecting the pods whose spec Poseidon cannot schedule (empty disables it), see deploy/validating-webhook.yaml")
+	pflag.StringVar(&config.ValidationWebhookTLSCertFile, "validationWebhookTLSCertFile", "", "Certificate file of the validating webhook server")
+	pflag.StringVar(&config.ValidationWebhookTLSKeyFile, "validationWebhookTLSKeyFile", "", "Key file of the validating webhook server")
+	pflag.StringVar(&config.FairSharePolicyFile, "fairSharePolicyFile", "",
+		"The path of a JSON file sharing the capacity between the batch pods of the namespaces by dominant resource fairness, with the spare capacity going to the namespaces which used the least of their share")
+	pflag.StringVar(&config.FeatureGates, "feature-gates", "",
+		"Comma separated <feature>=<true|false> pairs enabling or disabling the experimental features: "+
+			strings.Join(features.DefaultGate.Known(), ", "))
+	pflag.IntVar(&config.NodeNotReadyGracePeriod, "nodeNotReadyGracePeriod", 0,
+		"Time in seconds a node stays not ready or out of disk before its capacity is withdrawn and its pods are failed, so that brief flaps are ignored (0 fails it right away)")
+	pflag.IntVar(&config.ChurnWindow, "churnWindow", 3600,
+		"Window in seconds over which the preemptions and migrations of the pods of each Deployment, Job or other owner are counted")
+	pflag.IntVar(&config.ChurnBudget, "churnBudget", 0,
+		"Largest number of preemptions and migrations of the pods of an owner within the churn window, past which the migrations of its pods are suppressed (0 does not bound the churn)")
 	pflag.StringVar(&config.ConfigPath, "configPath", ".",
 		"The path to the config file (i.e poseidon_cfg) without filename or extension, supported extensions/formats are Yaml, Json")
 
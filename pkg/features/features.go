@@ -0,0 +1,159 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package features
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Feature is the name of a feature gate.
+type Feature string
+
+// Stage is the maturity of a feature.
+type Stage string
+
+const (
+	// Alpha features are experimental and disabled by default.
+	Alpha Stage = "ALPHA"
+	// Beta features are enabled by default.
+	Beta Stage = "BETA"
+	// GA features are always enabled, their gate is kept for the
+	// deployments which still set it.
+	GA Stage = "GA"
+)
+
+// FeatureSpec is the default and maturity of a feature.
+type FeatureSpec struct {
+	Default bool
+	Stage   Stage
+}
+
+const (
+	// Migrations deletes running pods to move them elsewhere: off the
+	// terminating nodes, to balance the ReplicaSets across zones, and where
+	// Firmament migrates them.
+	Migrations Feature = "Migrations"
+	// GangScheduling holds the placements of the pods of a pod group until
+	// minAvailable pods of the group are placed.
+	GangScheduling Feature = "GangScheduling"
+	// KnowledgeBaseSeed seeds the knowledge base of Firmament with the
+	// historical usage of the nodes and pods, for load-aware placements
+	// from the first scheduling cycles.
+	KnowledgeBaseSeed Feature = "KnowledgeBaseSeed"
+	// FairShareScheduling shares the capacity between the batch pods of the
+	// namespaces by dominant resource fairness.
+	FairShareScheduling Feature = "FairShareScheduling"
+)
+
+var defaultFeatures = map[Feature]FeatureSpec{
+	Migrations:          {Default: true, Stage: Beta},
+	GangScheduling:      {Default: true, Stage: Beta},
+	KnowledgeBaseSeed:   {Default: false, Stage: Alpha},
+	FairShareScheduling: {Default: false, Stage: Alpha},
+}
+
+// Gate tells whether the known features are enabled.
+type Gate struct {
+	mu      sync.RWMutex
+	known   map[Feature]FeatureSpec
+	enabled map[Feature]bool
+}
+
+// NewGate returns a gate of the known features, enabled by default
+// according to their spec.
+func NewGate(known map[Feature]FeatureSpec) *Gate {
+	return &Gate{known: known, enabled: make(map[Feature]bool)}
+}
+
+// DefaultGate is the gate of the features of Poseidon, set by the
+// --feature-gates flag.
+var DefaultGate = NewGate(defaultFeatures)
+
+// Set enables or disables the features given as comma separated
+// <feature>=<true|false> pairs, e.g. "Migrations=false,FairShareScheduling=true".
+func (g *Gate) Set(value string) error {
+	enabled := make(map[Feature]bool)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("feature gate %q is not <feature>=<true|false>", pair)
+		}
+		feature := Feature(strings.TrimSpace(parts[0]))
+		spec, ok := g.known[feature]
+		if !ok {
+			return fmt.Errorf("unknown feature gate %s", feature)
+		}
+		on, err := strconv.ParseBool(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return fmt.Errorf("invalid value %q of feature gate %s", parts[1], feature)
+		}
+		if spec.Stage == GA && !on {
+			return fmt.Errorf("feature gate %s is GA and cannot be disabled", feature)
+		}
+		enabled[feature] = on
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for feature, on := range enabled {
+		g.enabled[feature] = on
+	}
+	return nil
+}
+
+// Enabled returns whether the feature is enabled. The unknown features are
+// not.
+func (g *Gate) Enabled(feature Feature) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if on, ok := g.enabled[feature]; ok {
+		return on
+	}
+	return g.known[feature].Default
+}
+
+// Map returns whether each known feature is enabled.
+func (g *Gate) Map() map[string]bool {
+	features := make(map[string]bool, len(g.known))
+	for feature := range g.known {
+		features[string(feature)] = g.Enabled(feature)
+	}
+	return features
+}
+
+// Known returns the known features with their maturity and default, sorted,
+// for the usage of the --feature-gates flag.
+func (g *Gate) Known() []string {
+	var known []string
+	for feature, spec := range g.known {
+		known = append(known, fmt.Sprintf("%s=true|false (%s - default=%t)", feature, spec.Stage, spec.Default))
+	}
+	sort.Strings(known)
+	return known
+}
+
+// Enabled returns whether the feature is enabled by the default gate.
+func Enabled(feature Feature) bool {
+	return DefaultGate.Enabled(feature)
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package features
+
+import (
+	"testing"
+)
+
+func TestGate(t *testing.T) {
+	const alpha, beta, ga Feature = "Alpha", "Beta", "GA"
+	known := map[Feature]FeatureSpec{
+		alpha: {Default: false, Stage: Alpha},
+		beta:  {Default: true, Stage: Beta},
+		ga:    {Default: true, Stage: GA},
+	}
+	var testData = []struct {
+		value    string
+		expected map[string]bool
+		invalid  bool
+	}{
+		{value: "", expected: map[string]bool{"Alpha": false, "Beta": true, "GA": true}},
+		{value: "Alpha=true, Beta=false,", expected: map[string]bool{"Alpha": true, "Beta": false, "GA": true}},
+		{value: "GA=true", expected: map[string]bool{"Alpha": false, "Beta": true, "GA": true}},
+		{value: "GA=false", invalid: true},
+		{value: "Gamma=true", invalid: true},
+		{value: "Alpha", invalid: true},
+		{value: "Alpha=yes", invalid: true},
+	}
+	for _, tc := range testData {
+		gate := NewGate(known)
+		err := gate.Set(tc.value)
+		if (err != nil) != tc.invalid {
+			t.Errorf("Set(%q) = %v, expected invalid %v", tc.value, err, tc.invalid)
+			continue
+		}
+		if tc.invalid {
+			continue
+		}
+		for feature, on := range tc.expected {
+			if gate.Enabled(Feature(feature)) != on {
+				t.Errorf("Enabled(%s) = %v after Set(%q), expected %v", feature, !on, tc.value, on)
+			}
+		}
+		if len(gate.Map()) != len(known) {
+			t.Errorf("Map() = %v, expected the %d known features", gate.Map(), len(known))
+		}
+	}
+	if NewGate(known).Enabled("Gamma") {
+		t.Error("Enabled() enabled an unknown feature")
+	}
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package firmament
+
+import (
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorKind classifies the errors of the requests to Firmament by how the
+// scheduler recovers from them.
+type ErrorKind int
+
+const (
+	// Fatal errors are unexpected replies or failures the scheduler cannot
+	// recover from.
+	Fatal ErrorKind = iota
+	// Retryable errors are transient, e.g. Firmament is unavailable or the
+	// request timed out, and the request may be sent again.
+	Retryable
+	// StateMismatch errors mean Firmament and Poseidon disagree on the
+	// nodes or tasks, e.g. a task is already submitted or a node is not
+	// found, which resynchronizing Firmament recovers from.
+	StateMismatch
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case Retryable:
+		return "retryable"
+	case StateMismatch:
+		return "state_mismatch"
+	default:
+		return "fatal"
+	}
+}
+
+var (
+	// ErrTaskNotFound is the error of a request for a task Firmament does
+	// not know.
+	ErrTaskNotFound = errors.New("task not found")
+	// ErrJobNotFound is the error of a request for a task whose job
+	// Firmament does not know.
+	ErrJobNotFound = errors.New("job of the task not found")
+	// ErrTaskAlreadySubmitted is the error of the submission of a task
+	// Firmament already knows.
+	ErrTaskAlreadySubmitted = errors.New("task already submitted")
+	// ErrTaskNotCreated is the error of the submission of a task which is
+	// not in the created state.
+	ErrTaskNotCreated = errors.New("task not in created state")
+	// ErrNodeExists is the error of the addition of a node Firmament
+	// already knows.
+	ErrNodeExists = errors.New("node already exists")
+	// ErrNodeNotFound is the error of a request for a node Firmament does
+	// not know.
+	ErrNodeNotFound = errors.New("node not found")
+)
+
+// Error is an error of a request to Firmament, or of the state the request
+// is derived from, with its kind.
+type Error struct {
+	Kind ErrorKind
+	// Op is the request or the operation which failed, e.g. TaskSubmitted.
+	Op string
+	// Detail is what the request was about, e.g. its task, if any.
+	Detail string
+	Err    error
+}
+
+// NewError returns the error of the operation wrapping err.
+func NewError(kind ErrorKind, op string, err error) *Error {
+	return &Error{Kind: kind, Op: op, Err: err}
+}
+
+func (e *Error) Error() string {
+	if e.Detail != "" {
+		return fmt.Sprintf("%s: %s: %v", e.Op, e.Detail, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.Op, e.Err)
+}
+
+// Cause returns the error wrapped by the Errors wrapping err, e.g. one of the
+// ErrTaskNotFound errors, or err itself if it is not an Error.
+func Cause(err error) error {
+	for {
+		e, ok := err.(*Error)
+		if !ok || e.Err == nil {
+			return err
+		}
+		err = e.Err
+	}
+}
+
+// KindOf returns the kind of the error. The errors which are not an Error
+// are classified by their gRPC code, and are fatal if they have none.
+func KindOf(err error) ErrorKind {
+	if e, ok := err.(*Error); ok {
+		return e.Kind
+	}
+	switch Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted:
+		return Retryable
+	}
+	return Fatal
+}
+
+// Code returns the gRPC code of the error or of the error it wraps,
+// codes.Unknown if it has none.
+func Code(err error) codes.Code {
+	if err == nil {
+		return codes.Unknown
+	}
+	if s, ok := status.FromError(Cause(err)); ok {
+		return s.Code()
+	}
+	return codes.Unknown
+}
+
+// IsRetryable returns true if the request failed transiently.
+func IsRetryable(err error) bool {
+	return err != nil && KindOf(err) == Retryable
+}
+
+// IsStateMismatch returns true if the request failed because Firmament and
+// Poseidon disagree on the nodes or tasks.
+func IsStateMismatch(err error) bool {
+	return err != nil && KindOf(err) == StateMismatch
+}
+
+// rpcError wraps the error of a request which did not reach Firmament or
+// which it failed.
+func rpcError(op string, err error) error {
+	return NewError(KindOf(err), op, err)
+}
+
+// mismatchError returns the error of a request Firmament refused as its
+// state disagrees.
+func mismatchError(op string, err error, format string, args ...interface{}) error {
+	return &Error{Kind: StateMismatch, Op: op, Detail: fmt.Sprintf(format, args...), Err: err}
+}
+
+// replyError returns the error of an unexpected reply.
+func replyError(op string, reply interface{}, format string, args ...interface{}) error {
+	return NewError(Fatal, op, fmt.Errorf("unexpected reply %v for %s", reply, fmt.Sprintf(format, args...)))
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package firmament
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func TestKindOf(t *testing.T) {
+	var testData = []struct {
+		err      error
+		expected ErrorKind
+	}{
+		{err: grpc.Errorf(codes.Unavailable, "connection refused"), expected: Retryable},
+		{err: grpc.Errorf(codes.DeadlineExceeded, "timeout"), expected: Retryable},
+		{err: grpc.Errorf(codes.InvalidArgument, "invalid task"), expected: Fatal},
+		{err: errors.New("unknown"), expected: Fatal},
+		{err: rpcError("Schedule", grpc.Errorf(codes.Unavailable, "connection refused")), expected: Retryable},
+		{err: NewError(StateMismatch, "resubmitting", NewError(StateMismatch, "NodeAdded", ErrNodeExists)), expected: StateMismatch},
+	}
+	for _, tc := range testData {
+		if kind := KindOf(tc.err); kind != tc.expected {
+			t.Errorf("KindOf(%v) = %v, expected %v", tc.err, kind, tc.expected)
+		}
+	}
+	if code := Code(NewError(Retryable, "cycle", rpcError("Schedule", grpc.Errorf(codes.Unavailable, "")))); code != codes.Unavailable {
+		t.Errorf("Code() of a wrapped error = %v, expected %v", code, codes.Unavailable)
+	}
+	if cause := Cause(NewError(StateMismatch, "resubmitting", mismatchError("NodeAdded", ErrNodeExists, "node %s", "res0"))); cause != ErrNodeExists {
+		t.Errorf("Cause() of a wrapped error = %v, expected %v", cause, ErrNodeExists)
+	}
+}
+
+func TestReplyErrors(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	firmamentClient := NewMockFirmamentSchedulerClient(mockCtrl)
+	firmamentClient.EXPECT().TaskCompleted(gomock.Any(), gomock.Any()).Return(
+		&TaskCompletedResponse{Type: TaskReplyType_TASK_NOT_FOUND}, nil)
+	err := TaskCompleted(firmamentClient, &TaskUID{TaskUid: 1})
+	if !IsStateMismatch(err) || Cause(err) != ErrTaskNotFound {
+		t.Errorf("TaskCompleted() of an unknown task = %v, expected a state mismatch", err)
+	}
+	if err.Error() != "TaskCompleted: task 1: task not found" {
+		t.Errorf("TaskCompleted() of an unknown task = %q", err.Error())
+	}
+	// The task is removed either way.
+	for _, reply := range []TaskReplyType{TaskReplyType_TASK_NOT_FOUND, TaskReplyType_TASK_JOB_NOT_FOUND} {
+		firmamentClient.EXPECT().TaskRemoved(gomock.Any(), gomock.Any()).Return(&TaskRemovedResponse{Type: reply}, nil)
+		if err := TaskRemoved(firmamentClient, &TaskUID{TaskUid: 1}); err != nil {
+			t.Errorf("TaskRemoved() with reply %v = %v, expected nil", reply, err)
+		}
+	}
+	firmamentClient.EXPECT().NodeAdded(gomock.Any(), gomock.Any()).Return(
+		&NodeAddedResponse{Type: NodeReplyType_NODE_REMOVED_OK}, nil)
+	if err := NodeAdded(firmamentClient, nil); KindOf(err) != Fatal {
+		t.Errorf("NodeAdded() with an unexpected reply = %v, expected a fatal error", err)
+	}
+	firmamentClient.EXPECT().NodeFailed(gomock.Any(), gomock.Any()).Return(nil, grpc.Errorf(codes.Unavailable, "connection refused"))
+	if err := NodeFailed(firmamentClient, nil); !IsRetryable(err) {
+		t.Errorf("NodeFailed() of an unavailable Firmament = %v, expected a retryable error", err)
+	}
+}
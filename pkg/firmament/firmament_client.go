@@ -17,182 +17,205 @@ limitations under the License.
 package firmament
 
 import (
-	"fmt"
+	"time"
 
 	"github.com/golang/glog"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/grpclog"
 )
 
 // Schedule sends a schedule request to firmament server.
-func Schedule(client FirmamentSchedulerClient) *SchedulingDeltas {
-	scheduleResp, err := client.Schedule(context.Background(), &ScheduleRequest{})
+func Schedule(client FirmamentSchedulerClient) (*SchedulingDeltas, error) {
+	return ScheduleWithContext(context.Background(), client)
+}
+
+// ScheduleWithTimeout sends a schedule request to firmament server and gives up
+// if the deltas are not returned within the given timeout.
+func ScheduleWithTimeout(client FirmamentSchedulerClient, timeout time.Duration) (*SchedulingDeltas, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return ScheduleWithContext(ctx, client)
+}
+
+// ScheduleWithContext sends a schedule request to firmament server and gives up
+// once the context is done, e.g. when the scheduling cycle is cancelled.
+func ScheduleWithContext(ctx context.Context, client FirmamentSchedulerClient) (*SchedulingDeltas, error) {
+	deltas, err := client.Schedule(ctx, &ScheduleRequest{})
 	if err != nil {
-		grpclog.Fatalf("%v.Schedule(_) = _, %v: ", client, err)
+		return nil, rpcError("Schedule", err)
 	}
-	return scheduleResp
+	return deltas, nil
 }
 
 // TaskCompleted tells firmament server the given task is completed.
-func TaskCompleted(client FirmamentSchedulerClient, tuid *TaskUID) {
+func TaskCompleted(client FirmamentSchedulerClient, tuid *TaskUID) error {
 	tCompletedResp, err := client.TaskCompleted(context.Background(), tuid)
 	if err != nil {
-		grpclog.Fatalf("%v.TaskCompleted(_) = _, %v: ", client, err)
+		return rpcError("TaskCompleted", err)
 	}
 	switch tCompletedResp.Type {
 	case TaskReplyType_TASK_NOT_FOUND:
-		glog.Fatalf("Task %d not found", tuid.TaskUid)
+		return mismatchError("TaskCompleted", ErrTaskNotFound, "task %d", tuid.GetTaskUid())
 	case TaskReplyType_TASK_JOB_NOT_FOUND:
-		glog.Fatalf("Task's %d job not found", tuid.TaskUid)
+		return mismatchError("TaskCompleted", ErrJobNotFound, "task %d", tuid.GetTaskUid())
 	case TaskReplyType_TASK_COMPLETED_OK:
+		return nil
 	default:
-		panic(fmt.Sprintf("Unexpected TaskCompleted response %v for task %v", tCompletedResp, tuid.TaskUid))
+		return replyError("TaskCompleted", tCompletedResp, "task %d", tuid.GetTaskUid())
 	}
 }
 
 // TaskFailed tells firmament server the given task is failed.
-func TaskFailed(client FirmamentSchedulerClient, tuid *TaskUID) {
+func TaskFailed(client FirmamentSchedulerClient, tuid *TaskUID) error {
 	tFailedResp, err := client.TaskFailed(context.Background(), tuid)
 	if err != nil {
-		grpclog.Fatalf("%v.TaskFailed(_) = _, %v: ", client, err)
+		return rpcError("TaskFailed", err)
 	}
 	switch tFailedResp.Type {
 	case TaskReplyType_TASK_NOT_FOUND:
-		glog.Fatalf("Task %d not found", tuid.TaskUid)
+		return mismatchError("TaskFailed", ErrTaskNotFound, "task %d", tuid.GetTaskUid())
 	case TaskReplyType_TASK_JOB_NOT_FOUND:
-		glog.Fatalf("Task's %d job not found", tuid.TaskUid)
+		return mismatchError("TaskFailed", ErrJobNotFound, "task %d", tuid.GetTaskUid())
 	case TaskReplyType_TASK_FAILED_OK:
+		return nil
 	default:
-		panic(fmt.Sprintf("Unexpected TaskFailed response %v for task %v", tFailedResp, tuid.TaskUid))
+		return replyError("TaskFailed", tFailedResp, "task %d", tuid.GetTaskUid())
 	}
 }
 
 // TaskRemoved tells firmament server the given task is removed.
-func TaskRemoved(client FirmamentSchedulerClient, tuid *TaskUID) {
+func TaskRemoved(client FirmamentSchedulerClient, tuid *TaskUID) error {
 	tRemovedResp, err := client.TaskRemoved(context.Background(), tuid)
 	if err != nil {
-		grpclog.Fatalf("%v.TaskRemoved(_) = _, %v: ", client, err)
+		return rpcError("TaskRemoved", err)
 	}
 	switch tRemovedResp.Type {
-	case TaskReplyType_TASK_NOT_FOUND:
-		glog.Fatalf("Task %d not found", tuid.TaskUid)
-	case TaskReplyType_TASK_JOB_NOT_FOUND:
-		glog.Fatalf("Task's %d job not found", tuid.TaskUid)
+	case TaskReplyType_TASK_NOT_FOUND, TaskReplyType_TASK_JOB_NOT_FOUND:
+		// Firmament does not know the task, e.g. it restarted or the task
+		// was removed before, it is removed either way.
+		glog.V(2).Infof("Removed task %d was not known to Firmament", tuid.GetTaskUid())
+		return nil
 	case TaskReplyType_TASK_REMOVED_OK:
+		return nil
 	default:
-		panic(fmt.Sprintf("Unexpected TaskRemoved response %v for task %v", tRemovedResp, tuid.TaskUid))
+		return replyError("TaskRemoved", tRemovedResp, "task %d", tuid.GetTaskUid())
 	}
 }
 
 // TaskSubmitted tells firmament server the given task is submitted.
-func TaskSubmitted(client FirmamentSchedulerClient, td *TaskDescription) {
+func TaskSubmitted(client FirmamentSchedulerClient, td *TaskDescription) error {
 	tSubmittedResp, err := client.TaskSubmitted(context.Background(), td)
 	if err != nil {
-		grpclog.Fatalf("%v.TaskSubmitted(_) = _, %v: ", client, err)
+		return rpcError("TaskSubmitted", err)
 	}
 	switch tSubmittedResp.Type {
 	case TaskReplyType_TASK_ALREADY_SUBMITTED:
-		glog.Fatalf("Task (%s,%d) already submitted", td.JobDescriptor.Uuid, td.TaskDescriptor.Uid)
+		return mismatchError("TaskSubmitted", ErrTaskAlreadySubmitted, "task (%s,%d)", td.GetJobDescriptor().GetUuid(), td.GetTaskDescriptor().GetUid())
 	case TaskReplyType_TASK_STATE_NOT_CREATED:
-		glog.Fatalf("Task (%s,%d) not in created state", td.JobDescriptor.Uuid, td.TaskDescriptor.Uid)
+		return mismatchError("TaskSubmitted", ErrTaskNotCreated, "task (%s,%d)", td.GetJobDescriptor().GetUuid(), td.GetTaskDescriptor().GetUid())
 	case TaskReplyType_TASK_SUBMITTED_OK:
+		return nil
 	default:
-		panic(fmt.Sprintf("Unexpected TaskSubmitted response %v for task (%v,%v)", tSubmittedResp, td.JobDescriptor.Uuid, td.TaskDescriptor.Uid))
+		return replyError("TaskSubmitted", tSubmittedResp, "task (%s,%d)", td.GetJobDescriptor().GetUuid(), td.GetTaskDescriptor().GetUid())
 	}
 }
 
 // TaskUpdated tells firmament server the given task is updated.
-func TaskUpdated(client FirmamentSchedulerClient, td *TaskDescription) {
+func TaskUpdated(client FirmamentSchedulerClient, td *TaskDescription) error {
 	tUpdatedResp, err := client.TaskUpdated(context.Background(), td)
 	if err != nil {
-		grpclog.Fatalf("%v.TaskUpdated(_) = _, %v: ", client, err)
+		return rpcError("TaskUpdated", err)
 	}
 	switch tUpdatedResp.Type {
 	case TaskReplyType_TASK_NOT_FOUND:
-		glog.Fatalf("Task (%s,%d) not found", td.JobDescriptor.Uuid, td.TaskDescriptor.Uid)
+		return mismatchError("TaskUpdated", ErrTaskNotFound, "task (%s,%d)", td.GetJobDescriptor().GetUuid(), td.GetTaskDescriptor().GetUid())
 	case TaskReplyType_TASK_JOB_NOT_FOUND:
-		glog.Fatalf("Task's (%s,%d) job not found", td.JobDescriptor.Uuid, td.TaskDescriptor.Uid)
+		return mismatchError("TaskUpdated", ErrJobNotFound, "task (%s,%d)", td.GetJobDescriptor().GetUuid(), td.GetTaskDescriptor().GetUid())
 	case TaskReplyType_TASK_UPDATED_OK:
+		return nil
 	default:
-		panic(fmt.Sprintf("Unexpected TaskUpdated response %v for task (%v,%v)", tUpdatedResp, td.JobDescriptor.Uuid, td.TaskDescriptor.Uid))
+		return replyError("TaskUpdated", tUpdatedResp, "task (%s,%d)", td.GetJobDescriptor().GetUuid(), td.GetTaskDescriptor().GetUid())
 	}
 }
 
 // NodeAdded tells firmament server the given node is added.
-func NodeAdded(client FirmamentSchedulerClient, rtnd *ResourceTopologyNodeDescriptor) {
+func NodeAdded(client FirmamentSchedulerClient, rtnd *ResourceTopologyNodeDescriptor) error {
 	nAddedResp, err := client.NodeAdded(context.Background(), rtnd)
 	if err != nil {
-		grpclog.Fatalf("%v.NodeAdded(_) = _, %v: ", client, err)
+		return rpcError("NodeAdded", err)
 	}
 	switch nAddedResp.Type {
 	case NodeReplyType_NODE_ALREADY_EXISTS:
-		glog.Fatalf("Tried to add existing node %s", rtnd.ResourceDesc.Uuid)
+		return mismatchError("NodeAdded", ErrNodeExists, "node %s", rtnd.GetResourceDesc().GetUuid())
 	case NodeReplyType_NODE_ADDED_OK:
+		return nil
 	default:
-		panic(fmt.Sprintf("Unexpected NodeAdded response %v for node %v", nAddedResp, rtnd.ResourceDesc.Uuid))
+		return replyError("NodeAdded", nAddedResp, "node %s", rtnd.GetResourceDesc().GetUuid())
 	}
 }
 
 // NodeFailed tells firmament server the given node is failed.
-func NodeFailed(client FirmamentSchedulerClient, ruid *ResourceUID) {
+func NodeFailed(client FirmamentSchedulerClient, ruid *ResourceUID) error {
 	nFailedResp, err := client.NodeFailed(context.Background(), ruid)
 	if err != nil {
-		grpclog.Fatalf("%v.NodeFailed(_) = _, %v: ", client, err)
+		return rpcError("NodeFailed", err)
 	}
 	switch nFailedResp.Type {
 	case NodeReplyType_NODE_NOT_FOUND:
-		glog.Fatalf("Tried to fail non-existing node %s", ruid.ResourceUid)
+		return mismatchError("NodeFailed", ErrNodeNotFound, "node %s", ruid.GetResourceUid())
 	case NodeReplyType_NODE_FAILED_OK:
+		return nil
 	default:
-		panic(fmt.Sprintf("Unexpected NodeFailed response %v for node %v", nFailedResp, ruid.ResourceUid))
+		return replyError("NodeFailed", nFailedResp, "node %s", ruid.GetResourceUid())
 	}
 }
 
 // NodeRemoved tells firmament server the given node is removed.
-func NodeRemoved(client FirmamentSchedulerClient, ruid *ResourceUID) {
+func NodeRemoved(client FirmamentSchedulerClient, ruid *ResourceUID) error {
 	nRemovedResp, err := client.NodeRemoved(context.Background(), ruid)
 	if err != nil {
-		grpclog.Fatalf("%v.NodeRemoved(_) = _, %v: ", client, err)
+		return rpcError("NodeRemoved", err)
 	}
 	switch nRemovedResp.Type {
 	case NodeReplyType_NODE_NOT_FOUND:
-		glog.Fatalf("Tried to remove non-existing node %s", ruid.ResourceUid)
+		return mismatchError("NodeRemoved", ErrNodeNotFound, "node %s", ruid.GetResourceUid())
 	case NodeReplyType_NODE_REMOVED_OK:
+		return nil
 	default:
-		panic(fmt.Sprintf("Unexpected NodeRemoved response %v for node %v", nRemovedResp, ruid.ResourceUid))
+		return replyError("NodeRemoved", nRemovedResp, "node %s", ruid.GetResourceUid())
 	}
 }
 
 // NodeUpdated tells firmament server the given node is updated.
-func NodeUpdated(client FirmamentSchedulerClient, rtnd *ResourceTopologyNodeDescriptor) {
+func NodeUpdated(client FirmamentSchedulerClient, rtnd *ResourceTopologyNodeDescriptor) error {
 	nUpdatedResp, err := client.NodeUpdated(context.Background(), rtnd)
 	if err != nil {
-		grpclog.Fatalf("%v.NodeUpdated(_) = _, %v: ", client, err)
+		return rpcError("NodeUpdated", err)
 	}
 	switch nUpdatedResp.Type {
 	case NodeReplyType_NODE_NOT_FOUND:
-		glog.Fatalf("Tried to updated non-existing node %s", rtnd.ResourceDesc.Uuid)
+		return mismatchError("NodeUpdated", ErrNodeNotFound, "node %s", rtnd.GetResourceDesc().GetUuid())
 	case NodeReplyType_NODE_UPDATED_OK:
+		return nil
 	default:
-		panic(fmt.Sprintf("Unexpected NodeUpdated response %v for node %v", nUpdatedResp, rtnd.ResourceDesc.Uuid))
+		return replyError("NodeUpdated", nUpdatedResp, "node %s", rtnd.GetResourceDesc().GetUuid())
 	}
 }
 
 // AddTaskStats sends task status to firmament server.
-func AddTaskStats(client FirmamentSchedulerClient, ts *TaskStats) {
-	_, err := client.AddTaskStats(context.Background(), ts)
-	if err != nil {
-		grpclog.Fatalf("%v.AddTaskStats(_) = _, %v: ", client, err)
+func AddTaskStats(client FirmamentSchedulerClient, ts *TaskStats) error {
+	if _, err := client.AddTaskStats(context.Background(), ts); err != nil {
+		return rpcError("AddTaskStats", err)
 	}
+	return nil
 }
 
 // AddNodeStats sends node status to firmament server.
-func AddNodeStats(client FirmamentSchedulerClient, rs *ResourceStats) {
-	_, err := client.AddNodeStats(context.Background(), rs)
-	if err != nil {
-		grpclog.Fatalf("%v.AddNodeStats(_) = _, %v: ", client, err)
+func AddNodeStats(client FirmamentSchedulerClient, rs *ResourceStats) error {
+	if _, err := client.AddNodeStats(context.Background(), rs); err != nil {
+		return rpcError("AddNodeStats", err)
 	}
+	return nil
 }
 
 // Check tests if firmament server is health
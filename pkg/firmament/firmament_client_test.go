@@ -18,8 +18,10 @@ package firmament
 
 import (
 	"github.com/golang/mock/gomock"
+	"golang.org/x/net/context"
 
 	"testing"
+	"time"
 )
 
 func Test_New(t *testing.T) {
@@ -137,3 +139,26 @@ func Test_Schedule(t *testing.T) {
 		&SchedulingDeltas{}, nil)
 	Schedule(firmamentClient)
 }
+
+func Test_ScheduleWithTimeout(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	firmamentClient := NewMockFirmamentSchedulerClient(mockCtrl)
+	firmamentClient.EXPECT().Schedule(gomock.Any(), gomock.Any()).Return(
+		&SchedulingDeltas{}, nil)
+	if _, err := ScheduleWithTimeout(firmamentClient, time.Second); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
+
+func Test_ScheduleWithContext(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	firmamentClient := NewMockFirmamentSchedulerClient(mockCtrl)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	firmamentClient.EXPECT().Schedule(ctx, gomock.Any()).Return(nil, ctx.Err())
+	if _, err := ScheduleWithContext(ctx, firmamentClient); Cause(err) != context.Canceled {
+		t.Errorf("ScheduleWithContext() of a cancelled cycle = %v, expected %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package firmament
+
+import (
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// HealthState is the state of the connectivity to Firmament.
+type HealthState string
+
+const (
+	// HealthConnecting is the state until Firmament serves for the first
+	// time.
+	HealthConnecting HealthState = "Connecting"
+	// HealthReady means Firmament served the last request.
+	HealthReady HealthState = "Ready"
+	// HealthDegraded means Firmament failed the last request, for less than
+	// the down delay since it last served.
+	HealthDegraded HealthState = "Degraded"
+	// HealthDown means Firmament failed all the requests for the down delay.
+	HealthDown HealthState = "Down"
+)
+
+// HealthStates are all the states of the connectivity to Firmament.
+var HealthStates = []HealthState{HealthConnecting, HealthReady, HealthDegraded, HealthDown}
+
+// HealthMonitor tracks the connectivity to Firmament as a state machine fed
+// by periodic health checks and by the outcome of the scheduling requests.
+// Firmament is Connecting until it first serves, Ready while it serves,
+// Degraded as soon as it fails, and Down once it failed for the down delay,
+// counted from the start while Connecting.
+type HealthMonitor struct {
+	mu        sync.Mutex
+	state     HealthState
+	downAfter time.Duration
+	// failingSince is the start of the current outage, or of the monitor
+	// while Connecting.
+	failingSince time.Time
+	// changed is closed and replaced at every transition.
+	changed  chan struct{}
+	onChange func(HealthState)
+}
+
+// NewHealthMonitor starts checking the health of Firmament at every interval.
+// onChange, if not nil, is called with the new state at every transition,
+// and with the initial state.
+func NewHealthMonitor(client FirmamentSchedulerClient, interval, downAfter time.Duration,
+	onChange func(HealthState)) *HealthMonitor {
+	m := newHealthMonitor(downAfter, time.Now(), onChange)
+	go m.poll(client, interval)
+	return m
+}
+
+func newHealthMonitor(downAfter time.Duration, now time.Time, onChange func(HealthState)) *HealthMonitor {
+	m := &HealthMonitor{
+		state:        HealthConnecting,
+		downAfter:    downAfter,
+		failingSince: now,
+		changed:      make(chan struct{}),
+		onChange:     onChange,
+	}
+	if onChange != nil {
+		onChange(m.state)
+	}
+	return m
+}
+
+func (m *HealthMonitor) poll(client FirmamentSchedulerClient, interval time.Duration) {
+	serviceReq := new(HealthCheckRequest)
+	for {
+		ok, _ := Check(client, serviceReq)
+		m.Observe(ok)
+		time.Sleep(interval)
+	}
+}
+
+// Observe feeds the outcome of a request to Firmament to the state machine.
+func (m *HealthMonitor) Observe(ok bool) {
+	m.observe(ok, time.Now())
+}
+
+func (m *HealthMonitor) observe(ok bool, now time.Time) {
+	m.mu.Lock()
+	previous := m.state
+	switch {
+	case ok:
+		m.state = HealthReady
+	case m.state == HealthReady:
+		m.state = HealthDegraded
+		m.failingSince = now
+	case m.state != HealthDown && now.Sub(m.failingSince) >= m.downAfter:
+		m.state = HealthDown
+	}
+	state := m.state
+	if state != previous {
+		close(m.changed)
+		m.changed = make(chan struct{})
+	}
+	m.mu.Unlock()
+	if state == previous {
+		return
+	}
+	if state == HealthReady {
+		glog.Infof("Firmament is %s, was %s", state, previous)
+	} else {
+		glog.Warningf("Firmament is %s, was %s", state, previous)
+	}
+	if m.onChange != nil {
+		m.onChange(state)
+	}
+}
+
+// State returns the current state of the connectivity to Firmament.
+func (m *HealthMonitor) State() HealthState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state
+}
+
+// Serving returns whether Firmament is expected to serve the requests, i.e.
+// it is Ready or only Degraded.
+func (m *HealthMonitor) Serving() bool {
+	state := m.State()
+	return state == HealthReady || state == HealthDegraded
+}
+
+// WaitReady blocks until Firmament is Ready and returns true. It returns
+// false once Firmament is Down or stop is closed.
+func (m *HealthMonitor) WaitReady(stop <-chan struct{}) bool {
+	for {
+		m.mu.Lock()
+		state, changed := m.state, m.changed
+		m.mu.Unlock()
+		switch state {
+		case HealthReady:
+			return true
+		case HealthDown:
+			return false
+		}
+		select {
+		case <-changed:
+		case <-stop:
+			return false
+		}
+	}
+}
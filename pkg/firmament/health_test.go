@@ -0,0 +1,84 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package firmament
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestHealthMonitor(t *testing.T) {
+	start := time.Now()
+	var transitions []HealthState
+	m := newHealthMonitor(time.Minute, start, func(state HealthState) { transitions = append(transitions, state) })
+	var testData = []struct {
+		ok       bool
+		after    time.Duration
+		expected HealthState
+	}{
+		{ok: false, after: 30 * time.Second, expected: HealthConnecting},
+		{ok: false, after: time.Minute, expected: HealthDown},
+		{ok: true, after: 2 * time.Minute, expected: HealthReady},
+		{ok: false, after: 3 * time.Minute, expected: HealthDegraded},
+		{ok: true, after: 3*time.Minute + time.Second, expected: HealthReady},
+		{ok: false, after: 4 * time.Minute, expected: HealthDegraded},
+		// The down delay is counted from the first failure of the outage.
+		{ok: false, after: 4*time.Minute + 59*time.Second, expected: HealthDegraded},
+		{ok: false, after: 5 * time.Minute, expected: HealthDown},
+		{ok: false, after: 6 * time.Minute, expected: HealthDown},
+	}
+	for _, tc := range testData {
+		m.observe(tc.ok, start.Add(tc.after))
+		if state := m.State(); state != tc.expected {
+			t.Errorf("State() = %s after observing %v at %v, expected %s", state, tc.ok, tc.after, tc.expected)
+		}
+	}
+	expected := []HealthState{HealthConnecting, HealthDown, HealthReady, HealthDegraded, HealthReady, HealthDegraded, HealthDown}
+	if !reflect.DeepEqual(transitions, expected) {
+		t.Errorf("transitions = %v, expected %v", transitions, expected)
+	}
+	if m.Serving() {
+		t.Error("Serving() = true while Firmament is down")
+	}
+}
+
+func TestHealthMonitorWaitReady(t *testing.T) {
+	m := newHealthMonitor(time.Minute, time.Now(), nil)
+	ready := make(chan bool)
+	go func() { ready <- m.WaitReady(nil) }()
+	m.Observe(true)
+	select {
+	case ok := <-ready:
+		if !ok {
+			t.Error("WaitReady() = false once Firmament is ready")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("WaitReady() still blocked once Firmament is ready")
+	}
+
+	m = newHealthMonitor(0, time.Now(), nil)
+	m.Observe(false)
+	if m.WaitReady(nil) {
+		t.Error("WaitReady() = true while Firmament is down")
+	}
+	stop := make(chan struct{})
+	close(stop)
+	if newHealthMonitor(time.Minute, time.Now(), nil).WaitReady(stop) {
+		t.Error("WaitReady() = true once stopped")
+	}
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package firmament
+
+import (
+	"sync"
+
+	"github.com/golang/glog"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// ConnectionMonitor detects the loss of an established connection to
+// Firmament, e.g. because Firmament restarted and lost its state.
+type ConnectionMonitor struct {
+	mu   sync.Mutex
+	lost bool
+}
+
+// NewConnectionMonitor starts monitoring the connection until it is closed.
+func NewConnectionMonitor(conn *grpc.ClientConn) *ConnectionMonitor {
+	m := &ConnectionMonitor{}
+	go m.watch(conn)
+	return m
+}
+
+func (m *ConnectionMonitor) watch(conn *grpc.ClientConn) {
+	state := conn.GetState()
+	for state != connectivity.Shutdown && conn.WaitForStateChange(context.Background(), state) {
+		previous := state
+		state = conn.GetState()
+		if previous == connectivity.Ready && state != connectivity.Ready {
+			glog.Warningf("Lost the connection to Firmament, now %v", state)
+			m.mu.Lock()
+			m.lost = true
+			m.mu.Unlock()
+		}
+	}
+}
+
+// ConnectionLost returns true if the connection was lost since the previous
+// call.
+func (m *ConnectionMonitor) ConnectionLost() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	lost := m.lost
+	m.lost = false
+	return lost
+}
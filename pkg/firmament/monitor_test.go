@@ -0,0 +1,67 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package firmament
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+func TestConnectionMonitor(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	go server.Serve(listener)
+
+	_, conn, err := New(listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	monitor := NewConnectionMonitor(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	// Trigger the connection.
+	conn.WaitForStateChange(ctx, connectivity.Idle)
+	for state := conn.GetState(); state != connectivity.Ready; state = conn.GetState() {
+		if !conn.WaitForStateChange(ctx, state) {
+			t.Fatalf("connection not ready, %v", state)
+		}
+	}
+	if monitor.ConnectionLost() {
+		t.Error("ConnectionLost() = true for a ready connection")
+	}
+
+	server.Stop()
+	deadline := time.Now().Add(5 * time.Second)
+	for !monitor.ConnectionLost() {
+		if time.Now().After(deadline) {
+			t.Fatal("ConnectionLost() = false after the server stopped")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if monitor.ConnectionLost() {
+		t.Error("ConnectionLost() = true twice for a single loss")
+	}
+}
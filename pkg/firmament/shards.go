@@ -0,0 +1,232 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package firmament
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// ShardedClient partitions the nodes and tasks of the cluster between
+// several Firmament instances, one per node pool, so that their scheduling
+// cycles run concurrently. The shard of a node is the value of its shard
+// label if a Firmament instance schedules that pool, and the shard of a task
+// is the pool its label selectors restrict it to. The other nodes and tasks
+// are in the default shard, named "". A node or task stays in the shard it
+// was added to until it is removed. Schedule must be sent to the shards
+// themselves, the other requests are routed to the shard of their node or
+// task.
+type ShardedClient struct {
+	label  string
+	shards map[string]FirmamentSchedulerClient
+
+	mu sync.Mutex
+	// resources and tasks map the nodes and tasks added to their shard.
+	resources map[string]string
+	tasks     map[uint64]string
+}
+
+// NewShardedClient returns a client routing the requests to the Firmament
+// instances of the pools, keyed by the value of the shard label. The
+// client of the default shard is keyed by "".
+func NewShardedClient(label string, shards map[string]FirmamentSchedulerClient) (*ShardedClient, error) {
+	if label == "" {
+		return nil, fmt.Errorf("the shard label must be set")
+	}
+	if shards[""] == nil {
+		return nil, fmt.Errorf("no default shard")
+	}
+	return &ShardedClient{
+		label:     label,
+		shards:    shards,
+		resources: make(map[string]string),
+		tasks:     make(map[uint64]string),
+	}, nil
+}
+
+// Shards returns the names of the shards, the default one first.
+func (c *ShardedClient) Shards() []string {
+	names := make([]string, 0, len(c.shards))
+	for name := range c.shards {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Shard returns the client of the Firmament instance of a shard.
+func (c *ShardedClient) Shard(name string) FirmamentSchedulerClient {
+	return c.shards[name]
+}
+
+// NodeShard returns the shard of the node, the one it was added to if it
+// was.
+func (c *ShardedClient) NodeShard(rtnd *ResourceTopologyNodeDescriptor) string {
+	c.mu.Lock()
+	shard, ok := c.resources[rtnd.GetResourceDesc().GetUuid()]
+	c.mu.Unlock()
+	if ok {
+		return shard
+	}
+	for _, label := range rtnd.GetResourceDesc().GetLabels() {
+		if label.GetKey() == c.label {
+			if _, ok := c.shards[label.GetValue()]; ok {
+				return label.GetValue()
+			}
+		}
+	}
+	return ""
+}
+
+// TaskShard returns the shard of the task, the one it was submitted to if
+// it was. A task which may be placed in the pools of several shards is in
+// the default one, and only runs on the nodes of the pools not sharded.
+func (c *ShardedClient) TaskShard(td *TaskDescriptor) string {
+	if shard, ok := c.ShardOfTask(td.GetUid()); ok {
+		return shard
+	}
+	for _, selector := range td.GetLabelSelectors() {
+		if selector.GetKey() != c.label || selector.GetType() != LabelSelector_IN_SET || len(selector.GetValues()) != 1 {
+			continue
+		}
+		if _, ok := c.shards[selector.GetValues()[0]]; ok {
+			return selector.GetValues()[0]
+		}
+	}
+	return ""
+}
+
+// ShardOfTask returns the shard the task was submitted to, false if it was
+// not submitted.
+func (c *ShardedClient) ShardOfTask(taskID uint64) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	shard, ok := c.tasks[taskID]
+	return shard, ok
+}
+
+// resourceClient returns the client of the shard of the node, the default
+// one if it is unknown.
+func (c *ShardedClient) resourceClient(resourceID string) FirmamentSchedulerClient {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.shards[c.resources[resourceID]]
+}
+
+// taskClient returns the client of the shard of the task, the default one
+// if it is unknown.
+func (c *ShardedClient) taskClient(taskID uint64) FirmamentSchedulerClient {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.shards[c.tasks[taskID]]
+}
+
+// Schedule is not routed, the scheduling cycles of the shards run
+// separately.
+func (c *ShardedClient) Schedule(ctx context.Context, in *ScheduleRequest, opts ...grpc.CallOption) (*SchedulingDeltas, error) {
+	return nil, fmt.Errorf("a sharded Firmament is scheduled by shard")
+}
+
+func (c *ShardedClient) TaskCompleted(ctx context.Context, in *TaskUID, opts ...grpc.CallOption) (*TaskCompletedResponse, error) {
+	return c.taskClient(in.GetTaskUid()).TaskCompleted(ctx, in, opts...)
+}
+
+func (c *ShardedClient) TaskFailed(ctx context.Context, in *TaskUID, opts ...grpc.CallOption) (*TaskFailedResponse, error) {
+	return c.taskClient(in.GetTaskUid()).TaskFailed(ctx, in, opts...)
+}
+
+func (c *ShardedClient) TaskRemoved(ctx context.Context, in *TaskUID, opts ...grpc.CallOption) (*TaskRemovedResponse, error) {
+	resp, err := c.taskClient(in.GetTaskUid()).TaskRemoved(ctx, in, opts...)
+	if err == nil {
+		c.mu.Lock()
+		delete(c.tasks, in.GetTaskUid())
+		c.mu.Unlock()
+	}
+	return resp, err
+}
+
+func (c *ShardedClient) TaskSubmitted(ctx context.Context, in *TaskDescription, opts ...grpc.CallOption) (*TaskSubmittedResponse, error) {
+	shard := c.TaskShard(in.GetTaskDescriptor())
+	resp, err := c.shards[shard].TaskSubmitted(ctx, in, opts...)
+	if err == nil {
+		c.mu.Lock()
+		c.tasks[in.GetTaskDescriptor().GetUid()] = shard
+		c.mu.Unlock()
+	}
+	return resp, err
+}
+
+func (c *ShardedClient) TaskUpdated(ctx context.Context, in *TaskDescription, opts ...grpc.CallOption) (*TaskUpdatedResponse, error) {
+	return c.taskClient(in.GetTaskDescriptor().GetUid()).TaskUpdated(ctx, in, opts...)
+}
+
+func (c *ShardedClient) NodeAdded(ctx context.Context, in *ResourceTopologyNodeDescriptor, opts ...grpc.CallOption) (*NodeAddedResponse, error) {
+	shard := c.NodeShard(in)
+	resp, err := c.shards[shard].NodeAdded(ctx, in, opts...)
+	if err == nil {
+		c.mu.Lock()
+		c.resources[in.GetResourceDesc().GetUuid()] = shard
+		c.mu.Unlock()
+	}
+	return resp, err
+}
+
+func (c *ShardedClient) NodeFailed(ctx context.Context, in *ResourceUID, opts ...grpc.CallOption) (*NodeFailedResponse, error) {
+	return c.resourceClient(in.GetResourceUid()).NodeFailed(ctx, in, opts...)
+}
+
+func (c *ShardedClient) NodeRemoved(ctx context.Context, in *ResourceUID, opts ...grpc.CallOption) (*NodeRemovedResponse, error) {
+	resp, err := c.resourceClient(in.GetResourceUid()).NodeRemoved(ctx, in, opts...)
+	if err == nil {
+		c.mu.Lock()
+		delete(c.resources, in.GetResourceUid())
+		c.mu.Unlock()
+	}
+	return resp, err
+}
+
+func (c *ShardedClient) NodeUpdated(ctx context.Context, in *ResourceTopologyNodeDescriptor, opts ...grpc.CallOption) (*NodeUpdatedResponse, error) {
+	return c.resourceClient(in.GetResourceDesc().GetUuid()).NodeUpdated(ctx, in, opts...)
+}
+
+func (c *ShardedClient) AddTaskStats(ctx context.Context, in *TaskStats, opts ...grpc.CallOption) (*TaskStatsResponse, error) {
+	return c.taskClient(in.GetTaskId()).AddTaskStats(ctx, in, opts...)
+}
+
+func (c *ShardedClient) AddNodeStats(ctx context.Context, in *ResourceStats, opts ...grpc.CallOption) (*ResourceStatsResponse, error) {
+	return c.resourceClient(in.GetResourceId()).AddNodeStats(ctx, in, opts...)
+}
+
+// Check returns the status of the first shard which is not serving, that of
+// the default shard if all are.
+func (c *ShardedClient) Check(ctx context.Context, in *HealthCheckRequest, opts ...grpc.CallOption) (*HealthCheckResponse, error) {
+	var serving *HealthCheckResponse
+	for _, name := range c.Shards() {
+		resp, err := c.shards[name].Check(ctx, in, opts...)
+		if err != nil || resp.GetStatus() != ServingStatus_SERVING {
+			return resp, err
+		}
+		if serving == nil {
+			serving = resp
+		}
+	}
+	return serving, nil
+}
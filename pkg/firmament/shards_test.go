@@ -0,0 +1,104 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package firmament
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"golang.org/x/net/context"
+)
+
+func TestShardedClient(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	defaultShard, shardA := NewMockFirmamentSchedulerClient(mockCtrl), NewMockFirmamentSchedulerClient(mockCtrl)
+	if _, err := NewShardedClient("pool", map[string]FirmamentSchedulerClient{"a": shardA}); err == nil {
+		t.Error("NewShardedClient() accepted shards without a default one")
+	}
+	c, err := NewShardedClient("pool", map[string]FirmamentSchedulerClient{"": defaultShard, "a": shardA})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shards := c.Shards(); !reflect.DeepEqual(shards, []string{"", "a"}) {
+		t.Errorf("Shards() = %v, expected the default shard and a", shards)
+	}
+	ctx := context.Background()
+
+	// The nodes are routed by their pool label.
+	nodeA := &ResourceTopologyNodeDescriptor{ResourceDesc: &ResourceDescriptor{Uuid: "uuid-a", Labels: []*Label{{Key: "pool", Value: "a"}}}}
+	nodeB := &ResourceTopologyNodeDescriptor{ResourceDesc: &ResourceDescriptor{Uuid: "uuid-b", Labels: []*Label{{Key: "pool", Value: "b"}}}}
+	shardA.EXPECT().NodeAdded(ctx, nodeA).Return(&NodeAddedResponse{}, nil)
+	defaultShard.EXPECT().NodeAdded(ctx, nodeB).Return(&NodeAddedResponse{}, nil)
+	c.NodeAdded(ctx, nodeA)
+	c.NodeAdded(ctx, nodeB)
+	// A node stays in its shard until it is removed.
+	relabeled := &ResourceTopologyNodeDescriptor{ResourceDesc: &ResourceDescriptor{Uuid: "uuid-a"}}
+	shardA.EXPECT().NodeUpdated(ctx, relabeled).Return(&NodeUpdatedResponse{}, nil)
+	c.NodeUpdated(ctx, relabeled)
+	shardA.EXPECT().AddNodeStats(ctx, &ResourceStats{ResourceId: "uuid-a"}).Return(&ResourceStatsResponse{}, nil)
+	c.AddNodeStats(ctx, &ResourceStats{ResourceId: "uuid-a"})
+	shardA.EXPECT().NodeRemoved(ctx, &ResourceUID{ResourceUid: "uuid-a"}).Return(&NodeRemovedResponse{}, nil)
+	c.NodeRemoved(ctx, &ResourceUID{ResourceUid: "uuid-a"})
+	if shard := c.NodeShard(relabeled); shard != "" {
+		t.Errorf("NodeShard() = %q for a removed node without pool, expected the default shard", shard)
+	}
+
+	// The tasks are routed by the pool their label selectors restrict them
+	// to.
+	var testData = []struct {
+		selector *LabelSelector
+		shard    string
+	}{
+		{selector: &LabelSelector{Type: LabelSelector_IN_SET, Key: "pool", Values: []string{"a"}}, shard: "a"},
+		{selector: &LabelSelector{Type: LabelSelector_IN_SET, Key: "pool", Values: []string{"a", "b"}}, shard: ""},
+		{selector: &LabelSelector{Type: LabelSelector_NOT_IN_SET, Key: "pool", Values: []string{"a"}}, shard: ""},
+		{selector: &LabelSelector{Type: LabelSelector_IN_SET, Key: "zone", Values: []string{"a"}}, shard: ""},
+	}
+	for _, tc := range testData {
+		td := &TaskDescriptor{LabelSelectors: []*LabelSelector{tc.selector}}
+		if shard := c.TaskShard(td); shard != tc.shard {
+			t.Errorf("TaskShard(%v) = %q, expected %q", tc.selector, shard, tc.shard)
+		}
+	}
+	task := &TaskDescription{TaskDescriptor: &TaskDescriptor{Uid: 1, LabelSelectors: []*LabelSelector{testData[0].selector}}}
+	shardA.EXPECT().TaskSubmitted(ctx, task).Return(&TaskSubmittedResponse{}, nil)
+	c.TaskSubmitted(ctx, task)
+	if shard, ok := c.ShardOfTask(1); !ok || shard != "a" {
+		t.Errorf("ShardOfTask(1) = %q, %v, expected a", shard, ok)
+	}
+	shardA.EXPECT().AddTaskStats(ctx, &TaskStats{TaskId: 1}).Return(&TaskStatsResponse{}, nil)
+	c.AddTaskStats(ctx, &TaskStats{TaskId: 1})
+	shardA.EXPECT().TaskRemoved(ctx, &TaskUID{TaskUid: 1}).Return(&TaskRemovedResponse{}, nil)
+	c.TaskRemoved(ctx, &TaskUID{TaskUid: 1})
+	if _, ok := c.ShardOfTask(1); ok {
+		t.Error("ShardOfTask(1) found a removed task")
+	}
+	// The unknown tasks are sent to the default shard.
+	defaultShard.EXPECT().TaskFailed(ctx, &TaskUID{TaskUid: 2}).Return(&TaskFailedResponse{}, nil)
+	c.TaskFailed(ctx, &TaskUID{TaskUid: 2})
+
+	if _, err := c.Schedule(ctx, &ScheduleRequest{}); err == nil {
+		t.Error("Schedule() succeeded on the sharded client")
+	}
+	defaultShard.EXPECT().Check(ctx, gomock.Any()).Return(&HealthCheckResponse{Status: ServingStatus_SERVING}, nil)
+	shardA.EXPECT().Check(ctx, gomock.Any()).Return(&HealthCheckResponse{Status: ServingStatus_NOT_SERVING}, nil)
+	if resp, err := c.Check(ctx, &HealthCheckRequest{}); err != nil || resp.GetStatus() != ServingStatus_NOT_SERVING {
+		t.Errorf("Check() = %v, %v, expected the status of the shard not serving", resp, err)
+	}
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package firmament
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"google.golang.org/grpc/resolver"
+)
+
+// SRVScheme is the scheme of the Firmament addresses discovered from a DNS
+// SRV record, e.g. srv:///_grpc._tcp.firmament-service.kube-system.svc.cluster.local
+// for the port named grpc of a headless Firmament service.
+const SRVScheme = "srv"
+
+// srvResolveInterval is the interval at which SRV records are re-resolved.
+var srvResolveInterval = 30 * time.Second
+
+var (
+	lookupSRV  = net.LookupSRV
+	lookupHost = net.LookupHost
+)
+
+func init() {
+	resolver.Register(&srvBuilder{})
+}
+
+type srvBuilder struct{}
+
+func (*srvBuilder) Scheme() string {
+	return SRVScheme
+}
+
+func (*srvBuilder) Build(target resolver.Target, cc resolver.ClientConn, opts resolver.BuildOption) (resolver.Resolver, error) {
+	if target.Endpoint == "" {
+		return nil, fmt.Errorf("missing SRV record name in Firmament address")
+	}
+	r := &srvResolver{
+		name:       target.Endpoint,
+		cc:         cc,
+		resolveNow: make(chan struct{}, 1),
+		done:       make(chan struct{}),
+	}
+	go r.watch()
+	return r, nil
+}
+
+// srvResolver resolves the Firmament endpoints from a SRV record, and
+// re-resolves them periodically and when gRPC fails to connect to them, so
+// that Firmament can be moved without restarting Poseidon.
+type srvResolver struct {
+	name       string
+	cc         resolver.ClientConn
+	resolveNow chan struct{}
+	done       chan struct{}
+	// addrs are the last resolved endpoints, only accessed by watch.
+	addrs []string
+}
+
+func (r *srvResolver) ResolveNow(resolver.ResolveNowOption) {
+	select {
+	case r.resolveNow <- struct{}{}:
+	default:
+	}
+}
+
+func (r *srvResolver) Close() {
+	close(r.done)
+}
+
+func (r *srvResolver) watch() {
+	ticker := time.NewTicker(srvResolveInterval)
+	defer ticker.Stop()
+	for {
+		r.resolve()
+		select {
+		case <-r.done:
+			return
+		case <-ticker.C:
+		case <-r.resolveNow:
+		}
+	}
+}
+
+func (r *srvResolver) resolve() {
+	addrs, err := resolveSRV(r.name)
+	if err != nil {
+		// Keep the previous endpoints, the lookup may fail transiently.
+		glog.Errorf("Failed to resolve the Firmament SRV record %s: %v", r.name, err)
+		return
+	}
+	if equalAddrs(addrs, r.addrs) {
+		return
+	}
+	glog.Infof("Firmament endpoints of %s changed from %v to %v", r.name, r.addrs, addrs)
+	r.addrs = addrs
+	resolved := make([]resolver.Address, 0, len(addrs))
+	for _, addr := range addrs {
+		resolved = append(resolved, resolver.Address{Addr: addr})
+	}
+	r.cc.NewAddress(resolved)
+}
+
+// resolveSRV returns the sorted host:port endpoints of the SRV record.
+func resolveSRV(name string) ([]string, error) {
+	_, srvs, err := lookupSRV("", "", name)
+	if err != nil {
+		return nil, err
+	}
+	var addrs []string
+	for _, srv := range srvs {
+		hosts, err := lookupHost(strings.TrimSuffix(srv.Target, "."))
+		if err != nil {
+			return nil, err
+		}
+		for _, host := range hosts {
+			addrs = append(addrs, net.JoinHostPort(host, strconv.Itoa(int(srv.Port))))
+		}
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no endpoint")
+	}
+	sort.Strings(addrs)
+	return addrs, nil
+}
+
+func equalAddrs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
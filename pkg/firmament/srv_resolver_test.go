@@ -0,0 +1,84 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package firmament
+
+import (
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/resolver"
+)
+
+type addressRecorder struct {
+	addresses chan []resolver.Address
+}
+
+func (a *addressRecorder) NewAddress(addresses []resolver.Address) {
+	a.addresses <- addresses
+}
+
+func (a *addressRecorder) NewServiceConfig(string) {}
+
+func TestSRVResolver(t *testing.T) {
+	var mu sync.Mutex
+	hosts := map[string][]string{"firmament-0.firmament": {"10.0.0.1"}}
+	lookupSRV = func(service, proto, name string) (string, []*net.SRV, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		var srvs []*net.SRV
+		for target := range hosts {
+			srvs = append(srvs, &net.SRV{Target: target + ".", Port: 9090})
+		}
+		return "", srvs, nil
+	}
+	lookupHost = func(host string) ([]string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return hosts[host], nil
+	}
+	defer func() {
+		lookupSRV = net.LookupSRV
+		lookupHost = net.LookupHost
+	}()
+
+	cc := &addressRecorder{addresses: make(chan []resolver.Address, 1)}
+	r, err := (&srvBuilder{}).Build(resolver.Target{Scheme: SRVScheme, Endpoint: "_grpc._tcp.firmament"}, cc, resolver.BuildOption{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	expectAddresses := func(expected []resolver.Address) {
+		select {
+		case addresses := <-cc.addresses:
+			if !reflect.DeepEqual(addresses, expected) {
+				t.Errorf("resolved %v, expected %v", addresses, expected)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %v", expected)
+		}
+	}
+	expectAddresses([]resolver.Address{{Addr: "10.0.0.1:9090"}})
+
+	mu.Lock()
+	hosts["firmament-0.firmament"] = []string{"10.0.0.2"}
+	mu.Unlock()
+	r.ResolveNow(resolver.ResolveNowOption{})
+	expectAddresses([]resolver.Address{{Addr: "10.0.0.2:9090"}})
+}
@@ -0,0 +1,319 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// RecordType is the type of a scheduling decision.
+type RecordType string
+
+const (
+	// Place represents a pod bound to a node.
+	Place RecordType = "place"
+	// Preempt represents a pod deleted to free resources.
+	Preempt RecordType = "preempt"
+	// Migrate represents a pod deleted to be placed on another node.
+	Migrate RecordType = "migrate"
+)
+
+// Record is a scheduling decision applied by Poseidon.
+type Record struct {
+	Time time.Time  `json:"time"`
+	Type RecordType `json:"type"`
+	// Pod is the pod namespace/name.
+	Pod  string `json:"pod"`
+	Node string `json:"node,omitempty"`
+	// RunID is the ID of the Poseidon run which made the decision.
+	RunID string `json:"runId,omitempty"`
+	// Reason is why a pod was preempted or migrated.
+	Reason string `json:"reason,omitempty"`
+	// OnBehalfOf are the pods placed on the node of a preempted or migrated
+	// pod in the same scheduling cycle.
+	OnBehalfOf []string `json:"onBehalfOf,omitempty"`
+}
+
+// Query selects records. Empty fields match all records.
+type Query struct {
+	Pod   string
+	Node  string
+	Type  RecordType
+	Since time.Time
+	// Limit is the max number of most recent records returned, 0 means no limit.
+	Limit int
+}
+
+func (q *Query) matches(record *Record) bool {
+	return (q.Pod == "" || q.Pod == record.Pod) &&
+		(q.Node == "" || q.Node == record.Node) &&
+		(q.Type == "" || q.Type == record.Type) &&
+		!record.Time.Before(q.Since)
+}
+
+// Store is an embedded store recording the placements and preemptions applied
+// by Poseidon. Records are appended to a file, one JSON object per line, so
+// that the history survives scheduler restarts. The file is reopened if it is
+// moved away, e.g. by logrotate.
+type Store struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+	// records holds the maxRecords most recent records, as a ring whose
+	// oldest record is at head once it is full.
+	records    []Record
+	head       int
+	maxRecords int
+	// fileRecords is the number of records in the file.
+	fileRecords int
+	// sinks receive the records appended to the store.
+	sinks []Sink
+}
+
+// Sink is a destination of the records besides the store, e.g. an audit
+// system. Append must not block the scheduling.
+type Sink interface {
+	Append(record Record) error
+}
+
+// Open opens the store at the given path, loading the records persisted by
+// previous runs. At most maxRecords records are kept. The records are only
+// kept in memory if the path is empty.
+func Open(path string, maxRecords int) (*Store, error) {
+	if maxRecords <= 0 {
+		return nil, fmt.Errorf("invalid max number of records %d", maxRecords)
+	}
+	s := &Store{
+		path:       path,
+		maxRecords: maxRecords,
+	}
+	if path == "" {
+		return s, nil
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	s.file = file
+	glog.Infof("Loaded %d history records from %s", len(s.records), path)
+	return s, nil
+}
+
+func (s *Store) load() error {
+	file, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			// A partially written record, e.g. after a crash.
+			glog.Warningf("Skipping invalid placement history record %q: %v", scanner.Text(), err)
+			continue
+		}
+		s.fileRecords++
+		s.appendRecord(record)
+	}
+	return scanner.Err()
+}
+
+func (s *Store) appendRecord(record Record) {
+	if len(s.records) < s.maxRecords {
+		s.records = append(s.records, record)
+		return
+	}
+	s.records[s.head] = record
+	s.head = (s.head + 1) % len(s.records)
+}
+
+// record returns the i-th oldest record kept in memory.
+func (s *Store) record(i int) *Record {
+	return &s.records[(s.head+i)%len(s.records)]
+}
+
+// AddSink forwards the records appended to the store to the sink.
+func (s *Store) AddSink(sink Sink) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sinks = append(s.sinks, sink)
+}
+
+// Append persists a record, and forwards it to the sinks. The failures of
+// the sinks are logged but not returned.
+func (s *Store) Append(record Record) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sink := range s.sinks {
+		if err := sink.Append(record); err != nil {
+			glog.Errorf("Failed to forward the %s record of pod %s: %v", record.Type, record.Pod, err)
+		}
+	}
+	if s.file == nil {
+		s.appendRecord(record)
+		return nil
+	}
+	if err := s.reopenIfMoved(); err != nil {
+		return err
+	}
+	if _, err := s.file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	s.fileRecords++
+	s.appendRecord(record)
+	if s.fileRecords > 2*s.maxRecords {
+		return s.compact()
+	}
+	return nil
+}
+
+// reopenIfMoved reopens the file at the store path if the open file was
+// moved or removed. The caller must hold s.mu.
+func (s *Store) reopenIfMoved() error {
+	opened, err := s.file.Stat()
+	if err != nil {
+		return err
+	}
+	current, err := os.Stat(s.path)
+	if err == nil && os.SameFile(opened, current) {
+		return nil
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	glog.Infof("History file %s was moved, reopening it", s.path)
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	s.file.Close()
+	s.file = file
+	s.fileRecords = 0
+	return nil
+}
+
+// compact rewrites the file with the records kept in memory. The caller must
+// hold s.mu.
+func (s *Store) compact() error {
+	tmpPath := s.path + ".tmp"
+	tmpFile, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(tmpFile)
+	encoder := json.NewEncoder(writer)
+	for i := range s.records {
+		if err := encoder.Encode(s.record(i)); err != nil {
+			tmpFile.Close()
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return err
+	}
+	s.file.Close()
+	s.file, err = os.OpenFile(s.path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	s.fileRecords = len(s.records)
+	return nil
+}
+
+// Query returns the records matching the query, oldest first.
+func (s *Store) Query(query Query) []Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var records []Record
+	for i := len(s.records) - 1; i >= 0; i-- {
+		if query.Limit > 0 && len(records) == query.Limit {
+			break
+		}
+		if record := s.record(i); query.matches(record) {
+			records = append(records, *record)
+		}
+	}
+	// Reverse the records so that the oldest comes first.
+	for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+		records[i], records[j] = records[j], records[i]
+	}
+	return records
+}
+
+// Close closes the store file.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	return s.file.Close()
+}
+
+// ServeHTTP returns the records matching the pod, node, type, since
+// (RFC 3339) and limit query parameters.
+func (s *Store) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	query := Query{
+		Pod:  params.Get("pod"),
+		Node: params.Get("node"),
+		Type: RecordType(params.Get("type")),
+	}
+	if since := params.Get("since"); since != "" {
+		var err error
+		if query.Since, err = time.Parse(time.RFC3339, since); err != nil {
+			http.Error(w, fmt.Sprintf("invalid since parameter: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+	if limit := params.Get("limit"); limit != "" {
+		var err error
+		if query.Limit, err = strconv.Atoi(limit); err != nil {
+			http.Error(w, fmt.Sprintf("invalid limit parameter: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.Query(query))
+}
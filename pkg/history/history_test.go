@@ -0,0 +1,183 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "placements")
+
+	store, err := Open(path, 3)
+	if err != nil {
+		t.Fatalf("Open() failed: %v", err)
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	for i := 0; i < 7; i++ {
+		record := Record{
+			Time: now.Add(time.Duration(i) * time.Second),
+			Type: Place,
+			Pod:  fmt.Sprintf("default/pod%d", i),
+			Node: fmt.Sprintf("node%d", i%2),
+		}
+		if i == 6 {
+			record.Type = Preempt
+		}
+		if err := store.Append(record); err != nil {
+			t.Fatalf("Append() failed: %v", err)
+		}
+	}
+	store.Close()
+
+	// The store is reloaded with the most recent records.
+	store, err = Open(path, 3)
+	if err != nil {
+		t.Fatalf("Open() failed: %v", err)
+	}
+	defer store.Close()
+	var testData = []struct {
+		query    Query
+		expected []string
+	}{
+		{query: Query{}, expected: []string{"default/pod4", "default/pod5", "default/pod6"}},
+		{query: Query{Node: "node0"}, expected: []string{"default/pod4", "default/pod6"}},
+		{query: Query{Type: Preempt}, expected: []string{"default/pod6"}},
+		{query: Query{Pod: "default/pod5"}, expected: []string{"default/pod5"}},
+		{query: Query{Since: now.Add(5 * time.Second)}, expected: []string{"default/pod5", "default/pod6"}},
+		{query: Query{Limit: 1}, expected: []string{"default/pod6"}},
+	}
+	for _, tc := range testData {
+		var pods []string
+		for _, record := range store.Query(tc.query) {
+			pods = append(pods, record.Pod)
+		}
+		if !reflect.DeepEqual(pods, tc.expected) {
+			t.Errorf("Query(%+v) = %v, expected %v", tc.query, pods, tc.expected)
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	store.ServeHTTP(recorder, httptest.NewRequest("GET", "/placements?node=node1&limit=1", nil))
+	var records []Record
+	if err := json.NewDecoder(recorder.Body).Decode(&records); err != nil {
+		t.Fatalf("Failed to decode records: %v", err)
+	}
+	if len(records) != 1 || records[0].Pod != "default/pod5" {
+		t.Errorf("ServeHTTP() returned %v, expected default/pod5", records)
+	}
+}
+
+func TestStoreReopensMovedFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "preemptions")
+
+	store, err := Open(path, 10)
+	if err != nil {
+		t.Fatalf("Open() failed: %v", err)
+	}
+	defer store.Close()
+	record := Record{Type: Preempt, Pod: "default/pod0", Node: "node0", Reason: "preempted", OnBehalfOf: []string{"default/pod1"}}
+	if err := store.Append(record); err != nil {
+		t.Fatalf("Append() failed: %v", err)
+	}
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	record.Pod = "default/pod2"
+	if err := store.Append(record); err != nil {
+		t.Fatalf("Append() failed after the file was moved: %v", err)
+	}
+	for file, expected := range map[string]string{path + ".1": "default/pod0", path: "default/pod2"} {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var persisted Record
+		if err := json.Unmarshal(data, &persisted); err != nil || persisted.Pod != expected {
+			t.Errorf("%s holds %q, expected only the record of %s", file, data, expected)
+		}
+	}
+	if records := store.Query(Query{}); len(records) != 2 || !reflect.DeepEqual(records[1].OnBehalfOf, record.OnBehalfOf) {
+		t.Errorf("Query() = %v, expected both records", records)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	store, err := Open("", 2)
+	if err != nil {
+		t.Fatalf("Open() failed: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		if err := store.Append(Record{Type: Migrate, Pod: fmt.Sprintf("default/pod%d", i)}); err != nil {
+			t.Fatalf("Append() failed: %v", err)
+		}
+	}
+	if records := store.Query(Query{}); len(records) != 2 || records[0].Pod != "default/pod3" || records[1].Pod != "default/pod4" {
+		t.Errorf("Query() = %v, expected the 2 most recent records, oldest first", records)
+	}
+	if err := store.Close(); err != nil {
+		t.Errorf("Close() failed: %v", err)
+	}
+}
+
+// failingSink collects the records and fails to persist them.
+type failingSink struct {
+	records []Record
+}
+
+func (s *failingSink) Append(record Record) error {
+	s.records = append(s.records, record)
+	return fmt.Errorf("sink unavailable")
+}
+
+func TestStoreSinks(t *testing.T) {
+	store, err := Open("", 10)
+	if err != nil {
+		t.Fatalf("Open() failed: %v", err)
+	}
+	sink := &failingSink{}
+	store.AddSink(sink)
+	record := Record{Type: Place, Pod: "default/pod0", Node: "node0"}
+	// The failures of the sinks do not fail the store.
+	if err := store.Append(record); err != nil {
+		t.Fatalf("Append() failed: %v", err)
+	}
+	if !reflect.DeepEqual(sink.records, []Record{record}) {
+		t.Errorf("Sink received %v, expected %v", sink.records, []Record{record})
+	}
+	if records := store.Query(Query{}); len(records) != 1 {
+		t.Errorf("Query() = %v, expected the appended record", records)
+	}
+}
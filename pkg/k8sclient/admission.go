@@ -0,0 +1,153 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/golang/glog"
+	"k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// AdmissionPlacedCondition is the pod condition reporting back to the
+// admission system whether an admitted pod is placed: true once it is bound,
+// false once it was left unplaced by AdmissionPolicy.UnplacedCycles cycles,
+// e.g. for the admission system to release its quota.
+const AdmissionPlacedCondition v1.PodConditionType = "poseidon.k8s.io/Placed"
+
+// AdmissionPolicy defers the claiming of the pods managed by an external
+// admission system, e.g. Kueue, until the system admits them against its
+// quotas. Poseidon then places the admitted pods and reports the results back
+// on their AdmissionPlacedCondition.
+type AdmissionPolicy struct {
+	// Label is the label key of the pods managed by the admission system,
+	// e.g. kueue.x-k8s.io/queue-name.
+	Label string
+	// AdmittedAnnotation is the pod annotation the admission system sets to
+	// "true" once it admits the pod.
+	AdmittedAnnotation string
+	// UnplacedCycles is the number of scheduling cycles an admitted pod may
+	// be left unplaced before it is reported, 0 only reports the placements.
+	UnplacedCycles int
+}
+
+// admissionPolicy is the admission gate of the pods, nil if the pods are
+// claimed regardless of any admission system.
+var admissionPolicy *AdmissionPolicy
+
+var (
+	admissionMux sync.Mutex
+	// admittedTasks maps the tasks of the admitted pods to their pod and
+	// whether they were reported unplaced.
+	admittedTasks = make(map[uint64]*admittedTask)
+)
+
+type admittedTask struct {
+	pod              PodIdentifier
+	reportedUnplaced bool
+}
+
+// isAdmissionGated returns whether the pod is managed by the admission
+// system.
+func isAdmissionGated(pod *Pod) bool {
+	if admissionPolicy == nil {
+		return false
+	}
+	_, ok := pod.Labels[admissionPolicy.Label]
+	return ok
+}
+
+// awaitingAdmission returns why the pod must not be claimed yet, empty if it
+// is not gated or was admitted.
+func awaitingAdmission(pod *Pod) string {
+	if !isAdmissionGated(pod) || pod.Annotations[admissionPolicy.AdmittedAnnotation] == "true" {
+		return ""
+	}
+	return fmt.Sprintf("awaiting admission, the %s annotation is not set", admissionPolicy.AdmittedAnnotation)
+}
+
+// registerAdmittedTask records the task of an admitted pod.
+func registerAdmittedTask(pod *Pod, taskID uint64) {
+	if !isAdmissionGated(pod) {
+		return
+	}
+	admissionMux.Lock()
+	defer admissionMux.Unlock()
+	admittedTasks[taskID] = &admittedTask{pod: pod.Identifier}
+}
+
+// forgetAdmittedTask drops a removed task.
+func forgetAdmittedTask(taskID uint64) {
+	admissionMux.Lock()
+	defer admissionMux.Unlock()
+	delete(admittedTasks, taskID)
+}
+
+// reportAdmittedPlacement reports back the placement of a bound pod if it
+// was admitted.
+func reportAdmittedPlacement(podIdentifier PodIdentifier, nodeName string) {
+	td, ok := state.TaskOfPod(podIdentifier)
+	if !ok {
+		return
+	}
+	admissionMux.Lock()
+	_, admitted := admittedTasks[td.GetUid()]
+	admissionMux.Unlock()
+	if !admitted {
+		return
+	}
+	go reportAdmissionResult(clientSet, podIdentifier, admissionPlacedCondition(v1.ConditionTrue, "Bound", "placed on node "+nodeName))
+}
+
+// reportUnplacedAdmittedTasks reports back the admitted pods whose tasks
+// were left unplaced by the given number of cycles, once.
+func reportUnplacedAdmittedTasks(unplacedCycles map[uint64]int) {
+	if admissionPolicy == nil || admissionPolicy.UnplacedCycles <= 0 || shadowMode {
+		return
+	}
+	admissionMux.Lock()
+	defer admissionMux.Unlock()
+	for taskID, cycles := range unplacedCycles {
+		task, ok := admittedTasks[taskID]
+		if !ok || task.reportedUnplaced || cycles < admissionPolicy.UnplacedCycles {
+			continue
+		}
+		task.reportedUnplaced = true
+		message := fmt.Sprintf("left unplaced by %d scheduling cycles", cycles)
+		glog.Infof("Reporting admitted pod %v %s", task.pod, message)
+		go reportAdmissionResult(clientSet, task.pod, admissionPlacedCondition(v1.ConditionFalse, "Unplaced", message))
+	}
+}
+
+func admissionPlacedCondition(status v1.ConditionStatus, reason, message string) v1.PodCondition {
+	return v1.PodCondition{
+		Type:               AdmissionPlacedCondition,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		LastTransitionTime: meta_v1.Now(),
+	}
+}
+
+func reportAdmissionResult(client kubernetes.Interface, podIdentifier PodIdentifier, condition v1.PodCondition) {
+	if err := setPodConditions(client, podIdentifier, []v1.PodCondition{condition}); err != nil {
+		glog.Errorf("Failed to report the placement of admitted pod %v: %v", podIdentifier, err)
+	}
+}
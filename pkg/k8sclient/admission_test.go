@@ -0,0 +1,86 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"testing"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestAwaitingAdmission(t *testing.T) {
+	defer func() { admissionPolicy = nil }()
+	gated := &Pod{Labels: map[string]string{"kueue.x-k8s.io/queue-name": "team-a"}}
+	if reason := awaitingAdmission(gated); reason != "" {
+		t.Errorf("awaitingAdmission() = %q without a policy, expected none", reason)
+	}
+	admissionPolicy = &AdmissionPolicy{Label: "kueue.x-k8s.io/queue-name", AdmittedAnnotation: "kueue.x-k8s.io/admitted", UnplacedCycles: 3}
+	if reason := awaitingAdmission(gated); reason == "" {
+		t.Error("awaitingAdmission() accepted a pod not admitted yet")
+	}
+	if reason := pauseReason(gated); reason == "" {
+		t.Error("pauseReason() did not hold a pod not admitted yet")
+	}
+	if reason := awaitingAdmission(&Pod{}); reason != "" {
+		t.Errorf("awaitingAdmission() = %q for a pod not gated, expected none", reason)
+	}
+	gated.Annotations = map[string]string{"kueue.x-k8s.io/admitted": "true"}
+	if reason := awaitingAdmission(gated); reason != "" {
+		t.Errorf("awaitingAdmission() = %q for an admitted pod, expected none", reason)
+	}
+}
+
+func TestReportUnplacedAdmittedTasks(t *testing.T) {
+	defer func() { admissionPolicy = nil }()
+	admissionPolicy = &AdmissionPolicy{Label: "queue", AdmittedAnnotation: "admitted", UnplacedCycles: 3}
+	podIdentifier := PodIdentifier{Name: "pod0", Namespace: "ns"}
+	registerAdmittedTask(&Pod{Identifier: podIdentifier, Labels: map[string]string{"queue": "a"}}, 1)
+	defer forgetAdmittedTask(1)
+	registerAdmittedTask(&Pod{Identifier: PodIdentifier{Name: "pod1", Namespace: "ns"}}, 2)
+	if _, ok := admittedTasks[2]; ok {
+		t.Error("registerAdmittedTask() registered a pod not gated")
+	}
+
+	clientSet = fake.NewSimpleClientset(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod0", Namespace: "ns"}})
+	defer func() { clientSet = nil }()
+	reportUnplacedAdmittedTasks(map[uint64]int{1: 2})
+	if admittedTasks[1].reportedUnplaced {
+		t.Error("reportUnplacedAdmittedTasks() reported a pod before UnplacedCycles")
+	}
+	reportUnplacedAdmittedTasks(map[uint64]int{1: 3})
+	if !admittedTasks[1].reportedUnplaced {
+		t.Error("reportUnplacedAdmittedTasks() did not report a pod after UnplacedCycles")
+	}
+
+	client := fake.NewSimpleClientset(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod0", Namespace: "ns"}})
+	reportAdmissionResult(client, podIdentifier, admissionPlacedCondition(v1.ConditionTrue, "Bound", "placed on node node0"))
+	pod, err := client.CoreV1().Pods("ns").Get("pod0", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var status v1.ConditionStatus
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == AdmissionPlacedCondition {
+			status = condition.Status
+		}
+	}
+	if status != v1.ConditionTrue {
+		t.Errorf("reportAdmissionResult() set the %s condition to %q, expected True", AdmissionPlacedCondition, status)
+	}
+}
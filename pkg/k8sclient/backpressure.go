@@ -0,0 +1,139 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/kubernetes-sigs/poseidon/pkg/metrics"
+)
+
+// BackpressurePolicy delays the task submissions while the solver or the
+// bind path is saturated, e.g. during pod creation storms. The pods are not
+// dropped, their submission is deferred and retried. Each saturated cycle
+// halves the submission rate, down to MinSubmissionRate, and each cycle
+// within the limits doubles it, until it reaches the rate at which the
+// saturation started and the backpressure is lifted.
+type BackpressurePolicy struct {
+	// MaxSolveDuration and MaxBindDuration are the durations of the solver
+	// run and of the application of its deltas above which the scheduler is
+	// saturated, 0 if unlimited.
+	MaxSolveDuration time.Duration
+	MaxBindDuration  time.Duration
+	// MinSubmissionRate is the lowest number of task submissions per second
+	// while the scheduler is saturated.
+	MinSubmissionRate float64
+}
+
+// backpressurePolicy is the backpressure policy, nil if the submissions are
+// never delayed on the solver load.
+var backpressurePolicy *BackpressurePolicy
+
+var (
+	backpressureMux sync.Mutex
+	// submissionRate is the allowed number of task submissions per second,
+	// 0 while there is no backpressure, and liftRate the rate at which it is
+	// lifted.
+	submissionRate, liftRate float64
+	// backpressureReason is why the backpressure applies, empty if it does
+	// not.
+	backpressureReason string
+	// submissionTokens are the submissions allowed since lastRefill.
+	submissionTokens float64
+	lastRefill       time.Time
+	// cycleSubmissions are the submissions since lastCycleLoad.
+	cycleSubmissions int
+	lastCycleLoad    time.Time
+)
+
+// RecordCycleLoad adapts the submission rate to the durations of the solver
+// run and of the application of its deltas of the last scheduling cycle.
+func RecordCycleLoad(solve, bind time.Duration) {
+	if backpressurePolicy == nil {
+		return
+	}
+	backpressureMux.Lock()
+	defer backpressureMux.Unlock()
+	now := time.Now()
+	observedRate := 0.0
+	if !lastCycleLoad.IsZero() {
+		observedRate = float64(cycleSubmissions) / now.Sub(lastCycleLoad).Seconds()
+	}
+	cycleSubmissions, lastCycleLoad = 0, now
+
+	var reason string
+	switch {
+	case backpressurePolicy.MaxSolveDuration > 0 && solve > backpressurePolicy.MaxSolveDuration:
+		reason = fmt.Sprintf("solver run of %v exceeds %v", solve, backpressurePolicy.MaxSolveDuration)
+	case backpressurePolicy.MaxBindDuration > 0 && bind > backpressurePolicy.MaxBindDuration:
+		reason = fmt.Sprintf("bind of %v exceeds %v", bind, backpressurePolicy.MaxBindDuration)
+	}
+	switch {
+	case reason != "" && submissionRate == 0:
+		liftRate = math.Max(observedRate, backpressurePolicy.MinSubmissionRate)
+		submissionRate = math.Max(liftRate/2, backpressurePolicy.MinSubmissionRate)
+		submissionTokens, lastRefill = 0, now
+		glog.Warningf("Scheduler saturated, %s, task submissions limited to %.2f/s", reason, submissionRate)
+	case reason != "":
+		submissionRate = math.Max(submissionRate/2, backpressurePolicy.MinSubmissionRate)
+		glog.V(2).Infof("Scheduler still saturated, %s, task submissions limited to %.2f/s", reason, submissionRate)
+	case submissionRate > 0 && submissionRate*2 >= liftRate:
+		submissionRate = 0
+		glog.Info("Scheduler no longer saturated, lifted the task submission backpressure")
+	case submissionRate > 0:
+		submissionRate *= 2
+		reason = backpressureReason
+	}
+	backpressureReason = reason
+	if submissionRate == 0 {
+		backpressureReason = ""
+	}
+	metrics.SubmissionRateLimit.Set(submissionRate)
+}
+
+// admitSubmission returns true if a task may be submitted under the
+// backpressure, and accounts its submission.
+func admitSubmission(now time.Time) bool {
+	if backpressurePolicy == nil {
+		return true
+	}
+	backpressureMux.Lock()
+	defer backpressureMux.Unlock()
+	if submissionRate > 0 {
+		burst := math.Max(1, submissionRate)
+		submissionTokens = math.Min(burst, submissionTokens+now.Sub(lastRefill).Seconds()*submissionRate)
+		lastRefill = now
+		if submissionTokens < 1 {
+			return false
+		}
+		submissionTokens--
+	}
+	cycleSubmissions++
+	return true
+}
+
+// Backpressure returns why the task submissions are delayed, empty if they
+// are not, and their allowed rate per second.
+func Backpressure() (string, float64) {
+	backpressureMux.Lock()
+	defer backpressureMux.Unlock()
+	return backpressureReason, submissionRate
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackpressure(t *testing.T) {
+	defer func() {
+		backpressurePolicy = nil
+		submissionRate, liftRate, backpressureReason = 0, 0, ""
+		cycleSubmissions, lastCycleLoad = 0, time.Time{}
+	}()
+	backpressurePolicy = &BackpressurePolicy{MaxSolveDuration: time.Second, MinSubmissionRate: 1}
+	now := time.Now()
+	for i := 0; i < 20; i++ {
+		if !admitSubmission(now) {
+			t.Fatal("admitSubmission() = false without backpressure")
+		}
+	}
+	lastCycleLoad = now.Add(-time.Second)
+
+	// The saturation halves the rate of the last cycle, about 20/s.
+	RecordCycleLoad(2*time.Second, 0)
+	reason, rate := Backpressure()
+	if reason == "" || rate < 9 || rate > 10 {
+		t.Errorf("Backpressure() = %q, %v after a saturated cycle, expected about 10/s", reason, rate)
+	}
+	now = time.Now()
+	if admitSubmission(now) {
+		t.Error("admitSubmission() = true without any token")
+	}
+	if !admitSubmission(now.Add(time.Second)) {
+		t.Error("admitSubmission() = false after a second")
+	}
+	RecordCycleLoad(2*time.Second, 0)
+	if _, halved := Backpressure(); halved != rate/2 {
+		t.Errorf("Backpressure() rate = %v after another saturated cycle, expected %v", halved, rate/2)
+	}
+
+	// The rate doubles until the backpressure is lifted.
+	RecordCycleLoad(0, 0)
+	if reason, doubled := Backpressure(); reason == "" || doubled != rate {
+		t.Errorf("Backpressure() = %q, %v after a cycle within the limits, expected %v", reason, doubled, rate)
+	}
+	RecordCycleLoad(0, 0)
+	if reason, lifted := Backpressure(); reason != "" || lifted != 0 {
+		t.Errorf("Backpressure() = %q, %v once recovered, expected none", reason, lifted)
+	}
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"net"
+	"time"
+
+	"github.com/golang/glog"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// BindFailure is the class of a failed bind, which determines how it is
+// remedied.
+type BindFailure string
+
+const (
+	// BindConflict means the pod is already bound, e.g. by another
+	// scheduler. The pod watcher reports the binding to Firmament.
+	BindConflict BindFailure = "conflict"
+	// BindPodNotFound means the pod was deleted. The pod watcher reports
+	// the deletion to Firmament.
+	BindPodNotFound BindFailure = "pod_not_found"
+	// BindNodeNotFound means the node was removed. The node is quarantined
+	// and the pod submitted again.
+	BindNodeNotFound BindFailure = "node_not_found"
+	// BindForbidden means the bind was refused, e.g. by an admission
+	// plugin. It counts towards the quarantine of the node and the pod is
+	// submitted again.
+	BindForbidden BindFailure = "forbidden"
+	// BindTimeout means the API server did not handle the bind in time. The
+	// pod is submitted again, without blaming the node.
+	BindTimeout BindFailure = "timeout"
+	// BindError is any other failure. It counts towards the quarantine of
+	// the node and the pod is submitted again.
+	BindError BindFailure = "error"
+)
+
+// removedNodeQuarantine is the time for which no pod is placed on a node
+// the API server no longer knows, until the node watcher removes it.
+const removedNodeQuarantine = time.Minute
+
+// bindError is the error of a bind classified from more than the API
+// response.
+type bindError struct {
+	failure BindFailure
+	err     error
+}
+
+func (e *bindError) Error() string {
+	return e.err.Error()
+}
+
+// classifyBindError returns the class of the error of a bind.
+func classifyBindError(err error) BindFailure {
+	if bindErr, ok := err.(*bindError); ok {
+		return bindErr.failure
+	}
+	switch {
+	case errors.IsConflict(err) || errors.IsAlreadyExists(err):
+		return BindConflict
+	case errors.IsNotFound(err):
+		if status, ok := err.(errors.APIStatus); ok && status.Status().Details != nil &&
+			status.Status().Details.Kind == "nodes" {
+			return BindNodeNotFound
+		}
+		return BindPodNotFound
+	case errors.IsForbidden(err):
+		return BindForbidden
+	case errors.IsTimeout(err) || errors.IsServerTimeout(err) || errors.IsTooManyRequests(err):
+		return BindTimeout
+	}
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		return BindTimeout
+	}
+	return BindError
+}
+
+// resyncNode checks whether the node of a bind which did not find the pod
+// still exists, and returns true if it was removed. The binding only reports
+// the pod as not found, whether the node exists or not. A removed node is
+// quarantined until the node watcher observes its removal, and the capacity
+// of an existing node is advertised again.
+func resyncNode(nodeName string) bool {
+	_, err := clientSet.CoreV1().Nodes().Get(nodeName, metav1.GetOptions{})
+	switch {
+	case errors.IsNotFound(err):
+		glog.Warningf("Node %s was removed, no pod is placed on it until its removal is observed", nodeName)
+		quarantineNode(nodeName, "removed", removedNodeQuarantine)
+		return true
+	case err != nil:
+		glog.Errorf("Failed to resync node %s: %v", nodeName, err)
+	default:
+		refreshNodeCapacity(nodeName)
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+)
+
+// timeoutError is a network error which timed out.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestClassifyBindError(t *testing.T) {
+	var testData = []struct {
+		err      error
+		expected BindFailure
+	}{
+		{err: errors.NewConflict(schema.GroupResource{Resource: "pods"}, "pod0", fmt.Errorf("already bound")), expected: BindConflict},
+		{err: errors.NewAlreadyExists(schema.GroupResource{Resource: "pods"}, "pod0"), expected: BindConflict},
+		{err: errors.NewNotFound(schema.GroupResource{Resource: "pods"}, "pod0"), expected: BindPodNotFound},
+		{err: errors.NewNotFound(schema.GroupResource{Resource: "nodes"}, "node0"), expected: BindNodeNotFound},
+		{err: errors.NewForbidden(schema.GroupResource{Resource: "pods"}, "pod0", fmt.Errorf("denied")), expected: BindForbidden},
+		{err: errors.NewServerTimeout(schema.GroupResource{Resource: "pods"}, "create", 1), expected: BindTimeout},
+		{err: errors.NewTooManyRequests("slow down", 1), expected: BindTimeout},
+		{err: &url.Error{Op: "Post", URL: "https://apiserver", Err: timeoutError{}}, expected: BindTimeout},
+		{err: errors.NewInternalError(fmt.Errorf("etcd failure")), expected: BindError},
+		{err: ErrNodeQuarantined, expected: BindError},
+		{err: &bindError{failure: BindNodeNotFound, err: errors.NewNotFound(schema.GroupResource{Resource: "pods"}, "pod0")},
+			expected: BindNodeNotFound},
+	}
+	for _, tc := range testData {
+		if got := classifyBindError(tc.err); got != tc.expected {
+			t.Errorf("classifyBindError(%v) = %v, expected %v", tc.err, got, tc.expected)
+		}
+	}
+}
+
+func TestResyncNode(t *testing.T) {
+	refreshed := make(chan string, 1)
+	refreshNodeCapacity = func(nodeName string) { refreshed <- nodeName }
+	defer func() {
+		refreshNodeCapacity = func(string) {}
+		clientSet = nil
+		releaseNode("removed")
+	}()
+	clientSet = fake.NewSimpleClientset(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "existing"}})
+	if resyncNode("existing") || IsNodeQuarantined("existing") {
+		t.Errorf("resyncNode() quarantined an existing node")
+	}
+	select {
+	case nodeName := <-refreshed:
+		if nodeName != "existing" {
+			t.Errorf("resyncNode() refreshed the capacity of %s, expected existing", nodeName)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("resyncNode() did not refresh the capacity of an existing node")
+	}
+	if !resyncNode("removed") || !IsNodeQuarantined("removed") {
+		t.Errorf("resyncNode() did not quarantine a removed node")
+	}
+}
+
+func TestBindPodToNodeNotFound(t *testing.T) {
+	refreshNodeCapacity = func(string) {}
+	defer func() {
+		clientSet = nil
+		releaseNode("removed")
+	}()
+	client := fake.NewSimpleClientset(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "existing"}})
+	// The binding reports the pod as not found, whether its node exists or not.
+	client.PrependReactor("create", "pods", func(action core.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "binding" {
+			return false, nil, nil
+		}
+		return true, nil, errors.NewNotFound(schema.GroupResource{Resource: "pods"}, "pod0")
+	})
+	clientSet = client
+	var testData = []struct {
+		node     string
+		expected BindFailure
+	}{
+		{node: "existing", expected: BindPodNotFound},
+		{node: "removed", expected: BindNodeNotFound},
+	}
+	for _, tc := range testData {
+		err := BindPodToNode("pod0", "default", tc.node)
+		if got := classifyBindError(err); got != tc.expected {
+			t.Errorf("BindPodToNode() to node %s failed with %v, classified %v, expected %v", tc.node, err, got, tc.expected)
+		}
+		if got := IsNodeQuarantined(tc.node); got != (tc.expected == BindNodeNotFound) {
+			t.Errorf("IsNodeQuarantined(%s) = %v after the bind", tc.node, got)
+		}
+	}
+}
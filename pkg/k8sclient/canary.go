@@ -0,0 +1,64 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"fmt"
+	"hash/fnv"
+
+	"k8s.io/api/core/v1"
+)
+
+// notClaimedReason is the reason of the event and of the PodScheduled
+// condition of the pending pods Poseidon does not claim.
+const notClaimedReason = "NotClaimed"
+
+// claimPercentage is the percentage of eligible pods Poseidon claims. It
+// allows canarying Firmament-based scheduling on a fraction of workloads.
+var claimPercentage = 100
+
+// isClaimed returns true if Poseidon schedules the given pod. The decision
+// hashes the pod's owner so that all the pods of a workload are consistently
+// claimed, or not, across updates and scheduler restarts.
+func isClaimed(pod *v1.Pod) bool {
+	if claimPercentage >= 100 {
+		return true
+	}
+	if claimPercentage <= 0 {
+		return false
+	}
+	ownerHash := fnv.New32a()
+	ownerHash.Write([]byte(GetOwnerReference(pod)))
+	return int(ownerHash.Sum32()%100) < claimPercentage
+}
+
+// reportNotClaimed marks a pending pod Poseidon does not claim with an
+// event and its PodScheduled condition. No other scheduler places the pods
+// naming Poseidon, so they stay pending until the claim percentage covers
+// their workload or they are moved to another scheduler. In shadow mode the
+// pods are placed by the other scheduler and are left alone. It calls the
+// API server and must not be called from the informer handlers.
+func (pw *PodWatcher) reportNotClaimed(pod *v1.Pod) {
+	if shadowMode || pod.Spec.NodeName != "" || pod.Status.Phase != v1.PodPending {
+		return
+	}
+	message := fmt.Sprintf("%s only claims %d%% of the workloads, pod not scheduled", pw.schedulerName, claimPercentage)
+	condition := unschedulableCondition(message)
+	condition.Reason = notClaimedReason
+	podIdentifier := PodIdentifier{Name: pod.Name, Namespace: pod.Namespace}
+	pw.reportUnschedulable(&Pod{Identifier: podIdentifier}, notClaimedReason, message, condition)
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestIsClaimed(t *testing.T) {
+	defer func(percentage int) { claimPercentage = percentage }(claimPercentage)
+
+	var testData = []struct {
+		percentage  int
+		minExpected int
+		maxExpected int
+	}{
+		{percentage: 0, minExpected: 0, maxExpected: 0},
+		{percentage: 100, minExpected: 1000, maxExpected: 1000},
+		{percentage: 30, minExpected: 200, maxExpected: 400},
+	}
+	for _, tc := range testData {
+		claimPercentage = tc.percentage
+		claimed := 0
+		for i := 0; i < 1000; i++ {
+			pod := BuildPod("default", fmt.Sprintf("pod%d", i), nil, "Pending", "1", "1Ki", nil, fmt.Sprintf("owner%d", i))
+			if isClaimed(pod) {
+				claimed++
+			}
+			if isClaimed(pod) != isClaimed(pod) {
+				t.Errorf("Claim decision for pod %s is not stable", pod.Name)
+			}
+		}
+		if claimed < tc.minExpected || claimed > tc.maxExpected {
+			t.Errorf("Claimed %d of 1000 pods with percentage %d, expected [%d, %d]",
+				claimed, tc.percentage, tc.minExpected, tc.maxExpected)
+		}
+	}
+}
+
+func TestReportNotClaimed(t *testing.T) {
+	defer func(percentage int) { claimPercentage = percentage }(claimPercentage)
+	claimPercentage = 0
+	pending := BuildPod("ns", "pod0", nil, "Pending", "1", "1Ki", nil, "owner0")
+	bound := BuildPod("ns", "pod1", nil, "Pending", "1", "1Ki", nil, "owner1")
+	bound.Spec.NodeName = "node0"
+	client := fake.NewSimpleClientset(pending, bound)
+	// The pods not claimed are not queued, the watcher has no queue.
+	pw := &PodWatcher{clientset: client, schedulerName: "poseidon"}
+	defer forgetUnschedulable(PodIdentifier{Name: "pod0", Namespace: "ns"})
+	for _, pod := range []*v1.Pod{pending, pending, bound} {
+		pw.enqueuePodAddition(pod.Namespace+"/"+pod.Name, pod)
+	}
+	// The pods are reported in the background, the duplicate report is
+	// given some time to show up.
+	wait.PollImmediate(10*time.Millisecond, time.Second, func() (bool, error) {
+		updated, err := client.CoreV1().Pods("ns").Get("pod0", metav1.GetOptions{})
+		return err == nil && len(updated.Status.Conditions) > 0, err
+	})
+	time.Sleep(50 * time.Millisecond)
+	events, err := client.CoreV1().Events("ns").List(metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events.Items) != 1 || events.Items[0].Reason != notClaimedReason || events.Items[0].InvolvedObject.Name != "pod0" {
+		t.Errorf("enqueuePodAddition() created the events %v, expected one %s event for the pending pod", events.Items, notClaimedReason)
+	}
+	updated, err := client.CoreV1().Pods("ns").Get("pod0", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conditions := updated.Status.Conditions
+	if len(conditions) != 1 || conditions[0].Type != v1.PodScheduled || conditions[0].Status != v1.ConditionFalse ||
+		conditions[0].Reason != notClaimedReason {
+		t.Errorf("enqueuePodAddition() set the conditions %v, expected PodScheduled false as not claimed", conditions)
+	}
+}
+
+func TestReportNotClaimedShadowMode(t *testing.T) {
+	defer func(percentage int) { claimPercentage = percentage }(claimPercentage)
+	defer func(shadow bool) { shadowMode = shadow }(shadowMode)
+	claimPercentage, shadowMode = 0, true
+	pending := BuildPod("ns", "pod0", nil, "Pending", "1", "1Ki", nil, "owner0")
+	client := fake.NewSimpleClientset(pending)
+	pw := &PodWatcher{clientset: client, schedulerName: "poseidon"}
+	// The pods are placed by the other scheduler.
+	pw.reportNotClaimed(pending)
+	events, err := client.CoreV1().Events("ns").List(metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events.Items) != 0 {
+		t.Errorf("reportNotClaimed() created the events %v in shadow mode, expected none", events.Items)
+	}
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+	"github.com/kubernetes-sigs/poseidon/pkg/metrics"
+)
+
+// capacityDomain is a zone and node pool, empty if the nodes have none or
+// the pending pods may be placed in any.
+type capacityDomain struct {
+	zone, pool string
+}
+
+// capacityTotals are the CPU, in millicores, and memory, in KB, of a domain.
+type capacityTotals struct {
+	cpu, memKb int64
+}
+
+func (t *capacityTotals) add(cpu, memKb int64) {
+	t.cpu += cpu
+	t.memKb += memKb
+}
+
+// nodePool returns the pool of the node with the given labels, empty if
+// node pools are disabled or the node is in none.
+func nodePool(labels []*firmament.Label) string {
+	if nodePoolPolicy == nil {
+		return ""
+	}
+	for _, label := range labels {
+		if label.GetKey() == nodePoolPolicy.PoolLabel {
+			return label.GetValue()
+		}
+	}
+	return ""
+}
+
+// pendingDomain returns the domain the label selectors of a pending task
+// restrict it to. The zone or the pool is empty if the task may be placed in
+// several.
+func pendingDomain(selectors []*firmament.LabelSelector) capacityDomain {
+	var domain capacityDomain
+	for _, selector := range selectors {
+		if selector.GetType() != firmament.LabelSelector_IN_SET || len(selector.GetValues()) != 1 {
+			continue
+		}
+		if nodePoolPolicy != nil && selector.GetKey() == nodePoolPolicy.PoolLabel {
+			domain.pool = selector.GetValues()[0]
+		}
+		for _, key := range zoneLabels {
+			if selector.GetKey() == key {
+				domain.zone = selector.GetValues()[0]
+			}
+		}
+	}
+	return domain
+}
+
+// RecordCapacityMetrics updates the allocatable, allocated and pending
+// requests totals by zone and node pool. The allocatable is the capacity of
+// the nodes known to Firmament, the allocated the requests of the pods bound
+// to them and the pending the requests of the tasks submitted to Firmament
+// which are not placed yet.
+func RecordCapacityMetrics() {
+	allocatable := make(map[capacityDomain]*capacityTotals)
+	allocated := make(map[capacityDomain]*capacityTotals)
+	pending := make(map[capacityDomain]*capacityTotals)
+	totalsOf := func(totals map[capacityDomain]*capacityTotals, domain capacityDomain) *capacityTotals {
+		if totals[domain] == nil {
+			totals[domain] = &capacityTotals{}
+		}
+		return totals[domain]
+	}
+
+	nodeDomains := make(map[string]capacityDomain)
+	state.nodeMux.RLock()
+	for nodeName, rtnd := range state.nodeToRTND {
+		labels := rtnd.GetResourceDesc().GetLabels()
+		domain := capacityDomain{zone: labelsZone(labels), pool: nodePool(labels)}
+		nodeDomains[nodeName] = domain
+		capacity := rtnd.GetResourceDesc().GetResourceCapacity()
+		totalsOf(allocatable, domain).add(int64(capacity.GetCpuCores()), int64(capacity.GetRamCap()))
+		totalsOf(allocated, domain)
+	}
+	state.nodeMux.RUnlock()
+	for nodeName, domain := range nodeDomains {
+		for _, pod := range podsByNode.podsOnNode(nodeName) {
+			if pod.DaemonSet && discountDaemonSetOverhead {
+				// Already discounted from the capacity.
+				continue
+			}
+			totalsOf(allocated, domain).add(pod.CPURequest, pod.MemRequestKb)
+		}
+	}
+
+	pendingMux.Lock()
+	taskIDs := make([]uint64, 0, len(pendingTasks))
+	for taskID := range pendingTasks {
+		taskIDs = append(taskIDs, taskID)
+	}
+	pendingMux.Unlock()
+	for _, taskID := range taskIDs {
+		_, td, ok := state.taskOf(taskID)
+		if !ok {
+			continue
+		}
+		request := td.GetResourceRequest()
+		totalsOf(pending, pendingDomain(td.GetLabelSelectors())).add(int64(request.GetCpuCores()), int64(request.GetRamCap()))
+	}
+
+	for gauge, totals := range map[*metrics.Gauge]map[capacityDomain]*capacityTotals{
+		metrics.ZoneAllocatable:     allocatable,
+		metrics.ZoneAllocated:       allocated,
+		metrics.ZonePendingRequests: pending,
+	} {
+		gauge.Reset()
+		for domain, t := range totals {
+			gauge.Set(float64(t.cpu), domain.zone, domain.pool, "cpu")
+			gauge.Set(float64(t.memKb), domain.zone, domain.pool, "memory")
+		}
+	}
+}
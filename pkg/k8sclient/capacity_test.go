@@ -0,0 +1,82 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"testing"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+	"github.com/kubernetes-sigs/poseidon/pkg/metrics"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRecordCapacityMetrics(t *testing.T) {
+	defer func() { nodePoolPolicy = nil }()
+	nodePoolPolicy = &NodePoolPolicy{PoolLabel: "pool"}
+	node := func(zone string) *firmament.ResourceTopologyNodeDescriptor {
+		return &firmament.ResourceTopologyNodeDescriptor{ResourceDesc: &firmament.ResourceDescriptor{
+			Labels:           []*firmament.Label{{Key: zoneLabels[0], Value: zone}, {Key: "pool", Value: "batch"}},
+			ResourceCapacity: &firmament.ResourceVector{CpuCores: 4000, RamCap: 8000000},
+		}}
+	}
+	state = &memoryState{}
+	state.nodeToRTND = map[string]*firmament.ResourceTopologyNodeDescriptor{"node0": node("a"), "node1": node("a"), "node2": node("b")}
+	state.podToTD = map[PodIdentifier]*firmament.TaskDescriptor{
+		{Name: "any", Namespace: "ns"}: {Uid: 1, ResourceRequest: &firmament.ResourceVector{CpuCores: 500, RamCap: 1000}},
+		{Name: "zoned", Namespace: "ns"}: {Uid: 2, ResourceRequest: &firmament.ResourceVector{CpuCores: 250, RamCap: 2000},
+			LabelSelectors: []*firmament.LabelSelector{{Type: firmament.LabelSelector_IN_SET, Key: zoneLabels[0], Values: []string{"b"}}}},
+	}
+	state.taskIDToPod = map[uint64]PodIdentifier{1: {Name: "any", Namespace: "ns"}, 2: {Name: "zoned", Namespace: "ns"}}
+	markTaskPending(1)
+	markTaskPending(2)
+	defer MarkTaskPlaced(1)
+	defer MarkTaskPlaced(2)
+	podsByNode = newPodIndex()
+	defer func() { podsByNode = newPodIndex() }()
+	podsByNode.update(&v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "ns"},
+		Spec:       v1.PodSpec{NodeName: "node1"},
+		Status:     v1.PodStatus{Phase: v1.PodRunning},
+	}, 1000, 100000)
+
+	RecordCapacityMetrics()
+	var testData = []struct {
+		gauge              *metrics.Gauge
+		zone, pool, metric string
+		expected           float64
+	}{
+		{gauge: metrics.ZoneAllocatable, zone: "a", pool: "batch", metric: "cpu", expected: 8000},
+		{gauge: metrics.ZoneAllocatable, zone: "b", pool: "batch", metric: "memory", expected: 8000000},
+		{gauge: metrics.ZoneAllocated, zone: "a", pool: "batch", metric: "cpu", expected: 1000},
+		{gauge: metrics.ZoneAllocated, zone: "b", pool: "batch", metric: "memory", expected: 0},
+		{gauge: metrics.ZonePendingRequests, metric: "cpu", expected: 500},
+		{gauge: metrics.ZonePendingRequests, zone: "b", metric: "memory", expected: 2000},
+	}
+	for _, tc := range testData {
+		if value := tc.gauge.Get(tc.zone, tc.pool, tc.metric); value != tc.expected {
+			t.Errorf("%s of zone %q and pool %q = %v, expected %v", tc.metric, tc.zone, tc.pool, value, tc.expected)
+		}
+	}
+
+	// The removed zones are dropped.
+	delete(state.nodeToRTND, "node2")
+	RecordCapacityMetrics()
+	if value := metrics.ZoneAllocatable.Get("b", "batch", "cpu"); value != 0 {
+		t.Errorf("cpu of removed zone b = %v, expected 0", value)
+	}
+}
@@ -0,0 +1,189 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/metrics"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// defaultChurnWindow is the window over which the churn of the owners is
+// accounted if no policy is set.
+const defaultChurnWindow = time.Hour
+
+// ChurnPolicy bounds how often the pods of an owner, e.g. a Deployment or a
+// Job, are disrupted by the scheduler.
+type ChurnPolicy struct {
+	// Window is the sliding window over which the preemptions and the
+	// migrations of the pods of an owner are counted.
+	Window time.Duration
+	// Budget is the largest number of preemptions and migrations of the
+	// pods of an owner within the window, past which the migrations of its
+	// pods are suppressed. 0 does not bound the churn.
+	Budget int
+}
+
+// churnOwner is the namespace and the owner of the pods of a task.
+type churnOwner struct {
+	namespace, name string
+}
+
+var (
+	// churnPolicy is the churn policy, nil if the churn is only accounted.
+	churnPolicy *ChurnPolicy
+	churnMux    sync.Mutex
+	// churnTasks are the owners of the submitted tasks whose pods have one.
+	churnTasks = make(map[uint64]churnOwner)
+	// churnEvents are the times the pods of the owners were preempted or
+	// migrated within the window, oldest first.
+	churnEvents = make(map[churnOwner][]time.Time)
+	churnNow    = time.Now
+)
+
+// ownerOf returns the owner the churn of the pod is accounted to, as
+// <kind>/<name>, the Deployment for the pods of its ReplicaSets, empty if
+// the pod has no controller.
+func ownerOf(pod *v1.Pod) string {
+	ref := metav1.GetControllerOf(pod)
+	if ref == nil {
+		return ""
+	}
+	if ref.Kind == "ReplicaSet" {
+		if hash := pod.Labels[podTemplateHashLabel]; hash != "" && strings.HasSuffix(ref.Name, "-"+hash) {
+			return "Deployment/" + strings.TrimSuffix(ref.Name, "-"+hash)
+		}
+	}
+	return ref.Kind + "/" + ref.Name
+}
+
+func churnWindow() time.Duration {
+	if churnPolicy == nil || churnPolicy.Window <= 0 {
+		return defaultChurnWindow
+	}
+	return churnPolicy.Window
+}
+
+// registerChurnTask records the owner of the task of the pod.
+func registerChurnTask(pod *Pod, taskID uint64) {
+	if pod.Owner == "" {
+		return
+	}
+	churnMux.Lock()
+	defer churnMux.Unlock()
+	churnTasks[taskID] = churnOwner{namespace: pod.Identifier.Namespace, name: pod.Owner}
+}
+
+// forgetChurnTask drops the owner of a removed task. The churn of the owner
+// is kept until it leaves the window.
+func forgetChurnTask(taskID uint64) {
+	churnMux.Lock()
+	defer churnMux.Unlock()
+	delete(churnTasks, taskID)
+}
+
+// recentChurn returns the number of disruptions of the owner within the
+// window, dropping the older ones. churnMux must be held.
+func recentChurn(owner churnOwner, now time.Time) int {
+	events := churnEvents[owner]
+	cutoff := now.Add(-churnWindow())
+	i := 0
+	for i < len(events) && !events[i].After(cutoff) {
+		i++
+	}
+	if i == len(events) {
+		delete(churnEvents, owner)
+		return 0
+	}
+	churnEvents[owner] = events[i:]
+	return len(events) - i
+}
+
+// RecordChurn accounts the preemption or the migration of the pod of the
+// task to its owner.
+func RecordChurn(taskID uint64, migrated bool) {
+	churnMux.Lock()
+	defer churnMux.Unlock()
+	owner, ok := churnTasks[taskID]
+	if !ok {
+		return
+	}
+	now := churnNow()
+	churnEvents[owner] = append(churnEvents[owner], now)
+	churnType := "preempted"
+	if migrated {
+		churnType = "migrated"
+	}
+	metrics.PodChurn.Inc(owner.namespace, owner.name, churnType)
+	metrics.OwnerChurn.Set(float64(recentChurn(owner, now)), owner.namespace, owner.name)
+}
+
+// suppressChurn returns true if the owner of the task exhausted its churn
+// budget, in which case the pod of the task must not be migrated.
+func suppressChurn(taskID uint64) bool {
+	if churnPolicy == nil || churnPolicy.Budget <= 0 {
+		return false
+	}
+	churnMux.Lock()
+	defer churnMux.Unlock()
+	owner, ok := churnTasks[taskID]
+	if !ok || recentChurn(owner, churnNow()) < churnPolicy.Budget {
+		return false
+	}
+	metrics.SuppressedMigrations.Inc(owner.namespace, owner.name)
+	return true
+}
+
+// SuppressMigration returns true if the migration of the task by Firmament
+// must be suppressed as its owner exhausted its churn budget, in which case
+// the pod keeps running and the next placement of the task is ignored, as
+// for a refused preemption.
+func SuppressMigration(taskID uint64) bool {
+	if !suppressChurn(taskID) {
+		return false
+	}
+	preemptionMux.Lock()
+	defer preemptionMux.Unlock()
+	refusedPreemptions[taskID] = true
+	return true
+}
+
+// SuppressZoneMigration returns true if the migration of the task to
+// balance its ReplicaSet across zones must be suppressed as its owner
+// exhausted its churn budget. Unlike SuppressMigration, no placement of the
+// task is ignored, as Firmament did not evict it.
+func SuppressZoneMigration(taskID uint64) bool {
+	return suppressChurn(taskID)
+}
+
+// RecordChurnMetrics refreshes the churn of the owners within the window,
+// which decays as their disruptions leave it.
+func RecordChurnMetrics() {
+	churnMux.Lock()
+	defer churnMux.Unlock()
+	now := churnNow()
+	metrics.OwnerChurn.Reset()
+	for owner := range churnEvents {
+		if churn := recentChurn(owner, now); churn > 0 {
+			metrics.OwnerChurn.Set(float64(churn), owner.namespace, owner.name)
+		}
+	}
+}
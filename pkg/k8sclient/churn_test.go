@@ -0,0 +1,103 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestOwnerOf(t *testing.T) {
+	controlled := func(kind, name string, labels map[string]string) *v1.Pod {
+		controller := true
+		return &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Labels:          labels,
+			OwnerReferences: []metav1.OwnerReference{{Kind: kind, Name: name, Controller: &controller}},
+		}}
+	}
+	var testData = []struct {
+		pod      *v1.Pod
+		expected string
+	}{
+		{pod: controlled("ReplicaSet", "web-5d4f8b", map[string]string{podTemplateHashLabel: "5d4f8b"}), expected: "Deployment/web"},
+		{pod: controlled("ReplicaSet", "web", nil), expected: "ReplicaSet/web"},
+		{pod: controlled("Job", "backup", nil), expected: "Job/backup"},
+		{pod: &v1.Pod{}, expected: ""},
+	}
+	for _, tc := range testData {
+		if owner := ownerOf(tc.pod); owner != tc.expected {
+			t.Errorf("ownerOf(%v) = %q, expected %q", tc.pod.OwnerReferences, owner, tc.expected)
+		}
+	}
+}
+
+func TestChurnBudget(t *testing.T) {
+	now := time.Unix(1000, 0)
+	defer func(policy *ChurnPolicy, clock func() time.Time) { churnPolicy, churnNow = policy, clock }(churnPolicy, churnNow)
+	churnPolicy = &ChurnPolicy{Window: time.Minute, Budget: 2}
+	churnNow = func() time.Time { return now }
+	defer func() { churnEvents = make(map[churnOwner][]time.Time) }()
+	for taskID := uint64(1); taskID <= 3; taskID++ {
+		registerChurnTask(&Pod{Identifier: PodIdentifier{Name: "web", Namespace: "ns"}, Owner: "Deployment/web"}, taskID)
+		defer forgetChurnTask(taskID)
+		defer forgetTaskPreemption(taskID)
+	}
+	registerChurnTask(&Pod{Identifier: PodIdentifier{Name: "backup", Namespace: "ns"}, Owner: "Job/backup"}, 4)
+	defer forgetChurnTask(4)
+
+	RecordChurn(1, false)
+	if SuppressMigration(2) {
+		t.Error("SuppressMigration() = true within the churn budget")
+	}
+	RecordChurn(2, true)
+	// The owner exhausted its budget, the other owners are not affected.
+	if !SuppressMigration(3) {
+		t.Error("SuppressMigration() = false past the churn budget")
+	}
+	if !IsPreemptionRefused(3) {
+		t.Error("The next placement of the task whose migration was suppressed is applied")
+	}
+	if !SuppressZoneMigration(3) {
+		t.Error("SuppressZoneMigration() = false past the churn budget")
+	}
+	if IsPreemptionRefused(3) {
+		t.Error("The next placement of the task whose zone migration was suppressed is ignored")
+	}
+	if SuppressMigration(4) {
+		t.Error("SuppressMigration() = true for an owner within its churn budget")
+	}
+	// The tasks without an owner are never suppressed.
+	RecordChurn(5, true)
+	if SuppressMigration(5) {
+		t.Error("SuppressMigration() = true for a task without owner")
+	}
+
+	// The churn decays as the disruptions leave the window.
+	now = now.Add(time.Minute)
+	if SuppressMigration(3) {
+		t.Error("SuppressMigration() = true once the disruptions left the window")
+	}
+	churnPolicy = &ChurnPolicy{Window: time.Minute}
+	RecordChurn(1, true)
+	RecordChurn(2, true)
+	if SuppressMigration(3) {
+		t.Error("SuppressMigration() = true without a churn budget")
+	}
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/golang/glog"
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+)
+
+// ConstraintCompiler converts scheduling fields of a pod into Firmament
+// constraints of its task, e.g. label selectors or resource requests. A new
+// pod spec feature is supported by registering a compiler for it.
+type ConstraintCompiler interface {
+	// Name identifies the compiler.
+	Name() string
+	// Compile sets the constraints of the pod on its task. The task labels
+	// and resource requests are already set.
+	Compile(pod *Pod, td *firmament.TaskDescriptor) error
+}
+
+// constraintCompilers are run in order on the new tasks.
+var constraintCompilers = []ConstraintCompiler{
+	nodeSelectorCompiler{},
+	hostPathCompiler{},
+	networkRequirementCompiler{},
+	taskTypeCompiler{},
+	nodePoolCompiler{},
+	deviceHealthCompiler{},
+	labelSelectorsCompiler{},
+	namespaceConstraintsCompiler{},
+}
+
+// RegisterConstraintCompiler adds a compiler run after the ones already
+// registered. It must only be called during initialization, e.g. from an
+// init function, and panics if a compiler with the same name is registered.
+func RegisterConstraintCompiler(compiler ConstraintCompiler) {
+	for _, registered := range constraintCompilers {
+		if registered.Name() == compiler.Name() {
+			panic(fmt.Sprintf("constraint compiler %s registered twice", compiler.Name()))
+		}
+	}
+	constraintCompilers = append(constraintCompilers, compiler)
+}
+
+// compileConstraints sets the constraints of the pod on its task. The
+// constraints of a failing compiler are skipped.
+func compileConstraints(pod *Pod, td *firmament.TaskDescriptor) {
+	for _, compiler := range constraintCompilers {
+		if err := compiler.Compile(pod, td); err != nil {
+			glog.Errorf("Failed to compile the %s constraints of pod %v: %v", compiler.Name(), pod.Identifier, err)
+		}
+	}
+}
+
+func getFirmamentLabelSelectorFromNodeSelectorMap(nodeSelector NodeSelectors, nodeSelectorKeys []string) []*firmament.LabelSelector {
+	var firmamentLabelSelector []*firmament.LabelSelector
+	for _, key := range nodeSelectorKeys {
+		firmamentLabelSelector = append(firmamentLabelSelector, &firmament.LabelSelector{
+			Type:   firmament.LabelSelector_IN_SET,
+			Key:    key,
+			Values: []string{nodeSelector[key]},
+		})
+	}
+	return firmamentLabelSelector
+}
+
+// nodeSelectorCompiler restricts the task to the nodes matching the node
+// selector of the pod.
+type nodeSelectorCompiler struct{}
+
+func (nodeSelectorCompiler) Name() string {
+	return "nodeSelector"
+}
+
+func (nodeSelectorCompiler) Compile(pod *Pod, td *firmament.TaskDescriptor) error {
+	nodeSelectors := pod.nodeSelectors
+	if nodeSelectors == nil {
+		nodeSelectors = getFirmamentLabelSelectorFromNodeSelectorMap(pod.NodeSelector, SortNodeSelectorsKey(pod.NodeSelector))
+	}
+	// The node selectors may be shared by the replicas of a pod template.
+	td.LabelSelectors = append(append([]*firmament.LabelSelector(nil), td.LabelSelectors...), nodeSelectors...)
+	return nil
+}
+
+// hostPathCompiler restricts the task to the nodes holding the host paths
+// of the pod.
+type hostPathCompiler struct{}
+
+func (hostPathCompiler) Name() string {
+	return "hostPath"
+}
+
+func (hostPathCompiler) Compile(pod *Pod, td *firmament.TaskDescriptor) error {
+	td.LabelSelectors = append(td.LabelSelectors, hostPathLabelSelectors(pod.HostPaths)...)
+	return nil
+}
+
+// networkRequirementCompiler sets the network bandwidth request of the task
+// from the networkRequirement label of the pod.
+type networkRequirementCompiler struct{}
+
+func (networkRequirementCompiler) Name() string {
+	return "networkRequirement"
+}
+
+func (networkRequirementCompiler) Compile(pod *Pod, td *firmament.TaskDescriptor) error {
+	val, ok := pod.Labels["networkRequirement"]
+	if !ok {
+		return nil
+	}
+	res, err := strconv.ParseUint(val, 10, 64)
+	if err != nil {
+		return fmt.Errorf("failed to parse networkRequirement %v", err)
+	}
+	td.ResourceRequest.NetRxBw = res
+	return nil
+}
+
+// taskTypeCompiler sets the Firmament task type from the taskType label of
+// the pod.
+type taskTypeCompiler struct{}
+
+func (taskTypeCompiler) Name() string {
+	return "taskType"
+}
+
+func (taskTypeCompiler) Compile(pod *Pod, td *firmament.TaskDescriptor) error {
+	taskType, ok := pod.Labels["taskType"]
+	if !ok {
+		return nil
+	}
+	switch taskType {
+	case "Sheep":
+		td.TaskType = firmament.TaskDescriptor_SHEEP
+	case "Rabbit":
+		td.TaskType = firmament.TaskDescriptor_RABBIT
+	case "Devil":
+		td.TaskType = firmament.TaskDescriptor_DEVIL
+	case "Turtle":
+		td.TaskType = firmament.TaskDescriptor_TURTLE
+	default:
+		return fmt.Errorf("unexpected task type %s", taskType)
+	}
+	return nil
+}
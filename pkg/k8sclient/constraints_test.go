@@ -0,0 +1,122 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+)
+
+type gpuCompiler struct{}
+
+func (gpuCompiler) Name() string {
+	return "gpu"
+}
+
+func (gpuCompiler) Compile(pod *Pod, td *firmament.TaskDescriptor) error {
+	if _, ok := pod.Labels["gpu"]; ok {
+		td.LabelSelectors = append(td.LabelSelectors, &firmament.LabelSelector{
+			Type: firmament.LabelSelector_EXISTS_KEY,
+			Key:  "accelerator",
+		})
+	}
+	return nil
+}
+
+func TestCompileConstraints(t *testing.T) {
+	defaults := constraintCompilers
+	defer func() { constraintCompilers = defaults }()
+	RegisterConstraintCompiler(gpuCompiler{})
+
+	pod := &Pod{
+		Identifier:   PodIdentifier{Namespace: "default", Name: "pod"},
+		NodeSelector: NodeSelectors{"zone": "a", "disk": "ssd"},
+		Labels: map[string]string{
+			"gpu":                "",
+			"networkRequirement": "100",
+			"taskType":           "Rabbit",
+		},
+	}
+	td := &firmament.TaskDescriptor{ResourceRequest: &firmament.ResourceVector{}}
+	compileConstraints(pod, td)
+	expected := []*firmament.LabelSelector{
+		{Type: firmament.LabelSelector_IN_SET, Key: "disk", Values: []string{"ssd"}},
+		{Type: firmament.LabelSelector_IN_SET, Key: "zone", Values: []string{"a"}},
+		{Type: firmament.LabelSelector_EXISTS_KEY, Key: "accelerator"},
+	}
+	if !reflect.DeepEqual(td.LabelSelectors, expected) {
+		t.Errorf("compileConstraints() label selectors = %v, expected %v", td.LabelSelectors, expected)
+	}
+	if td.ResourceRequest.NetRxBw != 100 {
+		t.Errorf("compileConstraints() network requirement = %d, expected 100", td.ResourceRequest.NetRxBw)
+	}
+	if td.TaskType != firmament.TaskDescriptor_RABBIT {
+		t.Errorf("compileConstraints() task type = %v, expected %v", td.TaskType, firmament.TaskDescriptor_RABBIT)
+	}
+}
+
+func TestCompileConstraintsSharedSelectors(t *testing.T) {
+	shared := []*firmament.LabelSelector{
+		{Type: firmament.LabelSelector_IN_SET, Key: "zone", Values: []string{"a"}},
+	}
+	pod := &Pod{
+		nodeSelectors: shared[:1:1],
+		HostPaths:     []string{"/data"},
+	}
+	td := &firmament.TaskDescriptor{ResourceRequest: &firmament.ResourceVector{}}
+	compileConstraints(pod, td)
+	if len(td.LabelSelectors) != 2 {
+		t.Fatalf("compileConstraints() label selectors = %v, expected the node selector and the host path", td.LabelSelectors)
+	}
+	if len(shared) != 1 || shared[0].Key != "zone" {
+		t.Errorf("compileConstraints() modified the shared node selectors: %v", shared)
+	}
+}
+
+func TestConstraintCompilerErrors(t *testing.T) {
+	var testData = []struct {
+		compiler ConstraintCompiler
+		labels   map[string]string
+		fails    bool
+	}{
+		{compiler: networkRequirementCompiler{}, labels: map[string]string{"networkRequirement": "10"}},
+		{compiler: networkRequirementCompiler{}, labels: map[string]string{"networkRequirement": "ten"}, fails: true},
+		{compiler: taskTypeCompiler{}, labels: map[string]string{"taskType": "Turtle"}},
+		{compiler: taskTypeCompiler{}, labels: map[string]string{"taskType": "Llama"}, fails: true},
+		{compiler: taskTypeCompiler{}},
+	}
+	for _, tc := range testData {
+		td := &firmament.TaskDescriptor{ResourceRequest: &firmament.ResourceVector{}}
+		err := tc.compiler.Compile(&Pod{Labels: tc.labels}, td)
+		if (err != nil) != tc.fails {
+			t.Errorf("%s compiler with labels %v returned %v, expected failure %v", tc.compiler.Name(), tc.labels, err, tc.fails)
+		}
+	}
+}
+
+func TestRegisterConstraintCompilerTwice(t *testing.T) {
+	defaults := constraintCompilers
+	defer func() { constraintCompilers = defaults }()
+	defer func() {
+		if recover() == nil {
+			t.Errorf("RegisterConstraintCompiler() of a registered name did not panic")
+		}
+	}()
+	RegisterConstraintCompiler(taskTypeCompiler{})
+}
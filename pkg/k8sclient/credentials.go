@@ -0,0 +1,192 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// inClusterTokenFile is the service account token projected into the pod.
+const inClusterTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// credentialReloadInterval is the interval at which rotated token and
+// certificate files are reloaded.
+var credentialReloadInterval = time.Minute
+
+// enableCredentialRotation makes the clients built from the config reload
+// the bearer token and client certificate files when they are rotated, so
+// that long running clients keep authenticating without a restart. Exec
+// credential plugins already refresh their credentials on expiry.
+func enableCredentialRotation(config *rest.Config, kubeConfig string) {
+	// The certificate is set on the transport, so it is wrapped before the
+	// round trippers.
+	certFile, keyFile := config.TLSClientConfig.CertFile, config.TLSClientConfig.KeyFile
+	if certFile != "" && keyFile != "" && len(config.TLSClientConfig.CertData) == 0 {
+		cert := &fileCertificate{certFile: certFile, keyFile: keyFile}
+		wrapTransport(config, func(rt http.RoundTripper) http.RoundTripper {
+			if transport, ok := rt.(*http.Transport); ok && transport.TLSClientConfig != nil {
+				transport.TLSClientConfig.Certificates = nil
+				transport.TLSClientConfig.GetClientCertificate = cert.get
+			}
+			return rt
+		})
+		glog.Infof("Reloading the client certificate from %s every %v", certFile, credentialReloadInterval)
+	}
+	tokenFile := tokenFileFor(kubeConfig)
+	if tokenFile != "" && config.BearerToken != "" {
+		token := &fileToken{path: tokenFile, token: config.BearerToken, loaded: time.Now()}
+		// The token is set on each request by the round tripper instead.
+		config.BearerToken = ""
+		wrapTransport(config, func(rt http.RoundTripper) http.RoundTripper {
+			return &tokenRoundTripper{token: token, rt: rt}
+		})
+		glog.Infof("Reloading the bearer token from %s every %v", tokenFile, credentialReloadInterval)
+	}
+}
+
+func wrapTransport(config *rest.Config, wrap func(http.RoundTripper) http.RoundTripper) {
+	previous := config.WrapTransport
+	config.WrapTransport = func(rt http.RoundTripper) http.RoundTripper {
+		if previous != nil {
+			rt = previous(rt)
+		}
+		return wrap(rt)
+	}
+}
+
+// tokenFileFor returns the file holding the bearer token of the given
+// kubeconfig, or of the service account when running in cluster.
+func tokenFileFor(kubeConfig string) string {
+	if kubeConfig == "" {
+		return inClusterTokenFile
+	}
+	apiConfig, err := clientcmd.LoadFromFile(kubeConfig)
+	if err != nil {
+		return ""
+	}
+	context, ok := apiConfig.Contexts[apiConfig.CurrentContext]
+	if !ok {
+		return ""
+	}
+	authInfo, ok := apiConfig.AuthInfos[context.AuthInfo]
+	if !ok || authInfo.Token != "" {
+		// An inline token takes precedence over the token file.
+		return ""
+	}
+	return authInfo.TokenFile
+}
+
+// fileToken is a bearer token periodically reloaded from a file.
+type fileToken struct {
+	mu     sync.Mutex
+	path   string
+	token  string
+	loaded time.Time
+}
+
+func (t *fileToken) get() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if time.Since(t.loaded) < credentialReloadInterval {
+		return t.token
+	}
+	t.loaded = time.Now()
+	data, err := ioutil.ReadFile(t.path)
+	if err != nil {
+		// Keep using the previous token, it may still be valid.
+		glog.Errorf("Failed to reload the bearer token from %s: %v", t.path, err)
+		return t.token
+	}
+	t.token = strings.TrimSpace(string(data))
+	return t.token
+}
+
+// tokenRoundTripper sets the bearer token of the requests.
+type tokenRoundTripper struct {
+	token *fileToken
+	rt    http.RoundTripper
+}
+
+func (rt *tokenRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Authorization") != "" {
+		return rt.rt.RoundTrip(req)
+	}
+	// Round trippers must not modify the request.
+	authReq := new(http.Request)
+	*authReq = *req
+	authReq.Header = make(http.Header, len(req.Header)+1)
+	for key, values := range req.Header {
+		authReq.Header[key] = values
+	}
+	authReq.Header.Set("Authorization", "Bearer "+rt.token.get())
+	return rt.rt.RoundTrip(authReq)
+}
+
+// fileCertificate is a client certificate reloaded when its files change.
+type fileCertificate struct {
+	mu       sync.Mutex
+	certFile string
+	keyFile  string
+	cert     *tls.Certificate
+	modTime  time.Time
+	checked  time.Time
+}
+
+func (c *fileCertificate) get(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cert != nil && time.Since(c.checked) < credentialReloadInterval {
+		return c.cert, nil
+	}
+	c.checked = time.Now()
+	info, err := os.Stat(c.certFile)
+	if err != nil {
+		if c.cert != nil {
+			glog.Errorf("Failed to check the client certificate %s: %v", c.certFile, err)
+			return c.cert, nil
+		}
+		return nil, err
+	}
+	if c.cert != nil && info.ModTime().Equal(c.modTime) {
+		return c.cert, nil
+	}
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		if c.cert != nil {
+			// The files may be in the middle of being rotated.
+			glog.Errorf("Failed to reload the client certificate %s: %v", c.certFile, err)
+			return c.cert, nil
+		}
+		return nil, err
+	}
+	if c.cert != nil {
+		glog.Infof("Reloaded the rotated client certificate %s", c.certFile)
+	}
+	c.cert = &cert
+	c.modTime = info.ModTime()
+	return c.cert, nil
+}
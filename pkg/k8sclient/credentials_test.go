@@ -0,0 +1,168 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/rest"
+)
+
+func TestTokenRoundTripper(t *testing.T) {
+	defer func(interval time.Duration) { credentialReloadInterval = interval }(credentialReloadInterval)
+	credentialReloadInterval = 0
+	dir, err := ioutil.TempDir("", "credentials")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	tokenFile := filepath.Join(dir, "token")
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Get("Authorization"))
+	}))
+	defer server.Close()
+	rt := &tokenRoundTripper{
+		token: &fileToken{path: tokenFile, token: "initial", loaded: time.Now()},
+		rt:    http.DefaultTransport,
+	}
+	client := &http.Client{Transport: rt}
+	for _, token := range []string{"first", "second\n"} {
+		if err := ioutil.WriteFile(tokenFile, []byte(token), 0600); err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	expected := []string{"Bearer first", "Bearer second"}
+	if len(received) != len(expected) || received[0] != expected[0] || received[1] != expected[1] {
+		t.Errorf("Authorization headers %v, expected %v", received, expected)
+	}
+}
+
+func writeCertificate(t *testing.T, certFile, keyFile string, serial int64) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "poseidon"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFileCertificate(t *testing.T) {
+	defer func(interval time.Duration) { credentialReloadInterval = interval }(credentialReloadInterval)
+	credentialReloadInterval = 0
+	dir, err := ioutil.TempDir("", "credentials")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	certFile, keyFile := filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	writeCertificate(t, certFile, keyFile, 1)
+	cert := &fileCertificate{certFile: certFile, keyFile: keyFile}
+	first, err := cert.get(nil)
+	if err != nil {
+		t.Fatalf("get() failed: %v", err)
+	}
+	writeCertificate(t, certFile, keyFile, 2)
+	// Make sure the modification time changes on coarse grained filesystems.
+	later := time.Now().Add(time.Second)
+	if err := os.Chtimes(certFile, later, later); err != nil {
+		t.Fatal(err)
+	}
+	second, err := cert.get(nil)
+	if err != nil {
+		t.Fatalf("get() failed: %v", err)
+	}
+	firstLeaf, _ := x509.ParseCertificate(first.Certificate[0])
+	secondLeaf, _ := x509.ParseCertificate(second.Certificate[0])
+	if firstLeaf.SerialNumber.Int64() != 1 || secondLeaf.SerialNumber.Int64() != 2 {
+		t.Errorf("get() returned serials %v and %v, expected 1 and 2", firstLeaf.SerialNumber, secondLeaf.SerialNumber)
+	}
+	// A partially rotated pair keeps the previous certificate.
+	if err := ioutil.WriteFile(keyFile, []byte("invalid"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	later = later.Add(time.Second)
+	if err := os.Chtimes(certFile, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if third, err := cert.get(nil); err != nil || third != second {
+		t.Errorf("get() = %v, %v with an invalid key, expected the previous certificate", third, err)
+	}
+}
+
+func TestEnableCredentialRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "credentials")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	certFile, keyFile := filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	writeCertificate(t, certFile, keyFile, 1)
+	config := &rest.Config{
+		BearerToken:     "token",
+		TLSClientConfig: rest.TLSClientConfig{CertFile: certFile, KeyFile: keyFile},
+	}
+	enableCredentialRotation(config, "")
+	if config.BearerToken != "" {
+		t.Error("enableCredentialRotation() kept the static bearer token")
+	}
+	transport := &http.Transport{TLSClientConfig: &tls.Config{Certificates: []tls.Certificate{{}}}}
+	rt, ok := config.WrapTransport(transport).(*tokenRoundTripper)
+	if !ok || rt.rt != transport {
+		t.Fatalf("WrapTransport() = %#v, expected the token round tripper over the transport", config.WrapTransport(transport))
+	}
+	if transport.TLSClientConfig.GetClientCertificate == nil || transport.TLSClientConfig.Certificates != nil {
+		t.Error("WrapTransport() did not reload the client certificate of the transport")
+	}
+}
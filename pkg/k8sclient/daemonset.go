@@ -0,0 +1,169 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"sync"
+
+	"github.com/golang/glog"
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// nodeCapacityChanged is an internal phase used to advertise the capacity of
+// a node again, e.g. when the DaemonSets running on it changed.
+const nodeCapacityChanged NodePhase = "CapacityChanged"
+
+// discountDaemonSetOverhead enables the discount of the DaemonSet requests
+// from the node capacity advertised to Firmament.
+var discountDaemonSetOverhead bool
+
+var (
+	daemonSetMux sync.RWMutex
+	// daemonSets maps the namespace/name of the DaemonSets to their pod
+	// template.
+	daemonSets = make(map[string]*v1.PodTemplateSpec)
+)
+
+// newDaemonSetController returns a controller tracking the DaemonSets, which
+// asks the node watcher to advertise the node capacities again when they
+// change.
+func (nw *NodeWatcher) newDaemonSetController(client kubernetes.Interface) cache.Controller {
+	update := func(obj interface{}) {
+		daemonSet := obj.(*appsv1.DaemonSet)
+		daemonSetMux.Lock()
+		daemonSets[daemonSet.Namespace+"/"+daemonSet.Name] = &daemonSet.Spec.Template
+		daemonSetMux.Unlock()
+		nw.refreshCapacities()
+	}
+	_, controller := cache.NewInformer(
+		&cache.ListWatch{
+			ListFunc: func(alo metav1.ListOptions) (runtime.Object, error) {
+				return client.AppsV1().DaemonSets(metav1.NamespaceAll).List(alo)
+			},
+			WatchFunc: func(alo metav1.ListOptions) (watch.Interface, error) {
+				return client.AppsV1().DaemonSets(metav1.NamespaceAll).Watch(alo)
+			},
+		},
+		&appsv1.DaemonSet{},
+		0,
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: update,
+			UpdateFunc: func(old, new interface{}) {
+				update(new)
+			},
+			DeleteFunc: func(obj interface{}) {
+				key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+				if err != nil {
+					glog.Errorf("DeleteFunc: error getting key %v", err)
+					return
+				}
+				daemonSetMux.Lock()
+				delete(daemonSets, key)
+				daemonSetMux.Unlock()
+				nw.refreshCapacities()
+			},
+		},
+	)
+	return controller
+}
+
+// refreshCapacities queues the update of the capacity of all the nodes.
+func (nw *NodeWatcher) refreshCapacities() {
+	if !nw.daemonSetController.HasSynced() {
+		// The nodes are added with the overhead of the initial DaemonSets.
+		return
+	}
+	for _, obj := range nw.nodeStore.List() {
+		node := obj.(*v1.Node)
+		if !isSchedulable(node) {
+			continue
+		}
+		key, err := cache.MetaNamespaceKeyFunc(node)
+		if err != nil {
+			glog.Errorf("refreshCapacities: error getting key %v", err)
+			continue
+		}
+		nw.nodeWorkQueue.Add(key, nw.parseNode(node, nodeCapacityChanged))
+	}
+}
+
+// daemonSetOverhead returns the CPU in millicores and memory in KB requested
+// by the DaemonSets whose node selector matches the node labels. Node
+// affinities and taints are not considered.
+func daemonSetOverhead(nodeLabels map[string]string) (int64, int64) {
+	daemonSetMux.RLock()
+	defer daemonSetMux.RUnlock()
+	var cpu, memKb int64
+	for _, template := range daemonSets {
+		if !labels.SelectorFromSet(template.Spec.NodeSelector).Matches(labels.Set(nodeLabels)) {
+			continue
+		}
+		for _, container := range template.Spec.Containers {
+			cpu += container.Resources.Requests.Cpu().MilliValue()
+			memKb += container.Resources.Requests.Memory().Value() / bytesToKb
+		}
+	}
+	return cpu, memKb
+}
+
+// nodeCapacity returns the capacity of the node advertised to Firmament.
+func nodeCapacity(node *Node) (float32, uint64) {
+	if IsNodeQuarantined(node.Hostname) {
+		// No pod fits on the node until the end of its quarantine.
+		return 0, 0
+	}
+	cpu, memKb := node.CPUCapacity, node.MemCapacityKb
+	if discountDaemonSetOverhead {
+		cpuOverhead, memOverhead := daemonSetOverhead(node.Labels)
+		cpu -= cpuOverhead
+		memKb -= memOverhead
+	}
+	if isCgroupV2Node(node.Labels) {
+		memKb -= memoryQoSOverhead(node.Hostname)
+	}
+	cpuOverhead, memOverhead := nodeTargetedOverhead(node.Hostname)
+	cpu -= cpuOverhead
+	memKb -= memOverhead
+	if cpu < 0 {
+		cpu = 0
+	}
+	if memKb < 0 {
+		memKb = 0
+	}
+	return float32(cpu), uint64(memKb)
+}
+
+// updateCapacity advertises the capacity of the node again. The caller must
+// hold state.nodeMux.
+func updateCapacity(node *Node, rtnd *firmament.ResourceTopologyNodeDescriptor) {
+	cpuCapacity, ramCapacity := nodeCapacity(node)
+	if warming, ok := warmingNodes[node.Hostname]; ok {
+		// The capacity is reached at the end of the warm-up.
+		warming.cpuCapacity, warming.ramCapacity = cpuCapacity, ramCapacity
+		return
+	}
+	setCapacity(rtnd, cpuCapacity, ramCapacity)
+}
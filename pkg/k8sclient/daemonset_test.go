@@ -0,0 +1,69 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"testing"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestNodeCapacity(t *testing.T) {
+	defer func(discount bool) { discountDaemonSetOverhead = discount }(discountDaemonSetOverhead)
+	daemonSetTemplate := func(cpu, mem string, nodeSelector map[string]string) *v1.PodTemplateSpec {
+		return &v1.PodTemplateSpec{
+			Spec: v1.PodSpec{
+				NodeSelector: nodeSelector,
+				Containers: []v1.Container{{
+					Resources: v1.ResourceRequirements{
+						Requests: v1.ResourceList{
+							v1.ResourceCPU:    resource.MustParse(cpu),
+							v1.ResourceMemory: resource.MustParse(mem),
+						},
+					},
+				}},
+			},
+		}
+	}
+	daemonSetMux.Lock()
+	daemonSets = map[string]*v1.PodTemplateSpec{
+		"kube-system/proxy": daemonSetTemplate("100m", "64Mi", nil),
+		"kube-system/gpu":   daemonSetTemplate("200m", "128Mi", map[string]string{"gpu": "true"}),
+	}
+	daemonSetMux.Unlock()
+	defer func() { daemonSets = make(map[string]*v1.PodTemplateSpec) }()
+
+	var testData = []struct {
+		discount    bool
+		labels      map[string]string
+		expectedCPU float32
+		expectedRAM uint64
+	}{
+		{discount: false, labels: map[string]string{"gpu": "true"}, expectedCPU: 1000, expectedRAM: 1048576},
+		{discount: true, labels: nil, expectedCPU: 900, expectedRAM: 1048576 - 65536},
+		{discount: true, labels: map[string]string{"gpu": "true"}, expectedCPU: 700, expectedRAM: 1048576 - 65536 - 131072},
+	}
+	for _, tc := range testData {
+		discountDaemonSetOverhead = tc.discount
+		cpu, ram := nodeCapacity(&Node{CPUCapacity: 1000, MemCapacityKb: 1048576, Labels: tc.labels})
+		if cpu != tc.expectedCPU || ram != tc.expectedRAM {
+			t.Errorf("nodeCapacity(%v) with discount %v = %v, %v, expected %v, %v",
+				tc.labels, tc.discount, cpu, ram, tc.expectedCPU, tc.expectedRAM)
+		}
+	}
+}
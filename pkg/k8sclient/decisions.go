@@ -0,0 +1,79 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"time"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/sampling"
+)
+
+// DecisionFeatures returns the feature vector of a scheduling decision
+// about a task on a node. It must be called before the task is marked
+// placed. It returns false if the task is unknown.
+func DecisionFeatures(taskID uint64, nodeName string) (sampling.Features, bool) {
+	var features sampling.Features
+	podIdentifier, td, ok := state.taskOf(taskID)
+	if !ok || td == nil {
+		return features, false
+	}
+	features.CPURequest = int64(td.GetResourceRequest().GetCpuCores())
+	features.MemRequestKb = int64(td.GetResourceRequest().GetRamCap())
+	features.Priority = td.GetPriority()
+	features.Constraints = len(td.GetLabelSelectors())
+	pendingMux.Lock()
+	if pending, ok := pendingTasks[taskID]; ok {
+		features.PendingCycles = pending.cycles
+		features.PendingSeconds = time.Since(pending.submitted).Seconds()
+	}
+	pendingMux.Unlock()
+
+	var clusterCPU, clusterMemKb, clusterCPUReq, clusterMemReqKb int64
+	state.nodeMux.RLock()
+	for name, rtnd := range state.nodeToRTND {
+		features.Nodes++
+		capacity := rtnd.GetResourceDesc().GetResourceCapacity()
+		cpu, memKb := int64(capacity.GetCpuCores()), int64(capacity.GetRamCap())
+		var cpuReq, memReqKb int64
+		for _, pod := range podsByNode.podsOnNode(name) {
+			if pod.Identifier == podIdentifier {
+				continue
+			}
+			cpuReq += pod.CPURequest
+			memReqKb += pod.MemRequestKb
+		}
+		if name == nodeName {
+			features.NodeCPUUtil = utilization(cpuReq, cpu)
+			features.NodeMemUtil = utilization(memReqKb, memKb)
+		}
+		clusterCPU += cpu
+		clusterMemKb += memKb
+		clusterCPUReq += cpuReq
+		clusterMemReqKb += memReqKb
+	}
+	state.nodeMux.RUnlock()
+	features.ClusterCPUUtil = utilization(clusterCPUReq, clusterCPU)
+	features.ClusterMemUtil = utilization(clusterMemReqKb, clusterMemKb)
+	return features, true
+}
+
+func utilization(used, capacity int64) float64 {
+	if capacity <= 0 {
+		return 0
+	}
+	return float64(used) / float64(capacity)
+}
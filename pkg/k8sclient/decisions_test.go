@@ -0,0 +1,81 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"testing"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDecisionFeatures(t *testing.T) {
+	node := func(cpu float32, ramKb uint64) *firmament.ResourceTopologyNodeDescriptor {
+		return &firmament.ResourceTopologyNodeDescriptor{
+			ResourceDesc: &firmament.ResourceDescriptor{ResourceCapacity: &firmament.ResourceVector{CpuCores: cpu, RamCap: ramKb}},
+		}
+	}
+	state = &memoryState{}
+	state.nodeToRTND = map[string]*firmament.ResourceTopologyNodeDescriptor{
+		"node0": node(1000, 1000),
+		"node1": node(3000, 3000),
+	}
+	placed := PodIdentifier{Name: "placed", Namespace: "ns"}
+	state.taskIDToPod = map[uint64]PodIdentifier{1: placed}
+	state.podToTD = map[PodIdentifier]*firmament.TaskDescriptor{
+		placed: {
+			Uid:             1,
+			Priority:        5,
+			ResourceRequest: &firmament.ResourceVector{CpuCores: 200, RamCap: 100},
+			LabelSelectors: []*firmament.LabelSelector{
+				{Type: firmament.LabelSelector_EXISTS_KEY, Key: "ssd"},
+			},
+		},
+	}
+	podsByNode = newPodIndex()
+	defer func() { podsByNode = newPodIndex() }()
+	bound := func(name, nodeName string) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"},
+			Spec:       v1.PodSpec{NodeName: nodeName},
+			Status:     v1.PodStatus{Phase: v1.PodRunning},
+		}
+	}
+	podsByNode.update(bound("pod0", "node0"), 500, 250)
+	podsByNode.update(bound("pod1", "node1"), 1500, 750)
+	// The pod of the decision is not counted once it is bound.
+	podsByNode.update(bound("placed", "node0"), 200, 100)
+	markTaskPending(1)
+	defer MarkTaskPlaced(1)
+
+	features, ok := DecisionFeatures(1, "node0")
+	if !ok {
+		t.Fatal("DecisionFeatures(1, node0) = false, expected the task to be known")
+	}
+	if features.CPURequest != 200 || features.MemRequestKb != 100 || features.Priority != 5 || features.Constraints != 1 {
+		t.Errorf("DecisionFeatures(1, node0) = %+v, expected the requests, priority and constraints of the task", features)
+	}
+	if features.Nodes != 2 || features.NodeCPUUtil != 0.5 || features.NodeMemUtil != 0.25 ||
+		features.ClusterCPUUtil != 0.5 || features.ClusterMemUtil != 0.25 {
+		t.Errorf("DecisionFeatures(1, node0) = %+v, expected node utilizations 0.5/0.25 and cluster 0.5/0.25", features)
+	}
+	if _, ok := DecisionFeatures(2, "node0"); ok {
+		t.Error("DecisionFeatures() of an unknown task = true, expected false")
+	}
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DeletionPolicy configures the deletion of the preempted and migrated
+// pods, since workloads need different eviction aggressiveness.
+type DeletionPolicy struct {
+	// GracePeriodSeconds overrides the termination grace period of the
+	// pods if set. 0 deletes the pods immediately.
+	GracePeriodSeconds *int64
+	// Propagation is the propagation policy of the deletion, the default
+	// of the API server if empty.
+	Propagation metav1.DeletionPropagation
+}
+
+// NewDeletionPolicy returns the deletion policy overriding the grace
+// period of the pods if gracePeriodSeconds is not negative, with the given
+// propagation policy: Orphan, Background, Foreground or empty.
+func NewDeletionPolicy(gracePeriodSeconds int, propagation string) (*DeletionPolicy, error) {
+	policy := &DeletionPolicy{Propagation: metav1.DeletionPropagation(propagation)}
+	switch policy.Propagation {
+	case "", metav1.DeletePropagationOrphan, metav1.DeletePropagationBackground, metav1.DeletePropagationForeground:
+	default:
+		return nil, fmt.Errorf("invalid propagation policy %q", propagation)
+	}
+	if gracePeriodSeconds >= 0 {
+		gracePeriod := int64(gracePeriodSeconds)
+		policy.GracePeriodSeconds = &gracePeriod
+	}
+	return policy, nil
+}
+
+// preemptionDeletion is the deletion policy of the preempted pods, nil to
+// use the defaults of the API server.
+var preemptionDeletion *DeletionPolicy
+
+// deleteOptions returns the options of the deletion of a pod.
+func (p *DeletionPolicy) deleteOptions() *metav1.DeleteOptions {
+	options := &metav1.DeleteOptions{}
+	if p == nil {
+		return options
+	}
+	options.GracePeriodSeconds = p.GracePeriodSeconds
+	if p.Propagation != "" {
+		propagation := p.Propagation
+		options.PropagationPolicy = &propagation
+	}
+	return options
+}
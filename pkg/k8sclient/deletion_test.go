@@ -0,0 +1,53 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDeletionPolicy(t *testing.T) {
+	var disabled *DeletionPolicy
+	if options := disabled.deleteOptions(); options.GracePeriodSeconds != nil || options.PropagationPolicy != nil {
+		t.Errorf("deleteOptions() without policy = %+v, expected the API server defaults", options)
+	}
+	if _, err := NewDeletionPolicy(-1, "Cascade"); err == nil {
+		t.Error("NewDeletionPolicy() with an invalid propagation policy succeeded")
+	}
+
+	policy, err := NewDeletionPolicy(-1, "")
+	if err != nil {
+		t.Fatalf("NewDeletionPolicy() failed: %v", err)
+	}
+	if options := policy.deleteOptions(); options.GracePeriodSeconds != nil || options.PropagationPolicy != nil {
+		t.Errorf("deleteOptions() = %+v, expected the API server defaults", options)
+	}
+
+	policy, err = NewDeletionPolicy(0, "Foreground")
+	if err != nil {
+		t.Fatalf("NewDeletionPolicy() failed: %v", err)
+	}
+	options := policy.deleteOptions()
+	if options.GracePeriodSeconds == nil || *options.GracePeriodSeconds != 0 {
+		t.Errorf("deleteOptions() grace period = %v, expected 0", options.GracePeriodSeconds)
+	}
+	if options.PropagationPolicy == nil || *options.PropagationPolicy != metav1.DeletePropagationForeground {
+		t.Errorf("deleteOptions() propagation policy = %v, expected Foreground", options.PropagationPolicy)
+	}
+}
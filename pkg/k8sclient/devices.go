@@ -0,0 +1,151 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+	"k8s.io/api/core/v1"
+)
+
+// degradedDeviceLabelPrefix prefixes the Firmament labels of the device
+// resources, e.g. nvidia.com/gpu, which are degraded on a node.
+const degradedDeviceLabelPrefix = "poseidon.k8s.io/degraded-device:"
+
+// DeviceHealthPolicy keeps the pods requesting devices, e.g. GPUs, off the
+// nodes whose devices of the requested resources are degraded.
+type DeviceHealthPolicy struct {
+	// MaxUnhealthyFraction is the largest fraction of unhealthy devices of
+	// a resource for which a node still accepts the pods requesting the
+	// resource. 0 excludes the nodes with any unhealthy device.
+	MaxUnhealthyFraction float64
+}
+
+// deviceHealthPolicy is the policy gating the nodes on the health of their
+// devices, nil if their health is ignored.
+var deviceHealthPolicy *DeviceHealthPolicy
+
+// DeviceCount is the number of devices of a resource of a node.
+type DeviceCount struct {
+	Total     int64
+	Unhealthy int64
+}
+
+// isDeviceResource returns whether the resource is an extended resource
+// advertised by a device plugin.
+func isDeviceResource(name v1.ResourceName) bool {
+	return strings.Contains(string(name), "/") && !strings.HasPrefix(string(name), v1.ResourceDefaultNamespacePrefix)
+}
+
+// nodeDevices returns the devices of the node by resource. The device
+// plugins only advertise the healthy devices as allocatable, the others are
+// unhealthy.
+func nodeDevices(node *v1.Node) map[string]DeviceCount {
+	var devices map[string]DeviceCount
+	for name, capacity := range node.Status.Capacity {
+		if !isDeviceResource(name) {
+			continue
+		}
+		allocatable := node.Status.Allocatable[name]
+		count := DeviceCount{Total: capacity.Value(), Unhealthy: capacity.Value() - allocatable.Value()}
+		if count.Unhealthy < 0 {
+			count.Unhealthy = 0
+		}
+		if devices == nil {
+			devices = make(map[string]DeviceCount)
+		}
+		devices[string(name)] = count
+	}
+	return devices
+}
+
+// degraded returns whether the node must not accept the pods requesting
+// the devices.
+func (p *DeviceHealthPolicy) degraded(count DeviceCount) bool {
+	if count.Total <= 0 || count.Unhealthy == 0 {
+		return false
+	}
+	return count.Unhealthy == count.Total || float64(count.Unhealthy)/float64(count.Total) > p.MaxUnhealthyFraction
+}
+
+// degradedDeviceLabels returns the Firmament labels of the degraded device
+// resources of a node, sorted by resource.
+func degradedDeviceLabels(devices map[string]DeviceCount) []*firmament.Label {
+	if deviceHealthPolicy == nil {
+		return nil
+	}
+	var resources []string
+	for resource, count := range devices {
+		if deviceHealthPolicy.degraded(count) {
+			resources = append(resources, resource)
+		}
+	}
+	sort.Strings(resources)
+	var labels []*firmament.Label
+	for _, resource := range resources {
+		labels = append(labels, &firmament.Label{Key: degradedDeviceLabelPrefix + resource, Value: "true"})
+	}
+	return labels
+}
+
+// podDeviceRequests returns the device resources requested by the
+// containers of the pod, sorted.
+func podDeviceRequests(pod *v1.Pod) []string {
+	set := make(map[string]bool)
+	for _, containers := range [][]v1.Container{pod.Spec.InitContainers, pod.Spec.Containers} {
+		for _, container := range containers {
+			// Extended resources must have equal requests and limits, but
+			// the requests default to the limits.
+			for _, list := range []v1.ResourceList{container.Resources.Requests, container.Resources.Limits} {
+				for name, quantity := range list {
+					if isDeviceResource(name) && quantity.Value() > 0 {
+						set[string(name)] = true
+					}
+				}
+			}
+		}
+	}
+	var resources []string
+	for resource := range set {
+		resources = append(resources, resource)
+	}
+	sort.Strings(resources)
+	return resources
+}
+
+// deviceHealthCompiler keeps the tasks off the nodes whose devices of the
+// resources the pod requests are degraded.
+type deviceHealthCompiler struct{}
+
+func (deviceHealthCompiler) Name() string {
+	return "deviceHealth"
+}
+
+func (deviceHealthCompiler) Compile(pod *Pod, td *firmament.TaskDescriptor) error {
+	if deviceHealthPolicy == nil {
+		return nil
+	}
+	for _, resource := range pod.DeviceRequests {
+		td.LabelSelectors = append(td.LabelSelectors, &firmament.LabelSelector{
+			Type: firmament.LabelSelector_NOT_EXISTS_KEY,
+			Key:  degradedDeviceLabelPrefix + resource,
+		})
+	}
+	return nil
+}
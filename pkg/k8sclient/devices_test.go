@@ -0,0 +1,96 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestDegradedDeviceLabels(t *testing.T) {
+	node := &v1.Node{Status: v1.NodeStatus{
+		Capacity: v1.ResourceList{
+			"cpu":              resource.MustParse("8"),
+			"nvidia.com/gpu":   resource.MustParse("8"),
+			"example.com/fpga": resource.MustParse("2"),
+		},
+		Allocatable: v1.ResourceList{
+			"cpu":              resource.MustParse("7"),
+			"nvidia.com/gpu":   resource.MustParse("7"),
+			"example.com/fpga": resource.MustParse("0"),
+		},
+	}}
+	devices := nodeDevices(node)
+	expected := map[string]DeviceCount{"nvidia.com/gpu": {Total: 8, Unhealthy: 1}, "example.com/fpga": {Total: 2, Unhealthy: 2}}
+	if !reflect.DeepEqual(devices, expected) {
+		t.Errorf("nodeDevices() = %v, expected %v", devices, expected)
+	}
+
+	defer func() { deviceHealthPolicy = nil }()
+	if labels := degradedDeviceLabels(devices); labels != nil {
+		t.Errorf("degradedDeviceLabels() = %v without a policy, expected none", labels)
+	}
+	var testData = []struct {
+		maxUnhealthy float64
+		expected     []string
+	}{
+		{maxUnhealthy: 0, expected: []string{"example.com/fpga", "nvidia.com/gpu"}},
+		// A node without any healthy device is always degraded.
+		{maxUnhealthy: 0.2, expected: []string{"example.com/fpga"}},
+	}
+	for _, tc := range testData {
+		deviceHealthPolicy = &DeviceHealthPolicy{MaxUnhealthyFraction: tc.maxUnhealthy}
+		var degraded []string
+		for _, label := range degradedDeviceLabels(devices) {
+			degraded = append(degraded, label.GetKey()[len(degradedDeviceLabelPrefix):])
+		}
+		if !reflect.DeepEqual(degraded, tc.expected) {
+			t.Errorf("degradedDeviceLabels() = %v with at most %v unhealthy, expected %v", degraded, tc.maxUnhealthy, tc.expected)
+		}
+	}
+}
+
+func TestDeviceHealthCompiler(t *testing.T) {
+	pod := &v1.Pod{Spec: v1.PodSpec{Containers: []v1.Container{
+		{Resources: v1.ResourceRequirements{Limits: v1.ResourceList{
+			"nvidia.com/gpu": resource.MustParse("2"),
+			"memory":         resource.MustParse("1Gi"),
+		}}},
+		{Resources: v1.ResourceRequirements{Requests: v1.ResourceList{"example.com/fpga": resource.MustParse("0")}}},
+	}}}
+	requests := podDeviceRequests(pod)
+	if !reflect.DeepEqual(requests, []string{"nvidia.com/gpu"}) {
+		t.Errorf("podDeviceRequests() = %v, expected [nvidia.com/gpu]", requests)
+	}
+
+	defer func() { deviceHealthPolicy = nil }()
+	deviceHealthPolicy = &DeviceHealthPolicy{}
+	td := &firmament.TaskDescriptor{}
+	deviceHealthCompiler{}.Compile(&Pod{DeviceRequests: requests}, td)
+	expected := []*firmament.LabelSelector{{Type: firmament.LabelSelector_NOT_EXISTS_KEY, Key: degradedDeviceLabelPrefix + "nvidia.com/gpu"}}
+	if !reflect.DeepEqual(td.LabelSelectors, expected) {
+		t.Errorf("Compile() = %v, expected %v", td.LabelSelectors, expected)
+	}
+	labels := []*firmament.Label{{Key: degradedDeviceLabelPrefix + "nvidia.com/gpu", Value: "true"}}
+	if matchesSelectors(labels, td.LabelSelectors) {
+		t.Error("Task requesting GPUs matches a node with degraded GPUs")
+	}
+}
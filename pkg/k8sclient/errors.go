@@ -0,0 +1,62 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/golang/glog"
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+	"github.com/kubernetes-sigs/poseidon/pkg/metrics"
+)
+
+// recoverableErrors counts the retryable and state mismatch errors the
+// watchers carried on after.
+var recoverableErrors uint64
+
+// stateMismatch returns the error of an operation on a node or pod the
+// state of Poseidon disagrees on, e.g. the deletion of an unknown pod.
+func stateMismatch(op, format string, args ...interface{}) error {
+	return firmament.NewError(firmament.StateMismatch, op, fmt.Errorf(format, args...))
+}
+
+// handleError handles an error of a request to Firmament or of the state of
+// the watchers, if any. The fatal errors stop the scheduler. The watchers
+// carry on after the others, which are reported to the scheduling loops, so
+// that they resynchronize Firmament. It returns true if there was an error.
+func handleError(err error) bool {
+	if err == nil {
+		return false
+	}
+	kind := firmament.KindOf(err)
+	metrics.SchedulerErrors.Inc(kind.String())
+	if kind == firmament.Fatal {
+		glog.Fatalf("%v", err)
+	}
+	glog.Errorf("%v (%s error)", err, kind)
+	atomic.AddUint64(&recoverableErrors, 1)
+	return true
+}
+
+// RecoverableErrors returns the number of retryable and state mismatch
+// errors the watchers carried on after so far. Firmament may have missed
+// some nodes or tasks since the number last changed, and must be
+// resynchronized.
+func RecoverableErrors() uint64 {
+	return atomic.LoadUint64(&recoverableErrors)
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/kubernetes-sigs/poseidon/pkg/runinfo"
+	"k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// recordPodEvent creates an event on the pod, reported by the scheduler.
+func (pw *PodWatcher) recordPodEvent(podIdentifier PodIdentifier, eventType, reason, message string) {
+	now := meta_v1.NewTime(time.Now())
+	_, err := pw.clientset.CoreV1().Events(podIdentifier.Namespace).Create(&v1.Event{
+		ObjectMeta: meta_v1.ObjectMeta{
+			GenerateName: podIdentifier.Name + ".",
+			Namespace:    podIdentifier.Namespace,
+			Annotations:  map[string]string{runinfo.Annotation: runinfo.ID},
+		},
+		InvolvedObject: v1.ObjectReference{
+			Kind:      "Pod",
+			Namespace: podIdentifier.Namespace,
+			Name:      podIdentifier.Name,
+		},
+		Reason:         reason,
+		Message:        message,
+		Type:           eventType,
+		Source:         v1.EventSource{Component: pw.schedulerName},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	})
+	if err != nil {
+		glog.Errorf("Failed to create the %s event of pod %v: %v", reason, podIdentifier, err)
+	}
+}
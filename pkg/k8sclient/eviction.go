@@ -0,0 +1,228 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+)
+
+// nodeUsage is the live utilization of a node, in fractions of its
+// capacity.
+type nodeUsage struct {
+	cpu, mem float64
+}
+
+// podUsage is the live usage of a pod, in the units of the stats senders.
+type podUsage struct {
+	cpu, mem int64
+}
+
+var (
+	usageMux sync.Mutex
+	// nodeUsages and podUsages are the last utilizations reported by the
+	// stats senders.
+	nodeUsages = make(map[string]nodeUsage)
+	podUsages  = make(map[PodIdentifier]podUsage)
+)
+
+// RecordNodeUsage records the last reported utilization of a node, in
+// fractions of its capacity.
+func RecordNodeUsage(nodeName string, cpuUtilization, memUtilization float64) {
+	usageMux.Lock()
+	defer usageMux.Unlock()
+	nodeUsages[nodeName] = nodeUsage{cpu: cpuUtilization, mem: memUtilization}
+}
+
+// RecordPodUsage records the last reported usage of a pod.
+func RecordPodUsage(podIdentifier PodIdentifier, cpuUsage, memUsage int64) {
+	usageMux.Lock()
+	defer usageMux.Unlock()
+	podUsages[podIdentifier] = podUsage{cpu: cpuUsage, mem: memUsage}
+}
+
+// forgetNodeUsage drops the utilization of a removed node.
+func forgetNodeUsage(nodeName string) {
+	usageMux.Lock()
+	defer usageMux.Unlock()
+	delete(nodeUsages, nodeName)
+}
+
+// forgetPodUsage drops the usage of a removed pod.
+func forgetPodUsage(podIdentifier PodIdentifier) {
+	usageMux.Lock()
+	defer usageMux.Unlock()
+	delete(podUsages, podIdentifier)
+}
+
+// EvictionCandidate is a pod recommended for eviction.
+type EvictionCandidate struct {
+	Pod      string `json:"pod"`
+	Priority uint32 `json:"priority"`
+	// CPUShare and MemShare are the fractions of the node capacity used by
+	// the pod.
+	CPUShare float64 `json:"cpuShare"`
+	MemShare float64 `json:"memShare"`
+}
+
+// EvictionAdvice are the pods to evict to bring the utilization of a node
+// under a target, lowest priority and largest users first.
+type EvictionAdvice struct {
+	Node   string  `json:"node"`
+	Target float64 `json:"target"`
+	// Live is set if the utilizations are the reported ones, otherwise they
+	// are the requests of the pods bound to the node.
+	Live           bool    `json:"live"`
+	CPUUtilization float64 `json:"cpuUtilization"`
+	MemUtilization float64 `json:"memUtilization"`
+	// ProjectedCPUUtilization and ProjectedMemUtilization are the
+	// utilizations once the candidates are evicted.
+	ProjectedCPUUtilization float64             `json:"projectedCpuUtilization"`
+	ProjectedMemUtilization float64             `json:"projectedMemUtilization"`
+	Evictions               []EvictionCandidate `json:"evictions"`
+	// Relieved is false if evicting all the evictable pods does not bring
+	// the node under the target.
+	Relieved bool `json:"relieved"`
+}
+
+// ErrUnknownNode is the error of the advice for a node unknown to the
+// scheduler.
+var ErrUnknownNode = errors.New("node is not known to the scheduler")
+
+// AdviseEvictions recommends the pods to evict from a node to bring both
+// its CPU and memory utilization under target. The utilization of the node
+// is the last one reported if any, otherwise its requested capacity, and is
+// split between its pods in proportion to their reported usage, or to their
+// requests if some pods reported none. On the cgroup v2 nodes, the requested
+// memory includes the memory the pods may use before being throttled. Only the preemptible pods known to
+// the scheduler are candidates, the DaemonSet pods and the deleting pods are
+// not.
+func AdviseEvictions(nodeName string, target float64) (*EvictionAdvice, error) {
+	if target < 0 || target > 1 {
+		return nil, fmt.Errorf("invalid target utilization %v, expected a fraction of the capacity", target)
+	}
+	rtnd, ok := state.NodeTopology(nodeName)
+	if !ok {
+		return nil, ErrUnknownNode
+	}
+	capacity := rtnd.GetResourceDesc().GetResourceCapacity()
+	capacityCPU, capacityMemKb := float64(capacity.GetCpuCores()), float64(capacity.GetRamCap())
+	memoryQoS := isCgroupV2Resource(rtnd.GetResourceDesc().GetLabels())
+
+	type candidate struct {
+		EvictionCandidate
+		evictable bool
+		// cpu and mem are the weights of the pod in the node usage.
+		cpu, mem float64
+	}
+	pods := podsByNode.podsOnNode(nodeName)
+	sort.Slice(pods, func(i, j int) bool { return pods[i].Identifier.UniqueName() < pods[j].Identifier.UniqueName() })
+	usageMux.Lock()
+	usage, live := nodeUsages[nodeName]
+	reported := make(map[PodIdentifier]podUsage, len(pods))
+	for _, pod := range pods {
+		if u, ok := podUsages[pod.Identifier]; ok {
+			reported[pod.Identifier] = u
+		}
+	}
+	usageMux.Unlock()
+	candidates := make([]candidate, 0, len(pods))
+	for _, pod := range pods {
+		c := candidate{
+			EvictionCandidate: EvictionCandidate{Pod: pod.Identifier.UniqueName()},
+			cpu:               float64(pod.CPURequest),
+			mem:               float64(pod.MemRequestKb),
+		}
+		if memoryQoS {
+			c.mem += float64(pod.MemBurstKb)
+			// The advertised capacity is net of the memory the pods may
+			// use before being throttled.
+			capacityMemKb += float64(pod.MemBurstKb)
+		}
+		if len(reported) == len(pods) {
+			c.cpu, c.mem = float64(reported[pod.Identifier].cpu), float64(reported[pod.Identifier].mem)
+		}
+		if td, ok := state.TaskOfPod(pod.Identifier); ok && !pod.DaemonSet && !pod.Deleting && taskPreemptible(td.GetUid()) {
+			c.evictable = true
+			c.Priority = td.GetPriority()
+		}
+		candidates = append(candidates, c)
+	}
+
+	var totalCPU, totalMem float64
+	for _, c := range candidates {
+		totalCPU += c.cpu
+		totalMem += c.mem
+	}
+	if !live {
+		usage = nodeUsage{cpu: fraction64(totalCPU, capacityCPU), mem: fraction64(totalMem, capacityMemKb)}
+	}
+	for i := range candidates {
+		candidates[i].CPUShare = usage.cpu * fraction64(candidates[i].cpu, totalCPU)
+		candidates[i].MemShare = usage.mem * fraction64(candidates[i].mem, totalMem)
+	}
+	hottest := func(c candidate) float64 {
+		if usage.cpu >= usage.mem {
+			return c.CPUShare
+		}
+		return c.MemShare
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Priority != candidates[j].Priority {
+			return candidates[i].Priority < candidates[j].Priority
+		}
+		return hottest(candidates[i]) > hottest(candidates[j])
+	})
+
+	advice := &EvictionAdvice{
+		Node:                    nodeName,
+		Target:                  target,
+		Live:                    live,
+		CPUUtilization:          usage.cpu,
+		MemUtilization:          usage.mem,
+		ProjectedCPUUtilization: usage.cpu,
+		ProjectedMemUtilization: usage.mem,
+		Evictions:               []EvictionCandidate{},
+	}
+	for _, c := range candidates {
+		if advice.ProjectedCPUUtilization <= target && advice.ProjectedMemUtilization <= target {
+			break
+		}
+		if !c.evictable {
+			continue
+		}
+		// The pods relieving neither of the overloaded resources are kept.
+		if (advice.ProjectedCPUUtilization <= target || c.CPUShare == 0) && (advice.ProjectedMemUtilization <= target || c.MemShare == 0) {
+			continue
+		}
+		advice.Evictions = append(advice.Evictions, c.EvictionCandidate)
+		advice.ProjectedCPUUtilization = math.Max(0, advice.ProjectedCPUUtilization-c.CPUShare)
+		advice.ProjectedMemUtilization = math.Max(0, advice.ProjectedMemUtilization-c.MemShare)
+	}
+	advice.Relieved = advice.ProjectedCPUUtilization <= target && advice.ProjectedMemUtilization <= target
+	return advice, nil
+}
+
+func fraction64(value, total float64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return value / total
+}
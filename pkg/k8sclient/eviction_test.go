@@ -0,0 +1,90 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"testing"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAdviseEvictions(t *testing.T) {
+	state = &memoryState{}
+	state.nodeToRTND = map[string]*firmament.ResourceTopologyNodeDescriptor{
+		"node0": {ResourceDesc: &firmament.ResourceDescriptor{
+			ResourceCapacity: &firmament.ResourceVector{CpuCores: 4000, RamCap: 4000000},
+		}},
+	}
+	state.podToTD = map[PodIdentifier]*firmament.TaskDescriptor{
+		{Name: "batch", Namespace: "ns"}:    {Uid: 1, Priority: 1},
+		{Name: "web", Namespace: "ns"}:      {Uid: 2, Priority: 5},
+		{Name: "critical", Namespace: "ns"}: {Uid: 3, Priority: 0},
+		{Name: "small", Namespace: "ns"}:    {Uid: 4, Priority: 1},
+	}
+	setTaskPreemptible(3, false)
+	defer forgetTaskPreemption(3)
+	podsByNode = newPodIndex()
+	defer func() { podsByNode = newPodIndex() }()
+	for name, cpu := range map[string]int64{"batch": 1000, "web": 1000, "critical": 1000, "small": 200} {
+		podsByNode.update(&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"},
+			Spec:       v1.PodSpec{NodeName: "node0"},
+			Status:     v1.PodStatus{Phase: v1.PodRunning},
+		}, cpu, 100000)
+	}
+
+	if _, err := AdviseEvictions("node1", 0.5); err != ErrUnknownNode {
+		t.Errorf("AdviseEvictions(node1) = %v, expected ErrUnknownNode", err)
+	}
+	if _, err := AdviseEvictions("node0", 2); err == nil {
+		t.Error("AdviseEvictions() with a target above the capacity succeeded")
+	}
+
+	// 80% of the CPU is requested, the critical pod is not preemptible.
+	advice, err := AdviseEvictions("node0", 0.6)
+	if err != nil {
+		t.Fatalf("AdviseEvictions() failed: %v", err)
+	}
+	if advice.Live || len(advice.Evictions) != 1 || advice.Evictions[0].Pod != "ns/batch" || !advice.Relieved {
+		t.Errorf("AdviseEvictions() = %+v, expected the eviction of ns/batch", advice)
+	}
+
+	// With the live usage, the web pod uses most of the hot node.
+	RecordNodeUsage("node0", 0.9, 0.1)
+	defer forgetNodeUsage("node0")
+	for name, usage := range map[string]int64{"batch": 100, "web": 700, "critical": 100, "small": 100} {
+		RecordPodUsage(PodIdentifier{Name: name, Namespace: "ns"}, usage, 10)
+		defer forgetPodUsage(PodIdentifier{Name: name, Namespace: "ns"})
+	}
+	advice, err = AdviseEvictions("node0", 0.3)
+	if err != nil {
+		t.Fatalf("AdviseEvictions() failed: %v", err)
+	}
+	var evicted []string
+	for _, candidate := range advice.Evictions {
+		evicted = append(evicted, candidate.Pod)
+	}
+	// The low priority pods are evicted first, but do not relieve the node.
+	if !advice.Live || len(evicted) != 3 || evicted[2] != "ns/web" || !advice.Relieved {
+		t.Errorf("AdviseEvictions() = %+v, evicting %v, expected the low priority pods then ns/web", advice, evicted)
+	}
+	if advice.ProjectedMemUtilization > advice.MemUtilization {
+		t.Errorf("AdviseEvictions() projected the memory utilization up to %v", advice.ProjectedMemUtilization)
+	}
+}
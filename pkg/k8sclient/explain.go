@@ -0,0 +1,195 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+)
+
+// Pod states reported by Explain.
+const (
+	// ExplainDeferred means the submission of the pod to Firmament is
+	// deferred.
+	ExplainDeferred = "deferred"
+	// ExplainPending means the pod waits for Firmament to place it.
+	ExplainPending = "pending"
+	// ExplainPlaced means Firmament placed the pod.
+	ExplainPlaced = "placed"
+)
+
+// PodFailure is the last reason why a pod could not be placed.
+type PodFailure struct {
+	Time   time.Time `json:"time"`
+	Reason string    `json:"reason"`
+}
+
+// Explanation describes the scheduling state of a pod.
+type Explanation struct {
+	Pod   string `json:"pod"`
+	State string `json:"state"`
+	// PendingCycles is the number of scheduling cycles which did not
+	// place the pending pod.
+	PendingCycles int    `json:"pendingCycles,omitempty"`
+	CPURequest    int64  `json:"cpuRequestMillicores"`
+	MemRequestKb  int64  `json:"memRequestKb"`
+	Priority      uint32 `json:"priority"`
+	// Constraints are the node label constraints of the pod.
+	Constraints []string `json:"constraints,omitempty"`
+	Nodes       int      `json:"nodes"`
+	// MatchingNodes is the number of nodes matching the constraints.
+	MatchingNodes int `json:"matchingNodes"`
+	// CandidateNodes is the number of matching nodes whose capacity fits
+	// the requests, regardless of the pods running on them.
+	CandidateNodes int         `json:"candidateNodes"`
+	LastFailure    *PodFailure `json:"lastFailure,omitempty"`
+}
+
+var (
+	podFailureMux sync.Mutex
+	// podFailures maps the pods to the last reason why they could not be
+	// placed.
+	podFailures = make(map[PodIdentifier]PodFailure)
+)
+
+// recordPodFailure records why the pod could not be placed.
+func recordPodFailure(podIdentifier PodIdentifier, reason string) {
+	podFailureMux.Lock()
+	defer podFailureMux.Unlock()
+	podFailures[podIdentifier] = PodFailure{Time: time.Now(), Reason: reason}
+}
+
+func forgetPodFailure(podIdentifier PodIdentifier) {
+	podFailureMux.Lock()
+	defer podFailureMux.Unlock()
+	delete(podFailures, podIdentifier)
+}
+
+// Explain returns the scheduling state of the pod, or false if the pod is
+// not known to the scheduler, e.g. because it is not claimed or completed.
+func Explain(podIdentifier PodIdentifier) (*Explanation, bool) {
+	explanation := &Explanation{Pod: podIdentifier.UniqueName()}
+	var selectors []*firmament.LabelSelector
+	td, submitted := state.TaskOfPod(podIdentifier)
+	deferredMux.Lock()
+	deferredPod, deferred := deferredPods[podIdentifier]
+	deferredMux.Unlock()
+	switch {
+	case submitted:
+		explanation.State = ExplainPlaced
+		pendingMux.Lock()
+		if pending, ok := pendingTasks[td.GetUid()]; ok {
+			explanation.State = ExplainPending
+			explanation.PendingCycles = pending.cycles
+		}
+		pendingMux.Unlock()
+		explanation.CPURequest = int64(td.GetResourceRequest().GetCpuCores())
+		explanation.MemRequestKb = int64(td.GetResourceRequest().GetRamCap())
+		explanation.Priority = td.GetPriority()
+		selectors = td.GetLabelSelectors()
+	case deferred:
+		explanation.State = ExplainDeferred
+		explanation.CPURequest = deferredPod.CPURequest
+		explanation.MemRequestKb = deferredPod.MemRequestKb
+		explanation.Priority = firmamentPriority(deferredPod).Priority
+		td := &firmament.TaskDescriptor{ResourceRequest: &firmament.ResourceVector{}}
+		compileConstraints(deferredPod, td)
+		selectors = td.GetLabelSelectors()
+	default:
+		return nil, false
+	}
+	for _, selector := range selectors {
+		explanation.Constraints = append(explanation.Constraints, describeSelector(selector))
+	}
+	state.nodeMux.RLock()
+	for _, rtnd := range state.nodeToRTND {
+		explanation.Nodes++
+		rd := rtnd.GetResourceDesc()
+		if !matchesSelectors(rd.GetLabels(), selectors) {
+			continue
+		}
+		explanation.MatchingNodes++
+		capacity := rd.GetResourceCapacity()
+		if int64(capacity.GetCpuCores()) >= explanation.CPURequest && int64(capacity.GetRamCap()) >= explanation.MemRequestKb {
+			explanation.CandidateNodes++
+		}
+	}
+	state.nodeMux.RUnlock()
+	podFailureMux.Lock()
+	if failure, ok := podFailures[podIdentifier]; ok {
+		explanation.LastFailure = &failure
+	}
+	podFailureMux.Unlock()
+	return explanation, true
+}
+
+// describeSelector returns the human readable form of a label selector.
+func describeSelector(selector *firmament.LabelSelector) string {
+	switch selector.GetType() {
+	case firmament.LabelSelector_IN_SET:
+		return fmt.Sprintf("%s in (%s)", selector.GetKey(), strings.Join(selector.GetValues(), ", "))
+	case firmament.LabelSelector_NOT_IN_SET:
+		return fmt.Sprintf("%s notin (%s)", selector.GetKey(), strings.Join(selector.GetValues(), ", "))
+	case firmament.LabelSelector_EXISTS_KEY:
+		return selector.GetKey()
+	case firmament.LabelSelector_NOT_EXISTS_KEY:
+		return "!" + selector.GetKey()
+	}
+	return fmt.Sprintf("%v %s (%s)", selector.GetType(), selector.GetKey(), strings.Join(selector.GetValues(), ", "))
+}
+
+// matchesSelectors returns true if the node labels satisfy all the
+// selectors.
+func matchesSelectors(nodeLabels []*firmament.Label, selectors []*firmament.LabelSelector) bool {
+	labels := make(map[string]string, len(nodeLabels))
+	for _, label := range nodeLabels {
+		labels[label.GetKey()] = label.GetValue()
+	}
+	for _, selector := range selectors {
+		value, exists := labels[selector.GetKey()]
+		inSet := false
+		for _, v := range selector.GetValues() {
+			if exists && v == value {
+				inSet = true
+				break
+			}
+		}
+		switch selector.GetType() {
+		case firmament.LabelSelector_IN_SET:
+			if !inSet {
+				return false
+			}
+		case firmament.LabelSelector_NOT_IN_SET:
+			if inSet {
+				return false
+			}
+		case firmament.LabelSelector_EXISTS_KEY:
+			if !exists {
+				return false
+			}
+		case firmament.LabelSelector_NOT_EXISTS_KEY:
+			if exists {
+				return false
+			}
+		}
+	}
+	return true
+}
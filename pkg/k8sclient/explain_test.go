@@ -0,0 +1,100 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+)
+
+func TestExplain(t *testing.T) {
+	node := func(cpu float32, labels ...string) *firmament.ResourceTopologyNodeDescriptor {
+		rd := &firmament.ResourceDescriptor{ResourceCapacity: &firmament.ResourceVector{CpuCores: cpu, RamCap: 1024}}
+		for _, label := range labels {
+			rd.Labels = append(rd.Labels, &firmament.Label{Key: label, Value: "true"})
+		}
+		return &firmament.ResourceTopologyNodeDescriptor{ResourceDesc: rd}
+	}
+	state = &memoryState{}
+	state.nodeToRTND = map[string]*firmament.ResourceTopologyNodeDescriptor{
+		"small-ssd": node(1000, "ssd"),
+		"large-ssd": node(4000, "ssd"),
+		"large-hdd": node(4000, "hdd"),
+	}
+	pending := PodIdentifier{Name: "pending", Namespace: "ns"}
+	deferred := PodIdentifier{Name: "deferred", Namespace: "ns"}
+	state.podToTD = map[PodIdentifier]*firmament.TaskDescriptor{
+		pending: {
+			Uid:             1,
+			ResourceRequest: &firmament.ResourceVector{CpuCores: 2000, RamCap: 512},
+			LabelSelectors: []*firmament.LabelSelector{
+				{Type: firmament.LabelSelector_IN_SET, Key: "ssd", Values: []string{"true"}},
+			},
+		},
+	}
+	deferredPods = map[PodIdentifier]*Pod{
+		deferred: {Identifier: deferred, CPURequest: 500, NodeSelector: map[string]string{"hdd": "true"}},
+	}
+	markTaskPending(1)
+	defer MarkTaskPlaced(1)
+	recordPodFailure(pending, "placement failed: conflict")
+	defer forgetPodFailure(pending)
+
+	explanation, ok := Explain(pending)
+	if !ok {
+		t.Fatalf("Explain(%v) = false, expected the pod to be known", pending)
+	}
+	if explanation.State != ExplainPending || explanation.Nodes != 3 || explanation.MatchingNodes != 2 || explanation.CandidateNodes != 1 {
+		t.Errorf("Explain(%v) = %+v, expected pending with 2 matching and 1 candidate of 3 nodes", pending, explanation)
+	}
+	if !reflect.DeepEqual(explanation.Constraints, []string{"ssd in (true)"}) {
+		t.Errorf("Explain(%v) constraints = %v, expected [ssd in (true)]", pending, explanation.Constraints)
+	}
+	if explanation.LastFailure == nil || explanation.LastFailure.Reason != "placement failed: conflict" {
+		t.Errorf("Explain(%v) last failure = %v, expected the recorded failure", pending, explanation.LastFailure)
+	}
+
+	explanation, ok = Explain(deferred)
+	if !ok || explanation.State != ExplainDeferred || explanation.MatchingNodes != 1 || explanation.CandidateNodes != 1 {
+		t.Errorf("Explain(%v) = %+v, expected deferred with 1 candidate node", deferred, explanation)
+	}
+	if _, ok := Explain(PodIdentifier{Name: "unknown", Namespace: "ns"}); ok {
+		t.Errorf("Explain() of an unknown pod = true, expected false")
+	}
+}
+
+func TestMatchesSelectors(t *testing.T) {
+	labels := []*firmament.Label{{Key: "zone", Value: "a"}, {Key: "gpu", Value: "true"}}
+	var testData = []struct {
+		selector *firmament.LabelSelector
+		expected bool
+	}{
+		{&firmament.LabelSelector{Type: firmament.LabelSelector_IN_SET, Key: "zone", Values: []string{"a", "b"}}, true},
+		{&firmament.LabelSelector{Type: firmament.LabelSelector_IN_SET, Key: "zone", Values: []string{"b"}}, false},
+		{&firmament.LabelSelector{Type: firmament.LabelSelector_NOT_IN_SET, Key: "zone", Values: []string{"a"}}, false},
+		{&firmament.LabelSelector{Type: firmament.LabelSelector_NOT_IN_SET, Key: "disk", Values: []string{"ssd"}}, true},
+		{&firmament.LabelSelector{Type: firmament.LabelSelector_EXISTS_KEY, Key: "gpu"}, true},
+		{&firmament.LabelSelector{Type: firmament.LabelSelector_NOT_EXISTS_KEY, Key: "gpu"}, false},
+	}
+	for _, tc := range testData {
+		if got := matchesSelectors(labels, []*firmament.LabelSelector{tc.selector}); got != tc.expected {
+			t.Errorf("matchesSelectors(%s) = %v, expected %v", describeSelector(tc.selector), got, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,288 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/metrics"
+)
+
+// FairSharePolicy shares the capacity of the cluster between the batch pods
+// of the tenants, which are the namespaces, by dominant resource fairness:
+// a tenant is guaranteed its share of the dominant resource it uses, CPU or
+// memory, among the tenants with batch pods, and the capacity the others do
+// not use goes to the tenant which used the least of its share over the
+// window. Firmament is not aware of the tenants, their pods are only
+// submitted to it while the tenant is within its fair share.
+type FairSharePolicy struct {
+	// Shares are the shares of the tenants, DefaultShare that of the others,
+	// 1 if not set.
+	Shares       map[string]float64 `json:"shares,omitempty"`
+	DefaultShare float64            `json:"defaultShare,omitempty"`
+	// Classes are the priority classes of the batch pods, all the pods are
+	// if empty. The system critical pods never are.
+	Classes []string `json:"classes,omitempty"`
+	// WindowSeconds is the time constant of the average usage of the
+	// tenants, so that a tenant bursting after an idle period gets the
+	// spare capacity ahead of those which used it.
+	WindowSeconds int `json:"windowSeconds"`
+}
+
+// LoadFairSharePolicy reads a JSON fair share policy file.
+func LoadFairSharePolicy(path string) (*FairSharePolicy, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	policy := &FairSharePolicy{}
+	if err := json.Unmarshal(data, policy); err != nil {
+		return nil, fmt.Errorf("invalid fair share policy %s: %v", path, err)
+	}
+	for tenant, share := range policy.Shares {
+		if share <= 0 {
+			return nil, fmt.Errorf("invalid fair share policy %s: share of tenant %s is not positive", path, tenant)
+		}
+	}
+	if policy.DefaultShare < 0 || policy.WindowSeconds <= 0 {
+		return nil, fmt.Errorf("invalid fair share policy %s: negative default share or no window", path)
+	}
+	return policy, nil
+}
+
+// share returns the share of the tenant.
+func (p *FairSharePolicy) share(tenant string) float64 {
+	if share, ok := p.Shares[tenant]; ok {
+		return share
+	}
+	if p.DefaultShare > 0 {
+		return p.DefaultShare
+	}
+	return 1
+}
+
+// isBatch returns whether the pod is fair shared.
+func (p *FairSharePolicy) isBatch(pod *Pod) bool {
+	if isSystemCritical(pod) {
+		return false
+	}
+	if len(p.Classes) == 0 {
+		return true
+	}
+	for _, class := range p.Classes {
+		if pod.PriorityClassName == class {
+			return true
+		}
+	}
+	return false
+}
+
+// fairShareTenant is the allocation of a tenant.
+type fairShareTenant struct {
+	share float64
+	// cpu, in millicores, and memKb are requested by the submitted batch
+	// pods of the tenant.
+	cpu, memKb int64
+	tasks      int
+	// average is the dominant share of the tenant averaged over the window,
+	// as of updated.
+	average float64
+	updated time.Time
+	// lastDemand is the last time a batch pod of the tenant asked to be
+	// submitted.
+	lastDemand time.Time
+}
+
+// fairShareTask is a submitted batch pod.
+type fairShareTask struct {
+	tenant     string
+	cpu, memKb int64
+}
+
+// fairShareAllocator admits the submission of the batch pods of the
+// tenants within their fair share.
+type fairShareAllocator struct {
+	mu      sync.Mutex
+	policy  *FairSharePolicy
+	tenants map[string]*fairShareTenant
+	tasks   map[uint64]fairShareTask
+	now     func() time.Time
+	// capacity returns the CPU, in millicores, and memory, in KB, of the
+	// cluster.
+	capacity func() (int64, int64)
+}
+
+func newFairShareAllocator(policy *FairSharePolicy, now func() time.Time, capacity func() (int64, int64)) *fairShareAllocator {
+	return &fairShareAllocator{
+		policy:   policy,
+		tenants:  make(map[string]*fairShareTenant),
+		tasks:    make(map[uint64]fairShareTask),
+		now:      now,
+		capacity: capacity,
+	}
+}
+
+// fairShare allocates the capacity between the batch pods of the tenants,
+// nil if they are not fair shared.
+var fairShare *fairShareAllocator
+
+// clusterCapacity returns the CPU, in millicores, and memory, in KB, of the
+// nodes known to Firmament.
+func clusterCapacity() (int64, int64) {
+	state.nodeMux.RLock()
+	defer state.nodeMux.RUnlock()
+	var cpu, memKb int64
+	for _, rtnd := range state.nodeToRTND {
+		capacity := rtnd.GetResourceDesc().GetResourceCapacity()
+		cpu += int64(capacity.GetCpuCores())
+		memKb += int64(capacity.GetRamCap())
+	}
+	return cpu, memKb
+}
+
+// dominantShare returns the largest fraction of the capacity requested.
+func dominantShare(cpu, memKb, capacityCPU, capacityMemKb int64) float64 {
+	return math.Max(fraction64(float64(cpu), float64(capacityCPU)), fraction64(float64(memKb), float64(capacityMemKb)))
+}
+
+// tenant returns the allocation of the tenant, with its average usage
+// brought up to now. a.mu must be held.
+func (a *fairShareAllocator) tenant(name string, now time.Time, capacityCPU, capacityMemKb int64) *fairShareTenant {
+	t, ok := a.tenants[name]
+	if !ok {
+		t = &fairShareTenant{share: a.policy.share(name), updated: now}
+		a.tenants[name] = t
+		return t
+	}
+	// The usage is constant since the last update.
+	decay := math.Exp(-now.Sub(t.updated).Seconds() / float64(a.policy.WindowSeconds))
+	t.average = t.average*decay + dominantShare(t.cpu, t.memKb, capacityCPU, capacityMemKb)*(1-decay)
+	t.updated = now
+	return t
+}
+
+// isWaiting returns whether batch pods of the tenant recently asked to be
+// submitted, the deferred pods asking again every deferredSubmissionDelay.
+func (t *fairShareTenant) isWaiting(now time.Time) bool {
+	return !t.lastDemand.IsZero() && now.Sub(t.lastDemand) < activeBorrowerWindow
+}
+
+// isActive returns whether the tenant has batch pods submitted or waiting.
+func (t *fairShareTenant) isActive(now time.Time) bool {
+	return t.tasks > 0 || t.isWaiting(now)
+}
+
+// admit returns true if the batch pod can be submitted now: if its tenant
+// stays within its share of the capacity among the active tenants, or if no
+// other tenant waiting for capacity used less of its share over the window.
+func (a *fairShareAllocator) admit(pod *Pod) bool {
+	if !a.policy.isBatch(pod) {
+		return true
+	}
+	capacityCPU, capacityMemKb := a.capacity()
+	if capacityCPU <= 0 || capacityMemKb <= 0 {
+		// The capacity is not known yet.
+		return true
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := a.now()
+	name := pod.Identifier.Namespace
+	t := a.tenant(name, now, capacityCPU, capacityMemKb)
+	t.lastDemand = now
+	totalShares := t.share
+	for other, o := range a.tenants {
+		if other != name && o.isActive(now) {
+			totalShares += o.share
+		}
+	}
+	dominant := dominantShare(t.cpu+pod.CPURequest, t.memKb+pod.MemRequestKb, capacityCPU, capacityMemKb)
+	if dominant <= t.share/totalShares {
+		return true
+	}
+	// The tenant exceeds its share, the spare capacity goes to the waiting
+	// tenant which used the least of its share.
+	for other, o := range a.tenants {
+		if other == name || !o.isWaiting(now) {
+			continue
+		}
+		if a.tenant(other, now, capacityCPU, capacityMemKb).average/o.share < t.average/t.share {
+			return false
+		}
+	}
+	return true
+}
+
+// register accounts the requests of a submitted batch pod.
+func (a *fairShareAllocator) register(pod *Pod, taskID uint64) {
+	if !a.policy.isBatch(pod) {
+		return
+	}
+	capacityCPU, capacityMemKb := a.capacity()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	t := a.tenant(pod.Identifier.Namespace, a.now(), capacityCPU, capacityMemKb)
+	t.cpu += pod.CPURequest
+	t.memKb += pod.MemRequestKb
+	t.tasks++
+	a.tasks[taskID] = fairShareTask{tenant: pod.Identifier.Namespace, cpu: pod.CPURequest, memKb: pod.MemRequestKb}
+	metrics.TenantDominantShare.Set(dominantShare(t.cpu, t.memKb, capacityCPU, capacityMemKb), pod.Identifier.Namespace)
+}
+
+// release stops accounting the requests of a completed or removed batch
+// pod.
+func (a *fairShareAllocator) release(taskID uint64) {
+	capacityCPU, capacityMemKb := a.capacity()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	task, ok := a.tasks[taskID]
+	if !ok {
+		return
+	}
+	delete(a.tasks, taskID)
+	t := a.tenant(task.tenant, a.now(), capacityCPU, capacityMemKb)
+	t.cpu -= task.cpu
+	t.memKb -= task.memKb
+	t.tasks--
+	metrics.TenantDominantShare.Set(dominantShare(t.cpu, t.memKb, capacityCPU, capacityMemKb), task.tenant)
+}
+
+// admitFairShare returns true if the pod can be submitted within the fair
+// share of its tenant.
+func admitFairShare(pod *Pod) bool {
+	return fairShare == nil || fairShare.admit(pod)
+}
+
+// registerFairShareTask accounts the task of the pod in the allocation of
+// its tenant.
+func registerFairShareTask(pod *Pod, taskID uint64) {
+	if fairShare != nil {
+		fairShare.register(pod, taskID)
+	}
+}
+
+// forgetFairShareTask drops the task from the allocation of its tenant.
+func forgetFairShareTask(taskID uint64) {
+	if fairShare != nil {
+		fairShare.release(taskID)
+	}
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFairShareAllocator(t *testing.T) {
+	now := time.Unix(0, 0)
+	policy := &FairSharePolicy{Shares: map[string]float64{"a": 1, "b": 3}, Classes: []string{"batch"}, WindowSeconds: 60}
+	allocator := newFairShareAllocator(policy, func() time.Time { return now }, func() (int64, int64) { return 1000, 1000 })
+	batch := func(namespace string, cpu int64) *Pod {
+		return &Pod{Identifier: PodIdentifier{Namespace: namespace}, CPURequest: cpu, PriorityClassName: "batch"}
+	}
+
+	// A tenant alone gets the whole capacity.
+	if !allocator.admit(batch("a", 600)) {
+		t.Fatal("admit() deferred the pod of the only tenant")
+	}
+	allocator.register(batch("a", 600), 1)
+	now = now.Add(time.Minute)
+	// The other tenant is entitled to 3/4 of the capacity.
+	if !allocator.admit(batch("b", 300)) {
+		t.Fatal("admit() deferred a pod within its tenant's share")
+	}
+	allocator.register(batch("b", 300), 2)
+	// Past its share, a tenant gets the spare capacity while no other tenant
+	// waits.
+	if !allocator.admit(batch("b", 500)) {
+		t.Error("admit() deferred a pod while no other tenant waits")
+	}
+	// The tenant which used the most of its share is deferred.
+	if allocator.admit(batch("a", 100)) {
+		t.Error("admit() admitted a pod past its tenant's share while a tenant which used less of its share waits")
+	}
+	if !allocator.admit(batch("b", 500)) {
+		t.Error("admit() deferred a pod of the tenant which used the least of its share")
+	}
+	// The pods which are not batch are not fair shared.
+	if !allocator.admit(&Pod{Identifier: PodIdentifier{Namespace: "a"}, CPURequest: 500}) {
+		t.Error("admit() deferred a pod which is not batch")
+	}
+
+	// Once its pods complete, the tenant gets its share back.
+	allocator.release(1)
+	allocator.release(1)
+	if tenant := allocator.tenants["a"]; tenant.cpu != 0 || tenant.tasks != 0 {
+		t.Errorf("tenant a requests %d CPU by %d tasks after its pods completed, expected none", tenant.cpu, tenant.tasks)
+	}
+	// Over the window, the tenant which bursts after an idle period gets the
+	// spare capacity ahead of the one which used it.
+	now = now.Add(10 * time.Minute)
+	if !allocator.admit(batch("a", 100)) {
+		t.Error("admit() deferred a pod within its tenant's share")
+	}
+	if allocator.admit(batch("b", 500)) {
+		t.Error("admit() admitted a pod past its tenant's share while an idle tenant waits")
+	}
+}
+
+func TestFairShareAllocatorUnknownCapacity(t *testing.T) {
+	policy := &FairSharePolicy{WindowSeconds: 60}
+	allocator := newFairShareAllocator(policy, time.Now, func() (int64, int64) { return 0, 0 })
+	if !allocator.admit(&Pod{Identifier: PodIdentifier{Namespace: "a"}, CPURequest: 100}) {
+		t.Error("admit() deferred a pod before the capacity is known")
+	}
+}
@@ -0,0 +1,249 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+)
+
+// FallbackStrategy is the way the fallback scheduler picks the node of a
+// pod among the nodes it fits on.
+type FallbackStrategy string
+
+const (
+	// FirstFit places the pods on the first node, by name, they fit on.
+	FirstFit FallbackStrategy = "first-fit"
+	// BestFit places the pods on the node they leave the least free
+	// capacity on.
+	BestFit FallbackStrategy = "best-fit"
+)
+
+// FallbackPolicy configures the greedy scheduler placing the pending pods
+// on the state cached by Poseidon while Firmament is unavailable.
+type FallbackPolicy struct {
+	// After is the time Firmament must be unavailable for before the
+	// fallback scheduler places pods.
+	After    time.Duration
+	Strategy FallbackStrategy
+	// MinPriority is the lowest Firmament priority of the tasks placed by
+	// the fallback scheduler, so that it can be restricted to the critical
+	// pods.
+	MinPriority uint32
+}
+
+// NewFallbackPolicy returns the fallback policy placing the pods after an
+// outage of Firmament longer than after, nil if after is not positive.
+func NewFallbackPolicy(after time.Duration, strategy string, minPriority uint32) (*FallbackPolicy, error) {
+	if after <= 0 {
+		return nil, nil
+	}
+	switch FallbackStrategy(strategy) {
+	case FirstFit, BestFit:
+	default:
+		return nil, fmt.Errorf("invalid fallback strategy %q, expected %s or %s", strategy, FirstFit, BestFit)
+	}
+	return &FallbackPolicy{After: after, Strategy: FallbackStrategy(strategy), MinPriority: minPriority}, nil
+}
+
+// FallbackPlacement is a placement computed by the fallback scheduler.
+type FallbackPlacement struct {
+	TaskID uint64
+	Pod    PodIdentifier
+	Node   string
+	// CPURequest is in millicores and MemRequestKb in KB.
+	CPURequest   int64
+	MemRequestKb int64
+}
+
+// fallbackNode is the free capacity of a node seen by the fallback
+// scheduler.
+type fallbackNode struct {
+	name   string
+	labels []*firmament.Label
+	// freeCPU is in millicores and freeMemKb in KB.
+	freeCPU, freeMemKb         int64
+	capacityCPU, capacityMemKb int64
+}
+
+var (
+	fallbackMux sync.Mutex
+	// fallbackBound are the pods bound by the fallback scheduler which the
+	// pod index did not observe bound yet, whose requests must still be
+	// accounted on their node.
+	fallbackBound = make(map[PodIdentifier]FallbackPlacement)
+)
+
+// PlaceWithoutFirmament greedily places the tasks waiting for a placement
+// on the nodes they fit on, with the requests of the pods already bound
+// deducted from the node capacity. The tasks are placed by decreasing
+// priority, then in submission order. The pods of pod groups are left to
+// Firmament, as they must be placed together.
+func (p *FallbackPolicy) PlaceWithoutFirmament() []FallbackPlacement {
+	type candidate struct {
+		td        *firmament.TaskDescriptor
+		pod       PodIdentifier
+		submitted time.Time
+	}
+	pendingMux.Lock()
+	submitted := make(map[uint64]time.Time, len(pendingTasks))
+	for taskID, task := range pendingTasks {
+		submitted[taskID] = task.submitted
+	}
+	pendingMux.Unlock()
+	gangMux.Lock()
+	for taskID := range taskGroups {
+		delete(submitted, taskID)
+	}
+	gangMux.Unlock()
+
+	var candidates []candidate
+	for taskID, submittedAt := range submitted {
+		podIdentifier, td, ok := state.taskOf(taskID)
+		if !ok || td.GetPriority() < p.MinPriority {
+			continue
+		}
+		candidates = append(candidates, candidate{td: td, pod: podIdentifier, submitted: submittedAt})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].td.GetPriority() != candidates[j].td.GetPriority() {
+			return candidates[i].td.GetPriority() > candidates[j].td.GetPriority()
+		}
+		return candidates[i].submitted.Before(candidates[j].submitted)
+	})
+
+	nodes := fallbackNodes()
+	var placements []FallbackPlacement
+	for _, c := range candidates {
+		cpu := int64(c.td.GetResourceRequest().GetCpuCores())
+		memKb := int64(c.td.GetResourceRequest().GetRamCap())
+		node := p.pickNode(nodes, c.td.GetLabelSelectors(), nodePreferencesOf(c.td.GetUid()), cpu, memKb)
+		if node == nil {
+			continue
+		}
+		node.freeCPU -= cpu
+		node.freeMemKb -= memKb
+		placements = append(placements, FallbackPlacement{
+			TaskID:       c.td.GetUid(),
+			Pod:          c.pod,
+			Node:         node.name,
+			CPURequest:   cpu,
+			MemRequestKb: memKb,
+		})
+	}
+	return placements
+}
+
+// fallbackNodes returns the nodes which are not quarantined, in name order,
+// with their free capacity.
+func fallbackNodes() []*fallbackNode {
+	var nodes []*fallbackNode
+	state.nodeMux.RLock()
+	for nodeName, rtnd := range state.nodeToRTND {
+		capacity := rtnd.GetResourceDesc().GetResourceCapacity()
+		nodes = append(nodes, &fallbackNode{
+			name:          nodeName,
+			labels:        rtnd.GetResourceDesc().GetLabels(),
+			capacityCPU:   int64(capacity.GetCpuCores()),
+			capacityMemKb: int64(capacity.GetRamCap()),
+		})
+	}
+	state.nodeMux.RUnlock()
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].name < nodes[j].name })
+
+	fallbackMux.Lock()
+	defer fallbackMux.Unlock()
+	reserved := make(map[string][]FallbackPlacement)
+	for podIdentifier, placement := range fallbackBound {
+		if _, indexed := PodNodeName(podIdentifier); indexed {
+			delete(fallbackBound, podIdentifier)
+			continue
+		}
+		reserved[placement.Node] = append(reserved[placement.Node], placement)
+	}
+	var available []*fallbackNode
+	for _, node := range nodes {
+		if IsNodeQuarantined(node.name) {
+			continue
+		}
+		node.freeCPU, node.freeMemKb = node.capacityCPU, node.capacityMemKb
+		for _, pod := range podsByNode.podsOnNode(node.name) {
+			if pod.DaemonSet && discountDaemonSetOverhead {
+				// Already discounted from the capacity.
+				continue
+			}
+			node.freeCPU -= pod.CPURequest
+			node.freeMemKb -= pod.MemRequestKb
+		}
+		for _, placement := range reserved[node.name] {
+			node.freeCPU -= placement.CPURequest
+			node.freeMemKb -= placement.MemRequestKb
+		}
+		available = append(available, node)
+	}
+	return available
+}
+
+// pickNode returns the node the task fits on which best matches its node
+// preferences, picked according to the strategy among the equally preferred
+// ones, nil if it fits on none.
+func (p *FallbackPolicy) pickNode(nodes []*fallbackNode, selectors []*firmament.LabelSelector, preferences []NodePreference,
+	cpu, memKb int64) *fallbackNode {
+	var best *fallbackNode
+	var bestScore int
+	var bestLeft float64
+	for _, node := range nodes {
+		if node.freeCPU < cpu || node.freeMemKb < memKb || !matchesSelectors(node.labels, selectors) {
+			continue
+		}
+		score := preferenceScore(preferences, node.name, node.labels)
+		left := fraction(node.freeCPU-cpu, node.capacityCPU) + fraction(node.freeMemKb-memKb, node.capacityMemKb)
+		if best == nil || score > bestScore || (score == bestScore && p.Strategy == BestFit && left < bestLeft) {
+			best, bestScore, bestLeft = node, score, left
+		}
+	}
+	return best
+}
+
+func fraction(value, total int64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return float64(value) / float64(total)
+}
+
+// BindFallbackPlacement binds the pod of a fallback placement. Its task is
+// no longer pending, and the placement Firmament computes for it once it is
+// available again is ignored, as for the pods bound before it restarted.
+func BindFallbackPlacement(placement FallbackPlacement) error {
+	if err := BindPodToNode(placement.Pod.Name, placement.Pod.Namespace, placement.Node); err != nil {
+		recordPodFailure(placement.Pod, fmt.Sprintf("fallback bind failed: %v", err))
+		return err
+	}
+	fallbackMux.Lock()
+	fallbackBound[placement.Pod] = placement
+	fallbackMux.Unlock()
+	MarkTaskPlaced(placement.TaskID)
+	resyncMux.Lock()
+	resyncedTasks[placement.TaskID] = true
+	resyncMux.Unlock()
+	return nil
+}
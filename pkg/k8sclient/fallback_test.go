@@ -0,0 +1,126 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNewFallbackPolicy(t *testing.T) {
+	if policy, err := NewFallbackPolicy(0, "first-fit", 0); policy != nil || err != nil {
+		t.Errorf("NewFallbackPolicy(0) = %v, %v, expected no fallback", policy, err)
+	}
+	if _, err := NewFallbackPolicy(time.Minute, "random", 0); err == nil {
+		t.Error("NewFallbackPolicy() with an invalid strategy succeeded")
+	}
+}
+
+func TestPlaceWithoutFirmament(t *testing.T) {
+	fallbackNode := func(name string, cpu float32, zone string) *firmament.ResourceTopologyNodeDescriptor {
+		return &firmament.ResourceTopologyNodeDescriptor{ResourceDesc: &firmament.ResourceDescriptor{
+			Uuid:             name,
+			ResourceCapacity: &firmament.ResourceVector{CpuCores: cpu, RamCap: 1000000},
+			Labels:           []*firmament.Label{{Key: "zone", Value: zone}},
+		}}
+	}
+	task := func(taskID uint64, cpu float32, priority uint32, selectors ...*firmament.LabelSelector) *firmament.TaskDescriptor {
+		return &firmament.TaskDescriptor{
+			Uid:             taskID,
+			Priority:        priority,
+			ResourceRequest: &firmament.ResourceVector{CpuCores: cpu, RamCap: 1000},
+			LabelSelectors:  selectors,
+		}
+	}
+	state = &memoryState{}
+	state.nodeToRTND = map[string]*firmament.ResourceTopologyNodeDescriptor{
+		"node0": fallbackNode("node0", 1000, "a"),
+		"node1": fallbackNode("node1", 4000, "a"),
+		"node2": fallbackNode("node2", 4000, "b"),
+	}
+	state.podToTD = map[PodIdentifier]*firmament.TaskDescriptor{
+		{Name: "small", Namespace: "ns"}:    task(1, 500, 0),
+		{Name: "critical", Namespace: "ns"}: task(2, 3000, 10),
+		{Name: "zone-b", Namespace: "ns"}:   task(3, 500, 0, &firmament.LabelSelector{Type: firmament.LabelSelector_IN_SET, Key: "zone", Values: []string{"b"}}),
+		{Name: "huge", Namespace: "ns"}:     task(4, 8000, 0),
+	}
+	state.taskIDToPod = make(map[uint64]PodIdentifier)
+	for podIdentifier, td := range state.podToTD {
+		state.taskIDToPod[td.GetUid()] = podIdentifier
+		markTaskPending(td.GetUid())
+		defer MarkTaskPlaced(td.GetUid())
+	}
+	// node2 has 2000 millicores left.
+	podsByNode = newPodIndex()
+	defer func() { podsByNode = newPodIndex() }()
+	podsByNode.update(&v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "running", Namespace: "ns"},
+		Spec:       v1.PodSpec{NodeName: "node2"},
+		Status:     v1.PodStatus{Phase: v1.PodRunning},
+	}, 2000, 1000)
+
+	var testData = []struct {
+		name     string
+		policy   FallbackPolicy
+		expected map[string]string
+	}{
+		{
+			name:   "first fit",
+			policy: FallbackPolicy{Strategy: FirstFit},
+			// The critical pod is placed first, on the first node it fits on.
+			expected: map[string]string{"critical": "node1", "small": "node0", "zone-b": "node2"},
+		},
+		{
+			name:   "best fit",
+			policy: FallbackPolicy{Strategy: BestFit},
+			// The small pod fills the node left with the least free capacity.
+			expected: map[string]string{"critical": "node1", "small": "node1", "zone-b": "node2"},
+		},
+		{
+			name:     "critical pods only",
+			policy:   FallbackPolicy{Strategy: BestFit, MinPriority: 10},
+			expected: map[string]string{"critical": "node1"},
+		},
+	}
+	for _, tc := range testData {
+		placed := make(map[string]string)
+		for _, placement := range tc.policy.PlaceWithoutFirmament() {
+			placed[placement.Pod.Name] = placement.Node
+		}
+		if !reflect.DeepEqual(placed, tc.expected) {
+			t.Errorf("%s: PlaceWithoutFirmament() = %v, expected %v", tc.name, placed, tc.expected)
+		}
+	}
+
+	// The pods bound by the fallback scheduler are accounted until they are
+	// indexed.
+	fallbackBound = map[PodIdentifier]FallbackPlacement{
+		{Name: "bound", Namespace: "ns"}: {Node: "node1", CPURequest: 2000},
+	}
+	defer func() { fallbackBound = make(map[PodIdentifier]FallbackPlacement) }()
+	policy := FallbackPolicy{Strategy: FirstFit}
+	for _, placement := range policy.PlaceWithoutFirmament() {
+		if placement.Pod.Name == "critical" {
+			t.Errorf("critical pod placed on %s, which has only 2000 millicores left", placement.Node)
+		}
+	}
+}
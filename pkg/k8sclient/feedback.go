@@ -0,0 +1,79 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"fmt"
+
+	"github.com/golang/glog"
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+// DeltaResult is the outcome of applying a scheduling delta to the cluster.
+type DeltaResult string
+
+const (
+	// DeltaApplied means the delta was applied.
+	DeltaApplied DeltaResult = "applied"
+	// DeltaFailed means the delta could not be applied and Firmament was
+	// told so.
+	DeltaFailed DeltaResult = "failed"
+	// DeltaSuperseded means the pod changed since Firmament computed the
+	// delta, e.g. it was deleted or bound by another scheduler, and the pod
+	// watcher reports the change to Firmament.
+	DeltaSuperseded DeltaResult = "superseded"
+)
+
+// PlacementFailed reports to Firmament that the placement of the task could
+// not be applied, so that its model does not keep the task on the node. The
+// task is submitted again to be placed by a later scheduling cycle, unless
+// the pod was bound or deleted in the meantime.
+func PlacementFailed(fc firmament.FirmamentSchedulerClient, taskID uint64, err error) DeltaResult {
+	switch classifyBindError(err) {
+	case BindConflict, BindPodNotFound:
+		MarkTaskPlaced(taskID)
+		return DeltaSuperseded
+	}
+	podIdentifier, taskDescription, ok := state.taskDescription(taskID)
+	if !ok {
+		// The pod was deleted in the meantime.
+		MarkTaskPlaced(taskID)
+		return DeltaSuperseded
+	}
+	glog.Infof("Submitting pod %v again after its failed placement", podIdentifier)
+	unplaceGangTask(taskID)
+	recordPodFailure(podIdentifier, fmt.Sprintf("placement failed: %v", err))
+	handleError(firmament.TaskRemoved(fc, &firmament.TaskUID{TaskUid: taskID}))
+	handleError(firmament.TaskSubmitted(fc, taskDescription))
+	markTaskPending(taskID)
+	return DeltaFailed
+}
+
+// PreemptionFailed handles a preemption or migration of the task which could
+// not be applied. Firmament considers the task evicted, so its next
+// placement is ignored while the pod keeps running.
+func PreemptionFailed(taskID uint64, err error) DeltaResult {
+	if errors.IsNotFound(err) {
+		return DeltaSuperseded
+	}
+	preemptionMux.Lock()
+	refusedPreemptions[taskID] = true
+	preemptionMux.Unlock()
+	return DeltaFailed
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+
+	"github.com/golang/mock/gomock"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestPlacementFailed(t *testing.T) {
+	notFound := errors.NewNotFound(schema.GroupResource{Resource: "pods"}, "pod0")
+	var testData = []struct {
+		name            string
+		err             error
+		expected        DeltaResult
+		expectedPending bool
+	}{
+		{name: "pod deleted", err: notFound, expected: DeltaSuperseded},
+		{name: "node removed", err: errors.NewNotFound(schema.GroupResource{Resource: "nodes"}, "node0"), expected: DeltaFailed,
+			expectedPending: true},
+		{name: "api failure", err: fmt.Errorf("timeout"), expected: DeltaFailed, expectedPending: true},
+	}
+	for _, tc := range testData {
+		mockCtrl := gomock.NewController(t)
+		fc := firmament.NewMockFirmamentSchedulerClient(mockCtrl)
+		state = &memoryState{}
+		state.jobIDToJD = map[string]*firmament.JobDescriptor{"job0": {Uuid: "job0"}}
+		state.podToTD = map[PodIdentifier]*firmament.TaskDescriptor{{Name: "pod0", Namespace: "ns"}: {Uid: 1, JobId: "job0"}}
+		state.taskIDToPod = map[uint64]PodIdentifier{1: {Name: "pod0", Namespace: "ns"}}
+		markTaskPending(1)
+		if tc.expectedPending {
+			fc.EXPECT().TaskRemoved(gomock.Any(), &firmament.TaskUID{TaskUid: 1}).Return(
+				&firmament.TaskRemovedResponse{Type: firmament.TaskReplyType_TASK_REMOVED_OK}, nil)
+			fc.EXPECT().TaskSubmitted(gomock.Any(), gomock.Any()).Return(
+				&firmament.TaskSubmittedResponse{Type: firmament.TaskReplyType_TASK_SUBMITTED_OK}, nil)
+		}
+		if got := PlacementFailed(fc, 1, tc.err); got != tc.expected {
+			t.Errorf("%s: PlacementFailed() = %v, expected %v", tc.name, got, tc.expected)
+		}
+		if got := isTaskPending(1); got != tc.expectedPending {
+			t.Errorf("%s: task pending = %v, expected %v", tc.name, got, tc.expectedPending)
+		}
+		MarkTaskPlaced(1)
+		mockCtrl.Finish()
+	}
+}
+
+func TestPreemptionFailed(t *testing.T) {
+	notFound := errors.NewNotFound(schema.GroupResource{Resource: "pods"}, "pod0")
+	if got := PreemptionFailed(1, notFound); got != DeltaSuperseded || IsPreemptionRefused(1) {
+		t.Errorf("PreemptionFailed() = %v for a deleted pod, expected %v without ignoring its next placement", got, DeltaSuperseded)
+	}
+	if got := PreemptionFailed(1, fmt.Errorf("timeout")); got != DeltaFailed || !IsPreemptionRefused(1) {
+		t.Errorf("PreemptionFailed() = %v, expected %v and its next placement ignored", got, DeltaFailed)
+	}
+}
@@ -0,0 +1,316 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/kubernetes-sigs/poseidon/pkg/features"
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+	"github.com/kubernetes-sigs/poseidon/pkg/metrics"
+)
+
+const (
+	// PodGroupAnnotation is the pod annotation naming the group of the pod,
+	// in the namespace of the pod. The placed pods of a group are not bound
+	// until minAvailable pods of the group are placed.
+	PodGroupAnnotation = "poseidon.k8s.io/pod-group"
+	// PodGroupMinAvailableAnnotation is the pod annotation holding the
+	// minAvailable of its group, 1 if it is not set.
+	PodGroupMinAvailableAnnotation = "poseidon.k8s.io/pod-group-min-available"
+)
+
+// GangPolicy bounds the time the placements of a pod group are held.
+type GangPolicy struct {
+	// Timeout is the time the placed pods of a group wait for minAvailable
+	// pods of the group to be placed. After it, the capacity they reserve
+	// is released so that a group which can never fit does not block the
+	// cluster. 0 holds them forever.
+	Timeout time.Duration
+	// Backoff is the time during which the placements of the pods of a
+	// timed out group are released right away.
+	Backoff time.Duration
+}
+
+// GangPlacement is a placement of a pod of a group.
+type GangPlacement struct {
+	Delta *firmament.SchedulingDelta
+	Pod   PodIdentifier
+	Node  string
+}
+
+// podGroupKey identifies a pod group.
+type podGroupKey struct {
+	namespace string
+	name      string
+}
+
+// podGroup is the scheduling state of a pod group.
+type podGroup struct {
+	minAvailable int
+	// tasks are the submitted tasks of the group.
+	tasks map[uint64]PodIdentifier
+	// held are the placements waiting for minAvailable placed pods.
+	held []GangPlacement
+	// heldSince is the time the first held placement was held.
+	heldSince time.Time
+	// placed are the tasks of the group whose placements were released to
+	// be bound.
+	placed map[uint64]bool
+	// admitted is set once minAvailable pods were placed, the later pods
+	// of the group are bound right away until fewer than minAvailable pods
+	// remain placed.
+	admitted bool
+	// backoffUntil is the end of the backoff of a timed out group.
+	backoffUntil time.Time
+}
+
+var (
+	gangPolicy *GangPolicy
+	gangMux    sync.Mutex
+	podGroups  = make(map[podGroupKey]*podGroup)
+	taskGroups = make(map[uint64]podGroupKey)
+)
+
+// podGroupOf returns the group of the pod and its minAvailable, or false if
+// the pod is not in a group.
+func podGroupOf(pod *Pod) (podGroupKey, int, bool, error) {
+	name, ok := pod.Annotations[PodGroupAnnotation]
+	if !ok || name == "" {
+		return podGroupKey{}, 0, false, nil
+	}
+	minAvailable := 1
+	if value, ok := pod.Annotations[PodGroupMinAvailableAnnotation]; ok {
+		var err error
+		minAvailable, err = strconv.Atoi(value)
+		if err != nil || minAvailable < 1 {
+			return podGroupKey{}, 0, false, fmt.Errorf("invalid %s annotation %q", PodGroupMinAvailableAnnotation, value)
+		}
+	}
+	return podGroupKey{namespace: pod.Identifier.Namespace, name: name}, minAvailable, true, nil
+}
+
+// checkPodGroupAnnotations returns an error if the pod group annotations of
+// the pod are invalid.
+func checkPodGroupAnnotations(pod *Pod) error {
+	_, _, _, err := podGroupOf(pod)
+	return err
+}
+
+// registerGangTask records the task of the pod if it is in a group. The
+// pods are scheduled on their own while gang scheduling is disabled.
+func registerGangTask(pod *Pod, taskID uint64) {
+	key, minAvailable, ok, _ := podGroupOf(pod)
+	if !ok || !features.Enabled(features.GangScheduling) {
+		return
+	}
+	gangMux.Lock()
+	defer gangMux.Unlock()
+	group, ok := podGroups[key]
+	if !ok {
+		group = &podGroup{tasks: make(map[uint64]PodIdentifier), placed: make(map[uint64]bool)}
+		podGroups[key] = group
+	}
+	// The latest pod sets the minAvailable of its group.
+	group.minAvailable = minAvailable
+	group.tasks[taskID] = pod.Identifier
+	taskGroups[taskID] = key
+}
+
+// forgetGangTask drops a removed task from its group.
+func forgetGangTask(taskID uint64) {
+	gangMux.Lock()
+	defer gangMux.Unlock()
+	key, ok := taskGroups[taskID]
+	if !ok {
+		return
+	}
+	delete(taskGroups, taskID)
+	group := podGroups[key]
+	delete(group.tasks, taskID)
+	group.dropHeld(taskID)
+	group.unplace(key, taskID)
+	if len(group.tasks) == 0 {
+		delete(podGroups, key)
+	}
+	updateHeldGangPlacements()
+}
+
+// dropHeld drops the held placement of the task, and returns whether the
+// placement of the task was held.
+func (group *podGroup) dropHeld(taskID uint64) bool {
+	for i, placement := range group.held {
+		if placement.Delta.GetTaskId() == taskID {
+			group.held = append(group.held[:i], group.held[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// unplace drops the task from the placed tasks of the group. The group is
+// no longer admitted once fewer than minAvailable pods remain placed, so
+// that the pods placed again are held until the group is complete.
+func (group *podGroup) unplace(key podGroupKey, taskID uint64) {
+	delete(group.placed, taskID)
+	if group.admitted && len(group.placed) < group.minAvailable {
+		glog.Infof("Pod group %s/%s has %d of its %d pods placed, holding the placements of its pods",
+			key.namespace, key.name, len(group.placed), group.minAvailable)
+		group.admitted = false
+	}
+}
+
+// unplaceGangTask drops the task from the placed tasks of its group, e.g.
+// when its bind failed.
+func unplaceGangTask(taskID uint64) {
+	gangMux.Lock()
+	defer gangMux.Unlock()
+	if key, ok := taskGroups[taskID]; ok {
+		podGroups[key].unplace(key, taskID)
+	}
+}
+
+// EvictGangPlacement handles a preemption or migration of a task whose
+// placement is held: the held placement is dropped on preemption, and moved
+// to the node the task migrates to. It returns false if the placement of the
+// task is not held, e.g. its pod is bound and must be evicted.
+func EvictGangPlacement(delta *firmament.SchedulingDelta, nodeName string) bool {
+	gangMux.Lock()
+	defer gangMux.Unlock()
+	key, ok := taskGroups[delta.GetTaskId()]
+	if !ok {
+		return false
+	}
+	group := podGroups[key]
+	if delta.GetType() == firmament.SchedulingDelta_MIGRATE {
+		for i := range group.held {
+			if group.held[i].Delta.GetTaskId() == delta.GetTaskId() {
+				group.held[i].Node = nodeName
+				group.held[i].Delta = &firmament.SchedulingDelta{
+					TaskId:     delta.GetTaskId(),
+					ResourceId: delta.GetResourceId(),
+					Type:       firmament.SchedulingDelta_PLACE,
+				}
+				return true
+			}
+		}
+		return false
+	}
+	held := group.dropHeld(delta.GetTaskId())
+	updateHeldGangPlacements()
+	return held
+}
+
+// ErrPodGroupBackedOff is the error of the placements of the pods of a
+// pod group which timed out, until the end of its backoff.
+var ErrPodGroupBackedOff = errors.New("pod group is backed off")
+
+// IsPodGroupBackedOff returns whether the task is in a pod group which timed
+// out and is backed off, its placements must not reserve capacity.
+func IsPodGroupBackedOff(taskID uint64, now time.Time) bool {
+	gangMux.Lock()
+	defer gangMux.Unlock()
+	key, ok := taskGroups[taskID]
+	return ok && now.Before(podGroups[key].backoffUntil)
+}
+
+// HoldGangPlacement holds the placement of a pod of a group until
+// minAvailable pods of its group are placed. It returns the placements to
+// bind: the placement itself if the pod is not in a group or its group is
+// already admitted, all the held placements of the group once it reaches
+// minAvailable, or none while the placement is held.
+func HoldGangPlacement(delta *firmament.SchedulingDelta, podIdentifier PodIdentifier, nodeName string, now time.Time) []GangPlacement {
+	placement := GangPlacement{Delta: delta, Pod: podIdentifier, Node: nodeName}
+	gangMux.Lock()
+	defer gangMux.Unlock()
+	key, ok := taskGroups[delta.GetTaskId()]
+	if !ok {
+		return []GangPlacement{placement}
+	}
+	group := podGroups[key]
+	if group.admitted {
+		group.placed[delta.GetTaskId()] = true
+		return []GangPlacement{placement}
+	}
+	if len(group.held) == 0 {
+		group.heldSince = now
+	}
+	group.held = append(group.held, placement)
+	// The pods still placed count towards minAvailable.
+	if len(group.placed)+len(group.held) < group.minAvailable {
+		updateHeldGangPlacements()
+		return nil
+	}
+	glog.Infof("Pod group %s/%s reached its minAvailable %d, binding its pods", key.namespace, key.name, group.minAvailable)
+	placements := group.held
+	for _, placement := range placements {
+		group.placed[placement.Delta.GetTaskId()] = true
+	}
+	group.held = nil
+	group.admitted = true
+	updateHeldGangPlacements()
+	return placements
+}
+
+// ReleaseExpiredGangs releases the capacity reserved by the pod groups
+// which did not reach their minAvailable within the gang timeout: their held
+// placements are reported failed to Firmament, and the placements of their
+// pods are released until the end of the gang backoff. It returns the number
+// of timed out groups.
+func ReleaseExpiredGangs(fc firmament.FirmamentSchedulerClient, now time.Time) int {
+	if gangPolicy == nil || gangPolicy.Timeout <= 0 {
+		return 0
+	}
+	var expired int
+	var released []uint64
+	gangMux.Lock()
+	for key, group := range podGroups {
+		if len(group.held) == 0 || now.Sub(group.heldSince) < gangPolicy.Timeout {
+			continue
+		}
+		glog.Warningf("Pod group %s/%s has %d of its %d pods placed after %v, releasing their capacity for %v",
+			key.namespace, key.name, len(group.held), group.minAvailable, gangPolicy.Timeout, gangPolicy.Backoff)
+		metrics.PodGroupTimeouts.Inc(key.namespace)
+		expired++
+		for _, placement := range group.held {
+			released = append(released, placement.Delta.GetTaskId())
+		}
+		group.held = nil
+		group.backoffUntil = now.Add(gangPolicy.Backoff)
+	}
+	updateHeldGangPlacements()
+	gangMux.Unlock()
+	for _, taskID := range released {
+		PlacementFailed(fc, taskID, fmt.Errorf("pod group timed out after %v", gangPolicy.Timeout))
+	}
+	return expired
+}
+
+// updateHeldGangPlacements updates the held placements gauge. gangMux must
+// be held.
+func updateHeldGangPlacements() {
+	var held int
+	for _, group := range podGroups {
+		held += len(group.held)
+	}
+	metrics.HeldPodGroupPlacements.Set(float64(held))
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kubernetes-sigs/poseidon/pkg/features"
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+)
+
+// gangPod returns a pod of the group with the given minAvailable.
+func gangPod(name, group, minAvailable string) *Pod {
+	return &Pod{
+		Identifier:  PodIdentifier{Name: name, Namespace: "ns"},
+		Annotations: map[string]string{PodGroupAnnotation: group, PodGroupMinAvailableAnnotation: minAvailable},
+	}
+}
+
+func TestPodGroupOf(t *testing.T) {
+	var testData = []struct {
+		name         string
+		pod          *Pod
+		inGroup      bool
+		minAvailable int
+		invalid      bool
+	}{
+		{name: "no group", pod: &Pod{}},
+		{name: "group", pod: gangPod("pod0", "group0", "3"), inGroup: true, minAvailable: 3},
+		{name: "default minAvailable", pod: &Pod{Annotations: map[string]string{PodGroupAnnotation: "group0"}}, inGroup: true, minAvailable: 1},
+		{name: "invalid minAvailable", pod: gangPod("pod0", "group0", "many"), invalid: true},
+		{name: "zero minAvailable", pod: gangPod("pod0", "group0", "0"), invalid: true},
+	}
+	for _, tc := range testData {
+		_, minAvailable, inGroup, err := podGroupOf(tc.pod)
+		if (err != nil) != tc.invalid || inGroup != tc.inGroup || minAvailable != tc.minAvailable {
+			t.Errorf("%s: podGroupOf() = %d, %v, %v, expected %d, %v, invalid %v", tc.name, minAvailable, inGroup, err,
+				tc.minAvailable, tc.inGroup, tc.invalid)
+		}
+	}
+}
+
+func TestHoldGangPlacement(t *testing.T) {
+	podGroups = make(map[podGroupKey]*podGroup)
+	taskGroups = make(map[uint64]podGroupKey)
+	now := time.Now()
+	for i := uint64(1); i <= 3; i++ {
+		registerGangTask(gangPod(fmt.Sprintf("pod%d", i), "group0", "2"), i)
+	}
+	delta := func(taskID uint64) *firmament.SchedulingDelta {
+		return &firmament.SchedulingDelta{TaskId: taskID, Type: firmament.SchedulingDelta_PLACE}
+	}
+
+	if placements := HoldGangPlacement(delta(4), PodIdentifier{Name: "other"}, "node0", now); len(placements) != 1 {
+		t.Errorf("HoldGangPlacement() = %v for a pod out of any group, expected its placement", placements)
+	}
+	if placements := HoldGangPlacement(delta(1), PodIdentifier{Name: "pod1"}, "node0", now); len(placements) != 0 {
+		t.Errorf("HoldGangPlacement() = %v below minAvailable, expected the placement held", placements)
+	}
+	placements := HoldGangPlacement(delta(2), PodIdentifier{Name: "pod2"}, "node1", now)
+	if len(placements) != 2 || placements[0].Node != "node0" || placements[1].Node != "node1" {
+		t.Errorf("HoldGangPlacement() = %v at minAvailable, expected both placements", placements)
+	}
+	if placements := HoldGangPlacement(delta(3), PodIdentifier{Name: "pod3"}, "node2", now); len(placements) != 1 {
+		t.Errorf("HoldGangPlacement() = %v in an admitted group, expected its placement", placements)
+	}
+
+	for i := uint64(1); i <= 3; i++ {
+		forgetGangTask(i)
+	}
+	if len(podGroups) != 0 || len(taskGroups) != 0 {
+		t.Errorf("pod groups %v and tasks %v left after their pods were removed", podGroups, taskGroups)
+	}
+
+	// The pods of a group are placed on their own without gang scheduling.
+	defer features.DefaultGate.Set("GangScheduling=true")
+	features.DefaultGate.Set("GangScheduling=false")
+	registerGangTask(gangPod("pod1", "group0", "2"), 1)
+	if placements := HoldGangPlacement(delta(1), PodIdentifier{Name: "pod1"}, "node0", now); len(placements) != 1 {
+		t.Errorf("HoldGangPlacement() = %v with gang scheduling disabled, expected its placement", placements)
+	}
+}
+
+func TestGangReadmission(t *testing.T) {
+	podGroups = make(map[podGroupKey]*podGroup)
+	taskGroups = make(map[uint64]podGroupKey)
+	now := time.Now()
+	for i := uint64(1); i <= 4; i++ {
+		registerGangTask(gangPod(fmt.Sprintf("pod%d", i), "group0", "2"), i)
+	}
+	defer func() {
+		for i := uint64(1); i <= 4; i++ {
+			forgetGangTask(i)
+		}
+	}()
+	delta := func(taskID uint64) *firmament.SchedulingDelta {
+		return &firmament.SchedulingDelta{TaskId: taskID, Type: firmament.SchedulingDelta_PLACE}
+	}
+	HoldGangPlacement(delta(1), PodIdentifier{Name: "pod1"}, "node0", now)
+	if placements := HoldGangPlacement(delta(2), PodIdentifier{Name: "pod2"}, "node0", now); len(placements) != 2 {
+		t.Fatalf("HoldGangPlacement() = %v at minAvailable, expected both placements", placements)
+	}
+	// The group drops below minAvailable once a placed pod is removed.
+	forgetGangTask(1)
+	if placements := HoldGangPlacement(delta(3), PodIdentifier{Name: "pod3"}, "node1", now); len(placements) != 1 ||
+		placements[0].Pod.Name != "pod3" {
+		t.Errorf("HoldGangPlacement() = %v with a placed pod left, expected the placement completing the group", placements)
+	}
+	unplaceGangTask(2)
+	unplaceGangTask(3)
+	if placements := HoldGangPlacement(delta(4), PodIdentifier{Name: "pod4"}, "node1", now); len(placements) != 0 {
+		t.Errorf("HoldGangPlacement() = %v once no pod is placed, expected the placement held", placements)
+	}
+}
+
+func TestEvictGangPlacement(t *testing.T) {
+	podGroups = make(map[podGroupKey]*podGroup)
+	taskGroups = make(map[uint64]podGroupKey)
+	now := time.Now()
+	for i := uint64(1); i <= 3; i++ {
+		registerGangTask(gangPod(fmt.Sprintf("pod%d", i), "group0", "3"), i)
+	}
+	defer func() {
+		for i := uint64(1); i <= 3; i++ {
+			forgetGangTask(i)
+		}
+	}()
+	place := func(taskID uint64, node string) []GangPlacement {
+		return HoldGangPlacement(&firmament.SchedulingDelta{TaskId: taskID, ResourceId: node, Type: firmament.SchedulingDelta_PLACE},
+			PodIdentifier{Name: fmt.Sprintf("pod%d", taskID)}, node, now)
+	}
+	place(1, "node0")
+	place(2, "node0")
+	if EvictGangPlacement(&firmament.SchedulingDelta{TaskId: 3, Type: firmament.SchedulingDelta_PREEMPT}, "node0") {
+		t.Error("EvictGangPlacement() = true for a task without a held placement")
+	}
+	if !EvictGangPlacement(&firmament.SchedulingDelta{TaskId: 1, ResourceId: "node1", Type: firmament.SchedulingDelta_MIGRATE}, "node1") {
+		t.Error("EvictGangPlacement() = false for the migration of a held placement")
+	}
+	if !EvictGangPlacement(&firmament.SchedulingDelta{TaskId: 2, ResourceId: "node0", Type: firmament.SchedulingDelta_PREEMPT}, "node0") {
+		t.Error("EvictGangPlacement() = false for the preemption of a held placement")
+	}
+	// The preempted placement no longer counts towards minAvailable.
+	if placements := place(3, "node2"); len(placements) != 0 {
+		t.Errorf("HoldGangPlacement() = %v after a held placement was preempted, expected the placement held", placements)
+	}
+	placements := place(2, "node3")
+	if len(placements) != 3 || placements[0].Node != "node1" || placements[0].Delta.GetResourceId() != "node1" {
+		t.Errorf("HoldGangPlacement() = %v, expected the migrated placement on node1", placements)
+	}
+}
+
+func TestReleaseExpiredGangs(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	fc := firmament.NewMockFirmamentSchedulerClient(mockCtrl)
+	defer func() { gangPolicy = nil }()
+	gangPolicy = &GangPolicy{Timeout: time.Minute, Backoff: time.Minute}
+	podGroups = make(map[podGroupKey]*podGroup)
+	taskGroups = make(map[uint64]podGroupKey)
+	state = &memoryState{}
+	state.jobIDToJD = map[string]*firmament.JobDescriptor{"job0": {Uuid: "job0"}}
+	state.podToTD = map[PodIdentifier]*firmament.TaskDescriptor{{Name: "pod1", Namespace: "ns"}: {Uid: 1, JobId: "job0"}}
+	state.taskIDToPod = map[uint64]PodIdentifier{1: {Name: "pod1", Namespace: "ns"}}
+	registerGangTask(gangPod("pod1", "group0", "2"), 1)
+	registerGangTask(gangPod("pod2", "group0", "2"), 2)
+	now := time.Now()
+	HoldGangPlacement(&firmament.SchedulingDelta{TaskId: 1}, PodIdentifier{Name: "pod1", Namespace: "ns"}, "node0", now)
+
+	if expired := ReleaseExpiredGangs(fc, now.Add(30*time.Second)); expired != 0 {
+		t.Errorf("ReleaseExpiredGangs() = %d before the timeout, expected 0", expired)
+	}
+	// The held placement is reported failed to release its capacity.
+	fc.EXPECT().TaskRemoved(gomock.Any(), &firmament.TaskUID{TaskUid: 1}).Return(
+		&firmament.TaskRemovedResponse{Type: firmament.TaskReplyType_TASK_REMOVED_OK}, nil)
+	fc.EXPECT().TaskSubmitted(gomock.Any(), gomock.Any()).Return(
+		&firmament.TaskSubmittedResponse{Type: firmament.TaskReplyType_TASK_SUBMITTED_OK}, nil)
+	if expired := ReleaseExpiredGangs(fc, now.Add(time.Minute)); expired != 1 {
+		t.Errorf("ReleaseExpiredGangs() = %d after the timeout, expected 1", expired)
+	}
+	MarkTaskPlaced(1)
+	if !IsPodGroupBackedOff(2, now.Add(90*time.Second)) {
+		t.Error("IsPodGroupBackedOff() = false during the backoff")
+	}
+	if IsPodGroupBackedOff(2, now.Add(2*time.Minute)) {
+		t.Error("IsPodGroupBackedOff() = true after the backoff")
+	}
+	forgetGangTask(1)
+	forgetGangTask(2)
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+	"k8s.io/api/core/v1"
+)
+
+const (
+	// HostPathsAnnotation is the node annotation listing the comma separated
+	// host paths available on the node.
+	HostPathsAnnotation = "poseidon.k8s.io/host-paths"
+	// RequiredHostPathsAnnotation is the pod annotation listing the comma
+	// separated host paths the pod requires, in addition to the hostPath
+	// volumes which must exist on the node.
+	RequiredHostPathsAnnotation = "poseidon.k8s.io/required-host-paths"
+	// hostPathLabelPrefix prefixes the Firmament labels of the host paths
+	// available on a node.
+	hostPathLabelPrefix = "poseidon.k8s.io/host-path:"
+)
+
+// splitHostPaths adds the paths of a comma separated list to the set.
+func splitHostPaths(paths string, set map[string]bool) {
+	for _, path := range strings.Split(paths, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			set[path] = true
+		}
+	}
+}
+
+// sortedHostPaths returns the paths of the set in order, nil if it is empty.
+func sortedHostPaths(set map[string]bool) []string {
+	if len(set) == 0 {
+		return nil
+	}
+	paths := make([]string, 0, len(set))
+	for path := range set {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// requiredHostPaths returns the host paths which must exist on the node the
+// pod is placed on. hostPath volumes which are created if missing do not
+// constrain the placement.
+func requiredHostPaths(pod *v1.Pod) []string {
+	return withAnnotatedHostPaths(hostPathVolumes(pod), pod.Annotations)
+}
+
+// hostPathVolumes returns the paths of the hostPath volumes of the pod which
+// must exist on its node.
+func hostPathVolumes(pod *v1.Pod) []string {
+	set := make(map[string]bool)
+	for _, volume := range pod.Spec.Volumes {
+		hostPath := volume.HostPath
+		if hostPath == nil || hostPath.Type == nil {
+			continue
+		}
+		switch *hostPath.Type {
+		case v1.HostPathDirectory, v1.HostPathFile, v1.HostPathSocket, v1.HostPathCharDev, v1.HostPathBlockDev:
+			set[hostPath.Path] = true
+		}
+	}
+	return sortedHostPaths(set)
+}
+
+// withAnnotatedHostPaths adds the host paths required by the pod annotations
+// to the paths of its volumes.
+func withAnnotatedHostPaths(volumePaths []string, annotations map[string]string) []string {
+	set := make(map[string]bool)
+	for _, path := range volumePaths {
+		set[path] = true
+	}
+	splitHostPaths(annotations[RequiredHostPathsAnnotation], set)
+	return sortedHostPaths(set)
+}
+
+// hostPathLabels returns the Firmament labels of the host paths a node
+// declares in its annotations.
+func hostPathLabels(annotations map[string]string) []*firmament.Label {
+	set := make(map[string]bool)
+	splitHostPaths(annotations[HostPathsAnnotation], set)
+	var labels []*firmament.Label
+	for _, path := range sortedHostPaths(set) {
+		labels = append(labels, &firmament.Label{
+			Key:   hostPathLabelPrefix + path,
+			Value: "true",
+		})
+	}
+	return labels
+}
+
+// hostPathLabelSelectors returns the Firmament label selectors constraining
+// a task to the nodes holding the given host paths.
+func hostPathLabelSelectors(paths []string) []*firmament.LabelSelector {
+	var selectors []*firmament.LabelSelector
+	for _, path := range paths {
+		selectors = append(selectors, &firmament.LabelSelector{
+			Type:   firmament.LabelSelector_IN_SET,
+			Key:    hostPathLabelPrefix + path,
+			Values: []string{"true"},
+		})
+	}
+	return selectors
+}
+
+// hasHostPathNode returns true if a node holds all the given host paths.
+func hasHostPathNode(paths []string) bool {
+	state.nodeMux.RLock()
+	defer state.nodeMux.RUnlock()
+	for _, rtnd := range state.nodeToRTND {
+		available := make(map[string]bool)
+		for _, label := range rtnd.GetResourceDesc().GetLabels() {
+			available[label.GetKey()] = true
+		}
+		missing := false
+		for _, path := range paths {
+			if !available[hostPathLabelPrefix+path] {
+				missing = true
+				break
+			}
+		}
+		if !missing {
+			return true
+		}
+	}
+	return false
+}
+
+// checkHostPaths reports a pod no node can run because of its host paths.
+// The task is still submitted so that it gets placed once a matching node
+// is added.
+func (pw *PodWatcher) checkHostPaths(pod *Pod) {
+	if hasHostPathNode(pod.HostPaths) {
+		return
+	}
+	message := fmt.Sprintf("no node holds the required host paths %s", strings.Join(pod.HostPaths, ","))
+	glog.Warningf("Pod %v can not be placed: %s", pod.Identifier, message)
+	recordPodFailure(pod.Identifier, message)
+	pw.recordPodEvent(pod.Identifier, v1.EventTypeWarning, "FailedScheduling", message)
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRequiredHostPaths(t *testing.T) {
+	hostPathType := func(pathType v1.HostPathType) *v1.HostPathType { return &pathType }
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{RequiredHostPathsAnnotation: "/data/cache, /mnt/ssd"},
+		},
+		Spec: v1.PodSpec{
+			Volumes: []v1.Volume{
+				{
+					Name: "existing",
+					VolumeSource: v1.VolumeSource{
+						HostPath: &v1.HostPathVolumeSource{Path: "/mnt/ssd", Type: hostPathType(v1.HostPathDirectory)},
+					},
+				},
+				{
+					Name: "created",
+					VolumeSource: v1.VolumeSource{
+						HostPath: &v1.HostPathVolumeSource{Path: "/var/log", Type: hostPathType(v1.HostPathDirectoryOrCreate)},
+					},
+				},
+				{
+					Name: "unchecked",
+					VolumeSource: v1.VolumeSource{
+						HostPath: &v1.HostPathVolumeSource{Path: "/tmp"},
+					},
+				},
+				{
+					Name:         "empty",
+					VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}},
+				},
+			},
+		},
+	}
+	expected := []string{"/data/cache", "/mnt/ssd"}
+	if got := requiredHostPaths(pod); !reflect.DeepEqual(got, expected) {
+		t.Errorf("requiredHostPaths() = %v, expected %v", got, expected)
+	}
+	if got := requiredHostPaths(&v1.Pod{}); got != nil {
+		t.Errorf("requiredHostPaths() = %v for a pod without host paths, expected nil", got)
+	}
+}
+
+func TestHostPathLabels(t *testing.T) {
+	labels := hostPathLabels(map[string]string{HostPathsAnnotation: "/mnt/ssd,/data/cache"})
+	expected := []*firmament.Label{
+		{Key: hostPathLabelPrefix + "/data/cache", Value: "true"},
+		{Key: hostPathLabelPrefix + "/mnt/ssd", Value: "true"},
+	}
+	if !reflect.DeepEqual(labels, expected) {
+		t.Errorf("hostPathLabels() = %v, expected %v", labels, expected)
+	}
+}
+
+func TestCheckHostPaths(t *testing.T) {
+	state = &memoryState{}
+	state.nodeToRTND = map[string]*firmament.ResourceTopologyNodeDescriptor{
+		"node0": {
+			ResourceDesc: &firmament.ResourceDescriptor{
+				Labels: hostPathLabels(map[string]string{HostPathsAnnotation: "/mnt/ssd"}),
+			},
+		},
+	}
+	var testData = []struct {
+		paths          []string
+		expectedEvents int
+	}{
+		{paths: []string{"/mnt/ssd"}, expectedEvents: 0},
+		{paths: []string{"/mnt/ssd", "/data/cache"}, expectedEvents: 1},
+	}
+	for _, tc := range testData {
+		client := fake.NewSimpleClientset()
+		pw := &PodWatcher{clientset: client, schedulerName: "poseidon"}
+		pw.checkHostPaths(&Pod{
+			Identifier: PodIdentifier{Name: "pod0", Namespace: "ns"},
+			HostPaths:  tc.paths,
+		})
+		events, err := client.CoreV1().Events("ns").List(metav1.ListOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(events.Items) != tc.expectedEvents {
+			t.Errorf("checkHostPaths(%v) created %d events, expected %d", tc.paths, len(events.Items), tc.expectedEvents)
+		}
+	}
+}
@@ -17,7 +17,10 @@ limitations under the License.
 package k8sclient
 
 import (
+	"time"
+
 	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+	"github.com/kubernetes-sigs/poseidon/pkg/metrics"
 	"k8s.io/api/core/v1"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
@@ -30,27 +33,67 @@ import (
 var clientSet kubernetes.Interface
 
 // BindPodToNode call Kubernetes API to place a pod on a node.
-func BindPodToNode(podName string, namespace string, nodeName string) {
+func BindPodToNode(podName string, namespace string, nodeName string) error {
 	err := clientSet.CoreV1().Pods(namespace).Bind(&v1.Binding{
 		TypeMeta: meta_v1.TypeMeta{},
 		ObjectMeta: meta_v1.ObjectMeta{
 			Name: podName,
+			// The annotations of the binding are set on the pod.
+			Annotations: bindingAnnotations(nodeName),
 		},
 		Target: v1.ObjectReference{
 			Namespace: namespace,
 			Name:      nodeName,
 		}})
 	if err != nil {
-		glog.Fatalf("Could not bind pod:%s to nodeName:%s, error: %v", podName, nodeName, err)
+		failure := classifyBindError(err)
+		if failure == BindPodNotFound && resyncNode(nodeName) {
+			failure = BindNodeNotFound
+			err = &bindError{failure: failure, err: err}
+		}
+		metrics.BindFailures.Inc(string(failure))
+		glog.Errorf("Could not bind pod:%s to nodeName:%s, %s error: %v", podName, nodeName, failure, err)
+		switch failure {
+		case BindForbidden, BindError:
+			recordNodeFailure(nodeName, "bind")
+		}
+		return err
 	}
+	reportAdmittedPlacement(PodIdentifier{Name: podName, Namespace: namespace}, nodeName)
+	return nil
 }
 
-// DeletePod calls Kubernetes API to delete a Pod by its namespace and name.
-func DeletePod(podName string, namespace string) {
-	err := clientSet.CoreV1().Pods(namespace).Delete(podName, &meta_v1.DeleteOptions{})
+// DeletePod calls Kubernetes API to delete a Pod by its namespace and name,
+// with the preemption deletion policy.
+func DeletePod(podName string, namespace string) error {
+	err := clientSet.CoreV1().Pods(namespace).Delete(podName, preemptionDeletion.deleteOptions())
 	if err != nil {
-		glog.Fatalf("Could not delete pod:%s in namespace:%s, error: %v", podName, namespace, err)
+		glog.Errorf("Could not delete pod:%s in namespace:%s, error: %v", podName, namespace, err)
 	}
+	return err
+}
+
+// ClusterSize returns the number of nodes and pods of the cluster.
+func ClusterSize(kubeConfig string) (int, int, error) {
+	config, err := GetClientConfig(kubeConfig)
+	if err != nil {
+		return 0, 0, err
+	}
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return 0, 0, err
+	}
+	// Served from the API server cache.
+	options := meta_v1.ListOptions{ResourceVersion: "0"}
+	nodes, err := client.CoreV1().Nodes().List(options)
+	if err != nil {
+		return 0, 0, err
+	}
+	pods, err := client.CoreV1().Pods(meta_v1.NamespaceAll).List(options)
+	if err != nil {
+		return 0, 0, err
+	}
+	return len(nodes.Items), len(pods.Items), nil
 }
 
 // GetClientConfig returns a kubeconfig object which to be passed to a Kubernetes client on initialization.
@@ -61,25 +104,156 @@ func GetClientConfig(kubeconfig string) (*rest.Config, error) {
 	return rest.InClusterConfig()
 }
 
-// New initializes a firmament and Kubernetes client and starts watching Pod and Node.
-func New(schedulerName string, kubeConfig string, kubeVersionMajor, kubeVersionMinor int, firmamentAddress string) {
-	config, err := GetClientConfig(kubeConfig)
+// Options configures the Kubernetes client and the watchers New starts.
+type Options struct {
+	// SchedulerName is the scheduler name of the pods Poseidon claims.
+	SchedulerName string
+	// KubeConfig is the kubeconfig file, empty for the in-cluster config.
+	KubeConfig       string
+	KubeVersionMajor int
+	KubeVersionMinor int
+	// FirmamentAddress is the address of Firmament, ignored if Shards is
+	// set.
+	FirmamentAddress string
+	// Shards sends the nodes and tasks to the shards of a sharded Firmament
+	// if not nil.
+	Shards *firmament.ShardedClient
+	// MaxFirmamentBacklog is the number of tasks waiting to be placed
+	// beyond which the new task submissions are deferred, 0 disables the
+	// limit.
+	MaxFirmamentBacklog int
+	// ClaimPercentage is the percentage of the pods with the scheduler name
+	// which are claimed.
+	ClaimPercentage int
+	// Shadow leaves the pods to another scheduler, Poseidon only compares
+	// its placements with theirs.
+	Shadow bool
+	// NodeWarmUp is the period over which the capacity of the newly added
+	// nodes ramps up, unless their WarmUpCompleteLabel is set to "true".
+	NodeWarmUp          time.Duration
+	WarmUpCompleteLabel string
+	// NotReadyGrace is the period the nodes may be not ready for before
+	// they are failed.
+	NotReadyGrace time.Duration
+	// Priorities translates the pod priorities if not nil.
+	Priorities *PriorityMapping
+	// MigrateFromTerminating migrates the running pods of the terminating
+	// nodes.
+	MigrateFromTerminating bool
+	// DaemonSetOverhead discounts the requests of the DaemonSets from the
+	// node capacity.
+	DaemonSetOverhead bool
+	// TenantLimits rate limits the pod submissions of each namespace if not
+	// nil.
+	TenantLimits *TenantLimits
+	// CacheTemplates caches the requests and constraints of the pods by pod
+	// template.
+	CacheTemplates bool
+	// AnnotateZone annotates the bound pods with the zone of their node.
+	AnnotateZone bool
+	// Quarantine quarantines the nodes failing binds if not nil.
+	Quarantine *QuarantinePolicy
+	// NamespaceSelectors honors the node selector and default constraints
+	// annotations of the namespaces.
+	NamespaceSelectors bool
+	// NodePools steers the pods to node pools if not nil.
+	NodePools *NodePoolPolicy
+	// Deletion is the way the preempted pods are deleted.
+	Deletion *DeletionPolicy
+	// NodeWorkers and PodWorkers are the number of workers synchronizing
+	// the nodes and pods with Firmament.
+	NodeWorkers int
+	PodWorkers  int
+	// Gangs holds the placements of the pod groups if not nil.
+	Gangs *GangPolicy
+	// Devices gates the nodes with degraded devices if not nil.
+	Devices *DeviceHealthPolicy
+	// MemoryQoS accounts the memory QoS of the cgroup v2 nodes if not nil.
+	MemoryQoS *MemoryQoSPolicy
+	// Zones balances the replicas of the ReplicaSets across zones if not
+	// nil.
+	Zones *ZoneBalancePolicy
+	// RejectUnresolvable marks the pending pods whose constraints no node
+	// satisfies unschedulable instead of submitting them.
+	RejectUnresolvable bool
+	// Oversized handles the pods exceeding the allocatable of every node
+	// if not nil.
+	Oversized *OversizedPodPolicy
+	// Admission only claims the pods managed by an external admission
+	// system once admitted if not nil.
+	Admission *AdmissionPolicy
+	// Backpressure delays the task submissions while the scheduler is
+	// saturated if not nil.
+	Backpressure *BackpressurePolicy
+	// FairShares shares the capacity between the batch pods of the
+	// namespaces if not nil.
+	FairShares *FairSharePolicy
+	// Churn suppresses the migrations of the pods whose owner is disrupted
+	// too often if not nil.
+	Churn *ChurnPolicy
+}
+
+// New initializes a firmament and Kubernetes client according to the given
+// options and starts watching Pod and Node.
+func New(options Options) {
+	gangPolicy = options.Gangs
+	churnPolicy = options.Churn
+	backpressurePolicy = options.Backpressure
+	admissionPolicy = options.Admission
+	oversizedPodPolicy = options.Oversized
+	rejectUnresolvableConstraints = options.RejectUnresolvable
+	zoneBalancePolicy = options.Zones
+	memoryQoSPolicy = options.MemoryQoS
+	deviceHealthPolicy = options.Devices
+	priorityMapping = options.Priorities
+	preemptionDeletion = options.Deletion
+	nodePoolPolicy = options.NodePools
+	honorNamespaceNodeSelectors = options.NamespaceSelectors
+	quarantinePolicy = options.Quarantine
+	annotateTopologyZone = options.AnnotateZone
+	if options.CacheTemplates {
+		podTemplates = newTemplateCache()
+	}
+	if options.TenantLimits != nil {
+		tenantRateLimiter = newTenantLimiter(options.TenantLimits, time.Now)
+	}
+	if options.FairShares != nil {
+		fairShare = newFairShareAllocator(options.FairShares, time.Now, clusterCapacity)
+	}
+	migrateFromTerminatingNodes = options.MigrateFromTerminating
+	discountDaemonSetOverhead = options.DaemonSetOverhead
+	maxPendingTasks = options.MaxFirmamentBacklog
+	claimPercentage = options.ClaimPercentage
+	shadowMode = options.Shadow
+	nodeWarmUpPeriod = options.NodeWarmUp
+	notReadyGracePeriod = options.NotReadyGrace
+	nodeWarmUpCompleteLabel = options.WarmUpCompleteLabel
+	config, err := GetClientConfig(options.KubeConfig)
 	if err != nil {
 		glog.Fatalf("Failed to load client config: %v", err)
 	}
+	enableCredentialRotation(config, options.KubeConfig)
 	clientSet, err = kubernetes.NewForConfig(config)
 	if err != nil {
 		glog.Fatalf("Failed to create connection: %v", err)
 	}
-	fc, conn, err := firmament.New(firmamentAddress)
-	if err != nil {
-		glog.Fatalf("Failed to connect to Firmament: %v", err)
+	var fc firmament.FirmamentSchedulerClient
+	if options.Shards != nil {
+		fc = options.Shards
+	} else {
+		client, conn, err := firmament.New(options.FirmamentAddress)
+		if err != nil {
+			glog.Fatalf("Failed to connect to Firmament: %v", err)
+		}
+		defer conn.Close()
+		fc = client
 	}
-	defer conn.Close()
 	glog.Info("k8s newclient called")
 	stopCh := make(chan struct{})
-	go NewPodWatcher(kubeVersionMajor, kubeVersionMinor, schedulerName, clientSet, fc).Run(stopCh, 10)
-	go NewNodeWatcher(clientSet, fc).Run(stopCh, 10)
+	go NewPodWatcher(options.KubeVersionMajor, options.KubeVersionMinor, options.SchedulerName, clientSet, fc).Run(stopCh, options.PodWorkers)
+	nodeWatcher := NewNodeWatcher(clientSet, fc)
+	refreshNodeCapacity = nodeWatcher.refreshCapacity
+	go nodeWatcher.Run(stopCh, options.NodeWorkers)
 
 	// We block here.
 	<-stopCh
@@ -32,6 +32,8 @@ type Queue interface {
 	ShutDown()
 	// ShuttingDown tests if the queue is shutting down.
 	ShuttingDown() bool
+	// Keys returns the keys waiting to be processed.
+	Keys() []interface{}
 }
 
 type tk interface{}
@@ -134,6 +136,17 @@ func (q *Type) Done(key interface{}) {
 	}
 }
 
+// Keys returns the keys waiting to be processed, in queue order.
+func (q *Type) Keys() []interface{} {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	keys := make([]interface{}, 0, len(q.queue))
+	for _, key := range q.queue {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
 // ShutDown shuts down the queue.
 // After ShutDown is called new items will not be appended to the queue. Only
 // already appended items will be drained.
@@ -0,0 +1,98 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"fmt"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+// LabelSelectorsAnnotation is the pod annotation holding Firmament label
+// selectors the node of the pod must satisfy, in the Kubernetes label
+// selector syntax, e.g. "zone in (a,b),disk notin (hdd),!spot". It gives
+// access to the set based and negated constraints of Firmament which the
+// pod node selector can not express.
+const LabelSelectorsAnnotation = "poseidon.k8s.io/label-selectors"
+
+// parseLabelSelectors converts a label selector to Firmament label
+// selectors. The gt and lt operators have no Firmament equivalent.
+func parseLabelSelectors(selector string) ([]*firmament.LabelSelector, error) {
+	requirements, err := labels.ParseToRequirements(selector)
+	if err != nil {
+		return nil, err
+	}
+	var firmamentSelectors []*firmament.LabelSelector
+	for _, requirement := range requirements {
+		firmamentSelector := &firmament.LabelSelector{
+			Key:    requirement.Key(),
+			Values: requirement.Values().List(),
+		}
+		switch requirement.Operator() {
+		case selection.In, selection.Equals, selection.DoubleEquals:
+			firmamentSelector.Type = firmament.LabelSelector_IN_SET
+		case selection.NotIn, selection.NotEquals:
+			firmamentSelector.Type = firmament.LabelSelector_NOT_IN_SET
+		case selection.Exists:
+			firmamentSelector.Type = firmament.LabelSelector_EXISTS_KEY
+		case selection.DoesNotExist:
+			firmamentSelector.Type = firmament.LabelSelector_NOT_EXISTS_KEY
+		default:
+			return nil, fmt.Errorf("unsupported operator %s of %s", requirement.Operator(), requirement.Key())
+		}
+		firmamentSelectors = append(firmamentSelectors, firmamentSelector)
+	}
+	return firmamentSelectors, nil
+}
+
+// checkLabelSelectorsAnnotation returns an error if the label selectors
+// annotation of the pod is invalid. Such a pod must not be scheduled, as it
+// would be placed regardless of the constraints it asks for.
+func checkLabelSelectorsAnnotation(pod *Pod) error {
+	selector, ok := pod.Annotations[LabelSelectorsAnnotation]
+	if !ok {
+		return nil
+	}
+	if _, err := parseLabelSelectors(selector); err != nil {
+		return fmt.Errorf("invalid %s annotation: %v", LabelSelectorsAnnotation, err)
+	}
+	return nil
+}
+
+// labelSelectorsCompiler adds the label selectors of the annotation of the
+// pod.
+type labelSelectorsCompiler struct{}
+
+func (labelSelectorsCompiler) Name() string {
+	return "labelSelectorsAnnotation"
+}
+
+func (labelSelectorsCompiler) Compile(pod *Pod, td *firmament.TaskDescriptor) error {
+	selector, ok := pod.Annotations[LabelSelectorsAnnotation]
+	if !ok {
+		return nil
+	}
+	selectors, err := parseLabelSelectors(selector)
+	if err != nil {
+		return err
+	}
+	td.LabelSelectors = append(td.LabelSelectors, selectors...)
+	return nil
+}
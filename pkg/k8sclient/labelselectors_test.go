@@ -0,0 +1,70 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+)
+
+func TestParseLabelSelectors(t *testing.T) {
+	var testData = []struct {
+		selector string
+		expected []*firmament.LabelSelector
+		fails    bool
+	}{
+		{
+			selector: "zone in (b,a),disk!=hdd,gpu,!spot,tier=web",
+			expected: []*firmament.LabelSelector{
+				{Type: firmament.LabelSelector_NOT_IN_SET, Key: "disk", Values: []string{"hdd"}},
+				{Type: firmament.LabelSelector_EXISTS_KEY, Key: "gpu", Values: []string{}},
+				{Type: firmament.LabelSelector_NOT_EXISTS_KEY, Key: "spot", Values: []string{}},
+				{Type: firmament.LabelSelector_IN_SET, Key: "tier", Values: []string{"web"}},
+				{Type: firmament.LabelSelector_IN_SET, Key: "zone", Values: []string{"a", "b"}},
+			},
+		},
+		{selector: "cores gt 4", fails: true},
+		{selector: "zone in (a", fails: true},
+	}
+	for _, tc := range testData {
+		selectors, err := parseLabelSelectors(tc.selector)
+		if (err != nil) != tc.fails {
+			t.Errorf("parseLabelSelectors(%q) = %v, expected failure %v", tc.selector, err, tc.fails)
+			continue
+		}
+		if !tc.fails && !reflect.DeepEqual(selectors, tc.expected) {
+			t.Errorf("parseLabelSelectors(%q) = %v, expected %v", tc.selector, selectors, tc.expected)
+		}
+	}
+}
+
+func TestLabelSelectorsCompiler(t *testing.T) {
+	pod := &Pod{Annotations: map[string]string{LabelSelectorsAnnotation: "!spot"}}
+	if err := checkLabelSelectorsAnnotation(pod); err != nil {
+		t.Errorf("checkLabelSelectorsAnnotation() = %v, expected no error", err)
+	}
+	td := &firmament.TaskDescriptor{ResourceRequest: &firmament.ResourceVector{}}
+	compileConstraints(pod, td)
+	if len(td.LabelSelectors) != 1 || td.LabelSelectors[0].Type != firmament.LabelSelector_NOT_EXISTS_KEY {
+		t.Errorf("compileConstraints() label selectors = %v, expected the annotated selector", td.LabelSelectors)
+	}
+	if err := checkLabelSelectorsAnnotation(&Pod{Annotations: map[string]string{LabelSelectorsAnnotation: "a in"}}); err == nil {
+		t.Errorf("checkLabelSelectorsAnnotation() of an invalid annotation = nil, expected an error")
+	}
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"math"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+	"k8s.io/api/core/v1"
+)
+
+// CgroupV2NodeLabel is the node label set to "true" on the nodes running
+// their pods in cgroup v2 with the kubelet MemoryQoS feature enabled.
+const CgroupV2NodeLabel = "poseidon.k8s.io/cgroup-v2"
+
+// MemoryQoSPolicy accounts the memory.min and memory.high settings the
+// kubelet applies on the cgroup v2 nodes. memory.min protects the requests
+// of all the pods from reclaim, which is what the requests already account.
+// memory.high throttles the Burstable pods only once they use their request
+// plus ThrottlingFactor times the gap to their limit, so the memory they use
+// up to it is discounted from the headroom of their node. The Guaranteed
+// pods, whose limits are their requests, and the containers without memory
+// limit, throttled relatively to the whole node, add nothing.
+type MemoryQoSPolicy struct {
+	// ThrottlingFactor is the memoryThrottlingFactor of the kubelets.
+	ThrottlingFactor float64
+}
+
+// memoryQoSPolicy is the policy of the cgroup v2 nodes, nil if their memory
+// is accounted as the cgroup v1 ones.
+var memoryQoSPolicy *MemoryQoSPolicy
+
+// isCgroupV2Node returns whether the node with the given labels applies the
+// memory QoS of cgroup v2.
+func isCgroupV2Node(labels map[string]string) bool {
+	return memoryQoSPolicy != nil && labels[CgroupV2NodeLabel] == "true"
+}
+
+// isCgroupV2Resource returns whether the node with the given Firmament labels
+// applies the memory QoS of cgroup v2.
+func isCgroupV2Resource(labels []*firmament.Label) bool {
+	if memoryQoSPolicy == nil {
+		return false
+	}
+	for _, label := range labels {
+		if label.GetKey() == CgroupV2NodeLabel {
+			return label.GetValue() == "true"
+		}
+	}
+	return false
+}
+
+// memoryBurstKb returns the memory in KB the pod may use above its requests
+// before memory.high throttles it.
+func memoryBurstKb(pod *v1.Pod) int64 {
+	if memoryQoSPolicy == nil {
+		return 0
+	}
+	var burst int64
+	for _, container := range pod.Spec.Containers {
+		limit, ok := container.Resources.Limits[v1.ResourceMemory]
+		if !ok {
+			continue
+		}
+		request := limit
+		if quantity, ok := container.Resources.Requests[v1.ResourceMemory]; ok {
+			request = quantity
+		}
+		if gap := limit.Value() - request.Value(); gap > 0 {
+			burst += int64(math.Floor(memoryQoSPolicy.ThrottlingFactor*float64(gap))) / bytesToKb
+		}
+	}
+	return burst
+}
+
+// memoryQoSOverhead returns the memory in KB the Burstable pods bound to the
+// node may use above their requests before being throttled.
+func memoryQoSOverhead(nodeName string) int64 {
+	var memKb int64
+	for _, pod := range podsByNode.podsOnNode(nodeName) {
+		memKb += pod.MemBurstKb
+	}
+	return memKb
+}
+
+// refreshMemoryQoSNodes advertises the capacity of the nodes again, so that
+// the headroom of the cgroup v2 ones accounts the memory their pods may use
+// above their requests.
+func refreshMemoryQoSNodes(nodeNames []string) {
+	if memoryQoSPolicy == nil {
+		return
+	}
+	for _, nodeName := range nodeNames {
+		refreshNodeCapacity(nodeName)
+	}
+}
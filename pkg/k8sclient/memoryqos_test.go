@@ -0,0 +1,111 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func memoryPod(name, nodeName string, resources ...v1.ResourceRequirements) *v1.Pod {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"},
+		Spec:       v1.PodSpec{NodeName: nodeName},
+		Status:     v1.PodStatus{Phase: v1.PodRunning},
+	}
+	for _, r := range resources {
+		pod.Spec.Containers = append(pod.Spec.Containers, v1.Container{Resources: r})
+	}
+	return pod
+}
+
+func memoryResources(request, limit string) v1.ResourceRequirements {
+	var r v1.ResourceRequirements
+	if request != "" {
+		r.Requests = v1.ResourceList{v1.ResourceMemory: resource.MustParse(request)}
+	}
+	if limit != "" {
+		r.Limits = v1.ResourceList{v1.ResourceMemory: resource.MustParse(limit)}
+	}
+	return r
+}
+
+func TestMemoryBurstKb(t *testing.T) {
+	burstable := memoryPod("pod0", "node0", memoryResources("1Mi", "11Mi"))
+	if burst := memoryBurstKb(burstable); burst != 0 {
+		t.Errorf("memoryBurstKb() = %d without a policy, expected 0", burst)
+	}
+	defer func() { memoryQoSPolicy = nil }()
+	memoryQoSPolicy = &MemoryQoSPolicy{ThrottlingFactor: 0.8}
+	var testData = []struct {
+		pod      *v1.Pod
+		expected int64
+	}{
+		{pod: burstable, expected: 8 * 1024},
+		// Guaranteed.
+		{pod: memoryPod("pod1", "node0", memoryResources("1Mi", "1Mi")), expected: 0},
+		// The requests default to the limits.
+		{pod: memoryPod("pod2", "node0", memoryResources("", "1Mi")), expected: 0},
+		// Throttled relatively to the node.
+		{pod: memoryPod("pod3", "node0", memoryResources("1Mi", "")), expected: 0},
+		{pod: memoryPod("pod4", "node0", memoryResources("", "")), expected: 0},
+		{pod: memoryPod("pod5", "node0", memoryResources("1Mi", "11Mi"), memoryResources("1Mi", "")), expected: 8 * 1024},
+	}
+	for _, tc := range testData {
+		if burst := memoryBurstKb(tc.pod); burst != tc.expected {
+			t.Errorf("memoryBurstKb(%s) = %d, expected %d", tc.pod.Name, burst, tc.expected)
+		}
+	}
+}
+
+func TestMemoryQoSCapacity(t *testing.T) {
+	defer func() {
+		memoryQoSPolicy = nil
+		podsByNode = newPodIndex()
+	}()
+	memoryQoSPolicy = &MemoryQoSPolicy{ThrottlingFactor: 0.5}
+	if changed := podsByNode.update(memoryPod("pod0", "node0", memoryResources("1Mi", "5Mi")), 0, 1024); !reflect.DeepEqual(changed, []string{"node0"}) {
+		t.Errorf("update() = %v for a new Burstable pod, expected [node0]", changed)
+	}
+	if changed := podsByNode.update(memoryPod("pod0", "node0", memoryResources("1Mi", "5Mi")), 0, 1024); changed != nil {
+		t.Errorf("update() = %v for an unchanged pod, expected none", changed)
+	}
+	podsByNode.update(memoryPod("pod1", "node0", memoryResources("1Mi", "1Mi")), 0, 1024)
+
+	node := &Node{Hostname: "node0", CPUCapacity: 1000, MemCapacityKb: 16 * 1024}
+	if _, memKb := nodeCapacity(node); memKb != 16*1024 {
+		t.Errorf("nodeCapacity() = %d KB for a cgroup v1 node, expected %d", memKb, 16*1024)
+	}
+	node.Labels = map[string]string{CgroupV2NodeLabel: "true"}
+	if _, memKb := nodeCapacity(node); memKb != 14*1024 {
+		t.Errorf("nodeCapacity() = %d KB for a cgroup v2 node, expected %d", memKb, 14*1024)
+	}
+
+	if changed := podsByNode.remove(PodIdentifier{Name: "pod1", Namespace: "ns"}); changed != nil {
+		t.Errorf("remove() = %v for a Guaranteed pod, expected none", changed)
+	}
+	if changed := podsByNode.remove(PodIdentifier{Name: "pod0", Namespace: "ns"}); !reflect.DeepEqual(changed, []string{"node0"}) {
+		t.Errorf("remove() = %v for a Burstable pod, expected [node0]", changed)
+	}
+	if _, memKb := nodeCapacity(node); memKb != 16*1024 {
+		t.Errorf("nodeCapacity() = %d KB without Burstable pods, expected %d", memKb, 16*1024)
+	}
+}
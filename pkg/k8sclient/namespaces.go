@@ -0,0 +1,219 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/golang/glog"
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// NamespaceNodeSelectorAnnotation is the namespace annotation holding
+	// the node selector merged into the node selector of its pods, as with
+	// the PodNodeSelector admission plugin, e.g. "env=prod,tier=web".
+	NamespaceNodeSelectorAnnotation = "scheduler.alpha.kubernetes.io/node-selector"
+	// NamespaceNodeSelectorWhitelistAnnotation is the namespace annotation
+	// holding the only node labels the merged node selector of its pods may
+	// select, in the same format.
+	NamespaceNodeSelectorWhitelistAnnotation = "poseidon.k8s.io/node-selector-whitelist"
+	// NamespaceLabelSelectorsAnnotation is the namespace annotation holding
+	// the label selectors the nodes of all its pods must satisfy, in the
+	// syntax of the label selectors annotation of the pods.
+	NamespaceLabelSelectorsAnnotation = "poseidon.k8s.io/default-label-selectors"
+	// NamespaceNodePreferencesAnnotation is the namespace annotation holding
+	// node preferences added to the ones of its pods, in the syntax of the
+	// node preferences annotation of the pods.
+	NamespaceNodePreferencesAnnotation = "poseidon.k8s.io/default-node-preferences"
+)
+
+// honorNamespaceNodeSelectors enables the node selectors of the namespaces.
+var honorNamespaceNodeSelectors bool
+
+// namespaceSelector is the node selector policy of a namespace.
+type namespaceSelector struct {
+	// defaults are merged into the node selector of the pods.
+	defaults labels.Set
+	// whitelist holds the labels the pods may select, nil if they may
+	// select any.
+	whitelist labels.Set
+	// labelSelectors are added to the constraints of the pods, and
+	// preferences to their node preferences.
+	labelSelectors []*firmament.LabelSelector
+	preferences    []NodePreference
+	// err is the error parsing the annotations, the pods of a namespace with
+	// invalid annotations are not scheduled.
+	err error
+}
+
+var (
+	namespaceMux sync.RWMutex
+	// namespaceSelectors maps the namespaces with node selector annotations
+	// to their policy.
+	namespaceSelectors = make(map[string]*namespaceSelector)
+)
+
+// parseNamespaceSelector returns the node selector policy of the namespace,
+// nil if it has none.
+func parseNamespaceSelector(namespace *v1.Namespace) *namespaceSelector {
+	defaults, hasDefaults := namespace.Annotations[NamespaceNodeSelectorAnnotation]
+	whitelist, hasWhitelist := namespace.Annotations[NamespaceNodeSelectorWhitelistAnnotation]
+	labelSelectors, hasLabelSelectors := namespace.Annotations[NamespaceLabelSelectorsAnnotation]
+	preferences, hasPreferences := namespace.Annotations[NamespaceNodePreferencesAnnotation]
+	if !hasDefaults && !hasWhitelist && !hasLabelSelectors && !hasPreferences {
+		return nil
+	}
+	selector := &namespaceSelector{}
+	var err error
+	if selector.defaults, err = labels.ConvertSelectorToLabelsMap(defaults); err != nil {
+		selector.err = fmt.Errorf("invalid %s annotation of namespace %s: %v", NamespaceNodeSelectorAnnotation, namespace.Name, err)
+		return selector
+	}
+	if hasWhitelist {
+		if selector.whitelist, err = labels.ConvertSelectorToLabelsMap(whitelist); err != nil {
+			selector.err = fmt.Errorf("invalid %s annotation of namespace %s: %v", NamespaceNodeSelectorWhitelistAnnotation,
+				namespace.Name, err)
+			return selector
+		}
+	}
+	if selector.labelSelectors, err = parseLabelSelectors(labelSelectors); err != nil {
+		selector.err = fmt.Errorf("invalid %s annotation of namespace %s: %v", NamespaceLabelSelectorsAnnotation, namespace.Name, err)
+		return selector
+	}
+	if selector.preferences, err = parseNodePreferences(preferences); err != nil {
+		selector.err = fmt.Errorf("invalid %s annotation of namespace %s: %v", NamespaceNodePreferencesAnnotation, namespace.Name, err)
+	}
+	return selector
+}
+
+// namespaceSelectorOf returns the valid node selector policy of the
+// namespace, nil if it has none.
+func namespaceSelectorOf(namespace string) *namespaceSelector {
+	if !honorNamespaceNodeSelectors {
+		return nil
+	}
+	namespaceMux.RLock()
+	defer namespaceMux.RUnlock()
+	selector := namespaceSelectors[namespace]
+	if selector == nil || selector.err != nil {
+		return nil
+	}
+	return selector
+}
+
+// newNamespaceController returns a controller tracking the node selector
+// policy of the namespaces.
+func newNamespaceController(client kubernetes.Interface) cache.Controller {
+	update := func(obj interface{}) {
+		namespace := obj.(*v1.Namespace)
+		selector := parseNamespaceSelector(namespace)
+		if selector != nil && selector.err != nil {
+			glog.Errorf("Not scheduling the pods of namespace %s: %v", namespace.Name, selector.err)
+		}
+		namespaceMux.Lock()
+		defer namespaceMux.Unlock()
+		if selector == nil {
+			delete(namespaceSelectors, namespace.Name)
+			return
+		}
+		namespaceSelectors[namespace.Name] = selector
+	}
+	_, controller := cache.NewInformer(
+		&cache.ListWatch{
+			ListFunc: func(alo metav1.ListOptions) (runtime.Object, error) {
+				return client.CoreV1().Namespaces().List(alo)
+			},
+			WatchFunc: func(alo metav1.ListOptions) (watch.Interface, error) {
+				return client.CoreV1().Namespaces().Watch(alo)
+			},
+		},
+		&v1.Namespace{},
+		0,
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: update,
+			UpdateFunc: func(old, new interface{}) {
+				update(new)
+			},
+			DeleteFunc: func(obj interface{}) {
+				key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+				if err != nil {
+					glog.Errorf("DeleteFunc: error getting key %v", err)
+					return
+				}
+				namespaceMux.Lock()
+				delete(namespaceSelectors, key)
+				namespaceMux.Unlock()
+			},
+		},
+	)
+	return controller
+}
+
+// applyNamespaceNodeSelector merges the node selector of the namespace of
+// the pod into the pod node selector. It returns an error if the pod node
+// selector conflicts with the one of its namespace or selects labels out of
+// the namespace whitelist, in which case the pod must not be scheduled.
+func applyNamespaceNodeSelector(pod *Pod) error {
+	namespaceMux.RLock()
+	selector, ok := namespaceSelectors[pod.Identifier.Namespace]
+	namespaceMux.RUnlock()
+	if !ok {
+		return nil
+	}
+	if selector.err != nil {
+		return selector.err
+	}
+	podSelector := labels.Set(pod.NodeSelector)
+	if labels.Conflicts(selector.defaults, podSelector) {
+		return fmt.Errorf("its node selector %v conflicts with the node selector %v of its namespace", podSelector, selector.defaults)
+	}
+	merged := labels.Merge(selector.defaults, podSelector)
+	if selector.whitelist != nil && !labels.AreLabelsInWhiteList(merged, selector.whitelist) {
+		return fmt.Errorf("its node selector %v is not in the node selector whitelist %v of its namespace", merged, selector.whitelist)
+	}
+	if len(merged) != len(podSelector) {
+		pod.NodeSelector = merged
+		// The node selectors shared by the template must not be used.
+		pod.nodeSelectors = nil
+	}
+	return nil
+}
+
+// namespaceConstraintsCompiler restricts the task to the nodes matching the
+// label selectors of its namespace.
+type namespaceConstraintsCompiler struct{}
+
+func (namespaceConstraintsCompiler) Name() string {
+	return "namespaceConstraints"
+}
+
+func (namespaceConstraintsCompiler) Compile(pod *Pod, td *firmament.TaskDescriptor) error {
+	if selector := namespaceSelectorOf(pod.Identifier.Namespace); selector != nil {
+		td.LabelSelectors = append(td.LabelSelectors, selector.labelSelectors...)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestApplyNamespaceNodeSelector(t *testing.T) {
+	defer func() { namespaceSelectors = make(map[string]*namespaceSelector) }()
+	for _, namespace := range []*v1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "prod", Annotations: map[string]string{
+			NamespaceNodeSelectorAnnotation: "env=prod",
+		}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "restricted", Annotations: map[string]string{
+			NamespaceNodeSelectorAnnotation:          "env=prod",
+			NamespaceNodeSelectorWhitelistAnnotation: "env=prod,disk=ssd",
+		}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "invalid", Annotations: map[string]string{
+			NamespaceNodeSelectorAnnotation: "env in (prod)",
+		}}},
+	} {
+		namespaceSelectors[namespace.Name] = parseNamespaceSelector(namespace)
+	}
+	var testData = []struct {
+		namespace    string
+		nodeSelector map[string]string
+		expected     map[string]string
+		fails        bool
+	}{
+		{namespace: "default", nodeSelector: map[string]string{"disk": "hdd"}, expected: map[string]string{"disk": "hdd"}},
+		{namespace: "prod", expected: map[string]string{"env": "prod"}},
+		{namespace: "prod", nodeSelector: map[string]string{"disk": "hdd"}, expected: map[string]string{"env": "prod", "disk": "hdd"}},
+		{namespace: "prod", nodeSelector: map[string]string{"env": "dev"}, fails: true},
+		{namespace: "restricted", nodeSelector: map[string]string{"disk": "ssd"}, expected: map[string]string{"env": "prod", "disk": "ssd"}},
+		{namespace: "restricted", nodeSelector: map[string]string{"disk": "hdd"}, fails: true},
+		{namespace: "invalid", fails: true},
+	}
+	for _, tc := range testData {
+		pod := &Pod{
+			Identifier:    PodIdentifier{Namespace: tc.namespace, Name: "pod"},
+			NodeSelector:  tc.nodeSelector,
+			nodeSelectors: []*firmament.LabelSelector{},
+		}
+		err := applyNamespaceNodeSelector(pod)
+		if (err != nil) != tc.fails {
+			t.Errorf("applyNamespaceNodeSelector() of %v in namespace %s = %v, expected failure %v", tc.nodeSelector,
+				tc.namespace, err, tc.fails)
+			continue
+		}
+		if tc.fails {
+			continue
+		}
+		if len(tc.expected) == 0 && len(pod.NodeSelector) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(map[string]string(pod.NodeSelector), tc.expected) {
+			t.Errorf("applyNamespaceNodeSelector() of %v in namespace %s set %v, expected %v", tc.nodeSelector, tc.namespace,
+				pod.NodeSelector, tc.expected)
+		}
+		if merged := len(tc.expected) != len(tc.nodeSelector); merged != (pod.nodeSelectors == nil) {
+			t.Errorf("applyNamespaceNodeSelector() of %v in namespace %s kept the template node selectors: %v", tc.nodeSelector,
+				tc.namespace, pod.nodeSelectors)
+		}
+	}
+}
+
+func TestParseNamespaceSelector(t *testing.T) {
+	if selector := parseNamespaceSelector(&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "plain"}}); selector != nil {
+		t.Errorf("parseNamespaceSelector() of a namespace without annotations = %v, expected nil", selector)
+	}
+	selector := parseNamespaceSelector(&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "whitelisted", Annotations: map[string]string{
+		NamespaceNodeSelectorWhitelistAnnotation: "disk=ssd",
+	}}})
+	if selector == nil || selector.err != nil || len(selector.defaults) != 0 || selector.whitelist["disk"] != "ssd" {
+		t.Errorf("parseNamespaceSelector() of a namespace with a whitelist = %+v, expected only the whitelist", selector)
+	}
+}
+
+func TestNamespaceDefaultConstraints(t *testing.T) {
+	defer func() {
+		namespaceSelectors = make(map[string]*namespaceSelector)
+		honorNamespaceNodeSelectors = false
+	}()
+	honorNamespaceNodeSelectors = true
+	for _, namespace := range []*v1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "batch", Annotations: map[string]string{
+			NamespaceLabelSelectorsAnnotation:  "pool in (batch,spot)",
+			NamespaceNodePreferencesAnnotation: "10:pool=spot",
+		}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "invalid", Annotations: map[string]string{
+			NamespaceNodePreferencesAnnotation: "pool=spot",
+		}}},
+	} {
+		namespaceSelectors[namespace.Name] = parseNamespaceSelector(namespace)
+	}
+	if err := applyNamespaceNodeSelector(&Pod{Identifier: PodIdentifier{Namespace: "invalid", Name: "pod"}}); err == nil {
+		t.Error("applyNamespaceNodeSelector() accepted a pod of a namespace with invalid default node preferences")
+	}
+
+	pod := &Pod{
+		Identifier:  PodIdentifier{Namespace: "batch", Name: "pod"},
+		Annotations: map[string]string{NodePreferencesAnnotation: "5:zone=a"},
+	}
+	if err := applyNamespaceNodeSelector(pod); err != nil {
+		t.Fatalf("applyNamespaceNodeSelector() = %v", err)
+	}
+	td := &firmament.TaskDescriptor{}
+	namespaceConstraintsCompiler{}.Compile(pod, td)
+	expected := []*firmament.LabelSelector{{Type: firmament.LabelSelector_IN_SET, Key: "pool", Values: []string{"batch", "spot"}}}
+	if !reflect.DeepEqual(td.LabelSelectors, expected) {
+		t.Errorf("Compile() = %v, expected %v", td.LabelSelectors, expected)
+	}
+	defer forgetNodePreferences(1)
+	registerNodePreferences(pod, 1)
+	labels := []*firmament.Label{{Key: "zone", Value: "a"}, {Key: "pool", Value: "spot"}}
+	if score := preferenceScore(nodePreferencesOf(1), "node0", labels); score != 15 {
+		t.Errorf("preferenceScore() = %d, expected the pod and namespace preferences to add up to 15", score)
+	}
+
+	td = &firmament.TaskDescriptor{}
+	namespaceConstraintsCompiler{}.Compile(&Pod{Identifier: PodIdentifier{Namespace: "invalid", Name: "pod"}}, td)
+	if len(td.LabelSelectors) != 0 {
+		t.Errorf("Compile() = %v for a namespace with invalid annotations, expected none", td.LabelSelectors)
+	}
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"sort"
+)
+
+// Resources are amounts of CPU, in millicores, and of memory, in KB.
+type Resources struct {
+	CPU   int64 `json:"cpu"`
+	MemKb int64 `json:"memKb"`
+}
+
+func (r *Resources) add(cpu, memKb int64) {
+	r.CPU += cpu
+	r.MemKb += memKb
+}
+
+// NodeCapacity is the capacity of a node as Poseidon sees it, broken down
+// so that it can be reconciled with the one reported by the kubelet.
+type NodeCapacity struct {
+	Node string `json:"node"`
+	// Capacity and Allocatable are the ones reported by the kubelet.
+	Capacity    Resources `json:"capacity"`
+	Allocatable Resources `json:"allocatable"`
+	// DaemonSetOverhead are the requests of the DaemonSets matching the
+	// node, if they are discounted from its capacity.
+	DaemonSetOverhead Resources `json:"daemonSetOverhead"`
+	// NodeTargetedOverhead are the requests of the pods bound to the node
+	// without being scheduled, e.g. the node debug pods, which Firmament
+	// does not know.
+	NodeTargetedOverhead Resources `json:"nodeTargetedOverhead"`
+	// MemoryQoSOverheadKb is the memory the pods of a cgroup v2 node may use
+	// above their requests before being throttled.
+	MemoryQoSOverheadKb int64 `json:"memoryQoSOverheadKb"`
+	// Quarantined and WarmingUp are set if the advertised capacity is
+	// withheld by the quarantine or the warm-up of the node.
+	Quarantined bool `json:"quarantined"`
+	WarmingUp   bool `json:"warmingUp"`
+	// Advertised is the capacity currently advertised to Firmament.
+	Advertised Resources `json:"advertised"`
+	// Requested are the requests of the pods bound to the node, except the
+	// DaemonSet and node targeted pods already discounted.
+	Requested Resources `json:"requested"`
+	// Reserved are the requests of the pods assumed on the node: bound by
+	// the fallback scheduler but not observed bound yet, or whose placement
+	// is held until their pod group reaches its minAvailable.
+	Reserved Resources `json:"reserved"`
+	// Available is the advertised capacity left once the requested and
+	// reserved resources are deducted, negative if overcommitted.
+	Available Resources `json:"available"`
+}
+
+// NodeCapacities returns the effective capacity of the given nodes, or of
+// all the nodes known to the scheduler if none is given, in name order.
+func NodeCapacities(nodeNames ...string) ([]NodeCapacity, error) {
+	state.nodeMux.RLock()
+	if len(nodeNames) == 0 {
+		for nodeName := range state.nodeToRTND {
+			nodeNames = append(nodeNames, nodeName)
+		}
+	}
+	capacities := make([]NodeCapacity, 0, len(nodeNames))
+	nodeLabels := make([]map[string]string, 0, len(nodeNames))
+	for _, nodeName := range nodeNames {
+		rtnd, ok := state.nodeToRTND[nodeName]
+		if !ok {
+			state.nodeMux.RUnlock()
+			return nil, ErrUnknownNode
+		}
+		shape := nodeShapes[nodeName]
+		advertised := rtnd.GetResourceDesc().GetResourceCapacity()
+		_, warmingUp := warmingNodes[nodeName]
+		capacities = append(capacities, NodeCapacity{
+			Node:        nodeName,
+			Capacity:    Resources{CPU: shape.capacityCPU, MemKb: shape.capacityMemKb},
+			Allocatable: Resources{CPU: shape.cpu, MemKb: shape.memKb},
+			WarmingUp:   warmingUp,
+			Advertised:  Resources{CPU: int64(advertised.GetCpuCores()), MemKb: int64(advertised.GetRamCap())},
+		})
+		labels := make(map[string]string)
+		for _, label := range rtnd.GetResourceDesc().GetLabels() {
+			labels[label.GetKey()] = label.GetValue()
+		}
+		nodeLabels = append(nodeLabels, labels)
+	}
+	state.nodeMux.RUnlock()
+
+	reserved := assumedPlacements()
+	for i := range capacities {
+		c := &capacities[i]
+		c.Quarantined = IsNodeQuarantined(c.Node)
+		if discountDaemonSetOverhead {
+			c.DaemonSetOverhead.CPU, c.DaemonSetOverhead.MemKb = daemonSetOverhead(nodeLabels[i])
+		}
+		if isCgroupV2Node(nodeLabels[i]) {
+			c.MemoryQoSOverheadKb = memoryQoSOverhead(c.Node)
+		}
+		c.NodeTargetedOverhead.CPU, c.NodeTargetedOverhead.MemKb = nodeTargetedOverhead(c.Node)
+		for _, pod := range podsByNode.podsOnNode(c.Node) {
+			if (pod.DaemonSet && discountDaemonSetOverhead) || isNodeTargetedPod(pod.Identifier) {
+				continue
+			}
+			c.Requested.add(pod.CPURequest, pod.MemRequestKb)
+		}
+		c.Reserved = reserved[c.Node]
+		c.Available = Resources{
+			CPU:   c.Advertised.CPU - c.Requested.CPU - c.Reserved.CPU,
+			MemKb: c.Advertised.MemKb - c.Requested.MemKb - c.Reserved.MemKb,
+		}
+	}
+	sort.Slice(capacities, func(i, j int) bool { return capacities[i].Node < capacities[j].Node })
+	return capacities, nil
+}
+
+// assumedPlacements returns the requests of the pods assumed on each node:
+// the ones bound by the fallback scheduler but not observed bound yet, and
+// the held placements of the pod groups.
+func assumedPlacements() map[string]Resources {
+	reserved := make(map[string]Resources)
+	fallbackMux.Lock()
+	for podIdentifier, placement := range fallbackBound {
+		if _, indexed := PodNodeName(podIdentifier); indexed {
+			continue
+		}
+		r := reserved[placement.Node]
+		r.add(placement.CPURequest, placement.MemRequestKb)
+		reserved[placement.Node] = r
+	}
+	fallbackMux.Unlock()
+	var held []GangPlacement
+	gangMux.Lock()
+	for _, group := range podGroups {
+		held = append(held, group.held...)
+	}
+	gangMux.Unlock()
+	for _, placement := range held {
+		td, ok := state.TaskOfPod(placement.Pod)
+		if !ok {
+			continue
+		}
+		r := reserved[placement.Node]
+		r.add(int64(td.GetResourceRequest().GetCpuCores()), int64(td.GetResourceRequest().GetRamCap()))
+		reserved[placement.Node] = r
+	}
+	return reserved
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"testing"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNodeCapacities(t *testing.T) {
+	state = &memoryState{}
+	state.nodeToRTND = map[string]*firmament.ResourceTopologyNodeDescriptor{
+		"node0": {ResourceDesc: &firmament.ResourceDescriptor{
+			ResourceCapacity: &firmament.ResourceVector{CpuCores: 3500, RamCap: 7000000},
+		}},
+		"node1": {ResourceDesc: &firmament.ResourceDescriptor{
+			ResourceCapacity: &firmament.ResourceVector{CpuCores: 1000, RamCap: 1000000},
+		}},
+	}
+	state.podToTD = map[PodIdentifier]*firmament.TaskDescriptor{}
+	defer func() { nodeShapes = make(map[string]nodeShape) }()
+	recordNodeShape(&Node{Hostname: "node0", CPUCapacity: 4000, MemCapacityKb: 8000000, CPUAllocatable: 3800, MemAllocatableKb: 7500000})
+	podsByNode = newPodIndex()
+	defer func() { podsByNode = newPodIndex() }()
+	podsByNode.update(&v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "ns"},
+		Spec:       v1.PodSpec{NodeName: "node0"},
+		Status:     v1.PodStatus{Phase: v1.PodRunning},
+	}, 1000, 2000000)
+	batch := PodIdentifier{Name: "batch", Namespace: "ns"}
+	fallbackMux.Lock()
+	fallbackBound[batch] = FallbackPlacement{Pod: batch, Node: "node0", CPURequest: 500, MemRequestKb: 1000000}
+	fallbackMux.Unlock()
+	defer func() {
+		fallbackMux.Lock()
+		delete(fallbackBound, batch)
+		fallbackMux.Unlock()
+	}()
+
+	if _, err := NodeCapacities("node2"); err != ErrUnknownNode {
+		t.Errorf("NodeCapacities(node2) = %v, expected ErrUnknownNode", err)
+	}
+	capacities, err := NodeCapacities()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(capacities) != 2 || capacities[0].Node != "node0" || capacities[1].Node != "node1" {
+		t.Fatalf("NodeCapacities() = %+v, expected node0 and node1", capacities)
+	}
+	c := capacities[0]
+	if c.Capacity != (Resources{CPU: 4000, MemKb: 8000000}) || c.Allocatable != (Resources{CPU: 3800, MemKb: 7500000}) {
+		t.Errorf("NodeCapacities() reported the capacity %+v and allocatable %+v of node0", c.Capacity, c.Allocatable)
+	}
+	if c.Requested != (Resources{CPU: 1000, MemKb: 2000000}) || c.Reserved != (Resources{CPU: 500, MemKb: 1000000}) {
+		t.Errorf("NodeCapacities() reported the requested %+v and reserved %+v of node0", c.Requested, c.Reserved)
+	}
+	if expected := (Resources{CPU: 2000, MemKb: 4000000}); c.Available != expected {
+		t.Errorf("NodeCapacities() reported %+v available on node0, expected %+v", c.Available, expected)
+	}
+	if capacities[1].Available != (Resources{CPU: 1000, MemKb: 1000000}) {
+		t.Errorf("NodeCapacities() reported %+v available on the empty node1", capacities[1].Available)
+	}
+}
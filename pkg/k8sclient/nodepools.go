@@ -0,0 +1,120 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+)
+
+// NodePoolPolicy steers the pods to node pools by priority class. The pool
+// of a node is the value of its PoolLabel. The pods of a class listed in
+// Classes are only placed on the given pools, and the Dedicated pools only
+// run the pods of the classes allowed on them, e.g. so that system critical
+// pods run on dedicated nodes. Nodes without the pool label are in no pool.
+type NodePoolPolicy struct {
+	PoolLabel string              `json:"poolLabel"`
+	Classes   map[string][]string `json:"classes,omitempty"`
+	Dedicated []string            `json:"dedicated,omitempty"`
+}
+
+// LoadNodePoolPolicy reads a JSON node pool policy file.
+func LoadNodePoolPolicy(path string) (*NodePoolPolicy, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	policy := &NodePoolPolicy{}
+	if err := json.Unmarshal(data, policy); err != nil {
+		return nil, fmt.Errorf("invalid node pool policy %s: %v", path, err)
+	}
+	if err := policy.validate(); err != nil {
+		return nil, fmt.Errorf("invalid node pool policy %s: %v", path, err)
+	}
+	sort.Strings(policy.Dedicated)
+	return policy, nil
+}
+
+func (p *NodePoolPolicy) validate() error {
+	if p.PoolLabel == "" {
+		return fmt.Errorf("the pool label must be set")
+	}
+	for class, pools := range p.Classes {
+		if len(pools) == 0 {
+			return fmt.Errorf("no pool allowed to priority class %s", class)
+		}
+	}
+	for _, dedicated := range p.Dedicated {
+		if len(p.classesAllowedOn(dedicated)) == 0 {
+			// Nothing could run on the pool.
+			return fmt.Errorf("dedicated pool %s is allowed to no priority class", dedicated)
+		}
+	}
+	return nil
+}
+
+// classesAllowedOn returns the priority classes allowed on the pool.
+func (p *NodePoolPolicy) classesAllowedOn(pool string) []string {
+	var classes []string
+	for class, pools := range p.Classes {
+		for _, allowed := range pools {
+			if allowed == pool {
+				classes = append(classes, class)
+				break
+			}
+		}
+	}
+	return classes
+}
+
+// nodePoolPolicy is the node pool policy, nil if the pods can run on any
+// pool.
+var nodePoolPolicy *NodePoolPolicy
+
+// nodePoolCompiler restricts the tasks to the node pools of their priority
+// class.
+type nodePoolCompiler struct{}
+
+func (nodePoolCompiler) Name() string {
+	return "nodePool"
+}
+
+func (nodePoolCompiler) Compile(pod *Pod, td *firmament.TaskDescriptor) error {
+	if nodePoolPolicy == nil {
+		return nil
+	}
+	if pools, ok := nodePoolPolicy.Classes[pod.PriorityClassName]; ok && pod.PriorityClassName != "" {
+		td.LabelSelectors = append(td.LabelSelectors, &firmament.LabelSelector{
+			Type:   firmament.LabelSelector_IN_SET,
+			Key:    nodePoolPolicy.PoolLabel,
+			Values: pools,
+		})
+		return nil
+	}
+	if len(nodePoolPolicy.Dedicated) > 0 {
+		td.LabelSelectors = append(td.LabelSelectors, &firmament.LabelSelector{
+			Type:   firmament.LabelSelector_NOT_IN_SET,
+			Key:    nodePoolPolicy.PoolLabel,
+			Values: nodePoolPolicy.Dedicated,
+		})
+	}
+	return nil
+}
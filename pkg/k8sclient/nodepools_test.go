@@ -0,0 +1,115 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+)
+
+func TestLoadNodePoolPolicy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nodepools")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	var testData = []struct {
+		name  string
+		data  string
+		valid bool
+	}{
+		{
+			name:  "valid",
+			data:  `{"poolLabel": "pool", "classes": {"system-cluster-critical": ["system"]}, "dedicated": ["system"]}`,
+			valid: true,
+		},
+		{
+			name: "no pool label",
+			data: `{"classes": {"system-cluster-critical": ["system"]}}`,
+		},
+		{
+			name: "class without pools",
+			data: `{"poolLabel": "pool", "classes": {"system-cluster-critical": []}}`,
+		},
+		{
+			name: "unused dedicated pool",
+			data: `{"poolLabel": "pool", "classes": {"system-cluster-critical": ["system"]}, "dedicated": ["gpu"]}`,
+		},
+	}
+	for _, tc := range testData {
+		path := filepath.Join(dir, "pools.json")
+		if err := ioutil.WriteFile(path, []byte(tc.data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := LoadNodePoolPolicy(path)
+		if (err == nil) != tc.valid {
+			t.Errorf("%s: LoadNodePoolPolicy() = %v, expected valid %v", tc.name, err, tc.valid)
+		}
+	}
+}
+
+func TestNodePoolCompiler(t *testing.T) {
+	defer func() { nodePoolPolicy = nil }()
+	nodePoolPolicy = &NodePoolPolicy{
+		PoolLabel: "pool",
+		Classes: map[string][]string{
+			"system-cluster-critical": {"system"},
+			"batch":                   {"batch", "spot"},
+		},
+		Dedicated: []string{"system"},
+	}
+	var testData = []struct {
+		class    string
+		expected []*firmament.LabelSelector
+	}{
+		{
+			class:    "system-cluster-critical",
+			expected: []*firmament.LabelSelector{{Type: firmament.LabelSelector_IN_SET, Key: "pool", Values: []string{"system"}}},
+		},
+		{
+			class:    "batch",
+			expected: []*firmament.LabelSelector{{Type: firmament.LabelSelector_IN_SET, Key: "pool", Values: []string{"batch", "spot"}}},
+		},
+		{
+			class:    "web",
+			expected: []*firmament.LabelSelector{{Type: firmament.LabelSelector_NOT_IN_SET, Key: "pool", Values: []string{"system"}}},
+		},
+		{
+			expected: []*firmament.LabelSelector{{Type: firmament.LabelSelector_NOT_IN_SET, Key: "pool", Values: []string{"system"}}},
+		},
+	}
+	for _, tc := range testData {
+		td := &firmament.TaskDescriptor{}
+		if err := (nodePoolCompiler{}).Compile(&Pod{PriorityClassName: tc.class}, td); err != nil {
+			t.Errorf("Compile() of class %q = %v", tc.class, err)
+		}
+		if !reflect.DeepEqual(td.LabelSelectors, tc.expected) {
+			t.Errorf("Compile() of class %q set %v, expected %v", tc.class, td.LabelSelectors, tc.expected)
+		}
+	}
+	nodePoolPolicy = nil
+	td := &firmament.TaskDescriptor{}
+	(nodePoolCompiler{}).Compile(&Pod{PriorityClassName: "batch"}, td)
+	if len(td.LabelSelectors) != 0 {
+		t.Errorf("Compile() without policy set %v, expected no selector", td.LabelSelectors)
+	}
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"sync"
+
+	"github.com/golang/glog"
+)
+
+// nodeTargetedPod is a pod bound to its node by its creator rather than by
+// Poseidon, e.g. a node debug pod created by kubectl debug node/<node>, or a
+// pod bound before Poseidon started.
+type nodeTargetedPod struct {
+	node string
+	// cpuRequest is in millicores and memRequestKb in KB.
+	cpuRequest, memRequestKb int64
+	// done is set once the pod completed and no longer uses its node.
+	done bool
+}
+
+var (
+	nodeTargetedMux sync.Mutex
+	// nodeTargetedPods are the claimed pods bound to a node without a task,
+	// which are never submitted to Firmament.
+	nodeTargetedPods = make(map[PodIdentifier]*nodeTargetedPod)
+)
+
+// isNodeTargeted returns whether the pod is bound to a node without
+// Poseidon having placed it.
+func isNodeTargeted(pod *Pod) bool {
+	if pod.NodeName == "" {
+		return false
+	}
+	_, ok := state.TaskOfPod(pod.Identifier)
+	return !ok
+}
+
+// registerNodeTargetedPod records a pod bound to its node without a task, so
+// that its requests are deducted from the capacity of the node advertised to
+// Firmament, which does not know the pod.
+func registerNodeTargetedPod(pod *Pod) {
+	nodeTargetedMux.Lock()
+	targeted, ok := nodeTargetedPods[pod.Identifier]
+	if ok && targeted.node == pod.NodeName && targeted.cpuRequest == pod.CPURequest &&
+		targeted.memRequestKb == pod.MemRequestKb && !targeted.done {
+		nodeTargetedMux.Unlock()
+		return
+	}
+	nodeTargetedPods[pod.Identifier] = &nodeTargetedPod{node: pod.NodeName, cpuRequest: pod.CPURequest, memRequestKb: pod.MemRequestKb}
+	nodeTargetedMux.Unlock()
+	glog.Infof("Pod %s is bound to node %s without being scheduled, accounting it in the node capacity", pod.Identifier, pod.NodeName)
+	if ok && targeted.node != pod.NodeName {
+		refreshNodeCapacity(targeted.node)
+	}
+	refreshNodeCapacity(pod.NodeName)
+}
+
+// completeNodeTargetedPod releases the node of a completed node targeted
+// pod. It returns false if the pod is not node targeted.
+func completeNodeTargetedPod(podIdentifier PodIdentifier) bool {
+	nodeTargetedMux.Lock()
+	targeted, ok := nodeTargetedPods[podIdentifier]
+	if !ok {
+		nodeTargetedMux.Unlock()
+		return false
+	}
+	released := !targeted.done
+	targeted.done = true
+	nodeTargetedMux.Unlock()
+	if released {
+		refreshNodeCapacity(targeted.node)
+	}
+	return true
+}
+
+// forgetNodeTargetedPod drops a deleted node targeted pod. It returns false
+// if the pod is not node targeted.
+func forgetNodeTargetedPod(podIdentifier PodIdentifier) bool {
+	if !completeNodeTargetedPod(podIdentifier) {
+		return false
+	}
+	nodeTargetedMux.Lock()
+	defer nodeTargetedMux.Unlock()
+	delete(nodeTargetedPods, podIdentifier)
+	return true
+}
+
+// isNodeTargetedPod returns whether the pod is a known node targeted pod.
+func isNodeTargetedPod(podIdentifier PodIdentifier) bool {
+	nodeTargetedMux.Lock()
+	defer nodeTargetedMux.Unlock()
+	_, ok := nodeTargetedPods[podIdentifier]
+	return ok
+}
+
+// nodeTargetedOverhead returns the CPU in millicores and memory in KB
+// requested by the running node targeted pods of the node.
+func nodeTargetedOverhead(nodeName string) (int64, int64) {
+	nodeTargetedMux.Lock()
+	defer nodeTargetedMux.Unlock()
+	var cpu, memKb int64
+	for _, targeted := range nodeTargetedPods {
+		if targeted.node == nodeName && !targeted.done {
+			cpu += targeted.cpuRequest
+			memKb += targeted.memRequestKb
+		}
+	}
+	return cpu, memKb
+}
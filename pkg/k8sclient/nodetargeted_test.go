@@ -0,0 +1,75 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"testing"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+)
+
+func TestNodeTargetedPods(t *testing.T) {
+	state = &memoryState{}
+	state.podToTD = map[PodIdentifier]*firmament.TaskDescriptor{}
+	refreshed := make(chan string, 10)
+	refreshNodeCapacity = func(nodeName string) { refreshed <- nodeName }
+	defer func() {
+		refreshNodeCapacity = func(string) {}
+		nodeTargetedPods = make(map[PodIdentifier]*nodeTargetedPod)
+	}()
+
+	scheduled := PodIdentifier{Name: "web", Namespace: "ns"}
+	state.podToTD[scheduled] = &firmament.TaskDescriptor{Uid: 1}
+	var testData = []struct {
+		pod      *Pod
+		expected bool
+	}{
+		{pod: &Pod{Identifier: PodIdentifier{Name: "pending", Namespace: "ns"}}},
+		{pod: &Pod{Identifier: scheduled, NodeName: "node0"}},
+		{pod: &Pod{Identifier: PodIdentifier{Name: "node-debugger", Namespace: "ns"}, NodeName: "node0"}, expected: true},
+	}
+	for _, tc := range testData {
+		if targeted := isNodeTargeted(tc.pod); targeted != tc.expected {
+			t.Errorf("isNodeTargeted(%v) = %v, expected %v", tc.pod.Identifier, targeted, tc.expected)
+		}
+	}
+
+	debug := &Pod{Identifier: PodIdentifier{Name: "node-debugger", Namespace: "ns"}, NodeName: "node0", CPURequest: 100, MemRequestKb: 1024}
+	registerNodeTargetedPod(debug)
+	registerNodeTargetedPod(debug)
+	if nodeName := <-refreshed; nodeName != "node0" || len(refreshed) != 0 {
+		t.Errorf("registerNodeTargetedPod() refreshed %s and %d more nodes, expected node0 once", nodeName, len(refreshed))
+	}
+	cpu, ramCap := nodeCapacity(&Node{Hostname: "node0", CPUCapacity: 1000, MemCapacityKb: 4096})
+	if cpu != 900 || ramCap != 3072 {
+		t.Errorf("nodeCapacity() = %v, %v with a node debug pod, expected 900, 3072", cpu, ramCap)
+	}
+
+	// The completed pod no longer uses its node, but is known until deleted.
+	if !completeNodeTargetedPod(debug.Identifier) || <-refreshed != "node0" {
+		t.Error("completeNodeTargetedPod() did not release node0")
+	}
+	if cpu, memKb := nodeTargetedOverhead("node0"); cpu != 0 || memKb != 0 {
+		t.Errorf("nodeTargetedOverhead() = %d, %d after the pod completed, expected none", cpu, memKb)
+	}
+	if !forgetNodeTargetedPod(debug.Identifier) || len(refreshed) != 0 {
+		t.Error("forgetNodeTargetedPod() did not drop the completed pod quietly")
+	}
+	if forgetNodeTargetedPod(debug.Identifier) || completeNodeTargetedPod(scheduled) {
+		t.Error("Pods which are not node targeted handled as node targeted")
+	}
+}
@@ -19,7 +19,6 @@ package k8sclient
 import (
 	"fmt"
 	"reflect"
-	"sync"
 	"time"
 
 	"github.com/golang/glog"
@@ -37,14 +36,12 @@ import (
 // NewNodeWatcher initializes a NodeWatcher based on the given Kubernetes client and Firmament client.
 func NewNodeWatcher(client kubernetes.Interface, fc firmament.FirmamentSchedulerClient) *NodeWatcher {
 	glog.Info("Starting NodeWatcher...")
-	NodeMux = new(sync.RWMutex)
-	NodeToRTND = make(map[string]*firmament.ResourceTopologyNodeDescriptor)
-	ResIDToNode = make(map[string]string)
+	state.resetNodes()
 	nodewatcher := &NodeWatcher{
 		clientset: client,
 		fc:        fc,
 	}
-	_, controller := cache.NewInformer(
+	store, controller := cache.NewInformer(
 		&cache.ListWatch{
 			ListFunc: func(alo metav1.ListOptions) (runtime.Object, error) {
 				return client.CoreV1().Nodes().List(alo)
@@ -80,7 +77,11 @@ func NewNodeWatcher(client kubernetes.Interface, fc firmament.FirmamentScheduler
 		},
 	)
 	nodewatcher.controller = controller
+	nodewatcher.nodeStore = store
 	nodewatcher.nodeWorkQueue = NewKeyedQueue()
+	if discountDaemonSetOverhead {
+		nodewatcher.daemonSetController = nodewatcher.newDaemonSetController(client)
+	}
 	return nodewatcher
 }
 
@@ -117,13 +118,14 @@ func (nw *NodeWatcher) parseNode(node *v1.Node, phase NodePhase) *Node {
 		MemAllocatableKb: memAlloc / bytesToKb,
 		Labels:           node.Labels,
 		Annotations:      node.Annotations,
+		Devices:          nodeDevices(node),
 	}
 }
 
 func (nw *NodeWatcher) enqueueNodeAddition(key, obj interface{}) {
 	node := obj.(*v1.Node)
-	if node.Spec.Unschedulable {
-		glog.Info("enqueueNodeAddition: received an Unschedulable node", node.Name)
+	if !isSchedulable(node) {
+		glog.Info("enqueueNodeAddition: received an Unschedulable or terminating node", node.Name)
 		return
 	}
 	addedNode := nw.parseNode(node, NodeAdded)
@@ -135,29 +137,46 @@ func (nw *NodeWatcher) enqueueNodeUpdate(key, oldObj, newObj interface{}) {
 	// XXX(ionel): enqueueNodeUpdate gets called whenever one of node's timestamp is updated. Figure out solution such that the method is called only when certain fields change.
 	oldNode := oldObj.(*v1.Node)
 	newNode := newObj.(*v1.Node)
-	if oldNode.Spec.Unschedulable != newNode.Spec.Unschedulable {
-		if oldNode.Spec.Unschedulable {
+	if isSchedulable(oldNode) != isSchedulable(newNode) {
+		if isSchedulable(newNode) {
 			addedNode := nw.parseNode(newNode, NodeAdded)
 			nw.nodeWorkQueue.Add(key, addedNode)
 			glog.Info("enqueueNodeUpdate: Added node ", addedNode.Hostname)
 			return
 		}
+		if isTerminating(newNode) {
+			terminatingNode := nw.parseNode(newNode, nodeTerminating)
+			nw.nodeWorkQueue.Add(key, terminatingNode)
+			glog.Info("enqueueNodeUpdate: Terminating node ", terminatingNode.Hostname)
+			return
+		}
 		// Can not schedule pods on the node any more.
 		deletedNode := nw.parseNode(newNode, NodeDeleted)
 		nw.nodeWorkQueue.Add(key, deletedNode)
 		glog.Info("enqueueNodeUpdate: Deleted node ", deletedNode.Hostname)
 		return
 	}
+	if !isSchedulable(newNode) {
+		// The node is not known to Firmament.
+		return
+	}
 	oldIsReady, oldIsOutOfDisk := nw.getReadyAndOutOfDiskConditions(oldNode)
 	newIsReady, newIsOutOfDisk := nw.getReadyAndOutOfDiskConditions(newNode)
 
 	if oldIsReady != newIsReady || oldIsOutOfDisk != newIsOutOfDisk {
 		if newIsReady && !newIsOutOfDisk {
+			if recoverNode(newNode.Name) {
+				// The node never failed.
+				return
+			}
 			addedNode := nw.parseNode(newNode, NodeAdded)
 			nw.nodeWorkQueue.Add(key, addedNode)
 			glog.Info("enqueueNodeUpdate: Added node ", addedNode.Hostname)
 			return
 		}
+		if nw.deferNodeFailure(key, newNode.Name) {
+			return
+		}
 		failedNode := nw.parseNode(newNode, NodeFailed)
 		nw.nodeWorkQueue.Add(key, failedNode)
 		glog.Info("enqueueNodeUpdate: Failed node ", failedNode.Hostname)
@@ -170,6 +189,9 @@ func (nw *NodeWatcher) enqueueNodeUpdate(key, oldObj, newObj interface{}) {
 	if !reflect.DeepEqual(oldNode.Annotations, newNode.Annotations) {
 		nodeUpdated = true
 	}
+	if !reflect.DeepEqual(degradedDeviceLabels(nodeDevices(oldNode)), degradedDeviceLabels(nodeDevices(newNode))) {
+		nodeUpdated = true
+	}
 	if nodeUpdated {
 		updatedNode := nw.parseNode(newNode, NodeUpdated)
 		nw.nodeWorkQueue.Add(key, updatedNode)
@@ -179,8 +201,10 @@ func (nw *NodeWatcher) enqueueNodeUpdate(key, oldObj, newObj interface{}) {
 
 func (nw *NodeWatcher) enqueueNodeDeletion(key, obj interface{}) {
 	node := obj.(*v1.Node)
-	if node.Spec.Unschedulable {
-		// Poseidon doesn't care about Unschedulable nodes.
+	if !isSchedulable(node) {
+		// Poseidon doesn't care about Unschedulable nodes, and already
+		// removed the terminating ones.
+		forgetTerminatingNode(node.Name)
 		return
 	}
 	deletedNode := &Node{
@@ -200,14 +224,21 @@ func (nw *NodeWatcher) Run(stopCh <-chan struct{}, nWorkers int) {
 	defer glog.Info("Shutting down NodeWatcher")
 	glog.Info("Getting node updates...")
 
+	start := time.Now()
 	go nw.controller.Run(stopCh)
+	synced := []cache.InformerSynced{nw.controller.HasSynced}
+	if nw.daemonSetController != nil {
+		go nw.daemonSetController.Run(stopCh)
+		synced = append(synced, nw.daemonSetController.HasSynced)
+	}
 
-	if !cache.WaitForCacheSync(stopCh, nw.controller.HasSynced) {
+	if !cache.WaitForCacheSync(stopCh, synced...) {
 		utilruntime.HandleError(fmt.Errorf("Timed out waiting for caches to sync"))
 		return
 	}
 
-	glog.Info("Starting node watching workers")
+	nw.startup = newStartupSync("node", nw.nodeWorkQueue.Keys(), start)
+	glog.Infof("Starting %d node watching workers", nWorkers)
 	for i := 0; i < nWorkers; i++ {
 		go wait.Until(nw.nodeWorker, time.Second, stopCh)
 	}
@@ -227,63 +258,138 @@ func (nw *NodeWatcher) nodeWorker() {
 				node := item.(*Node)
 				switch node.Phase {
 				case NodeAdded:
-					NodeMux.Lock()
+					state.nodeMux.Lock()
 					rtnd := nw.createResourceTopologyForNode(node)
-					_, ok := NodeToRTND[node.Hostname]
+					_, ok := state.nodeToRTND[node.Hostname]
 					if ok {
-						glog.Fatalf("Node %s already exists", node.Hostname)
+						state.nodeMux.Unlock()
+						handleError(stateMismatch("NodeAdded", "node %s already exists", node.Hostname))
+						continue
 					}
-					NodeToRTND[node.Hostname] = rtnd
-					ResIDToNode[rtnd.GetResourceDesc().GetUuid()] = node.Hostname
-					NodeMux.Unlock()
-					firmament.NodeAdded(nw.fc, rtnd)
+					state.setNode(node.Hostname, rtnd)
+					recordNodeShape(node)
+					nw.startWarmUp(key, node, rtnd)
+					state.nodeMux.Unlock()
+					handleError(firmament.NodeAdded(nw.fc, rtnd))
 
 				case NodeDeleted:
-					NodeMux.RLock()
-					rtnd, ok := NodeToRTND[node.Hostname]
-					NodeMux.RUnlock()
+					forgetNotReadyNode(node.Hostname)
+					state.nodeMux.RLock()
+					rtnd, ok := state.nodeToRTND[node.Hostname]
+					state.nodeMux.RUnlock()
 					if !ok {
-						glog.Fatalf("Node %s does not exist", node.Hostname)
+						handleError(stateMismatch("NodeDeleted", "node %s does not exist", node.Hostname))
+						continue
 					}
 					resID := rtnd.GetResourceDesc().GetUuid()
-					firmament.NodeRemoved(nw.fc, &firmament.ResourceUID{ResourceUid: resID})
-					NodeMux.Lock()
-					delete(NodeToRTND, node.Hostname)
-					delete(ResIDToNode, resID)
-					NodeMux.Unlock()
+					handleError(firmament.NodeRemoved(nw.fc, &firmament.ResourceUID{ResourceUid: resID}))
+					state.nodeMux.Lock()
+					delete(warmingNodes, node.Hostname)
+					delete(nodeShapes, node.Hostname)
+					state.deleteNode(node.Hostname, resID)
+					state.nodeMux.Unlock()
+					forgetNodeUsage(node.Hostname)
 				case NodeFailed:
-					NodeMux.RLock()
-					rtnd, ok := NodeToRTND[node.Hostname]
-					NodeMux.RUnlock()
+					state.nodeMux.RLock()
+					rtnd, ok := state.nodeToRTND[node.Hostname]
+					state.nodeMux.RUnlock()
 					if !ok {
-						glog.Fatalf("Node %s does not exist", node.Hostname)
+						handleError(stateMismatch("NodeFailed", "node %s does not exist", node.Hostname))
+						continue
 					}
-					resID := rtnd.GetResourceDesc().GetUuid()
-					firmament.NodeFailed(nw.fc, &firmament.ResourceUID{ResourceUid: resID})
-					NodeMux.Lock()
-					nw.cleanResourceStateForNode(rtnd)
-					delete(NodeToRTND, node.Hostname)
-					delete(ResIDToNode, resID)
-					NodeMux.Unlock()
+					nw.failNode(node.Hostname, rtnd)
+				case nodeNotReadyExpired:
+					if !expireNotReadyNode(node.Hostname) {
+						// The node recovered or was removed in the meantime.
+						continue
+					}
+					state.nodeMux.RLock()
+					rtnd, ok := state.nodeToRTND[node.Hostname]
+					state.nodeMux.RUnlock()
+					if !ok {
+						continue
+					}
+					glog.Infof("Node %s was not ready for %v, failing it", node.Hostname, notReadyGracePeriod)
+					nw.failNode(node.Hostname, rtnd)
 				case NodeUpdated:
-					NodeMux.RLock()
-					rtnd, ok := NodeToRTND[node.Hostname]
-					NodeMux.RUnlock()
+					state.nodeMux.RLock()
+					rtnd, ok := state.nodeToRTND[node.Hostname]
+					state.nodeMux.RUnlock()
+					if !ok {
+						handleError(stateMismatch("NodeUpdated", "node %s does not exist", node.Hostname))
+						continue
+					}
+					state.nodeMux.Lock()
+					if isWarmUpComplete(node) {
+						nw.stopWarmUp(node.Hostname)
+					}
+					// Refresh the labels, e.g. when the host paths annotation
+					// changed or devices became unhealthy.
+					labels := nodeLabels(node)
+					rtnd.ResourceDesc.Labels = labels
+					for _, childRTND := range rtnd.GetChildren() {
+						childRTND.ResourceDesc.Labels = labels
+					}
+					state.nodeMux.Unlock()
+					handleError(firmament.NodeUpdated(nw.fc, rtnd))
+				case nodeTerminating:
+					forgetNotReadyNode(node.Hostname)
+					state.nodeMux.RLock()
+					rtnd, ok := state.nodeToRTND[node.Hostname]
+					state.nodeMux.RUnlock()
 					if !ok {
-						glog.Fatalf("Node %s does not exist", node.Hostname)
+						// The node already failed.
+						glog.V(2).Infof("Terminating node %s is not known to Firmament", node.Hostname)
+						continue
 					}
-					firmament.NodeUpdated(nw.fc, rtnd)
+					nw.terminate(node.Hostname, rtnd)
+				case nodeCapacityChanged:
+					state.nodeMux.Lock()
+					rtnd, ok := state.nodeToRTND[node.Hostname]
+					if !ok {
+						// The node failed or was removed in the meantime.
+						state.nodeMux.Unlock()
+						continue
+					}
+					updateCapacity(node, rtnd)
+					state.nodeMux.Unlock()
+					handleError(firmament.NodeUpdated(nw.fc, rtnd))
+				case nodeWarmingUp:
+					state.nodeMux.Lock()
+					warming, ok := warmingNodes[node.Hostname]
+					if !ok {
+						// The node warmed up or was removed in the meantime.
+						state.nodeMux.Unlock()
+						continue
+					}
+					nw.rampUp(key, node.Hostname, warming)
+					rtnd := state.nodeToRTND[node.Hostname]
+					state.nodeMux.Unlock()
+					handleError(firmament.NodeUpdated(nw.fc, rtnd))
 				default:
 					glog.Fatalf("Unexpected node %s phase %s", node.Hostname, node.Phase)
 				}
 			}
+			defer nw.startup.processed(key)
 			defer nw.nodeWorkQueue.Done(key)
 		}()
 	}
 }
 
+// failNode removes a failed node from Firmament, which fails its tasks.
+func (nw *NodeWatcher) failNode(hostname string, rtnd *firmament.ResourceTopologyNodeDescriptor) {
+	resID := rtnd.GetResourceDesc().GetUuid()
+	handleError(firmament.NodeFailed(nw.fc, &firmament.ResourceUID{ResourceUid: resID}))
+	state.nodeMux.Lock()
+	delete(warmingNodes, hostname)
+	delete(nodeShapes, hostname)
+	nw.cleanResourceStateForNode(rtnd)
+	state.deleteNode(hostname, resID)
+	state.nodeMux.Unlock()
+}
+
 func (nw *NodeWatcher) cleanResourceStateForNode(rtnd *firmament.ResourceTopologyNodeDescriptor) {
-	delete(ResIDToNode, rtnd.GetResourceDesc().GetUuid())
+	state.deleteResource(rtnd.GetResourceDesc().GetUuid())
 	for _, childRTND := range rtnd.GetChildren() {
 		nw.cleanResourceStateForNode(childRTND)
 	}
@@ -291,6 +397,7 @@ func (nw *NodeWatcher) cleanResourceStateForNode(rtnd *firmament.ResourceTopolog
 
 func (nw *NodeWatcher) createResourceTopologyForNode(node *Node) *firmament.ResourceTopologyNodeDescriptor {
 	resUUID := nw.generateResourceID(node.Hostname)
+	cpuCapacity, ramCapacity := nodeCapacity(node)
 	rtnd := &firmament.ResourceTopologyNodeDescriptor{
 		ResourceDesc: &firmament.ResourceDescriptor{
 			Uuid:         resUUID,
@@ -298,21 +405,14 @@ func (nw *NodeWatcher) createResourceTopologyForNode(node *Node) *firmament.Reso
 			State:        firmament.ResourceDescriptor_RESOURCE_IDLE,
 			FriendlyName: node.Hostname,
 			ResourceCapacity: &firmament.ResourceVector{
-				RamCap:   uint64(node.MemCapacityKb),
-				CpuCores: float32(node.CPUCapacity),
+				RamCap:   ramCapacity,
+				CpuCores: cpuCapacity,
 			},
 		},
 	}
-	ResIDToNode[resUUID] = node.Hostname
+	state.setResource(resUUID, node.Hostname)
 	// TODO(ionel) Add annotations.
-	// Add labels.
-	for label, value := range node.Labels {
-		rtnd.ResourceDesc.Labels = append(rtnd.ResourceDesc.Labels,
-			&firmament.Label{
-				Key:   label,
-				Value: value,
-			})
-	}
+	rtnd.ResourceDesc.Labels = nodeLabels(node)
 	// TODO(ionel): In the future, we want to get real node topology.
 	// We currently only create a PU per machine because Heapster doesn't
 	// provide per PU/core statistics.
@@ -326,18 +426,33 @@ func (nw *NodeWatcher) createResourceTopologyForNode(node *Node) *firmament.Reso
 			FriendlyName: friendlyName,
 			Labels:       rtnd.ResourceDesc.Labels,
 			ResourceCapacity: &firmament.ResourceVector{
-				RamCap:   uint64(node.MemCapacityKb),
-				CpuCores: float32(node.CPUCapacity),
+				RamCap:   ramCapacity,
+				CpuCores: cpuCapacity,
 			},
 		},
 		ParentId: resUUID,
 	}
 	rtnd.Children = append(rtnd.Children, puRtnd)
-	ResIDToNode[puUUID] = node.Hostname
+	state.setResource(puUUID, node.Hostname)
 
 	return rtnd
 }
 
+// nodeLabels returns the Firmament labels of a node: its Kubernetes labels,
+// the host paths it holds and its degraded device resources.
+func nodeLabels(node *Node) []*firmament.Label {
+	var labels []*firmament.Label
+	for label, value := range node.Labels {
+		labels = append(labels,
+			&firmament.Label{
+				Key:   label,
+				Value: value,
+			})
+	}
+	labels = append(labels, hostPathLabels(node.Annotations)...)
+	return append(labels, degradedDeviceLabels(node.Devices)...)
+}
+
 func (nw *NodeWatcher) generateResourceID(seed string) string {
 	return GenerateUUID(seed)
 }
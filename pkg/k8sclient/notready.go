@@ -0,0 +1,103 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/kubernetes-sigs/poseidon/pkg/metrics"
+)
+
+// nodeNotReadyExpired is an internal phase used to fail a node which stayed
+// not ready for the grace period.
+const nodeNotReadyExpired NodePhase = "NotReadyExpired"
+
+// notReadyGracePeriod is the time a node stays not ready or out of disk
+// before it is failed, so that the brief flaps, e.g. network blips, neither
+// withdraw its capacity nor migrate its pods. 0 fails the nodes right away.
+var notReadyGracePeriod time.Duration
+
+var (
+	notReadyMux sync.Mutex
+	// notReadyNodes maps the names of the nodes not ready for less than the
+	// grace period to the time they became not ready.
+	notReadyNodes = make(map[string]time.Time)
+)
+
+// deferNodeFailure delays the failure of a node which is no longer ready by
+// the grace period. It returns false if the node must fail right away.
+func (nw *NodeWatcher) deferNodeFailure(key interface{}, hostname string) bool {
+	if notReadyGracePeriod <= 0 {
+		return false
+	}
+	notReadyMux.Lock()
+	if _, ok := notReadyNodes[hostname]; ok {
+		// The node is already within its grace period.
+		notReadyMux.Unlock()
+		return true
+	}
+	since := time.Now()
+	notReadyNodes[hostname] = since
+	notReadyMux.Unlock()
+	glog.Infof("Node %s is not ready, failing it in %v unless it recovers", hostname, notReadyGracePeriod)
+	time.AfterFunc(notReadyGracePeriod, func() {
+		notReadyMux.Lock()
+		current, ok := notReadyNodes[hostname]
+		notReadyMux.Unlock()
+		if !ok || !current.Equal(since) {
+			// The node recovered or was removed in the meantime.
+			return
+		}
+		nw.nodeWorkQueue.Add(key, &Node{Hostname: hostname, Phase: nodeNotReadyExpired})
+	})
+	return true
+}
+
+// recoverNode cancels the failure of a node ready again within its grace
+// period. It returns false if the node was not within its grace period.
+func recoverNode(hostname string) bool {
+	notReadyMux.Lock()
+	since, ok := notReadyNodes[hostname]
+	delete(notReadyNodes, hostname)
+	notReadyMux.Unlock()
+	if ok {
+		metrics.NodeNotReadyFlaps.Inc()
+		glog.Infof("Node %s is ready again after %v, keeping it", hostname, time.Since(since))
+	}
+	return ok
+}
+
+// expireNotReadyNode ends the grace period of a node. It returns false if
+// the node recovered or was removed in the meantime.
+func expireNotReadyNode(hostname string) bool {
+	notReadyMux.Lock()
+	defer notReadyMux.Unlock()
+	if _, ok := notReadyNodes[hostname]; !ok {
+		return false
+	}
+	delete(notReadyNodes, hostname)
+	return true
+}
+
+// forgetNotReadyNode drops the grace period of a removed node.
+func forgetNotReadyNode(hostname string) {
+	notReadyMux.Lock()
+	defer notReadyMux.Unlock()
+	delete(notReadyNodes, hostname)
+}
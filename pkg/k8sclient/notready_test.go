@@ -0,0 +1,69 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestNodeWatcher_notReadyGracePeriod(t *testing.T) {
+	defer func(period time.Duration) { notReadyGracePeriod = period }(notReadyGracePeriod)
+	nw := &NodeWatcher{nodeWorkQueue: NewKeyedQueue()}
+	ready := BuildNode("node0", "10", "1024", nil, []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}}, false)
+	notReady := BuildNode("node0", "10", "1024", nil, []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionFalse}}, false)
+	key, err := cache.MetaNamespaceKeyFunc(ready)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := func() NodePhase {
+		_, items, _ := nw.nodeWorkQueue.Get()
+		nw.nodeWorkQueue.Done(key)
+		return items[len(items)-1].(*Node).Phase
+	}
+
+	// Without grace period, the node fails right away.
+	notReadyGracePeriod = 0
+	nw.enqueueNodeUpdate(key, ready, notReady)
+	if phase := next(); phase != NodeFailed {
+		t.Fatalf("enqueueNodeUpdate() queued %s without grace period, expected %s", phase, NodeFailed)
+	}
+
+	// A flap within the grace period is ignored.
+	notReadyGracePeriod = time.Hour
+	nw.enqueueNodeUpdate(key, ready, notReady)
+	nw.enqueueNodeUpdate(key, notReady, ready)
+	if len(nw.nodeWorkQueue.Keys()) != 0 {
+		t.Fatalf("enqueueNodeUpdate() queued %v for a flap, expected nothing", nw.nodeWorkQueue.Keys())
+	}
+	if expireNotReadyNode("node0") {
+		t.Error("expireNotReadyNode() expired a node ready again")
+	}
+
+	// A sustained outage fails the node at the end of the grace period.
+	notReadyGracePeriod = 10 * time.Millisecond
+	nw.enqueueNodeUpdate(key, ready, notReady)
+	if phase := next(); phase != nodeNotReadyExpired {
+		t.Fatalf("enqueueNodeUpdate() queued %s after the grace period, expected %s", phase, nodeNotReadyExpired)
+	}
+	if !expireNotReadyNode("node0") || expireNotReadyNode("node0") {
+		t.Error("expireNotReadyNode() did not expire the grace period of the node once")
+	}
+}
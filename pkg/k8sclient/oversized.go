@@ -0,0 +1,115 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"fmt"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/metrics"
+	"k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// OversizedCondition is the pod condition set on the pods whose requests
+// exceed the allocatable of every node.
+const OversizedCondition v1.PodConditionType = "poseidon.k8s.io/Oversized"
+
+// OversizedPodPolicy is the handling of the pods whose requests exceed the
+// allocatable of every node, which Firmament would fail to place every
+// cycle. They are reported with an event and the Oversized condition.
+type OversizedPodPolicy struct {
+	// Reject keeps the oversized pods from being submitted to Firmament
+	// until a node they fit on is added.
+	Reject bool
+	// ScaleUpHint also marks the oversized pods unschedulable, so that the
+	// cluster autoscaler adds a node of a shape they fit on if one of its
+	// node groups has one.
+	ScaleUpHint bool
+}
+
+// oversizedPodPolicy is the handling of the oversized pods, nil if they are
+// submitted as the other pods.
+var oversizedPodPolicy *OversizedPodPolicy
+
+// nodeShape is the allocatable of a node, in millicores and KB, and its
+// capacity.
+type nodeShape struct {
+	cpu, memKb                 int64
+	capacityCPU, capacityMemKb int64
+}
+
+// nodeShapes maps the nodes to their allocatable. Guarded by state.nodeMux.
+var nodeShapes = make(map[string]nodeShape)
+
+// recordNodeShape records the allocatable and capacity of an added node.
+// The caller must hold state.nodeMux.
+func recordNodeShape(node *Node) {
+	nodeShapes[node.Hostname] = nodeShape{
+		cpu:           node.CPUAllocatable,
+		memKb:         node.MemAllocatableKb,
+		capacityCPU:   node.CPUCapacity,
+		capacityMemKb: node.MemCapacityKb,
+	}
+}
+
+// oversizedRequests returns why the requests of the pod exceed the
+// allocatable of every node, empty if a node is large enough or no node is
+// known yet. The allocatable is the one of the node, regardless of the
+// capacity reserved or temporarily withheld by Poseidon.
+func oversizedRequests(pod *Pod) string {
+	if !watcherSynchronized("node") {
+		return ""
+	}
+	state.nodeMux.RLock()
+	defer state.nodeMux.RUnlock()
+	if len(nodeShapes) == 0 {
+		return ""
+	}
+	var largest nodeShape
+	for _, shape := range nodeShapes {
+		if pod.CPURequest <= shape.cpu && pod.MemRequestKb <= shape.memKb {
+			return ""
+		}
+		if shape.cpu > largest.cpu {
+			largest.cpu = shape.cpu
+		}
+		if shape.memKb > largest.memKb {
+			largest.memKb = shape.memKb
+		}
+	}
+	return fmt.Sprintf("requests of %dm CPU and %d KB of memory exceed the allocatable of every node, of at most %dm CPU and %d KB",
+		pod.CPURequest, pod.MemRequestKb, largest.cpu, largest.memKb)
+}
+
+// reportOversized reports an oversized pod, unless it was already for the
+// same requests. It returns whether the pod must not be submitted.
+func (pw *PodWatcher) reportOversized(pod *Pod, reason string) bool {
+	conditions := []v1.PodCondition{{
+		Type:               OversizedCondition,
+		Status:             v1.ConditionTrue,
+		Reason:             "ExceedsNodeAllocatable",
+		Message:            reason,
+		LastTransitionTime: meta_v1.Now(),
+	}}
+	if oversizedPodPolicy.ScaleUpHint {
+		conditions = append(conditions, unschedulableCondition(reason))
+	}
+	if pw.reportUnschedulable(pod, "Oversized", reason, conditions...) {
+		metrics.OversizedPods.Inc()
+	}
+	return oversizedPodPolicy.Reject
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestOversizedRequests(t *testing.T) {
+	state = &memoryState{}
+	defer func() {
+		nodeShapes = make(map[string]nodeShape)
+		synchronizedMux.Lock()
+		delete(synchronizedWatchers, "node")
+		synchronizedMux.Unlock()
+	}()
+	recordNodeShape(&Node{Hostname: "cpu", CPUAllocatable: 32000, MemAllocatableKb: 64000000})
+	recordNodeShape(&Node{Hostname: "mem", CPUAllocatable: 8000, MemAllocatableKb: 256000000})
+	newStartupSync("node", nil, time.Now())
+	var testData = []struct {
+		cpu, memKb int64
+		oversized  bool
+	}{
+		{cpu: 16000, memKb: 32000000},
+		{cpu: 32000, memKb: 64000000},
+		{cpu: 4000, memKb: 200000000},
+		// Each request fits on a node, but no node fits both.
+		{cpu: 16000, memKb: 128000000, oversized: true},
+		{cpu: 64000, memKb: 1000, oversized: true},
+	}
+	for _, tc := range testData {
+		reason := oversizedRequests(&Pod{CPURequest: tc.cpu, MemRequestKb: tc.memKb})
+		if (reason != "") != tc.oversized {
+			t.Errorf("oversizedRequests(%dm, %d KB) = %q, expected oversized %v", tc.cpu, tc.memKb, reason, tc.oversized)
+		}
+	}
+
+	defer func() { oversizedPodPolicy = nil }()
+	var policies = []struct {
+		policy     OversizedPodPolicy
+		conditions []v1.PodConditionType
+	}{
+		{policy: OversizedPodPolicy{}, conditions: []v1.PodConditionType{OversizedCondition}},
+		{policy: OversizedPodPolicy{Reject: true, ScaleUpHint: true}, conditions: []v1.PodConditionType{OversizedCondition, v1.PodScheduled}},
+	}
+	podIdentifier := PodIdentifier{Name: "pod0", Namespace: "ns"}
+	pod := &Pod{Identifier: podIdentifier, CPURequest: 64000}
+	for _, tc := range policies {
+		oversizedPodPolicy = &tc.policy
+		client := fake.NewSimpleClientset(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod0", Namespace: "ns"}})
+		pw := &PodWatcher{clientset: client, schedulerName: "poseidon"}
+		if reject := pw.reportOversized(pod, oversizedRequests(pod)); reject != tc.policy.Reject {
+			t.Errorf("reportOversized() = %v with %+v, expected %v", reject, tc.policy, tc.policy.Reject)
+		}
+		forgetUnschedulable(podIdentifier)
+		updated, err := client.CoreV1().Pods("ns").Get("pod0", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var conditions []v1.PodConditionType
+		for _, condition := range updated.Status.Conditions {
+			conditions = append(conditions, condition.Type)
+		}
+		if !reflect.DeepEqual(conditions, tc.conditions) {
+			t.Errorf("reportOversized() set the conditions %v with %+v, expected %v", conditions, tc.policy, tc.conditions)
+		}
+	}
+}
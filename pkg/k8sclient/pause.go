@@ -0,0 +1,93 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"github.com/golang/glog"
+	"github.com/kubernetes-sigs/poseidon/pkg/metrics"
+)
+
+// SchedulingPausedAnnotation is the pod annotation which, set to "true",
+// holds the pending pod out of Firmament until it is removed, e.g. to stage
+// a large rollout or to debug a single workload.
+const SchedulingPausedAnnotation = "poseidon.k8s.io/scheduling-paused"
+
+// pausedPods are the pending pods held by their annotation or until their
+// admission. They are also deferred pods, without retry. Guarded by
+// deferredMux.
+var pausedPods = make(map[PodIdentifier]bool)
+
+// isSchedulingPaused returns whether the scheduling of the pod is paused.
+func isSchedulingPaused(pod *Pod) bool {
+	return pod.Annotations[SchedulingPausedAnnotation] == "true"
+}
+
+// pauseReason returns why the pending pod is held out of Firmament, empty if
+// it is not.
+func pauseReason(pod *Pod) string {
+	if isSchedulingPaused(pod) {
+		return "scheduling paused by the " + SchedulingPausedAnnotation + " annotation"
+	}
+	return awaitingAdmission(pod)
+}
+
+// pausePod holds a pending pod out of Firmament until its annotation is
+// removed or it is admitted. The task of a pod already submitted is removed from Firmament
+// while it waits for a placement, the pods already placed are not affected.
+func (pw *PodWatcher) pausePod(pod *Pod, reason string) {
+	td, submitted := state.TaskOfPod(pod.Identifier)
+	if submitted {
+		if !isTaskPending(td.GetUid()) {
+			glog.V(2).Infof("Not pausing pod %v, it is already placed", pod.Identifier)
+			return
+		}
+		glog.Infof("Withdrawing task %d of paused pod %v from Firmament", td.GetUid(), pod.Identifier)
+		pw.removeTask(pod, td)
+	}
+	deferredMux.Lock()
+	_, alreadyPaused := pausedPods[pod.Identifier]
+	pausedPods[pod.Identifier] = true
+	deferredPods[pod.Identifier] = pod
+	metrics.PausedPods.Set(float64(len(pausedPods)))
+	deferredMux.Unlock()
+	recordPodFailure(pod.Identifier, reason)
+	if !alreadyPaused {
+		glog.Infof("Paused the scheduling of pod %v, %s", pod.Identifier, reason)
+	}
+}
+
+// resumePod releases a paused pod whose annotation was removed or which was
+// admitted, so that it is submitted as the other pending pods.
+func resumePod(podIdentifier PodIdentifier) {
+	deferredMux.Lock()
+	defer deferredMux.Unlock()
+	if !pausedPods[podIdentifier] {
+		return
+	}
+	delete(pausedPods, podIdentifier)
+	delete(deferredPods, podIdentifier)
+	metrics.PausedPods.Set(float64(len(pausedPods)))
+	glog.Infof("Resumed the scheduling of pod %v", podIdentifier)
+}
+
+// forgetPausedPod drops a removed pod. The caller must hold deferredMux.
+func forgetPausedPod(podIdentifier PodIdentifier) {
+	if pausedPods[podIdentifier] {
+		delete(pausedPods, podIdentifier)
+		metrics.PausedPods.Set(float64(len(pausedPods)))
+	}
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+)
+
+func TestPauseResumePod(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	fc := firmament.NewMockFirmamentSchedulerClient(mockCtrl)
+	pw := &PodWatcher{fc: fc}
+	podIdentifier := PodIdentifier{Name: "pod0", Namespace: "ns"}
+	pod := &Pod{Identifier: podIdentifier, OwnerRef: "job0", Annotations: map[string]string{SchedulingPausedAnnotation: "true"}}
+	if !isSchedulingPaused(pod) {
+		t.Fatal("isSchedulingPaused() = false with the annotation")
+	}
+	state = &memoryState{}
+	state.podToTD = map[PodIdentifier]*firmament.TaskDescriptor{podIdentifier: {Uid: 1}}
+	state.taskIDToPod = map[uint64]PodIdentifier{1: podIdentifier}
+	jobID := pw.generateJobID("job0")
+	state.jobIDToJD = map[string]*firmament.JobDescriptor{jobID: {Uuid: jobID}}
+	state.jobNumTasksToRemove = map[string]int{jobID: 1}
+	markTaskPending(1)
+	defer MarkTaskPlaced(1)
+
+	// The pending task is withdrawn from Firmament.
+	fc.EXPECT().TaskRemoved(gomock.Any(), &firmament.TaskUID{TaskUid: 1}).Return(
+		&firmament.TaskRemovedResponse{Type: firmament.TaskReplyType_TASK_REMOVED_OK}, nil)
+	pw.pausePod(pod, pauseReason(pod))
+	if _, ok := state.TaskOfPod(podIdentifier); ok {
+		t.Error("pausePod() kept the task of the paused pod")
+	}
+	if _, ok := state.jobIDToJD[jobID]; ok {
+		t.Error("pausePod() kept the job without tasks")
+	}
+	if !pausedPods[podIdentifier] || deferredPods[podIdentifier] == nil {
+		t.Error("pausePod() did not hold the pod")
+	}
+	resumePod(podIdentifier)
+	if pausedPods[podIdentifier] || deferredPods[podIdentifier] != nil {
+		t.Error("resumePod() did not release the pod")
+	}
+
+	// A deleted paused pod is forgotten.
+	pw.pausePod(pod, pauseReason(pod))
+	if !pw.dropDeferredPod(podIdentifier) || pausedPods[podIdentifier] {
+		t.Error("dropDeferredPod() did not forget the paused pod")
+	}
+
+	// The pods already placed are not affected.
+	state.podToTD[podIdentifier] = &firmament.TaskDescriptor{Uid: 2}
+	pw.pausePod(pod, pauseReason(pod))
+	if pausedPods[podIdentifier] {
+		t.Error("pausePod() paused a placed pod")
+	}
+}
@@ -0,0 +1,295 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+	"github.com/kubernetes-sigs/poseidon/pkg/metrics"
+)
+
+// deferredSubmissionDelay is the time after which a deferred task submission is retried.
+const deferredSubmissionDelay = 5 * time.Second
+
+// pendingTask is a task submitted to Firmament which has not been placed yet.
+type pendingTask struct {
+	submitted time.Time
+	// cycles is the number of scheduling cycles which did not place the task.
+	cycles int
+}
+
+// pendingMux is used to guard access to pendingTasks. It is separate from
+// the state because the scheduling loop polls the pending tasks before the pod
+// watcher is started.
+var pendingMux sync.Mutex
+
+// pendingTasks maps the ID of tasks submitted to Firmament which have not
+// been placed yet to their pending state.
+var pendingTasks = make(map[uint64]*pendingTask)
+
+// maxPendingTasks is the backlog above which new task submissions are
+// deferred. 0 means the backlog is not limited.
+var maxPendingTasks int
+
+// claimingStopped is set when pending pods must no longer be submitted to
+// Firmament, e.g. while the scheduler is draining. Guarded by pendingMux.
+var claimingStopped bool
+
+// submissionNotifier notifies the task submissions of a shard.
+type submissionNotifier struct {
+	// submitted receives a value whenever a task is submitted to Firmament.
+	submitted chan struct{}
+	// critical receives a value whenever a system critical task is
+	// submitted to Firmament.
+	critical chan struct{}
+}
+
+// submissionNotifiers maps the name of a shard to the notifier of its task
+// submissions. The default shard, which is the whole cluster if Firmament
+// is not sharded, is named "". Guarded by pendingMux.
+var submissionNotifiers = map[string]*submissionNotifier{"": newSubmissionNotifier()}
+
+// submissionShards routes the task submissions to the notifier of their
+// shard, nil if Firmament is not sharded. Guarded by pendingMux.
+var submissionShards *firmament.ShardedClient
+
+func newSubmissionNotifier() *submissionNotifier {
+	return &submissionNotifier{submitted: make(chan struct{}, 1), critical: make(chan struct{}, 1)}
+}
+
+// submissionNotifierOf returns the notifier of the shard of the scope.
+// pendingMux must be held.
+func submissionNotifierOf(scope *ShardScope) *submissionNotifier {
+	name := ""
+	if scope != nil {
+		name = scope.Name
+		submissionShards = scope.Shards
+	}
+	notifier, ok := submissionNotifiers[name]
+	if !ok {
+		notifier = newSubmissionNotifier()
+		submissionNotifiers[name] = notifier
+	}
+	return notifier
+}
+
+// notifySubmission wakes up the scheduling cycles of the shard of a task
+// just submitted to Firmament.
+func notifySubmission(taskID uint64, critical bool) {
+	pendingMux.Lock()
+	shard := ""
+	if submissionShards != nil {
+		shard, _ = submissionShards.ShardOfTask(taskID)
+	}
+	notifier, ok := submissionNotifiers[shard]
+	pendingMux.Unlock()
+	if !ok {
+		// No scheduling cycles wait for the tasks of the shard.
+		return
+	}
+	notify := notifier.submitted
+	if critical {
+		notify = notifier.critical
+	}
+	select {
+	case notify <- struct{}{}:
+	default:
+		// A notification is already waiting to be consumed.
+	}
+}
+
+// markTaskPending records a task that has just been submitted to Firmament.
+func markTaskPending(taskID uint64) {
+	pendingMux.Lock()
+	pendingTasks[taskID] = &pendingTask{submitted: time.Now()}
+	metrics.FirmamentBacklog.Set(float64(len(pendingTasks)))
+	pendingMux.Unlock()
+	notifySubmission(taskID, false)
+}
+
+// MarkTaskPlaced records that a task is no longer waiting for a placement.
+func MarkTaskPlaced(taskID uint64) {
+	pendingMux.Lock()
+	delete(pendingTasks, taskID)
+	metrics.FirmamentBacklog.Set(float64(len(pendingTasks)))
+	pendingMux.Unlock()
+}
+
+// NumPendingTasks returns the number of tasks submitted to Firmament which
+// have not been placed yet.
+func NumPendingTasks() int {
+	pendingMux.Lock()
+	defer pendingMux.Unlock()
+	return len(pendingTasks)
+}
+
+// NumShardPendingTasks returns the number of tasks of the shard of the scope
+// which were submitted to Firmament and not placed yet.
+func NumShardPendingTasks(scope *ShardScope) int {
+	pendingMux.Lock()
+	defer pendingMux.Unlock()
+	pending := 0
+	for taskID := range pendingTasks {
+		if scope.hasTaskID(taskID) {
+			pending++
+		}
+	}
+	return pending
+}
+
+// markCriticalTaskPending records a system critical task that has just
+// been submitted to Firmament, which must be placed right away.
+func markCriticalTaskPending(taskID uint64) {
+	markTaskPending(taskID)
+	notifySubmission(taskID, true)
+}
+
+// CriticalTaskSubmittedNotify returns a channel which receives a value
+// whenever new system critical tasks of the shard of the scope are submitted
+// to Firmament.
+func CriticalTaskSubmittedNotify(scope *ShardScope) <-chan struct{} {
+	pendingMux.Lock()
+	defer pendingMux.Unlock()
+	return submissionNotifierOf(scope).critical
+}
+
+// TaskSubmittedNotify returns a channel which receives a value whenever new
+// tasks of the shard of the scope are submitted to Firmament.
+func TaskSubmittedNotify(scope *ShardScope) <-chan struct{} {
+	pendingMux.Lock()
+	defer pendingMux.Unlock()
+	return submissionNotifierOf(scope).submitted
+}
+
+// RecordSchedulingCycle must be called once the deltas of a scheduling cycle
+// which started at cycleStart are applied. It accounts the tasks left
+// unplaced by the cycle, updates the backlog metrics and reports the
+// admitted pods left unplaced for too long. With a scope, only the tasks of
+// its shard are accounted, the backlog metrics are those of all the shards.
+func RecordSchedulingCycle(scope *ShardScope, cycleStart time.Time) {
+	pendingMux.Lock()
+	defer pendingMux.Unlock()
+	carriedOver, maxCycles := 0, 0
+	var oldest time.Time
+	unplacedCycles := make(map[uint64]int)
+	for taskID, task := range pendingTasks {
+		if task.submitted.Before(cycleStart) && scope.hasTaskID(taskID) {
+			// The task was known to the solver but did not get placed.
+			task.cycles++
+			carriedOver++
+			unplacedCycles[taskID] = task.cycles
+		}
+		if task.cycles > maxCycles {
+			maxCycles = task.cycles
+		}
+		if oldest.IsZero() || task.submitted.Before(oldest) {
+			oldest = task.submitted
+		}
+	}
+	metrics.FirmamentBacklog.Set(float64(len(pendingTasks)))
+	metrics.FirmamentBacklogCarriedOver.Set(float64(carriedOver))
+	metrics.FirmamentBacklogMaxCycles.Set(float64(maxCycles))
+	if oldest.IsZero() {
+		metrics.FirmamentBacklogOldestAge.Set(0)
+	} else {
+		metrics.FirmamentBacklogOldestAge.Set(time.Since(oldest).Seconds())
+	}
+	reportUnplacedAdmittedTasks(unplacedCycles)
+}
+
+// StopClaimingPods stops submitting new pending pods to Firmament. The pods
+// are left pending for the next scheduler instance to claim.
+func StopClaimingPods() {
+	pendingMux.Lock()
+	claimingStopped = true
+	pendingMux.Unlock()
+	glog.Info("Stopped claiming new pods")
+}
+
+func isClaimingStopped() bool {
+	pendingMux.Lock()
+	defer pendingMux.Unlock()
+	return claimingStopped
+}
+
+// backlogExceeded returns true if new task submissions must be deferred.
+func backlogExceeded() bool {
+	return maxPendingTasks > 0 && NumPendingTasks() >= maxPendingTasks
+}
+
+// deferPod holds back the submission of a pending pod, e.g. until the
+// backlog drains. The latest state of the pod is re-enqueued after a delay.
+func (pw *PodWatcher) deferPod(key interface{}, pod *Pod, reason string) {
+	deferredMux.Lock()
+	_, alreadyDeferred := deferredPods[pod.Identifier]
+	deferredPods[pod.Identifier] = pod
+	deferredMux.Unlock()
+	recordPodFailure(pod.Identifier, "submission deferred, "+reason)
+	if alreadyDeferred {
+		// The pending retry will pick up the latest state of the pod.
+		return
+	}
+	glog.V(2).Infof("Deferring submission of pod %v, %s", pod.Identifier, reason)
+	time.AfterFunc(deferredSubmissionDelay, func() {
+		deferredMux.Lock()
+		deferredPod, ok := deferredPods[pod.Identifier]
+		delete(deferredPods, pod.Identifier)
+		deferredMux.Unlock()
+		if ok {
+			pw.podWorkQueue.Add(key, deferredPod)
+		}
+	})
+}
+
+// resetDeferredPods clears the deferred pods.
+func resetDeferredPods() {
+	deferredMux.Lock()
+	deferredPods = make(map[PodIdentifier]*Pod)
+	deferredMux.Unlock()
+}
+
+// forgetDeferredPod drops a pod about to be submitted.
+func forgetDeferredPod(podIdentifier PodIdentifier) {
+	deferredMux.Lock()
+	delete(deferredPods, podIdentifier)
+	deferredMux.Unlock()
+}
+
+// dropDeferredPod forgets a deferred pod. It returns true if the pod was
+// never submitted to Firmament.
+func (pw *PodWatcher) dropDeferredPod(podIdentifier PodIdentifier) bool {
+	deferredMux.Lock()
+	defer deferredMux.Unlock()
+	forgetPausedPod(podIdentifier)
+	if _, ok := deferredPods[podIdentifier]; !ok {
+		return false
+	}
+	delete(deferredPods, podIdentifier)
+	return true
+}
+
+// isTaskPending returns true if the task was submitted to Firmament and not
+// placed yet.
+func isTaskPending(taskID uint64) bool {
+	pendingMux.Lock()
+	defer pendingMux.Unlock()
+	_, ok := pendingTasks[taskID]
+	return ok
+}
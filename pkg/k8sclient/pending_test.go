@@ -0,0 +1,73 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/metrics"
+)
+
+func TestPendingTasks(t *testing.T) {
+	defer func(limit int) { maxPendingTasks = limit }(maxPendingTasks)
+	maxPendingTasks = 2
+
+	markTaskPending(1)
+	time.Sleep(time.Millisecond)
+	cycleStart := time.Now()
+	markTaskPending(2)
+	if !backlogExceeded() {
+		t.Errorf("backlogExceeded() = false with %d pending tasks", NumPendingTasks())
+	}
+	select {
+	case <-TaskSubmittedNotify(nil):
+	default:
+		t.Error("Task submission was not notified")
+	}
+	select {
+	case <-CriticalTaskSubmittedNotify(nil):
+		t.Error("Submission of a task which is not critical was notified as critical")
+	default:
+	}
+	markCriticalTaskPending(3)
+	select {
+	case <-CriticalTaskSubmittedNotify(nil):
+	default:
+		t.Error("Critical task submission was not notified")
+	}
+
+	MarkTaskPlaced(3)
+
+	RecordSchedulingCycle(nil, cycleStart)
+	if got := metrics.FirmamentBacklog.Get(); got != 2 {
+		t.Errorf("Backlog = %v, expected 2", got)
+	}
+	if got := metrics.FirmamentBacklogCarriedOver.Get(); got != 1 {
+		t.Errorf("Carried over backlog = %v, expected 1", got)
+	}
+
+	MarkTaskPlaced(1)
+	MarkTaskPlaced(2)
+	if backlogExceeded() {
+		t.Errorf("backlogExceeded() = true with %d pending tasks", NumPendingTasks())
+	}
+	RecordSchedulingCycle(nil, time.Now())
+	if got := metrics.FirmamentBacklog.Get(); got != 0 {
+		t.Errorf("Backlog = %v, expected 0", got)
+	}
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/golang/glog"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// permission is an API access Poseidon needs.
+type permission struct {
+	group       string
+	resource    string
+	subresource string
+	verb        string
+	// reason explains what the permission is needed for.
+	reason string
+	// binding is set for the permissions only needed to act on placements,
+	// which are not needed in shadow mode.
+	binding bool
+	// daemonSetOverhead is set for the permissions only needed to discount
+	// the DaemonSet overhead.
+	daemonSetOverhead bool
+	// status is set for the permissions only needed to publish the status,
+	// in the namespace of the status ConfigMap.
+	status bool
+	// namespaceSelectors is set for the permissions only needed to honor
+	// the node selectors of the namespaces.
+	namespaceSelectors bool
+	// statsTokenReview is set for the permissions only needed to
+	// authenticate the stats senders by their bearer token.
+	statsTokenReview bool
+	// conditions is set for the permissions only needed to set the
+	// conditions of the pods Poseidon can not place.
+	conditions bool
+	// placementDecisions is set for the permissions only needed to persist
+	// the decisions as PlacementDecision custom resources.
+	placementDecisions bool
+}
+
+func (p permission) String() string {
+	resource := p.resource
+	if p.subresource != "" {
+		resource += "/" + p.subresource
+	}
+	group := p.group
+	if group == "" {
+		group = "core"
+	}
+	return fmt.Sprintf("%s %s (%s API group)", p.verb, resource, group)
+}
+
+// requiredPermissions are the API accesses of Poseidon, the minimal RBAC
+// rules it must be granted.
+var requiredPermissions = []permission{
+	{resource: "pods", verb: "list", reason: "watch the pods to schedule"},
+	{resource: "pods", verb: "watch", reason: "watch the pods to schedule"},
+	{resource: "nodes", verb: "list", reason: "watch the nodes"},
+	{resource: "nodes", verb: "watch", reason: "watch the nodes"},
+	{resource: "nodes", verb: "get", reason: "resync the nodes of the pods a bind did not find", binding: true},
+	{resource: "events", verb: "create", reason: "report scheduling failures on pods"},
+	{resource: "pods", subresource: "binding", verb: "create", reason: "bind pods to nodes", binding: true},
+	{resource: "pods", verb: "delete", reason: "preempt and migrate pods", binding: true},
+	{group: "apps", resource: "daemonsets", verb: "list", reason: "discount the DaemonSet overhead", daemonSetOverhead: true},
+	{group: "apps", resource: "daemonsets", verb: "watch", reason: "discount the DaemonSet overhead", daemonSetOverhead: true},
+	{resource: "configmaps", verb: "get", reason: "publish the scheduler status", status: true},
+	{resource: "configmaps", verb: "create", reason: "publish the scheduler status", status: true},
+	{resource: "configmaps", verb: "update", reason: "publish the scheduler status", status: true},
+	{resource: "namespaces", verb: "list", reason: "honor the namespace node selectors", namespaceSelectors: true},
+	{resource: "namespaces", verb: "watch", reason: "honor the namespace node selectors", namespaceSelectors: true},
+	{group: "authentication.k8s.io", resource: "tokenreviews", verb: "create", reason: "authenticate the stats senders",
+		statsTokenReview: true},
+	{resource: "pods", verb: "get", reason: "set the conditions of the pods which can not be placed", conditions: true},
+	{resource: "pods", subresource: "status", verb: "update", reason: "set the conditions of the pods which can not be placed",
+		conditions: true},
+	{group: "poseidon.k8s.io", resource: "placementdecisions", verb: "create", reason: "persist the PlacementDecisions",
+		placementDecisions: true},
+	{group: "poseidon.k8s.io", resource: "placementdecisions", verb: "list", reason: "delete the expired PlacementDecisions",
+		placementDecisions: true},
+	{group: "poseidon.k8s.io", resource: "placementdecisions", verb: "delete", reason: "delete the expired PlacementDecisions",
+		placementDecisions: true},
+}
+
+// PermissionOptions are the optional features needing extra permissions.
+type PermissionOptions struct {
+	Shadow            bool
+	DaemonSetOverhead bool
+	// StatusNamespace is the namespace of the status ConfigMap, empty if
+	// the status is not published.
+	StatusNamespace        string
+	NamespaceNodeSelectors bool
+	StatsTokenReview       bool
+	// PodConditions is set if the conditions of the pods which can not be
+	// placed are set, e.g. when their constraints are unresolvable, or the
+	// placements of the admitted pods are reported.
+	PodConditions      bool
+	PlacementDecisions bool
+}
+
+// CheckPermissions verifies with SelfSubjectAccessReviews that Poseidon
+// has all the permissions it needs, so that it fails at startup with a
+// report of the missing permissions instead of failing mid-run. Binding
+// permissions are not needed in shadow mode, DaemonSet permissions only if
+// their overhead is discounted, ConfigMap permissions only if the status is
+// published, Namespace permissions only if their node selectors are
+// honored, TokenReview permissions only if the stats senders are
+// authenticated by token, pod status permissions only if the conditions of
+// the pods which can not be placed are set and PlacementDecision permissions
+// only if the decisions are persisted as custom resources.
+func CheckPermissions(kubeConfig string, options PermissionOptions) error {
+	config, err := GetClientConfig(kubeConfig)
+	if err != nil {
+		return err
+	}
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+	return checkPermissions(client, options)
+}
+
+func checkPermissions(client kubernetes.Interface, options PermissionOptions) error {
+	var missing []permission
+	for _, p := range requiredPermissions {
+		if (options.Shadow && p.binding) || (!options.DaemonSetOverhead && p.daemonSetOverhead) ||
+			(options.StatusNamespace == "" && p.status) || (!options.NamespaceNodeSelectors && p.namespaceSelectors) ||
+			(!options.StatsTokenReview && p.statsTokenReview) || (!options.PodConditions && p.conditions) ||
+			(!options.PlacementDecisions && p.placementDecisions) {
+			continue
+		}
+		var namespace string
+		if p.status {
+			namespace = options.StatusNamespace
+		}
+		review, err := client.AuthorizationV1().SelfSubjectAccessReviews().Create(&authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Namespace:   namespace,
+					Group:       p.group,
+					Resource:    p.resource,
+					Subresource: p.subresource,
+					Verb:        p.verb,
+				},
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to review permission to %s: %v", p, err)
+		}
+		if !review.Status.Allowed {
+			missing = append(missing, p)
+			continue
+		}
+		glog.V(2).Infof("Permission to %s granted", p)
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	var report bytes.Buffer
+	fmt.Fprintf(&report, "missing %d permissions:", len(missing))
+	for _, p := range missing {
+		fmt.Fprintf(&report, "\n  %s, needed to %s", p, p.reason)
+	}
+	return fmt.Errorf("%s", report.String())
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+)
+
+func TestCheckPermissions(t *testing.T) {
+	var testData = []struct {
+		name     string
+		denied   string
+		options  PermissionOptions
+		expected string
+	}{
+		{
+			name: "all granted",
+		},
+		{
+			name:     "binding denied",
+			denied:   "binding",
+			expected: "create pods/binding (core API group), needed to bind pods to nodes",
+		},
+		{
+			name:    "binding denied in shadow mode",
+			denied:  "binding",
+			options: PermissionOptions{Shadow: true},
+		},
+		{
+			name:     "status denied",
+			denied:   "configmaps",
+			options:  PermissionOptions{StatusNamespace: "kube-system"},
+			expected: "update configmaps (core API group), needed to publish the scheduler status",
+		},
+		{
+			name:   "status not published",
+			denied: "configmaps",
+		},
+		{
+			name:     "namespaces denied",
+			denied:   "namespaces",
+			options:  PermissionOptions{NamespaceNodeSelectors: true},
+			expected: "watch namespaces (core API group), needed to honor the namespace node selectors",
+		},
+		{
+			name:   "namespace node selectors not honored",
+			denied: "namespaces",
+		},
+		{
+			name:     "token reviews denied",
+			denied:   "tokenreviews",
+			options:  PermissionOptions{StatsTokenReview: true},
+			expected: "create tokenreviews (authentication.k8s.io API group), needed to authenticate the stats senders",
+		},
+		{
+			name:   "stats senders not authenticated by token",
+			denied: "tokenreviews",
+		},
+		{
+			name:     "pod status denied",
+			denied:   "status",
+			options:  PermissionOptions{PodConditions: true},
+			expected: "update pods/status (core API group), needed to set the conditions of the pods which can not be placed",
+		},
+		{
+			name:   "pod conditions not set",
+			denied: "status",
+		},
+		{
+			name:     "placement decisions denied",
+			denied:   "placementdecisions",
+			options:  PermissionOptions{PlacementDecisions: true},
+			expected: "create placementdecisions (poseidon.k8s.io API group), needed to persist the PlacementDecisions",
+		},
+		{
+			name:   "placement decisions not persisted",
+			denied: "placementdecisions",
+		},
+	}
+	for _, tc := range testData {
+		client := fake.NewSimpleClientset()
+		client.PrependReactor("create", "selfsubjectaccessreviews", func(action core.Action) (bool, runtime.Object, error) {
+			review := action.(core.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+			attributes := review.Spec.ResourceAttributes
+			review.Status.Allowed = tc.denied == "" || (attributes.Subresource != tc.denied && attributes.Resource != tc.denied)
+			return true, review, nil
+		})
+		err := checkPermissions(client, tc.options)
+		if tc.expected == "" {
+			if err != nil {
+				t.Errorf("%s: checkPermissions() = %v, expected no error", tc.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.expected) {
+			t.Errorf("%s: checkPermissions() = %v, expected to report %q", tc.name, err, tc.expected)
+		}
+	}
+}
+
+// TestDeploymentClusterRole verifies that the ClusterRole of the deployment
+// grants the required permissions.
+func TestDeploymentClusterRole(t *testing.T) {
+	file, err := os.Open("../../deploy/poseidon-deployment.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var role *rbacv1.ClusterRole
+	decoder := yaml.NewYAMLOrJSONDecoder(file, 4096)
+	for {
+		var object rbacv1.ClusterRole
+		if err := decoder.Decode(&object); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if object.Kind == "ClusterRole" && object.Name == "system:poseidon" {
+			role = &object
+		}
+	}
+	if role == nil {
+		t.Fatal("no system:poseidon ClusterRole in the deployment")
+	}
+	contains := func(values []string, value string) bool {
+		for _, v := range values {
+			if v == value {
+				return true
+			}
+		}
+		return false
+	}
+	for _, p := range requiredPermissions {
+		resource := p.resource
+		if p.subresource != "" {
+			resource += "/" + p.subresource
+		}
+		granted := false
+		for _, rule := range role.Rules {
+			if contains(rule.APIGroups, p.group) && contains(rule.Resources, resource) && contains(rule.Verbs, p.verb) {
+				granted = true
+			}
+		}
+		if !granted {
+			t.Errorf("the ClusterRole of the deployment does not grant the permission to %s", p)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/kubernetes-sigs/poseidon/pkg/history"
+	"github.com/kubernetes-sigs/poseidon/pkg/metrics"
+	"github.com/kubernetes-sigs/poseidon/pkg/runinfo"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	// PlacementDecisionAPIVersion is the API version of the PlacementDecision
+	// custom resources, defined by deploy/placementdecision-crd.yaml.
+	PlacementDecisionAPIVersion = "poseidon.k8s.io/v1alpha1"
+	// placementDecisionResource is the plural resource name of the
+	// PlacementDecisions.
+	placementDecisionResource = "placementdecisions"
+	// PlacementDecisionTypeLabel is the label holding the type of decision of
+	// a PlacementDecision, so that controllers can watch some types only.
+	PlacementDecisionTypeLabel = "poseidon.k8s.io/decision-type"
+	// placementDecisionQueueLength bounds the decisions waiting to be
+	// created, above which they are dropped.
+	placementDecisionQueueLength = 1000
+	// placementDecisionListLimit is the number of PlacementDecisions listed
+	// per page when the expired ones are collected.
+	placementDecisionListLimit = 500
+)
+
+// PlacementDecision is a scheduling decision persisted as a custom resource
+// in the namespace of its pod.
+type PlacementDecision struct {
+	meta_v1.TypeMeta   `json:",inline"`
+	meta_v1.ObjectMeta `json:"metadata,omitempty"`
+	Spec               PlacementDecisionSpec `json:"spec"`
+}
+
+// PlacementDecisionSpec is the decision of a PlacementDecision.
+type PlacementDecisionSpec struct {
+	Type string `json:"type"`
+	// Pod is the name of the pod in the namespace of the PlacementDecision.
+	Pod        string       `json:"pod"`
+	Node       string       `json:"node,omitempty"`
+	Time       meta_v1.Time `json:"time"`
+	RunID      string       `json:"runId,omitempty"`
+	Reason     string       `json:"reason,omitempty"`
+	OnBehalfOf []string     `json:"onBehalfOf,omitempty"`
+	// ExpiresAt is the time after which Poseidon deletes the
+	// PlacementDecision.
+	ExpiresAt meta_v1.Time `json:"expiresAt"`
+}
+
+// placementDecisionList is a list of PlacementDecisions.
+type placementDecisionList struct {
+	meta_v1.TypeMeta `json:",inline"`
+	meta_v1.ListMeta `json:"metadata,omitempty"`
+	Items            []PlacementDecision `json:"items"`
+}
+
+// PlacementDecisionSink creates a PlacementDecision custom resource for each
+// decision recorded, for auditing and for the external controllers reacting
+// to the placements, and deletes them once their TTL elapsed. The resources
+// are created asynchronously, so that the scheduling is not slowed down by
+// the API server.
+type PlacementDecisionSink struct {
+	// client returns the client of the API server, nil until the scheduler
+	// connected to it.
+	client func() rest.Interface
+	ttl    time.Duration
+	queue  chan history.Record
+	now    func() time.Time
+}
+
+// NewPlacementDecisionSink returns the sink of the decisions kept for ttl.
+func NewPlacementDecisionSink(ttl time.Duration) *PlacementDecisionSink {
+	return &PlacementDecisionSink{
+		client: func() rest.Interface {
+			if clientSet == nil {
+				return nil
+			}
+			return clientSet.CoreV1().RESTClient()
+		},
+		ttl:   ttl,
+		queue: make(chan history.Record, placementDecisionQueueLength),
+		now:   time.Now,
+	}
+}
+
+// Append implements history.Sink. The decision is dropped if too many are
+// waiting to be created.
+func (s *PlacementDecisionSink) Append(record history.Record) error {
+	select {
+	case s.queue <- record:
+		return nil
+	default:
+		metrics.PlacementDecisions.Inc("dropped")
+		return fmt.Errorf("%d PlacementDecisions are already waiting to be created", placementDecisionQueueLength)
+	}
+}
+
+// Run creates the PlacementDecisions of the recorded decisions and deletes
+// the expired ones until stop is closed.
+func (s *PlacementDecisionSink) Run(stop <-chan struct{}) {
+	interval := s.ttl / 10
+	if interval < time.Minute {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case record := <-s.queue:
+			if err := s.create(record); err != nil {
+				metrics.PlacementDecisions.Inc("failed")
+				glog.Errorf("Failed to create the PlacementDecision of the %s of pod %s: %v", record.Type, record.Pod, err)
+				continue
+			}
+			metrics.PlacementDecisions.Inc("created")
+		case <-ticker.C:
+			if s.client() == nil {
+				continue
+			}
+			if err := s.collect(); err != nil {
+				glog.Errorf("Failed to delete the expired PlacementDecisions: %v", err)
+			}
+		}
+	}
+}
+
+// decision returns the PlacementDecision of a record.
+func (s *PlacementDecisionSink) decision(record history.Record) (*PlacementDecision, error) {
+	parts := strings.SplitN(record.Pod, "/", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid pod %q, expected <namespace>/<name>", record.Pod)
+	}
+	return &PlacementDecision{
+		TypeMeta: meta_v1.TypeMeta{APIVersion: PlacementDecisionAPIVersion, Kind: "PlacementDecision"},
+		ObjectMeta: meta_v1.ObjectMeta{
+			GenerateName: parts[1] + "-",
+			Namespace:    parts[0],
+			Labels:       map[string]string{PlacementDecisionTypeLabel: string(record.Type)},
+			Annotations:  map[string]string{runinfo.Annotation: record.RunID},
+		},
+		Spec: PlacementDecisionSpec{
+			Type:       string(record.Type),
+			Pod:        parts[1],
+			Node:       record.Node,
+			Time:       meta_v1.NewTime(record.Time),
+			RunID:      record.RunID,
+			Reason:     record.Reason,
+			OnBehalfOf: record.OnBehalfOf,
+			ExpiresAt:  meta_v1.NewTime(record.Time.Add(s.ttl)),
+		},
+	}, nil
+}
+
+// create creates the PlacementDecision of a record.
+func (s *PlacementDecisionSink) create(record history.Record) error {
+	decision, err := s.decision(record)
+	if err != nil {
+		return err
+	}
+	client := s.client()
+	if client == nil {
+		return fmt.Errorf("not connected to the API server")
+	}
+	data, err := json.Marshal(decision)
+	if err != nil {
+		return err
+	}
+	return client.Post().
+		AbsPath("/apis", PlacementDecisionAPIVersion, "namespaces", decision.Namespace, placementDecisionResource).
+		Body(data).
+		Do().
+		Error()
+}
+
+// collect deletes the expired PlacementDecisions of all the namespaces,
+// listed by pages. The PlacementDecisions which fail to be deleted are left
+// to the next collection.
+func (s *PlacementDecisionSink) collect() error {
+	now := s.now()
+	var deleted, failed int
+	var continueToken string
+	for {
+		request := s.client().Get().
+			AbsPath("/apis", PlacementDecisionAPIVersion, placementDecisionResource).
+			Param("limit", strconv.Itoa(placementDecisionListLimit))
+		if continueToken != "" {
+			request = request.Param("continue", continueToken)
+		}
+		data, err := request.DoRaw()
+		if err != nil {
+			return err
+		}
+		var list placementDecisionList
+		if err := json.Unmarshal(data, &list); err != nil {
+			return err
+		}
+		for _, decision := range list.Items {
+			if decision.Spec.ExpiresAt.Time.After(now) {
+				continue
+			}
+			err := s.client().Delete().
+				AbsPath("/apis", PlacementDecisionAPIVersion, "namespaces", decision.Namespace, placementDecisionResource, decision.Name).
+				Do().
+				Error()
+			if err != nil {
+				glog.Warningf("Failed to delete the expired PlacementDecision %s/%s: %v", decision.Namespace, decision.Name, err)
+				failed++
+				continue
+			}
+			deleted++
+		}
+		if continueToken = list.Continue; continueToken == "" {
+			break
+		}
+	}
+	metrics.PlacementDecisions.Add(float64(deleted), "expired")
+	glog.V(2).Infof("Deleted %d expired PlacementDecisions, %d failed", deleted, failed)
+	return nil
+}
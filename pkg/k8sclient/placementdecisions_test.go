@@ -0,0 +1,135 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/history"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func TestPlacementDecisionSink(t *testing.T) {
+	now := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	var mu sync.Mutex
+	var created []PlacementDecision
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/apis/poseidon.k8s.io/v1alpha1/namespaces/ns/placementdecisions":
+			data, _ := ioutil.ReadAll(r.Body)
+			var decision PlacementDecision
+			if err := json.Unmarshal(data, &decision); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			created = append(created, decision)
+			w.WriteHeader(http.StatusCreated)
+			w.Write(data)
+		case r.Method == http.MethodGet && r.URL.Path == "/apis/poseidon.k8s.io/v1alpha1/placementdecisions":
+			if r.URL.Query().Get("limit") != strconv.Itoa(placementDecisionListLimit) {
+				http.Error(w, "unexpected limit", http.StatusBadRequest)
+				return
+			}
+			// The decisions are listed by pages of the limit.
+			if r.URL.Query().Get("continue") == "" {
+				page := placementDecisionList{Items: []PlacementDecision{
+					{ObjectMeta: meta_v1.ObjectMeta{Name: "failing", Namespace: "ns"},
+						Spec: PlacementDecisionSpec{ExpiresAt: meta_v1.NewTime(now.Add(-time.Second))}},
+					{ObjectMeta: meta_v1.ObjectMeta{Name: "expired", Namespace: "ns"},
+						Spec: PlacementDecisionSpec{ExpiresAt: meta_v1.NewTime(now.Add(-time.Second))}},
+				}}
+				page.Continue = "page2"
+				json.NewEncoder(w).Encode(page)
+				return
+			}
+			json.NewEncoder(w).Encode(placementDecisionList{Items: []PlacementDecision{
+				{ObjectMeta: meta_v1.ObjectMeta{Name: "live", Namespace: "ns"},
+					Spec: PlacementDecisionSpec{ExpiresAt: meta_v1.NewTime(now.Add(time.Second))}},
+				{ObjectMeta: meta_v1.ObjectMeta{Name: "expired2", Namespace: "ns"},
+					Spec: PlacementDecisionSpec{ExpiresAt: meta_v1.NewTime(now.Add(-time.Second))}},
+			}})
+		case r.Method == http.MethodDelete && strings.HasSuffix(r.URL.Path, "/failing"):
+			http.Error(w, "etcd unavailable", http.StatusInternalServerError)
+		case r.Method == http.MethodDelete:
+			deleted = append(deleted, r.URL.Path)
+			w.Write([]byte("{}"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	client, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink := NewPlacementDecisionSink(time.Hour)
+	sink.client = func() rest.Interface { return client.CoreV1().RESTClient() }
+	sink.now = func() time.Time { return now }
+
+	record := history.Record{Time: now, Type: history.Preempt, Pod: "ns/pod0", Node: "node0", RunID: "run0",
+		Reason: "preempted by Firmament", OnBehalfOf: []string{"ns/pod1"}}
+	if err := sink.create(record); err != nil {
+		t.Fatalf("create() failed: %v", err)
+	}
+	expected := PlacementDecisionSpec{Type: "preempt", Pod: "pod0", Node: "node0", Time: meta_v1.NewTime(now), RunID: "run0",
+		Reason: "preempted by Firmament", OnBehalfOf: []string{"ns/pod1"}, ExpiresAt: meta_v1.NewTime(now.Add(time.Hour))}
+	if len(created) != 1 || !created[0].Spec.Time.Equal(&expected.Time) || !created[0].Spec.ExpiresAt.Equal(&expected.ExpiresAt) {
+		t.Fatalf("create() created %v, expected %v", created, expected)
+	}
+	created[0].Spec.Time, created[0].Spec.ExpiresAt = expected.Time, expected.ExpiresAt
+	if !reflect.DeepEqual(created[0].Spec, expected) || created[0].Labels[PlacementDecisionTypeLabel] != "preempt" {
+		t.Errorf("create() created %+v, expected %+v", created[0], expected)
+	}
+	if err := sink.create(history.Record{Pod: "pod0"}); err == nil {
+		t.Error("create() succeeded for a pod without namespace")
+	}
+
+	// A failed deletion does not stop the collection.
+	if err := sink.collect(); err != nil {
+		t.Fatalf("collect() failed: %v", err)
+	}
+	if expected := []string{
+		"/apis/poseidon.k8s.io/v1alpha1/namespaces/ns/placementdecisions/expired",
+		"/apis/poseidon.k8s.io/v1alpha1/namespaces/ns/placementdecisions/expired2",
+	}; !reflect.DeepEqual(deleted, expected) {
+		t.Errorf("collect() deleted %v, expected %v", deleted, expected)
+	}
+
+	// The decisions are dropped once the queue is full.
+	for i := 0; i < placementDecisionQueueLength; i++ {
+		if err := sink.Append(record); err != nil {
+			t.Fatalf("Append() failed with %d queued decisions: %v", i, err)
+		}
+	}
+	if err := sink.Append(record); err == nil {
+		t.Error("Append() succeeded with a full queue")
+	}
+}
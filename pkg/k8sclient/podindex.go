@@ -0,0 +1,161 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"sync"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// NodePod is a pod bound to a node, as indexed by node.
+type NodePod struct {
+	Identifier PodIdentifier
+	Phase      v1.PodPhase
+	// Deleting is set once the pod has a deletion timestamp.
+	Deleting  bool
+	DaemonSet bool
+	// CPURequest is in millicores and MemRequestKb in KB.
+	CPURequest   int64
+	MemRequestKb int64
+	// MemBurstKb is the memory in KB the pod may use above its requests
+	// before memory.high throttles it on a cgroup v2 node.
+	MemBurstKb int64
+	// ReplicaSet is the UID of the ReplicaSet controlling the pod, empty if
+	// it has none.
+	ReplicaSet string
+}
+
+// podIndex indexes the bound pods watched by the pod watcher by node, so
+// that the per node computations only visit the pods of the node. The
+// completed pods are not indexed as they no longer use their node.
+type podIndex struct {
+	mu sync.RWMutex
+	// nodes maps the node names to their pods.
+	nodes map[string]map[PodIdentifier]NodePod
+	// podNodes maps the indexed pods to their node.
+	podNodes map[PodIdentifier]string
+}
+
+func newPodIndex() *podIndex {
+	return &podIndex{
+		nodes:    make(map[string]map[PodIdentifier]NodePod),
+		podNodes: make(map[PodIdentifier]string),
+	}
+}
+
+// podsByNode is the index of the pods watched by the pod watcher.
+var podsByNode = newPodIndex()
+
+// update indexes the latest state of a pod, which requests cpuReq
+// millicores and memReqKb KB of memory. It returns the nodes whose pods may
+// use more or less memory above their requests than before.
+func (i *podIndex) update(pod *v1.Pod, cpuReq, memReqKb int64) []string {
+	podIdentifier := PodIdentifier{Name: pod.Name, Namespace: pod.Namespace}
+	if pod.Spec.NodeName == "" || pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+		return i.remove(podIdentifier)
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	var changed []string
+	if nodeName, ok := i.podNodes[podIdentifier]; ok && nodeName != pod.Spec.NodeName {
+		changed = i.removeLocked(podIdentifier)
+	}
+	pods, ok := i.nodes[pod.Spec.NodeName]
+	if !ok {
+		pods = make(map[PodIdentifier]NodePod)
+		i.nodes[pod.Spec.NodeName] = pods
+	}
+	memBurstKb := memoryBurstKb(pod)
+	if pods[podIdentifier].MemBurstKb != memBurstKb {
+		changed = append(changed, pod.Spec.NodeName)
+	}
+	pods[podIdentifier] = NodePod{
+		Identifier:   podIdentifier,
+		Phase:        pod.Status.Phase,
+		Deleting:     pod.DeletionTimestamp != nil,
+		DaemonSet:    isDaemonSetPod(pod),
+		CPURequest:   cpuReq,
+		MemRequestKb: memReqKb,
+		MemBurstKb:   memBurstKb,
+		ReplicaSet:   replicaSetOf(pod),
+	}
+	i.podNodes[podIdentifier] = pod.Spec.NodeName
+	return changed
+}
+
+// remove drops a pod from the index. It returns the node of the pod if the
+// pod could use memory above its requests.
+func (i *podIndex) remove(podIdentifier PodIdentifier) []string {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.removeLocked(podIdentifier)
+}
+
+func (i *podIndex) removeLocked(podIdentifier PodIdentifier) []string {
+	nodeName, ok := i.podNodes[podIdentifier]
+	if !ok {
+		return nil
+	}
+	delete(i.podNodes, podIdentifier)
+	pods := i.nodes[nodeName]
+	burst := pods[podIdentifier].MemBurstKb
+	delete(pods, podIdentifier)
+	if len(pods) == 0 {
+		delete(i.nodes, nodeName)
+	}
+	if burst != 0 {
+		return []string{nodeName}
+	}
+	return nil
+}
+
+// podsOnNode returns the indexed pods of the node.
+func (i *podIndex) podsOnNode(nodeName string) []NodePod {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	pods := make([]NodePod, 0, len(i.nodes[nodeName]))
+	for _, pod := range i.nodes[nodeName] {
+		pods = append(pods, pod)
+	}
+	return pods
+}
+
+// indexPod indexes the latest state of a watched pod.
+func (pw *PodWatcher) indexPod(pod *v1.Pod) {
+	cpuReq, memReq := pw.getCPUMemRequest(pod)
+	refreshMemoryQoSNodes(podsByNode.update(pod, cpuReq, memReq/bytesToKb))
+}
+
+// unindexPod drops a deleted pod from the index.
+func unindexPod(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	if pod, ok := obj.(*v1.Pod); ok {
+		refreshMemoryQoSNodes(podsByNode.remove(PodIdentifier{Name: pod.Name, Namespace: pod.Namespace}))
+	}
+}
+
+// PodNodeName returns the node a watched pod is bound to.
+func PodNodeName(podIdentifier PodIdentifier) (string, bool) {
+	podsByNode.mu.RLock()
+	defer podsByNode.mu.RUnlock()
+	nodeName, ok := podsByNode.podNodes[podIdentifier]
+	return nodeName, ok
+}
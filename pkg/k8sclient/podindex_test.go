@@ -0,0 +1,87 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"testing"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestPodIndex(t *testing.T) {
+	index := newPodIndex()
+	pod := func(name, nodeName string, phase v1.PodPhase) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"},
+			Spec:       v1.PodSpec{NodeName: nodeName},
+			Status:     v1.PodStatus{Phase: phase},
+		}
+	}
+	index.update(pod("pending", "", v1.PodPending), 0, 0)
+	index.update(pod("pod0", "node0", v1.PodRunning), 100, 1024)
+	index.update(pod("pod1", "node0", v1.PodPending), 0, 0)
+	index.update(pod("pod2", "node1", v1.PodRunning), 0, 0)
+	pods := index.podsOnNode("node0")
+	if len(pods) != 2 {
+		t.Fatalf("podsOnNode(node0) = %v, expected pod0 and pod1", pods)
+	}
+	for _, p := range pods {
+		if p.Identifier.Name == "pod0" && (p.CPURequest != 100 || p.MemRequestKb != 1024 || p.Phase != v1.PodRunning) {
+			t.Errorf("podsOnNode(node0) indexed pod0 as %+v", p)
+		}
+	}
+	index.update(pod("pod1", "node0", v1.PodSucceeded), 0, 0)
+	index.update(pod("pod2", "node0", v1.PodRunning), 0, 0)
+	if pods := index.podsOnNode("node1"); len(pods) != 0 {
+		t.Errorf("podsOnNode(node1) = %v after its pod moved, expected none", pods)
+	}
+	if pods := index.podsOnNode("node0"); len(pods) != 2 {
+		t.Errorf("podsOnNode(node0) = %v, expected pod0 and pod2 only", pods)
+	}
+	index.remove(PodIdentifier{Name: "pod0", Namespace: "ns"})
+	index.remove(PodIdentifier{Name: "pod2", Namespace: "ns"})
+	if len(index.nodes) != 0 || len(index.podNodes) != 0 {
+		t.Errorf("index not empty after all pods were removed: %v, %v", index.nodes, index.podNodes)
+	}
+}
+
+func TestPodWatcherIndexPod(t *testing.T) {
+	defer func() { podsByNode = newPodIndex() }()
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod0", Namespace: "ns"},
+		Spec: v1.PodSpec{
+			NodeName: "node0",
+			Containers: []v1.Container{{Resources: v1.ResourceRequirements{Requests: v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("250m"),
+				v1.ResourceMemory: resource.MustParse("1Mi"),
+			}}}},
+		},
+		Status: v1.PodStatus{Phase: v1.PodRunning},
+	}
+	(&PodWatcher{}).indexPod(pod)
+	pods := podsByNode.podsOnNode("node0")
+	if len(pods) != 1 || pods[0].CPURequest != 250 || pods[0].MemRequestKb != 1024 {
+		t.Errorf("podsOnNode(node0) = %+v, expected pod0 requesting 250 millicores and 1024 KB", pods)
+	}
+	unindexPod(cache.DeletedFinalStateUnknown{Key: "ns/pod0", Obj: pod})
+	if pods := podsByNode.podsOnNode("node0"); len(pods) != 0 {
+		t.Errorf("podsOnNode(node0) = %v after the pod deletion, expected none", pods)
+	}
+}
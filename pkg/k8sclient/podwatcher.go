@@ -21,11 +21,10 @@ import (
 	"fmt"
 	"reflect"
 	"sort"
-	"strconv"
-	"sync"
 	"time"
 
 	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+	"github.com/kubernetes-sigs/poseidon/pkg/metrics"
 
 	"github.com/golang/glog"
 	"k8s.io/api/core/v1"
@@ -67,14 +66,12 @@ func SortNodeSelectorsKey(nodeSelector NodeSelectors) []string {
 // NewPodWatcher initialize a PodWatcher.
 func NewPodWatcher(kubeVerMajor, kubeVerMinor int, schedulerName string, client kubernetes.Interface, fc firmament.FirmamentSchedulerClient) *PodWatcher {
 	glog.Info("Starting PodWatcher...")
-	PodMux = new(sync.RWMutex)
-	PodToTD = make(map[PodIdentifier]*firmament.TaskDescriptor)
-	TaskIDToPod = make(map[uint64]PodIdentifier)
-	jobIDToJD = make(map[string]*firmament.JobDescriptor)
-	jobNumTasksToRemove = make(map[string]int)
+	state.resetPods()
+	resetDeferredPods()
 	podWatcher := &PodWatcher{
-		clientset: client,
-		fc:        fc,
+		clientset:     client,
+		fc:            fc,
+		schedulerName: schedulerName,
 	}
 	schedulerSelector := fields.Everything()
 	podSelector := labels.Everything()
@@ -109,6 +106,7 @@ func NewPodWatcher(kubeVerMajor, kubeVerMinor int, schedulerName string, client
 				if err != nil {
 					glog.Errorf("AddFunc: error getting key %v", err)
 				}
+				podWatcher.indexPod(obj.(*v1.Pod))
 				podWatcher.enqueuePodAddition(key, obj)
 			},
 			UpdateFunc: func(old, new interface{}) {
@@ -116,6 +114,7 @@ func NewPodWatcher(kubeVerMajor, kubeVerMinor int, schedulerName string, client
 				if err != nil {
 					glog.Errorf("UpdateFunc: error getting key %v", err)
 				}
+				podWatcher.indexPod(new.(*v1.Pod))
 				podWatcher.enqueuePodUpdate(key, old, new)
 			},
 			DeleteFunc: func(obj interface{}) {
@@ -123,15 +122,22 @@ func NewPodWatcher(kubeVerMajor, kubeVerMinor int, schedulerName string, client
 				if err != nil {
 					glog.Errorf("DeleteFunc: error getting key %v", err)
 				}
+				unindexPod(obj)
 				podWatcher.enqueuePodDeletion(key, obj)
 			},
 		},
 	)
 	podWatcher.controller = controller
 	podWatcher.podWorkQueue = NewKeyedQueue()
+	if honorNamespaceNodeSelectors {
+		podWatcher.namespaceController = newNamespaceController(client)
+	}
 	return podWatcher
 }
 
+// getCPUMemRequest returns the CPU in millicores and memory in bytes
+// requested by the containers of the pod. The ephemeral containers, e.g. the
+// ones kubectl debug adds, cannot request resources and are not accounted.
 func (pw *PodWatcher) getCPUMemRequest(pod *v1.Pod) (int64, int64) {
 	cpuReq := int64(0)
 	memReq := int64(0)
@@ -147,7 +153,7 @@ func (pw *PodWatcher) getCPUMemRequest(pod *v1.Pod) (int64, int64) {
 }
 
 func (pw *PodWatcher) parsePod(pod *v1.Pod) *Pod {
-	cpuReq, memReq := pw.getCPUMemRequest(pod)
+	constraints := podTemplates.constraints(pw, pod)
 	podPhase := PodPhase("Unknown")
 	switch pod.Status.Phase {
 	case "Pending":
@@ -164,18 +170,38 @@ func (pw *PodWatcher) parsePod(pod *v1.Pod) *Pod {
 			Name:      pod.Name,
 			Namespace: pod.Namespace,
 		},
-		State:        podPhase,
-		CPURequest:   cpuReq,
-		MemRequestKb: memReq / bytesToKb,
-		Labels:       pod.Labels,
-		Annotations:  pod.Annotations,
-		NodeSelector: pod.Spec.NodeSelector,
-		OwnerRef:     GetOwnerReference(pod),
+		State:             podPhase,
+		CPURequest:        constraints.cpuRequest,
+		MemRequestKb:      constraints.memRequest / bytesToKb,
+		Labels:            pod.Labels,
+		Annotations:       pod.Annotations,
+		NodeSelector:      pod.Spec.NodeSelector,
+		OwnerRef:          GetOwnerReference(pod),
+		Priority:          pod.Spec.Priority,
+		PriorityClassName: pod.Spec.PriorityClassName,
+		HostPaths:         withAnnotatedHostPaths(constraints.hostPathVolumes, pod.Annotations),
+		DeviceRequests:    podDeviceRequests(pod),
+		ReplicaSet:        replicaSetOf(pod),
+		NodeName:          pod.Spec.NodeName,
+		Owner:             ownerOf(pod),
+		nodeSelectors:     constraints.nodeSelectors,
 	}
 }
 
 func (pw *PodWatcher) enqueuePodAddition(key interface{}, obj interface{}) {
 	pod := obj.(*v1.Pod)
+	if !isClaimed(pod) {
+		glog.V(2).Infof("enqueuePodAddition: pod %s/%s not claimed", pod.Namespace, pod.Name)
+		metrics.CanaryPods.Inc("skipped")
+		// The event delivery is not held up by the API calls.
+		go pw.reportNotClaimed(pod)
+		return
+	}
+	metrics.CanaryPods.Inc("claimed")
+	if shadowMode && pod.Spec.NodeName != "" {
+		// The pod was bound before Poseidon could compute its placement.
+		return
+	}
 	addedPod := pw.parsePod(pod)
 	pw.podWorkQueue.Add(key, addedPod)
 	glog.Info("enqueuePodAddition: Added pod ", addedPod.Identifier)
@@ -183,6 +209,13 @@ func (pw *PodWatcher) enqueuePodAddition(key interface{}, obj interface{}) {
 
 func (pw *PodWatcher) enqueuePodDeletion(key interface{}, obj interface{}) {
 	pod := obj.(*v1.Pod)
+	if !isClaimed(pod) {
+		forgetUnschedulable(PodIdentifier{Name: pod.Name, Namespace: pod.Namespace})
+		return
+	}
+	if shadowMode {
+		forgetShadowPlacement(PodIdentifier{Name: pod.Name, Namespace: pod.Namespace})
+	}
 	if pod.DeletionTimestamp != nil {
 		// Only delete pods if they have a DeletionTimestamp.
 		deletedPod := &Pod{
@@ -201,18 +234,30 @@ func (pw *PodWatcher) enqueuePodDeletion(key interface{}, obj interface{}) {
 func (pw *PodWatcher) enqueuePodUpdate(key, oldObj, newObj interface{}) {
 	oldPod := oldObj.(*v1.Pod)
 	newPod := newObj.(*v1.Pod)
+	if !isClaimed(newPod) {
+		return
+	}
+	if shadowMode && oldPod.Spec.NodeName == "" && newPod.Spec.NodeName != "" {
+		recordActualPlacement(PodIdentifier{Name: newPod.Name, Namespace: newPod.Namespace}, newPod.Spec.NodeName)
+	}
 	if oldPod.Status.Phase != newPod.Status.Phase {
+		if isKubeletRejection(newPod) {
+			recordNodeFailure(newPod.Spec.NodeName, "kubelet")
+		}
 		// TODO(ionel): pw code assumes that if other fields changed as well then Firmament will automatically update them upon state transition. pw is currently not true.
 		updatedPod := pw.parsePod(newPod)
 		pw.podWorkQueue.Add(key, updatedPod)
 		glog.Infof("enqueuePodUpdate: Updated pod state change %v %s", updatedPod.Identifier, updatedPod.State)
 		return
 	}
+	// Adding ephemeral containers to a running pod changes none of the fields
+	// below, so the task of the pod is left alone.
 	oldCPUReq, oldMemReq := pw.getCPUMemRequest(oldPod)
 	newCPUReq, newMemReq := pw.getCPUMemRequest(newPod)
+	// The annotations set when a pod is bound are ignored.
 	if oldCPUReq != newCPUReq || oldMemReq != newMemReq ||
 		!reflect.DeepEqual(oldPod.Labels, newPod.Labels) ||
-		!reflect.DeepEqual(oldPod.Annotations, newPod.Annotations) ||
+		!sameAnnotations(oldPod.Annotations, newPod.Annotations) ||
 		!reflect.DeepEqual(oldPod.Spec.NodeSelector, newPod.Spec.NodeSelector) {
 		updatedPod := pw.parsePod(newPod)
 		pw.podWorkQueue.Add(key, updatedPod)
@@ -230,14 +275,21 @@ func (pw *PodWatcher) Run(stopCh <-chan struct{}, nWorkers int) {
 	defer glog.Info("Shutting down PodWatcher")
 	glog.Info("Getting pod updates...")
 
+	start := time.Now()
 	go pw.controller.Run(stopCh)
+	synced := []cache.InformerSynced{pw.controller.HasSynced}
+	if pw.namespaceController != nil {
+		go pw.namespaceController.Run(stopCh)
+		synced = append(synced, pw.namespaceController.HasSynced)
+	}
 
-	if !cache.WaitForCacheSync(stopCh, pw.controller.HasSynced) {
+	if !cache.WaitForCacheSync(stopCh, synced...) {
 		utilruntime.HandleError(fmt.Errorf("Timed out waiting for caches to sync"))
 		return
 	}
 
-	glog.Info("Starting pod watching workers")
+	pw.startup = newStartupSync("pod", pw.podWorkQueue.Keys(), start)
+	glog.Infof("Starting %d pod watching workers", nWorkers)
 	for i := 0; i < nWorkers; i++ {
 		go wait.Until(pw.podWorker, time.Second, stopCh)
 	}
@@ -258,98 +310,258 @@ func (pw *PodWatcher) podWorker() {
 				switch pod.State {
 				case PodPending:
 					glog.V(2).Info("PodPending ", pod.Identifier)
-					PodMux.Lock()
-					jobID := pw.generateJobID(pod.OwnerRef)
-					jd, ok := jobIDToJD[jobID]
-					if !ok {
-						jd = pw.createNewJob(pod.OwnerRef)
-						jobIDToJD[jobID] = jd
-						jobNumTasksToRemove[jobID] = 0
+					if isNodeTargeted(pod) {
+						// The pod was bound by its creator, e.g. a node debug
+						// pod, and must never be placed as a task.
+						pw.dropDeferredPod(pod.Identifier)
+						registerNodeTargetedPod(pod)
+						continue
+					}
+					if pod.NodeName != "" {
+						// The pod is being bound, it must not be submitted again.
+						glog.V(2).Infof("Pod %v is already bound", pod.Identifier)
+						continue
+					}
+					if _, ok := state.TaskOfPod(pod.Identifier); ok {
+						// The pod was updated after its submission.
+						if reason := pauseReason(pod); reason != "" {
+							pw.pausePod(pod, reason)
+							continue
+						}
+						pw.updateSubmittedPod(key, pod)
+						continue
+					}
+					if isClaimingStopped() {
+						glog.V(2).Infof("Not claiming pod %v, the scheduler is draining", pod.Identifier)
+						pw.dropDeferredPod(pod.Identifier)
+						continue
+					}
+					if reason := pauseReason(pod); reason != "" {
+						pw.pausePod(pod, reason)
+						continue
 					}
-					td := pw.addTaskToJob(pod, jd)
-					jobNumTasksToRemove[jobID]++
-					PodToTD[pod.Identifier] = td
-					TaskIDToPod[td.GetUid()] = pod.Identifier
-					taskDescription := &firmament.TaskDescription{
-						TaskDescriptor: td,
-						JobDescriptor:  jd,
+					resumePod(pod.Identifier)
+					if err := checkConstraints(pod); err != nil {
+						// Retried in case the annotations of the pod or of
+						// its namespace are fixed.
+						pw.deferPod(key, pod, err.Error())
+						continue
+					}
+					if oversizedPodPolicy != nil && !shadowMode {
+						if reason := oversizedRequests(pod); reason != "" && pw.reportOversized(pod, reason) {
+							// Retried in case a larger node is added.
+							pw.deferPod(key, pod, reason)
+							continue
+						}
+					}
+					if rejectUnresolvableConstraints && !shadowMode {
+						if reason := unresolvableConstraints(pod); reason != "" {
+							pw.reportUnresolvable(pod, reason)
+							// Retried in case a matching node is added.
+							pw.deferPod(key, pod, reason)
+							continue
+						}
+					}
+					// The system critical pods are never deferred.
+					critical := isSystemCritical(pod)
+					if !critical && backlogExceeded() {
+						metrics.DeferredTaskSubmissions.Inc()
+						pw.deferPod(key, pod, fmt.Sprintf("Firmament backlog exceeds %d tasks", maxPendingTasks))
+						continue
+					}
+					if !critical && tenantRateLimiter != nil && !tenantRateLimiter.admit(pod.Identifier.Namespace) {
+						metrics.RateLimitedTaskSubmissions.Inc(pod.Identifier.Namespace)
+						pw.deferPod(key, pod, "its namespace exceeds its submission rate")
+						continue
+					}
+					if !critical && !admitFairShare(pod) {
+						metrics.FairShareDeferredTaskSubmissions.Inc(pod.Identifier.Namespace)
+						pw.deferPod(key, pod, "its namespace exceeds its fair share")
+						continue
+					}
+					if !critical && !admitSubmission(time.Now()) {
+						metrics.BackpressuredTaskSubmissions.Inc()
+						pw.deferPod(key, pod, "the scheduler is saturated")
+						continue
+					}
+					forgetUnschedulable(pod.Identifier)
+					forgetDeferredPod(pod.Identifier)
+					taskDescription := state.submitTask(pod.Identifier, pw.generateJobID(pod.OwnerRef),
+						func() *firmament.JobDescriptor { return pw.createNewJob(pod.OwnerRef) },
+						func(jd *firmament.JobDescriptor) *firmament.TaskDescriptor { return pw.addTaskToJob(pod, jd) })
+					td := taskDescription.TaskDescriptor
+					if len(pod.HostPaths) > 0 {
+						pw.checkHostPaths(pod)
+					}
+					registerGangTask(pod, td.GetUid())
+					registerNodePreferences(pod, td.GetUid())
+					registerZoneBalancedTask(pod, td.GetUid())
+					registerAdmittedTask(pod, td.GetUid())
+					registerFairShareTask(pod, td.GetUid())
+					registerChurnTask(pod, td.GetUid())
+					handleError(firmament.TaskSubmitted(pw.fc, taskDescription))
+					if critical {
+						markCriticalTaskPending(td.GetUid())
+					} else {
+						markTaskPending(td.GetUid())
 					}
-					PodMux.Unlock()
-					firmament.TaskSubmitted(pw.fc, taskDescription)
 				case PodSucceeded:
 					glog.V(2).Info("PodSucceeded ", pod.Identifier)
-					PodMux.RLock()
-					td, ok := PodToTD[pod.Identifier]
-					PodMux.RUnlock()
+					if completeNodeTargetedPod(pod.Identifier) {
+						continue
+					}
+					if pw.dropDeferredPod(pod.Identifier) {
+						// The pod was never submitted to Firmament.
+						continue
+					}
+					td, ok := state.TaskOfPod(pod.Identifier)
 					if !ok {
-						glog.Fatalf("Pod %v does not exist", pod.Identifier)
+						if shadowMode {
+							// Pods bound before Poseidon saw them are not shadowed.
+							continue
+						}
+						handleError(stateMismatch("PodSucceeded", "pod %v does not exist", pod.Identifier))
+						continue
 					}
-					firmament.TaskCompleted(pw.fc, &firmament.TaskUID{TaskUid: td.Uid})
+					handleError(firmament.TaskCompleted(pw.fc, &firmament.TaskUID{TaskUid: td.Uid}))
+					forgetFairShareTask(td.GetUid())
 				case PodDeleted:
 					glog.V(2).Info("PodDeleted ", pod.Identifier)
-					PodMux.RLock()
-					td, ok := PodToTD[pod.Identifier]
-					PodMux.RUnlock()
+					forgetPodFailure(pod.Identifier)
+					forgetPodUsage(pod.Identifier)
+					forgetUnschedulable(pod.Identifier)
+					if forgetNodeTargetedPod(pod.Identifier) {
+						continue
+					}
+					if pw.dropDeferredPod(pod.Identifier) {
+						// The pod was never submitted to Firmament.
+						continue
+					}
+					td, ok := state.TaskOfPod(pod.Identifier)
 					if !ok {
-						glog.Fatalf("Pod %s does not exist", pod.Identifier)
+						if shadowMode {
+							// Pods bound before Poseidon saw them are not shadowed.
+							continue
+						}
+						handleError(stateMismatch("PodDeleted", "pod %v does not exist", pod.Identifier))
+						continue
 					}
 
-					firmament.TaskRemoved(pw.fc, &firmament.TaskUID{TaskUid: td.Uid})
-					PodMux.Lock()
-					delete(PodToTD, pod.Identifier)
-					delete(TaskIDToPod, td.GetUid())
-					// TODO(ionel): Should we delete the task from JD's spawned field?
-					jobID := pw.generateJobID(pod.OwnerRef)
-					jobNumTasksToRemove[jobID]--
-					if jobNumTasksToRemove[jobID] == 0 {
-						// Clean state because the job doesn't have any tasks left.
-						delete(jobNumTasksToRemove, jobID)
-						delete(jobIDToJD, jobID)
-					}
-					PodMux.Unlock()
+					pw.removeTask(pod, td)
 				case PodFailed:
 					glog.V(2).Info("PodFailed ", pod.Identifier)
-					PodMux.RLock()
-					td, ok := PodToTD[pod.Identifier]
-					PodMux.RUnlock()
+					if completeNodeTargetedPod(pod.Identifier) {
+						continue
+					}
+					if pw.dropDeferredPod(pod.Identifier) {
+						// The pod was never submitted to Firmament.
+						continue
+					}
+					td, ok := state.TaskOfPod(pod.Identifier)
 					if !ok {
-						glog.Fatalf("Pod %s does not exist", pod.Identifier)
+						if shadowMode {
+							// Pods bound before Poseidon saw them are not shadowed.
+							continue
+						}
+						handleError(stateMismatch("PodFailed", "pod %v does not exist", pod.Identifier))
+						continue
 					}
-					firmament.TaskFailed(pw.fc, &firmament.TaskUID{TaskUid: td.Uid})
+					handleError(firmament.TaskFailed(pw.fc, &firmament.TaskUID{TaskUid: td.Uid}))
 				case PodRunning:
 					glog.V(2).Info("PodRunning ", pod.Identifier)
-					// We don't have to do anything.
+					if isNodeTargeted(pod) {
+						// The pod runs on a node Firmament does not know it
+						// uses, e.g. a node debug pod.
+						registerNodeTargetedPod(pod)
+					}
 				case PodUnknown:
 					glog.Errorf("Pod %s in unknown state", pod.Identifier)
 					// TODO(ionel): Handle Unknown case.
 				case PodUpdated:
 					glog.V(2).Info("PodUpdated ", pod.Identifier)
-					PodMux.Lock()
-					jobId := pw.generateJobID(pod.OwnerRef)
-					jd, okJob := jobIDToJD[jobId]
-					td, okPod := PodToTD[pod.Identifier]
-					PodMux.Unlock()
-					if !okJob {
-						glog.Fatalf("Pod's %v job does not exist", pod.Identifier)
-					}
-					if !okPod {
-						glog.Fatalf("Pod %v does not exist", pod.Identifier)
-					}
-					pw.updateTask(pod, td)
-					taskDescription := &firmament.TaskDescription{
-						TaskDescriptor: td,
-						JobDescriptor:  jd,
-					}
-					firmament.TaskUpdated(pw.fc, taskDescription)
+					pw.updateSubmittedPod(key, pod)
 				default:
 					glog.Fatalf("Pod %v in unexpected state %v", pod.Identifier, pod.State)
 				}
 			}
+			defer pw.startup.processed(key)
 			defer pw.podWorkQueue.Done(key)
 		}()
 	}
 }
 
+// checkConstraints applies the node selector of the namespace of the pod,
+// if honored, and returns an error if the pod must not be scheduled as its
+// constraint annotations, or those of its namespace, are invalid.
+func checkConstraints(pod *Pod) error {
+	if honorNamespaceNodeSelectors {
+		if err := applyNamespaceNodeSelector(pod); err != nil {
+			return err
+		}
+	}
+	if err := checkLabelSelectorsAnnotation(pod); err != nil {
+		return err
+	}
+	if err := checkPodGroupAnnotations(pod); err != nil {
+		return err
+	}
+	return checkNodePreferencesAnnotation(pod)
+}
+
+// updateSubmittedPod updates the task of a pending pod already submitted to
+// Firmament after the requests, labels, node selector or annotations of the
+// pod changed. A pod whose constraints became invalid is withdrawn from
+// Firmament and deferred until they are fixed. The tasks already placed are
+// left alone, their pods are being bound.
+func (pw *PodWatcher) updateSubmittedPod(key interface{}, pod *Pod) {
+	td, okPod := state.TaskOfPod(pod.Identifier)
+	_, okJob := state.jobOf(pw.generateJobID(pod.OwnerRef))
+	if !okPod {
+		handleError(stateMismatch("PodUpdated", "pod %v does not exist", pod.Identifier))
+		return
+	}
+	if !okJob {
+		handleError(stateMismatch("PodUpdated", "job of pod %v does not exist", pod.Identifier))
+		return
+	}
+	if !isTaskPending(td.GetUid()) {
+		glog.V(2).Infof("Not updating pod %v, it is already placed", pod.Identifier)
+		return
+	}
+	if err := checkConstraints(pod); err != nil {
+		glog.Infof("Withdrawing task %d of pod %v from Firmament, %v", td.GetUid(), pod.Identifier, err)
+		pw.removeTask(pod, td)
+		pw.deferPod(key, pod, err.Error())
+		return
+	}
+	// The zones to avoid are set again by the next zone balancing.
+	forgetZoneBalancedTask(td.GetUid())
+	forgetNodePreferences(td.GetUid())
+	forgetGangTask(td.GetUid())
+	updated := state.updateTasks([]uint64{td.GetUid()}, func(td *firmament.TaskDescriptor) { pw.updateTask(pod, td) })
+	registerGangTask(pod, td.GetUid())
+	registerNodePreferences(pod, td.GetUid())
+	registerZoneBalancedTask(pod, td.GetUid())
+	for _, taskDescription := range updated {
+		handleError(firmament.TaskUpdated(pw.fc, taskDescription))
+	}
+}
+
+// removeTask removes the task of the pod from Firmament and forgets it.
+func (pw *PodWatcher) removeTask(pod *Pod, td *firmament.TaskDescriptor) {
+	handleError(firmament.TaskRemoved(pw.fc, &firmament.TaskUID{TaskUid: td.Uid}))
+	MarkTaskPlaced(td.GetUid())
+	forgetTaskPreemption(td.GetUid())
+	forgetResyncedTask(td.GetUid())
+	forgetGangTask(td.GetUid())
+	forgetNodePreferences(td.GetUid())
+	forgetZoneBalancedTask(td.GetUid())
+	forgetAdmittedTask(td.GetUid())
+	forgetFairShareTask(td.GetUid())
+	forgetChurnTask(td.GetUid())
+	state.removeTask(pod.Identifier, td.GetUid(), pw.generateJobID(pod.OwnerRef))
+}
+
 func (pw *PodWatcher) createNewJob(jobName string) *firmament.JobDescriptor {
 	jobDesc := &firmament.JobDescriptor{
 		Uuid:  pw.generateJobID(jobName),
@@ -360,7 +572,6 @@ func (pw *PodWatcher) createNewJob(jobName string) *firmament.JobDescriptor {
 }
 
 func (pw *PodWatcher) updateTask(pod *Pod, td *firmament.TaskDescriptor) {
-	// TODO(ionel): Update LabelSelector!
 	td.ResourceRequest.CpuCores = float32(pod.CPURequest)
 	td.ResourceRequest.RamCap = uint64(pod.MemRequestKb)
 	// Update labels.
@@ -372,9 +583,13 @@ func (pw *PodWatcher) updateTask(pod *Pod, td *firmament.TaskDescriptor) {
 				Value: value,
 			})
 	}
+	// The constraints are compiled again from the updated pod.
+	td.LabelSelectors = nil
+	compileConstraints(pod, td)
 }
 
 func (pw *PodWatcher) addTaskToJob(pod *Pod, jd *firmament.JobDescriptor) *firmament.TaskDescriptor {
+	priority := firmamentPriority(pod)
 	task := &firmament.TaskDescriptor{
 		Name:  pod.Identifier.UniqueName(),
 		State: firmament.TaskDescriptor_CREATED,
@@ -384,6 +599,7 @@ func (pw *PodWatcher) addTaskToJob(pod *Pod, jd *firmament.JobDescriptor) *firma
 			CpuCores: float32(pod.CPURequest),
 			RamCap:   uint64(pod.MemRequestKb),
 		},
+		Priority: priority.Priority,
 	}
 
 	// Add labels.
@@ -394,10 +610,7 @@ func (pw *PodWatcher) addTaskToJob(pod *Pod, jd *firmament.JobDescriptor) *firma
 				Value: value,
 			})
 	}
-	// Get the network requirement from pods label, and set it in ResourceRequest of the TaskDescriptor
-	setTaskNetworkRequirement(task, pod.Labels)
-	task.LabelSelectors = pw.getFirmamentLabelSelectorFromNodeSelectorMap(pod.NodeSelector, SortNodeSelectorsKey(pod.NodeSelector))
-	setTaskType(task)
+	compileConstraints(pod, task)
 
 	if jd.RootTask == nil {
 		task.Uid = pw.generateTaskID(jd.Name, 0)
@@ -406,6 +619,7 @@ func (pw *PodWatcher) addTaskToJob(pod *Pod, jd *firmament.JobDescriptor) *firma
 		task.Uid = pw.generateTaskID(jd.Name, len(jd.RootTask.Spawned)+1)
 		jd.RootTask.Spawned = append(jd.RootTask.Spawned, task)
 	}
+	setTaskPreemptible(task.Uid, !priority.NonPreemptible)
 	return task
 }
 
@@ -451,45 +665,3 @@ func GetOwnerReference(pod *v1.Pod) string {
 	// Return the uid of the ObjectMeta if none from the above is present.
 	return string(pod.GetObjectMeta().GetUID())
 }
-
-func (pw *PodWatcher) getFirmamentLabelSelectorFromNodeSelectorMap(nodeSelector NodeSelectors, nodeSelectorKeys []string) []*firmament.LabelSelector {
-	var firmamentLabelSelector []*firmament.LabelSelector
-	for _, key := range nodeSelectorKeys {
-		firmamentLabelSelector = append(firmamentLabelSelector, &firmament.LabelSelector{
-			Type:   firmament.LabelSelector_IN_SET,
-			Key:    key,
-			Values: []string{nodeSelector[key]},
-		})
-	}
-	return firmamentLabelSelector
-}
-
-func setTaskNetworkRequirement(td *firmament.TaskDescriptor, nodeSelectors NodeSelectors) {
-	if val, ok := nodeSelectors["networkRequirement"]; ok {
-		res, err := strconv.ParseUint(val, 10, 64)
-		if err == nil {
-			td.ResourceRequest.NetRxBw = res
-		} else {
-			glog.Errorf("Failed to parse networkRequirement %v", err)
-		}
-	}
-}
-
-func setTaskType(td *firmament.TaskDescriptor) {
-	for _, label := range td.Labels {
-		if label.Key == "taskType" {
-			switch label.Value {
-			case "Sheep":
-				td.TaskType = firmament.TaskDescriptor_SHEEP
-			case "Rabbit":
-				td.TaskType = firmament.TaskDescriptor_RABBIT
-			case "Devil":
-				td.TaskType = firmament.TaskDescriptor_DEVIL
-			case "Turtle":
-				td.TaskType = firmament.TaskDescriptor_TURTLE
-			default:
-				glog.Errorf("Unexpected task type %s for task %s", label.Value, td.Name)
-			}
-		}
-	}
-}
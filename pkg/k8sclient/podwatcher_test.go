@@ -134,6 +134,92 @@ func GetKey(pod *v1.Pod, t *testing.T) string {
 	return key
 }
 
+// TestPodWatcher_bindingAnnotations verifies that the update of a pod by
+// the annotations set when it is bound does not submit the pod again.
+func TestPodWatcher_pauseSubmittedPod(t *testing.T) {
+	testObj := initializePodObj(t)
+	defer testObj.mockCtrl.Finish()
+	podWatch := NewPodWatcher(testObj.kubeVerMajor, testObj.kubeVerMinor, testObj.schedulerName, testObj.kubeClient, testObj.firmamentClient)
+	pod := BuildPod("Poseidon-Namespace", "Paused", nil, GetPodPhase("Pending"), "2", "1024", nil, "abcdfe12345")
+	key := GetKey(pod, t)
+	podIdentifier := PodIdentifier{Name: pod.Name, Namespace: pod.Namespace}
+	removed := make(chan struct{}, 1)
+	gomock.InOrder(
+		testObj.firmamentClient.EXPECT().TaskSubmitted(gomock.Any(), gomock.Any()).Return(
+			&firmament.TaskSubmittedResponse{Type: firmament.TaskReplyType_TASK_SUBMITTED_OK}, nil),
+		testObj.firmamentClient.EXPECT().TaskRemoved(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(interface{}, interface{}, ...interface{}) (*firmament.TaskRemovedResponse, error) {
+				removed <- struct{}{}
+				return &firmament.TaskRemovedResponse{Type: firmament.TaskReplyType_TASK_REMOVED_OK}, nil
+			}),
+	)
+	go podWatch.podWorker()
+	podWatch.enqueuePodAddition(key, pod)
+
+	// The pending task of the submitted pod is withdrawn once it is paused.
+	paused := *pod
+	paused.Annotations = map[string]string{SchedulingPausedAnnotation: "true"}
+	podWatch.enqueuePodUpdate(key, pod, &paused)
+	select {
+	case <-removed:
+	case <-time.After(time.Second):
+		t.Fatal("the task of the paused pod was not removed")
+	}
+	defer podWatch.dropDeferredPod(podIdentifier)
+	deferredMux.Lock()
+	isPaused := pausedPods[podIdentifier]
+	deferredMux.Unlock()
+	if !isPaused {
+		t.Error("the submitted pod was not paused")
+	}
+	if _, ok := state.TaskOfPod(podIdentifier); ok {
+		t.Error("the paused pod kept its task")
+	}
+}
+
+func TestPodWatcher_bindingAnnotations(t *testing.T) {
+	testObj := initializePodObj(t)
+	defer testObj.mockCtrl.Finish()
+	podWatch := NewPodWatcher(testObj.kubeVerMajor, testObj.kubeVerMinor, testObj.schedulerName, testObj.kubeClient, testObj.firmamentClient)
+	pod := BuildPod("Poseidon-Namespace", "Pod1", nil, GetPodPhase("Pending"), "2", "1024", nil, "abcdfe12345")
+	key := GetKey(pod, t)
+	submitted := make(chan struct{}, 2)
+	testObj.firmamentClient.EXPECT().TaskSubmitted(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(interface{}, interface{}, ...interface{}) (*firmament.TaskSubmittedResponse, error) {
+			submitted <- struct{}{}
+			return &firmament.TaskSubmittedResponse{Type: firmament.TaskReplyType_TASK_SUBMITTED_OK}, nil
+		})
+	go podWatch.podWorker()
+	podWatch.enqueuePodAddition(key, pod)
+	<-submitted
+
+	bound := *pod
+	bound.Spec.NodeName = "node0"
+	bound.Annotations = bindingAnnotations("node0")
+	podWatch.enqueuePodUpdate(key, pod, &bound)
+	// The binding annotations are ignored, the other annotations of a
+	// submitted pod update its task once.
+	rebound := *pod
+	rebound.Annotations = bindingAnnotations("node0")
+	podWatch.enqueuePodUpdate(key, pod, &rebound)
+	updated := make(chan struct{}, 1)
+	testObj.firmamentClient.EXPECT().TaskUpdated(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(interface{}, interface{}, ...interface{}) (*firmament.TaskUpdatedResponse, error) {
+			updated <- struct{}{}
+			return &firmament.TaskUpdatedResponse{Type: firmament.TaskReplyType_TASK_UPDATED_OK}, nil
+		})
+	annotated := *pod
+	annotated.Annotations = map[string]string{"updated": "true"}
+	podWatch.enqueuePodUpdate(key, pod, &annotated)
+	<-updated
+	podWatch.podWorkQueue.Add(key, podWatch.parsePod(&bound))
+	select {
+	case <-submitted:
+		t.Error("the pod was submitted again after being bound")
+	case <-time.After(time.Second):
+	}
+}
+
 // TestNewPodWatcher tests for different k8s versions for NewPodWatcher()
 func TestNewPodWatcher(t *testing.T) {
 	testObj := initializePodObj(t)
@@ -322,20 +408,22 @@ func TestPodWatcher_podWorker(t *testing.T) {
 		testObj.firmamentClient.EXPECT().TaskCompleted(gomock.Any(), gomock.Any()).Return(
 			&firmament.TaskCompletedResponse{Type: firmament.TaskReplyType_TASK_COMPLETED_OK}, nil),
 
-		//case 3
-		testObj.firmamentClient.EXPECT().TaskSubmitted(gomock.Any(), gomock.Any()).Return(
-			&firmament.TaskSubmittedResponse{Type: firmament.TaskReplyType_TASK_SUBMITTED_OK}, nil),
+		//case 3, the updated pod is not submitted again but its task updated
 		testObj.firmamentClient.EXPECT().TaskSubmitted(gomock.Any(), gomock.Any()).Return(
 			&firmament.TaskSubmittedResponse{Type: firmament.TaskReplyType_TASK_SUBMITTED_OK}, nil),
+		testObj.firmamentClient.EXPECT().TaskUpdated(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ interface{}, td *firmament.TaskDescription, _ ...interface{}) (*firmament.TaskUpdatedResponse, error) {
+				if cpu := td.GetTaskDescriptor().GetResourceRequest().GetCpuCores(); cpu != 3000 {
+					t.Errorf("Updated task requests %v CPU, expected 3000", cpu)
+				}
+				return &firmament.TaskUpdatedResponse{Type: firmament.TaskReplyType_TASK_UPDATED_OK}, nil
+			}),
 
 		//case 4
 		testObj.firmamentClient.EXPECT().TaskSubmitted(gomock.Any(), gomock.Any()).Return(
 			&firmament.TaskSubmittedResponse{Type: firmament.TaskReplyType_TASK_SUBMITTED_OK}, nil),
 		testObj.firmamentClient.EXPECT().TaskFailed(gomock.Any(), gomock.Any()).Return(
 			&firmament.TaskFailedResponse{Type: firmament.TaskReplyType_TASK_FAILED_OK}, nil),
-
-		//testObj.firmamentClient.EXPECT().TaskUpdated(gomock.Any(), gomock.Any()).Return(
-		//	&firmament.TaskUpdatedResponse{Type: firmament.TaskReplyType_TASK_UPDATED_OK}, nil),
 	)
 	go podWatch.podWorker()
 	newTimer := time.NewTimer(time.Second * 2)
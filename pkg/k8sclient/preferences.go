@@ -0,0 +1,146 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+)
+
+const (
+	// NodePreferencesAnnotation is the pod annotation listing the nodes the
+	// pod prefers, as weighted terms separated by semicolons. A term is a
+	// weight from 1 to 100, a colon and a label selector in the Kubernetes
+	// syntax, in which the metadata.name key is the node name, e.g.
+	// "10:metadata.name=node-a;5:zone=a,disk=ssd". Unlike the label
+	// selectors annotation, the preferences are hints the pod may be placed
+	// against.
+	NodePreferencesAnnotation = "poseidon.k8s.io/node-preferences"
+	// nodeNameKey is the label selector key of the node name.
+	nodeNameKey = "metadata.name"
+	// maxPreferenceWeight is the largest weight of a node preference, as
+	// for the preferred node affinity terms.
+	maxPreferenceWeight = 100
+)
+
+// NodePreference is a weighted term of the node preferences of a pod.
+type NodePreference struct {
+	Weight    int
+	Selectors []*firmament.LabelSelector
+}
+
+var (
+	preferencesMux sync.Mutex
+	// taskPreferences are the node preferences of the tasks which have
+	// some.
+	taskPreferences = make(map[uint64][]NodePreference)
+)
+
+// parseNodePreferences parses the node preferences annotation.
+func parseNodePreferences(annotation string) ([]NodePreference, error) {
+	var preferences []NodePreference
+	for _, term := range strings.Split(annotation, ";") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		parts := strings.SplitN(term, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("term %q is not <weight>:<label selector>", term)
+		}
+		weight, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+		if err != nil || weight < 1 || weight > maxPreferenceWeight {
+			return nil, fmt.Errorf("invalid weight %q of term %q, expected 1 to %d", parts[0], term, maxPreferenceWeight)
+		}
+		selectors, err := parseLabelSelectors(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid label selector of term %q: %v", term, err)
+		}
+		if len(selectors) == 0 {
+			return nil, fmt.Errorf("term %q has an empty label selector", term)
+		}
+		preferences = append(preferences, NodePreference{Weight: weight, Selectors: selectors})
+	}
+	return preferences, nil
+}
+
+// checkNodePreferencesAnnotation returns an error if the node preferences
+// annotation of the pod is invalid.
+func checkNodePreferencesAnnotation(pod *Pod) error {
+	annotation, ok := pod.Annotations[NodePreferencesAnnotation]
+	if !ok {
+		return nil
+	}
+	if _, err := parseNodePreferences(annotation); err != nil {
+		return fmt.Errorf("invalid %s annotation: %v", NodePreferencesAnnotation, err)
+	}
+	return nil
+}
+
+// registerNodePreferences records the node preferences of the task of the
+// pod, including the default ones of its namespace. Firmament has no notion
+// of soft constraints, so they only steer the placements Poseidon computes
+// itself, e.g. by the fallback scheduler.
+func registerNodePreferences(pod *Pod, taskID uint64) {
+	preferences, err := parseNodePreferences(pod.Annotations[NodePreferencesAnnotation])
+	if err != nil {
+		return
+	}
+	if selector := namespaceSelectorOf(pod.Identifier.Namespace); selector != nil {
+		preferences = append(preferences, selector.preferences...)
+	}
+	if len(preferences) == 0 {
+		return
+	}
+	preferencesMux.Lock()
+	defer preferencesMux.Unlock()
+	taskPreferences[taskID] = preferences
+}
+
+// forgetNodePreferences drops the node preferences of a removed task.
+func forgetNodePreferences(taskID uint64) {
+	preferencesMux.Lock()
+	defer preferencesMux.Unlock()
+	delete(taskPreferences, taskID)
+}
+
+// nodePreferencesOf returns the node preferences of the task.
+func nodePreferencesOf(taskID uint64) []NodePreference {
+	preferencesMux.Lock()
+	defer preferencesMux.Unlock()
+	return taskPreferences[taskID]
+}
+
+// preferenceScore returns the sum of the weights of the preferences the node
+// matches.
+func preferenceScore(preferences []NodePreference, nodeName string, labels []*firmament.Label) int {
+	if len(preferences) == 0 {
+		return 0
+	}
+	labels = append(append([]*firmament.Label(nil), labels...), &firmament.Label{Key: nodeNameKey, Value: nodeName})
+	var score int
+	for _, preference := range preferences {
+		if matchesSelectors(labels, preference.Selectors) {
+			score += preference.Weight
+		}
+	}
+	return score
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"testing"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+)
+
+func TestParseNodePreferences(t *testing.T) {
+	var testData = []struct {
+		annotation string
+		terms      int
+		invalid    bool
+	}{
+		{annotation: "10:metadata.name=node-a; 5:zone=a,disk=ssd;", terms: 2},
+		{annotation: "", terms: 0},
+		{annotation: "zone=a", invalid: true},
+		{annotation: "0:zone=a", invalid: true},
+		{annotation: "101:zone=a", invalid: true},
+		{annotation: "10:zone>a", invalid: true},
+		{annotation: "10:", invalid: true},
+	}
+	for _, tc := range testData {
+		preferences, err := parseNodePreferences(tc.annotation)
+		if (err != nil) != tc.invalid || len(preferences) != tc.terms {
+			t.Errorf("parseNodePreferences(%q) = %v, %v, expected %d terms, invalid %v", tc.annotation, preferences, err,
+				tc.terms, tc.invalid)
+		}
+	}
+	pod := &Pod{Annotations: map[string]string{NodePreferencesAnnotation: "high:zone=a"}}
+	if err := checkNodePreferencesAnnotation(pod); err == nil {
+		t.Error("checkNodePreferencesAnnotation() accepted an invalid annotation")
+	}
+}
+
+func TestPreferenceScore(t *testing.T) {
+	preferences, err := parseNodePreferences("10:metadata.name=node1;5:zone=a,disk=ssd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	labels := []*firmament.Label{{Key: "zone", Value: "a"}, {Key: "disk", Value: "ssd"}}
+	var testData = []struct {
+		node     string
+		labels   []*firmament.Label
+		expected int
+	}{
+		{node: "node0", expected: 0},
+		{node: "node1", expected: 10},
+		{node: "node0", labels: labels, expected: 5},
+		{node: "node1", labels: labels, expected: 15},
+		{node: "node0", labels: labels[:1], expected: 0},
+	}
+	for _, tc := range testData {
+		if score := preferenceScore(preferences, tc.node, tc.labels); score != tc.expected {
+			t.Errorf("preferenceScore(%s, %v) = %d, expected %d", tc.node, tc.labels, score, tc.expected)
+		}
+	}
+
+	// The fallback scheduler places the pods on their preferred nodes.
+	defer forgetNodePreferences(1)
+	registerNodePreferences(&Pod{Annotations: map[string]string{NodePreferencesAnnotation: "10:zone=b"}}, 1)
+	nodes := []*fallbackNode{
+		{name: "node0", labels: []*firmament.Label{{Key: "zone", Value: "a"}}, freeCPU: 1000, capacityCPU: 1000},
+		{name: "node1", labels: []*firmament.Label{{Key: "zone", Value: "b"}}, freeCPU: 1000, capacityCPU: 1000},
+	}
+	policy := FallbackPolicy{Strategy: FirstFit}
+	if node := policy.pickNode(nodes, nil, nodePreferencesOf(1), 100, 0); node == nil || node.name != "node1" {
+		t.Errorf("pickNode() = %v, expected the preferred node1", node)
+	}
+	if node := policy.pickNode(nodes, nil, nodePreferencesOf(2), 100, 0); node == nil || node.name != "node0" {
+		t.Errorf("pickNode() = %v without preferences, expected the first node", node)
+	}
+}
@@ -0,0 +1,275 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"sync"
+
+	"github.com/golang/glog"
+	"github.com/kubernetes-sigs/poseidon/pkg/features"
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+)
+
+// nodeHostnameLabel is the node label the tasks kept on their node after a
+// refused preemption are constrained to.
+const nodeHostnameLabel = "kubernetes.io/hostname"
+
+// FirmamentPriority is the Firmament task priority and preemption
+// eligibility a pod priority maps to.
+type FirmamentPriority struct {
+	Priority       uint32 `json:"priority"`
+	NonPreemptible bool   `json:"nonPreemptible,omitempty"`
+}
+
+// PriorityValueMapping maps the pods whose priority value is at least
+// MinPriority to a Firmament priority.
+type PriorityValueMapping struct {
+	MinPriority int32 `json:"minPriority"`
+	FirmamentPriority
+}
+
+// PriorityMapping translates Kubernetes pod priorities to Firmament task
+// priorities. A pod is mapped by its priority class name if listed in
+// Classes, otherwise by the value mapping with the largest MinPriority not
+// above its priority value, otherwise to Default.
+type PriorityMapping struct {
+	Classes map[string]FirmamentPriority `json:"classes,omitempty"`
+	Values  []PriorityValueMapping       `json:"values,omitempty"`
+	Default FirmamentPriority            `json:"default"`
+}
+
+// LoadPriorityMapping reads a JSON priority mapping file.
+func LoadPriorityMapping(path string) (*PriorityMapping, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	mapping := &PriorityMapping{}
+	if err := json.Unmarshal(data, mapping); err != nil {
+		return nil, fmt.Errorf("invalid priority mapping %s: %v", path, err)
+	}
+	sort.Slice(mapping.Values, func(i, j int) bool {
+		return mapping.Values[i].MinPriority > mapping.Values[j].MinPriority
+	})
+	return mapping, nil
+}
+
+const (
+	// SystemClusterCritical and SystemNodeCritical are the priority classes
+	// of the critical addons. Their pods are never deferred, trigger an
+	// immediate scheduling cycle and are never preempted, as guaranteed by
+	// the default scheduler.
+	SystemClusterCritical = "system-cluster-critical"
+	SystemNodeCritical    = "system-node-critical"
+)
+
+// isSystemCritical returns whether the pod has a system critical priority
+// class.
+func isSystemCritical(pod *Pod) bool {
+	return pod.PriorityClassName == SystemClusterCritical || pod.PriorityClassName == SystemNodeCritical
+}
+
+// priorityMapping is the mapping applied to new tasks. All the tasks have
+// the default Firmament priority and are preemptible if it is nil.
+var priorityMapping *PriorityMapping
+
+// firmamentPriority returns the Firmament priority of the pod. The system
+// critical pods are not preemptible whatever their mapping.
+func firmamentPriority(pod *Pod) FirmamentPriority {
+	priority := mappedPriority(pod)
+	if isSystemCritical(pod) {
+		priority.NonPreemptible = true
+	}
+	return priority
+}
+
+// mappedPriority returns the Firmament priority the pod is mapped to.
+func mappedPriority(pod *Pod) FirmamentPriority {
+	if priorityMapping == nil {
+		return FirmamentPriority{}
+	}
+	if priority, ok := priorityMapping.Classes[pod.PriorityClassName]; ok && pod.PriorityClassName != "" {
+		return priority
+	}
+	if pod.Priority != nil {
+		// The value mappings are sorted by decreasing MinPriority.
+		for _, mapping := range priorityMapping.Values {
+			if *pod.Priority >= mapping.MinPriority {
+				return mapping.FirmamentPriority
+			}
+		}
+	}
+	return priorityMapping.Default
+}
+
+// preemptionMux is used to guard access to nonPreemptibleTasks,
+// refusedPreemptions and pinnedTasks.
+var preemptionMux sync.Mutex
+
+// nonPreemptibleTasks holds the IDs of the tasks which must not be preempted.
+var nonPreemptibleTasks = make(map[uint64]bool)
+
+// refusedPreemptions holds the IDs of the non preemptible tasks Firmament
+// preempted. The pods keep running, so the next placement Firmament makes
+// for them must not be applied.
+var refusedPreemptions = make(map[uint64]bool)
+
+// pinnedTasks holds the IDs of the tasks submitted again to Firmament
+// constrained to the node their pod kept running on.
+var pinnedTasks = make(map[uint64]bool)
+
+func setTaskPreemptible(taskID uint64, preemptible bool) {
+	preemptionMux.Lock()
+	defer preemptionMux.Unlock()
+	if preemptible {
+		delete(nonPreemptibleTasks, taskID)
+	} else {
+		nonPreemptibleTasks[taskID] = true
+	}
+}
+
+// taskPreemptible returns whether the task may be preempted.
+func taskPreemptible(taskID uint64) bool {
+	preemptionMux.Lock()
+	defer preemptionMux.Unlock()
+	return !nonPreemptibleTasks[taskID]
+}
+
+func forgetTaskPreemption(taskID uint64) {
+	preemptionMux.Lock()
+	defer preemptionMux.Unlock()
+	delete(nonPreemptibleTasks, taskID)
+	delete(refusedPreemptions, taskID)
+	delete(pinnedTasks, taskID)
+}
+
+// RefusePreemption returns true if the task must not be preempted, in which
+// case the next placement of the task is ignored.
+func RefusePreemption(taskID uint64) bool {
+	preemptionMux.Lock()
+	defer preemptionMux.Unlock()
+	if !nonPreemptibleTasks[taskID] {
+		return false
+	}
+	refusedPreemptions[taskID] = true
+	return true
+}
+
+// RefuseMigration returns true if the migrations are disabled by the
+// Migrations feature gate, in which case the pod keeps running and the next
+// placement of the task is ignored, as for a refused preemption.
+func RefuseMigration(taskID uint64) bool {
+	if features.Enabled(features.Migrations) {
+		return false
+	}
+	preemptionMux.Lock()
+	defer preemptionMux.Unlock()
+	refusedPreemptions[taskID] = true
+	return true
+}
+
+// IsPreemptionRefused returns true if the task kept running after a refused
+// preemption and the placement must be ignored. The placement is consumed.
+func IsPreemptionRefused(taskID uint64) bool {
+	preemptionMux.Lock()
+	defer preemptionMux.Unlock()
+	if !refusedPreemptions[taskID] {
+		return false
+	}
+	delete(refusedPreemptions, taskID)
+	return true
+}
+
+// KeepTaskOnNode reports to Firmament that the task whose eviction was not
+// applied still runs on the node. Firmament considers the task evicted and
+// its slot free, so the task is submitted again constrained to the node,
+// which the next placement of the task, ignored, accounts for.
+func KeepTaskOnNode(fc firmament.FirmamentSchedulerClient, taskID uint64, nodeName string) {
+	rtnd, ok := state.NodeTopology(nodeName)
+	if !ok {
+		glog.Warningf("Cannot keep task %d on removed node %s", taskID, nodeName)
+		return
+	}
+	hostname := nodeHostname(rtnd)
+	if hostname == "" {
+		glog.Warningf("Cannot keep task %d on node %s, it has no %s label", taskID, nodeName, nodeHostnameLabel)
+		return
+	}
+	podIdentifier, taskDescription, ok := state.taskDescription(taskID)
+	if !ok {
+		// The pod was deleted in the meantime.
+		return
+	}
+	glog.V(2).Infof("Submitting pod %v again on node %s, it kept running", podIdentifier, nodeName)
+	handleError(firmament.TaskRemoved(fc, &firmament.TaskUID{TaskUid: taskID}))
+	handleError(firmament.TaskSubmitted(fc, &firmament.TaskDescription{
+		TaskDescriptor: pinTask(taskDescription.TaskDescriptor, hostname),
+		JobDescriptor:  taskDescription.JobDescriptor,
+	}))
+	markTaskPinned(taskID)
+}
+
+// nodeHostname returns the hostname label of the node, empty if it has none.
+func nodeHostname(rtnd *firmament.ResourceTopologyNodeDescriptor) string {
+	for _, label := range rtnd.GetResourceDesc().GetLabels() {
+		if label.GetKey() == nodeHostnameLabel {
+			return label.GetValue()
+		}
+	}
+	return ""
+}
+
+// pinTask returns a copy of the task descriptor constrained to the node with
+// the given hostname.
+func pinTask(td *firmament.TaskDescriptor, hostname string) *firmament.TaskDescriptor {
+	pinned := *td
+	pinned.LabelSelectors = append(td.LabelSelectors[:len(td.LabelSelectors):len(td.LabelSelectors)], &firmament.LabelSelector{
+		Type:   firmament.LabelSelector_IN_SET,
+		Key:    nodeHostnameLabel,
+		Values: []string{hostname},
+	})
+	return &pinned
+}
+
+// markTaskPinned records that the task was submitted constrained to its
+// node, so that UnpinTask restores its constraints once it is placed.
+func markTaskPinned(taskID uint64) {
+	preemptionMux.Lock()
+	pinnedTasks[taskID] = true
+	preemptionMux.Unlock()
+}
+
+// UnpinTask restores the constraints of a task KeepTaskOnNode or the resync
+// of Firmament submitted again, once Firmament placed it back on its node.
+func UnpinTask(fc firmament.FirmamentSchedulerClient, taskID uint64) {
+	preemptionMux.Lock()
+	pinned := pinnedTasks[taskID]
+	delete(pinnedTasks, taskID)
+	preemptionMux.Unlock()
+	if !pinned {
+		return
+	}
+	_, taskDescription, ok := state.taskDescription(taskID)
+	if !ok {
+		return
+	}
+	handleError(firmament.TaskUpdated(fc, taskDescription))
+}
@@ -0,0 +1,189 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/features"
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestFirmamentPriority(t *testing.T) {
+	dir, err := ioutil.TempDir("", "priority")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "mapping.json")
+	mapping := `{
+		"classes": {"system-cluster-critical": {"priority": 100, "nonPreemptible": true}},
+		"values": [{"minPriority": 1000, "priority": 10}, {"minPriority": 100000, "priority": 50}],
+		"default": {"priority": 1}
+	}`
+	if err := ioutil.WriteFile(path, []byte(mapping), 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer func(mapping *PriorityMapping) { priorityMapping = mapping }(priorityMapping)
+	priorityMapping, err = LoadPriorityMapping(path)
+	if err != nil {
+		t.Fatalf("LoadPriorityMapping() failed: %v", err)
+	}
+	value := func(priority int32) *int32 { return &priority }
+	var testData = []struct {
+		name     string
+		pod      *Pod
+		expected FirmamentPriority
+	}{
+		{
+			name:     "priority class",
+			pod:      &Pod{PriorityClassName: "system-cluster-critical", Priority: value(2000000000)},
+			expected: FirmamentPriority{Priority: 100, NonPreemptible: true},
+		},
+		{
+			name:     "preemptible mapping of a system critical pod",
+			pod:      &Pod{PriorityClassName: "system-node-critical", Priority: value(2000001000)},
+			expected: FirmamentPriority{Priority: 50, NonPreemptible: true},
+		},
+		{
+			name:     "highest matching value",
+			pod:      &Pod{PriorityClassName: "high", Priority: value(200000)},
+			expected: FirmamentPriority{Priority: 50},
+		},
+		{
+			name:     "matching value",
+			pod:      &Pod{Priority: value(1000)},
+			expected: FirmamentPriority{Priority: 10},
+		},
+		{
+			name:     "below all values",
+			pod:      &Pod{Priority: value(0)},
+			expected: FirmamentPriority{Priority: 1},
+		},
+		{
+			name:     "no priority",
+			pod:      &Pod{},
+			expected: FirmamentPriority{Priority: 1},
+		},
+	}
+	for _, tc := range testData {
+		if got := firmamentPriority(tc.pod); got != tc.expected {
+			t.Errorf("%s: firmamentPriority() = %+v, expected %+v", tc.name, got, tc.expected)
+		}
+	}
+
+	priorityMapping = nil
+	if got := firmamentPriority(&Pod{PriorityClassName: SystemClusterCritical}); !got.NonPreemptible {
+		t.Errorf("firmamentPriority() = %+v without a mapping, expected a system critical pod to be non preemptible", got)
+	}
+}
+
+func TestRefusePreemption(t *testing.T) {
+	defer forgetTaskPreemption(1)
+	defer forgetTaskPreemption(2)
+	setTaskPreemptible(1, false)
+	setTaskPreemptible(2, true)
+	if RefusePreemption(2) {
+		t.Error("RefusePreemption() refused the preemption of a preemptible task")
+	}
+	if IsPreemptionRefused(2) {
+		t.Error("IsPreemptionRefused() = true for a preempted task")
+	}
+	if !RefusePreemption(1) {
+		t.Error("RefusePreemption() accepted the preemption of a non preemptible task")
+	}
+	if !IsPreemptionRefused(1) {
+		t.Error("IsPreemptionRefused() = false after a refused preemption")
+	}
+	if IsPreemptionRefused(1) {
+		t.Error("IsPreemptionRefused() did not consume the ignored placement")
+	}
+}
+
+func TestRefuseMigration(t *testing.T) {
+	defer forgetTaskPreemption(1)
+	if RefuseMigration(1) {
+		t.Error("RefuseMigration() refused a migration with the Migrations feature enabled")
+	}
+	defer features.DefaultGate.Set("Migrations=true")
+	features.DefaultGate.Set("Migrations=false")
+	if !RefuseMigration(1) {
+		t.Error("RefuseMigration() accepted a migration with the Migrations feature disabled")
+	}
+	if !IsPreemptionRefused(1) {
+		t.Error("IsPreemptionRefused() = false after a refused migration")
+	}
+}
+
+func TestKeepTaskOnNode(t *testing.T) {
+	defer forgetTaskPreemption(1)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	fc := firmament.NewMockFirmamentSchedulerClient(mockCtrl)
+	state = &memoryState{}
+	state.jobIDToJD = map[string]*firmament.JobDescriptor{"job0": {Uuid: "job0"}}
+	selector := &firmament.LabelSelector{Type: firmament.LabelSelector_IN_SET, Key: "zone", Values: []string{"a"}}
+	td := &firmament.TaskDescriptor{Uid: 1, JobId: "job0", LabelSelectors: []*firmament.LabelSelector{selector}}
+	state.podToTD = map[PodIdentifier]*firmament.TaskDescriptor{{Name: "pod0", Namespace: "ns"}: td}
+	state.taskIDToPod = map[uint64]PodIdentifier{1: {Name: "pod0", Namespace: "ns"}}
+	state.nodeToRTND = map[string]*firmament.ResourceTopologyNodeDescriptor{"node0": {
+		ResourceDesc: &firmament.ResourceDescriptor{Labels: []*firmament.Label{{Key: nodeHostnameLabel, Value: "node0"}}},
+	}}
+
+	pinned := []*firmament.LabelSelector{selector, {Type: firmament.LabelSelector_IN_SET, Key: nodeHostnameLabel, Values: []string{"node0"}}}
+	gomock.InOrder(
+		fc.EXPECT().TaskRemoved(gomock.Any(), &firmament.TaskUID{TaskUid: 1}).Return(
+			&firmament.TaskRemovedResponse{Type: firmament.TaskReplyType_TASK_REMOVED_OK}, nil),
+		fc.EXPECT().TaskSubmitted(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ interface{}, td *firmament.TaskDescription, _ ...interface{}) (*firmament.TaskSubmittedResponse, error) {
+				if !reflect.DeepEqual(td.GetTaskDescriptor().GetLabelSelectors(), pinned) {
+					t.Errorf("KeepTaskOnNode() submitted the task with selectors %v, expected %v", td.GetTaskDescriptor().GetLabelSelectors(), pinned)
+				}
+				return &firmament.TaskSubmittedResponse{Type: firmament.TaskReplyType_TASK_SUBMITTED_OK}, nil
+			}),
+		fc.EXPECT().TaskUpdated(gomock.Any(), &firmament.TaskDescription{TaskDescriptor: td, JobDescriptor: state.jobIDToJD["job0"]}).Return(
+			&firmament.TaskUpdatedResponse{Type: firmament.TaskReplyType_TASK_UPDATED_OK}, nil),
+	)
+	KeepTaskOnNode(fc, 1, "node0")
+	if len(td.LabelSelectors) != 1 {
+		t.Errorf("KeepTaskOnNode() changed the selectors of the task to %v", td.LabelSelectors)
+	}
+	UnpinTask(fc, 1)
+	// The task is no longer pinned.
+	UnpinTask(fc, 1)
+
+	// A task on a removed node is not submitted again.
+	KeepTaskOnNode(fc, 1, "node1")
+
+	fc.EXPECT().TaskRemoved(gomock.Any(), gomock.Any()).Return(
+		&firmament.TaskRemovedResponse{Type: firmament.TaskReplyType_TASK_REMOVED_OK}, nil)
+	fc.EXPECT().TaskSubmitted(gomock.Any(), gomock.Any()).Return(
+		&firmament.TaskSubmittedResponse{Type: firmament.TaskReplyType_TASK_SUBMITTED_OK}, nil)
+	KeepTaskOnNode(fc, 1, "node0")
+	forgetTaskPreemption(1)
+	preemptionMux.Lock()
+	defer preemptionMux.Unlock()
+	if pinnedTasks[1] {
+		t.Error("forgetTaskPreemption() kept the task pinned")
+	}
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/kubernetes-sigs/poseidon/pkg/metrics"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// ErrNodeQuarantined is the error of the placements on a quarantined node.
+var ErrNodeQuarantined = errors.New("node is quarantined")
+
+// QuarantinePolicy quarantines the nodes on which Failures binds failed
+// within Window, so that no pod is placed on them for Duration.
+type QuarantinePolicy struct {
+	Failures int
+	Window   time.Duration
+	Duration time.Duration
+}
+
+// quarantinePolicy is the node quarantine policy, nil if the nodes are
+// never quarantined.
+var quarantinePolicy *QuarantinePolicy
+
+var (
+	quarantineMux sync.Mutex
+	// nodeFailures maps the nodes to the time of their recent failed binds.
+	nodeFailures = make(map[string][]time.Time)
+	// quarantinedNodes maps the quarantined nodes to the end of their
+	// quarantine.
+	quarantinedNodes = make(map[string]time.Time)
+	// refreshNodeCapacity advertises the capacity of a node to Firmament
+	// again, set once the node watcher is created.
+	refreshNodeCapacity = func(string) {}
+)
+
+// recordNodeFailure accounts a failed bind of a pod to the node, be it
+// rejected by the API server or by the kubelet, and quarantines the node
+// once the failures exceed the policy.
+func recordNodeFailure(nodeName, source string) {
+	if quarantinePolicy == nil || nodeName == "" {
+		return
+	}
+	now := time.Now()
+	quarantineMux.Lock()
+	if _, ok := quarantinedNodes[nodeName]; ok {
+		quarantineMux.Unlock()
+		return
+	}
+	var recent []time.Time
+	for _, failure := range nodeFailures[nodeName] {
+		if now.Sub(failure) < quarantinePolicy.Window {
+			recent = append(recent, failure)
+		}
+	}
+	recent = append(recent, now)
+	if len(recent) < quarantinePolicy.Failures {
+		nodeFailures[nodeName] = recent
+		quarantineMux.Unlock()
+		return
+	}
+	delete(nodeFailures, nodeName)
+	quarantineMux.Unlock()
+	glog.Warningf("Quarantining node %s for %v after %d failed binds within %v", nodeName, quarantinePolicy.Duration,
+		len(recent), quarantinePolicy.Window)
+	quarantineNode(nodeName, source, quarantinePolicy.Duration)
+}
+
+// quarantineNode keeps the pods off the node for the given duration.
+func quarantineNode(nodeName, source string, duration time.Duration) {
+	quarantineMux.Lock()
+	if _, ok := quarantinedNodes[nodeName]; ok {
+		quarantineMux.Unlock()
+		return
+	}
+	quarantinedNodes[nodeName] = time.Now().Add(duration)
+	metrics.NodeQuarantines.Inc(source)
+	metrics.QuarantinedNodes.Set(float64(len(quarantinedNodes)))
+	quarantineMux.Unlock()
+	time.AfterFunc(duration, func() {
+		releaseNode(nodeName)
+	})
+	refreshNodeCapacity(nodeName)
+}
+
+// releaseNode ends the quarantine of a node.
+func releaseNode(nodeName string) {
+	quarantineMux.Lock()
+	delete(quarantinedNodes, nodeName)
+	metrics.QuarantinedNodes.Set(float64(len(quarantinedNodes)))
+	quarantineMux.Unlock()
+	glog.Infof("Node %s is no longer quarantined", nodeName)
+	refreshNodeCapacity(nodeName)
+}
+
+// IsNodeQuarantined returns true if no pod must be placed on the node.
+func IsNodeQuarantined(nodeName string) bool {
+	quarantineMux.Lock()
+	defer quarantineMux.Unlock()
+	_, ok := quarantinedNodes[nodeName]
+	return ok
+}
+
+// isKubeletRejection returns true if the kubelet of its node refused to run
+// the pod, e.g. because it does not have the resources the pod requests.
+func isKubeletRejection(pod *v1.Pod) bool {
+	return pod.Status.Phase == v1.PodFailed && pod.Spec.NodeName != "" && pod.Status.Reason != "" &&
+		len(pod.Status.ContainerStatuses) == 0
+}
+
+// refreshCapacity queues the update of the capacity of the node.
+func (nw *NodeWatcher) refreshCapacity(nodeName string) {
+	obj, exists, err := nw.nodeStore.GetByKey(nodeName)
+	if err != nil || !exists {
+		return
+	}
+	node := obj.(*v1.Node)
+	if !isSchedulable(node) {
+		return
+	}
+	key, err := cache.MetaNamespaceKeyFunc(node)
+	if err != nil {
+		glog.Errorf("refreshCapacity: error getting key %v", err)
+		return
+	}
+	nw.nodeWorkQueue.Add(key, nw.parseNode(node, nodeCapacityChanged))
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/api/core/v1"
+)
+
+func TestNodeQuarantine(t *testing.T) {
+	refreshed := make(chan string, 2)
+	quarantinePolicy = &QuarantinePolicy{Failures: 3, Window: time.Minute, Duration: 100 * time.Millisecond}
+	refreshNodeCapacity = func(nodeName string) { refreshed <- nodeName }
+	defer func() {
+		quarantinePolicy = nil
+		refreshNodeCapacity = func(string) {}
+	}()
+	node := &Node{Hostname: "node0", CPUCapacity: 4000, MemCapacityKb: 1024}
+	recordNodeFailure("node0", "bind")
+	recordNodeFailure("node0", "kubelet")
+	recordNodeFailure("node1", "bind")
+	if IsNodeQuarantined("node0") {
+		t.Fatalf("node0 quarantined after 2 failures, expected 3")
+	}
+	recordNodeFailure("node0", "bind")
+	if !IsNodeQuarantined("node0") || IsNodeQuarantined("node1") {
+		t.Fatalf("Expected node0 only to be quarantined after 3 failures")
+	}
+	if cpu, mem := nodeCapacity(node); cpu != 0 || mem != 0 {
+		t.Errorf("nodeCapacity() of a quarantined node = %v, %v, expected no capacity", cpu, mem)
+	}
+	for _, event := range []string{"quarantine", "release"} {
+		select {
+		case nodeName := <-refreshed:
+			if nodeName != "node0" {
+				t.Errorf("Refreshed the capacity of %s on %s, expected node0", nodeName, event)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("The capacity of node0 was not refreshed on %s", event)
+		}
+	}
+	if IsNodeQuarantined("node0") {
+		t.Errorf("node0 still quarantined after its quarantine ended")
+	}
+	if cpu, mem := nodeCapacity(node); cpu != 4000 || mem != 1024 {
+		t.Errorf("nodeCapacity() of a released node = %v, %v, expected 4000, 1024", cpu, mem)
+	}
+}
+
+func TestIsKubeletRejection(t *testing.T) {
+	var testData = []struct {
+		name     string
+		pod      *v1.Pod
+		expected bool
+	}{
+		{
+			name: "out of cpu",
+			pod: &v1.Pod{
+				Spec:   v1.PodSpec{NodeName: "node0"},
+				Status: v1.PodStatus{Phase: v1.PodFailed, Reason: "OutOfcpu"},
+			},
+			expected: true,
+		},
+		{
+			name: "failed container",
+			pod: &v1.Pod{
+				Spec: v1.PodSpec{NodeName: "node0"},
+				Status: v1.PodStatus{Phase: v1.PodFailed, Reason: "Evicted",
+					ContainerStatuses: []v1.ContainerStatus{{Name: "main"}}},
+			},
+		},
+		{
+			name: "running",
+			pod: &v1.Pod{
+				Spec:   v1.PodSpec{NodeName: "node0"},
+				Status: v1.PodStatus{Phase: v1.PodRunning},
+			},
+		},
+	}
+	for _, tc := range testData {
+		if got := isKubeletRejection(tc.pod); got != tc.expected {
+			t.Errorf("%s: isKubeletRejection() = %v, expected %v", tc.name, got, tc.expected)
+		}
+	}
+}
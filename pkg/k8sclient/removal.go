@@ -0,0 +1,122 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"sort"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+)
+
+// RemovalMove is a pod of a removed node and the node it would move to,
+// empty if it fits on none.
+type RemovalMove struct {
+	Pod      string `json:"pod"`
+	Priority uint32 `json:"priority"`
+	From     string `json:"from"`
+	To       string `json:"to,omitempty"`
+}
+
+// RemovalSimulation is the outcome of the removal of some nodes.
+type RemovalSimulation struct {
+	Nodes []string      `json:"nodes"`
+	Moves []RemovalMove `json:"moves"`
+	// Unplaced are the pods of the removed nodes which fit on no remaining
+	// node.
+	Unplaced []string `json:"unplaced"`
+	// Fits is set if all the pods of the removed nodes fit on the remaining
+	// nodes.
+	Fits bool `json:"fits"`
+}
+
+// SimulateNodeRemoval computes where the pods of the nodes would move if the
+// nodes were removed, to help the scale-down decisions. Firmament solves on
+// its live flow graph only, so the placements are the ones the fallback
+// scheduler would compute on the state cached by Poseidon: the pods move by
+// decreasing priority to the remaining node which best matches their
+// constraints and preferences, then which they leave the least free capacity
+// on. The DaemonSet pods and the deleting pods do not move, and the pending
+// pods are not placed. It returns ErrUnknownNode if a node is unknown to the
+// scheduler.
+func SimulateNodeRemoval(nodeNames []string) (*RemovalSimulation, error) {
+	removed := make(map[string]bool, len(nodeNames))
+	for _, nodeName := range nodeNames {
+		if _, ok := state.NodeTopology(nodeName); !ok {
+			return nil, ErrUnknownNode
+		}
+		removed[nodeName] = true
+	}
+	type candidate struct {
+		move      RemovalMove
+		selectors []*firmament.LabelSelector
+		taskID    uint64
+		// cpu is in millicores and memKb in KB.
+		cpu, memKb int64
+	}
+	var candidates []candidate
+	for nodeName := range removed {
+		for _, pod := range podsByNode.podsOnNode(nodeName) {
+			if pod.DaemonSet || pod.Deleting {
+				continue
+			}
+			c := candidate{
+				move:  RemovalMove{Pod: pod.Identifier.UniqueName(), From: nodeName},
+				cpu:   pod.CPURequest,
+				memKb: pod.MemRequestKb,
+			}
+			// The pods bound by other schedulers have no constraints known
+			// to Poseidon.
+			if td, ok := state.TaskOfPod(pod.Identifier); ok {
+				c.move.Priority = td.GetPriority()
+				c.selectors = td.GetLabelSelectors()
+				c.taskID = td.GetUid()
+			}
+			candidates = append(candidates, c)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].move.Priority != candidates[j].move.Priority {
+			return candidates[i].move.Priority > candidates[j].move.Priority
+		}
+		return candidates[i].move.Pod < candidates[j].move.Pod
+	})
+
+	var nodes []*fallbackNode
+	for _, node := range fallbackNodes() {
+		if !removed[node.name] {
+			nodes = append(nodes, node)
+		}
+	}
+	simulation := &RemovalSimulation{Moves: []RemovalMove{}, Unplaced: []string{}}
+	for nodeName := range removed {
+		simulation.Nodes = append(simulation.Nodes, nodeName)
+	}
+	sort.Strings(simulation.Nodes)
+	policy := FallbackPolicy{Strategy: BestFit}
+	for _, c := range candidates {
+		if node := policy.pickNode(nodes, c.selectors, nodePreferencesOf(c.taskID), c.cpu, c.memKb); node != nil {
+			node.freeCPU -= c.cpu
+			node.freeMemKb -= c.memKb
+			c.move.To = node.name
+		} else {
+			simulation.Unplaced = append(simulation.Unplaced, c.move.Pod)
+		}
+		simulation.Moves = append(simulation.Moves, c.move)
+	}
+	simulation.Fits = len(simulation.Unplaced) == 0
+	return simulation, nil
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSimulateNodeRemoval(t *testing.T) {
+	state = &memoryState{}
+	state.nodeToRTND = make(map[string]*firmament.ResourceTopologyNodeDescriptor)
+	for _, nodeName := range []string{"node0", "node1", "node2"} {
+		state.nodeToRTND[nodeName] = &firmament.ResourceTopologyNodeDescriptor{ResourceDesc: &firmament.ResourceDescriptor{
+			ResourceCapacity: &firmament.ResourceVector{CpuCores: 2000, RamCap: 2000000},
+			Labels:           []*firmament.Label{{Key: "disk", Value: "hdd"}},
+		}}
+	}
+	state.nodeToRTND["node2"].ResourceDesc.Labels = []*firmament.Label{{Key: "disk", Value: "ssd"}}
+	state.podToTD = map[PodIdentifier]*firmament.TaskDescriptor{
+		{Name: "db", Namespace: "ns"}: {Uid: 1, Priority: 5, LabelSelectors: []*firmament.LabelSelector{
+			{Type: firmament.LabelSelector_IN_SET, Key: "disk", Values: []string{"ssd"}},
+		}},
+		{Name: "web", Namespace: "ns"}: {Uid: 2, Priority: 1},
+	}
+	podsByNode = newPodIndex()
+	defer func() { podsByNode = newPodIndex() }()
+	for name, nodeName := range map[string]string{"db": "node0", "web": "node0", "other": "node1", "batch": "node2"} {
+		podsByNode.update(&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"},
+			Spec:       v1.PodSpec{NodeName: nodeName},
+			Status:     v1.PodStatus{Phase: v1.PodRunning},
+		}, 1000, 1000000)
+	}
+
+	if _, err := SimulateNodeRemoval([]string{"node0", "node3"}); err != ErrUnknownNode {
+		t.Errorf("SimulateNodeRemoval(node3) = %v, expected ErrUnknownNode", err)
+	}
+	// The db pod only fits on node2, the web pod on either remaining node.
+	simulation, err := SimulateNodeRemoval([]string{"node0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &RemovalSimulation{
+		Nodes: []string{"node0"},
+		Moves: []RemovalMove{
+			{Pod: "ns/db", Priority: 5, From: "node0", To: "node2"},
+			{Pod: "ns/web", Priority: 1, From: "node0", To: "node1"},
+		},
+		Unplaced: []string{},
+		Fits:     true,
+	}
+	if !reflect.DeepEqual(simulation, expected) {
+		t.Errorf("SimulateNodeRemoval(node0) = %+v, expected %+v", simulation, expected)
+	}
+
+	// Without node2, the db pod fits nowhere and node1 is full once the web
+	// pod moved.
+	simulation, err = SimulateNodeRemoval([]string{"node2", "node0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if simulation.Fits || !reflect.DeepEqual(simulation.Unplaced, []string{"ns/db", "ns/batch"}) {
+		t.Errorf("SimulateNodeRemoval(node0, node2) = %+v, expected ns/db and ns/batch unplaced", simulation)
+	}
+	if !reflect.DeepEqual(simulation.Nodes, []string{"node0", "node2"}) {
+		t.Errorf("SimulateNodeRemoval() nodes = %v, expected [node0 node2]", simulation.Nodes)
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ReplayCluster replays recorded scheduling deltas against a cluster,
+// usually a test cluster reproducing a production one. In dry run, it only
+// checks the deltas could be applied.
+type ReplayCluster struct {
+	client kubernetes.Interface
+	dryRun bool
+}
+
+// NewReplayCluster returns the cluster of the given kubeconfig to replay
+// deltas against.
+func NewReplayCluster(kubeConfig string, dryRun bool) (*ReplayCluster, error) {
+	config, err := GetClientConfig(kubeConfig)
+	if err != nil {
+		return nil, err
+	}
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return &ReplayCluster{client: client, dryRun: dryRun}, nil
+}
+
+// replayPod returns the pod given as namespace/name.
+func (c *ReplayCluster) replayPod(pod string) (*v1.Pod, error) {
+	parts := strings.Split(pod, "/")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid pod %q, expected <namespace>/<name>", pod)
+	}
+	return c.client.CoreV1().Pods(parts[0]).Get(parts[1], metav1.GetOptions{})
+}
+
+// Place binds the pod to the node. The pod must exist and not be bound yet,
+// the node must exist.
+func (c *ReplayCluster) Place(pod, node string) error {
+	p, err := c.replayPod(pod)
+	if err != nil {
+		return err
+	}
+	if p.Spec.NodeName != "" {
+		return fmt.Errorf("pod %s is already bound to node %s", pod, p.Spec.NodeName)
+	}
+	if _, err := c.client.CoreV1().Nodes().Get(node, metav1.GetOptions{}); err != nil {
+		return err
+	}
+	if c.dryRun {
+		return nil
+	}
+	return c.client.CoreV1().Pods(p.Namespace).Bind(&v1.Binding{
+		ObjectMeta: metav1.ObjectMeta{Namespace: p.Namespace, Name: p.Name},
+		Target:     v1.ObjectReference{Kind: "Node", Name: node},
+	})
+}
+
+// Evict deletes the pod, which must exist.
+func (c *ReplayCluster) Evict(pod string) error {
+	p, err := c.replayPod(pod)
+	if err != nil {
+		return err
+	}
+	if c.dryRun {
+		return nil
+	}
+	return c.client.CoreV1().Pods(p.Namespace).Delete(p.Name, preemptionDeletion.deleteOptions())
+}
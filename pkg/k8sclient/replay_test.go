@@ -0,0 +1,70 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"testing"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+)
+
+func TestReplayCluster(t *testing.T) {
+	objects := []runtime.Object{
+		&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pending", Namespace: "ns"}},
+		&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "bound", Namespace: "ns"}, Spec: v1.PodSpec{NodeName: "node0"}},
+		&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node0"}},
+	}
+	for _, dryRun := range []bool{true, false} {
+		client := fake.NewSimpleClientset(objects...)
+		var binds int
+		client.PrependReactor("create", "pods", func(action core.Action) (bool, runtime.Object, error) {
+			if action.GetSubresource() != "bindings" {
+				return false, nil, nil
+			}
+			binds++
+			return true, nil, nil
+		})
+		cluster := &ReplayCluster{client: client, dryRun: dryRun}
+
+		if err := cluster.Place("ns/pending", "node0"); err != nil {
+			t.Errorf("dry run %v: Place(ns/pending) failed: %v", dryRun, err)
+		}
+		if err := cluster.Place("ns/bound", "node0"); err == nil {
+			t.Errorf("dry run %v: Place(ns/bound) of a bound pod succeeded", dryRun)
+		}
+		if err := cluster.Place("ns/pending", "node1"); err == nil {
+			t.Errorf("dry run %v: Place() on an unknown node succeeded", dryRun)
+		}
+		if err := cluster.Place("pending", "node0"); err == nil {
+			t.Errorf("dry run %v: Place() of an invalid pod succeeded", dryRun)
+		}
+		if err := cluster.Evict("ns/bound"); err != nil {
+			t.Errorf("dry run %v: Evict(ns/bound) failed: %v", dryRun, err)
+		}
+		_, err := client.CoreV1().Pods("ns").Get("bound", metav1.GetOptions{})
+		if deleted := err != nil; deleted == dryRun {
+			t.Errorf("dry run %v: pod deleted %v", dryRun, deleted)
+		}
+		if expected := map[bool]int{true: 0, false: 1}[dryRun]; binds != expected {
+			t.Errorf("dry run %v: %d binds, expected %d", dryRun, binds, expected)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/golang/glog"
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+	"golang.org/x/net/context"
+)
+
+var (
+	resyncMux sync.Mutex
+	// resyncedTasks are the tasks already placed when they were submitted
+	// again to a restarted Firmament. Their next placement is ignored.
+	resyncedTasks = make(map[uint64]bool)
+)
+
+// ResyncFirmament checks whether Firmament lost its state, e.g. because it
+// restarted, and submits all the nodes and tasks known to Poseidon again if
+// so, so that no placement is computed against an empty flow graph. It
+// returns true if Firmament was resynced. With a scope, only the Firmament
+// instance of its shard is resynced.
+func ResyncFirmament(fc firmament.FirmamentSchedulerClient, scope *ShardScope) (bool, error) {
+	return resyncFirmament(fc, scope, false)
+}
+
+// ForceResyncFirmament submits all the nodes and tasks known to Poseidon
+// again even if Firmament did not lose its state, e.g. after some of the
+// requests of the watchers failed. The nodes and tasks Firmament already
+// knows are kept. It returns true if Firmament had lost its state.
+func ForceResyncFirmament(fc firmament.FirmamentSchedulerClient, scope *ShardScope) (bool, error) {
+	return resyncFirmament(fc, scope, true)
+}
+
+// resubmittedNode is a node of the scope to submit again to Firmament.
+type resubmittedNode struct {
+	name string
+	rtnd *firmament.ResourceTopologyNodeDescriptor
+}
+
+// resubmittedTask is a task of the scope to submit again to Firmament.
+type resubmittedTask struct {
+	podIdentifier PodIdentifier
+	td            *firmament.TaskDescriptor
+	jd            *firmament.JobDescriptor
+	// bound is set if the pod is bound, in which case its next placement is
+	// ignored.
+	bound bool
+	// pinned is set if td is constrained to the node the pod is bound to.
+	pinned bool
+}
+
+func resyncFirmament(fc firmament.FirmamentSchedulerClient, scope *ShardScope, force bool) (bool, error) {
+	if !state.watched() {
+		// The watchers did not start yet.
+		return false, nil
+	}
+	nodes, tasks := state.resyncDescriptors(scope)
+	lost, err := firmamentLostState(fc, nodes, tasks)
+	if err != nil || (!lost && !force) {
+		return false, err
+	}
+	if lost {
+		glog.Warningf("Firmament lost its state, submitting %d nodes and %d tasks again", len(nodes), len(tasks))
+	} else {
+		glog.Infof("Submitting %d nodes and %d tasks again to Firmament", len(nodes), len(tasks))
+	}
+	for _, node := range nodes {
+		if err := firmament.NodeAdded(fc, node.rtnd); err != nil && firmament.Cause(err) != firmament.ErrNodeExists {
+			return false, firmament.NewError(firmament.KindOf(err), fmt.Sprintf("resubmitting node %s", node.name), err)
+		}
+	}
+	for _, task := range tasks {
+		err := firmament.TaskSubmitted(fc, &firmament.TaskDescription{
+			TaskDescriptor: task.td,
+			JobDescriptor:  task.jd,
+		})
+		if firmament.Cause(err) == firmament.ErrTaskAlreadySubmitted {
+			// Submitted by the pod watcher since the restart, or known to
+			// Firmament if it did not lose its state.
+			continue
+		}
+		if err != nil {
+			return false, firmament.NewError(firmament.KindOf(err), fmt.Sprintf("resubmitting pod %v", task.podIdentifier), err)
+		}
+		if task.bound {
+			// The pod is bound, it keeps running where it is.
+			resyncMux.Lock()
+			resyncedTasks[task.td.GetUid()] = true
+			resyncMux.Unlock()
+		}
+		if task.pinned {
+			markTaskPinned(task.td.GetUid())
+		}
+	}
+	return lost, nil
+}
+
+// resyncDescriptors implements StateStore. The nodes and tasks are copied,
+// so that they are submitted again without holding the state locks. The
+// bound tasks are constrained to the node their pod runs on, as Firmament
+// would otherwise account them wherever it places them.
+func (s *memoryState) resyncDescriptors(scope *ShardScope) ([]resubmittedNode, []resubmittedTask) {
+	s.nodeMux.RLock()
+	defer s.nodeMux.RUnlock()
+	s.podMux.RLock()
+	defer s.podMux.RUnlock()
+	var nodes []resubmittedNode
+	for name, rtnd := range s.nodeToRTND {
+		if scope.hasNode(rtnd) {
+			nodes = append(nodes, resubmittedNode{name: name, rtnd: rtnd})
+		}
+	}
+	var tasks []resubmittedTask
+	for podIdentifier, td := range s.podToTD {
+		if !scope.hasTask(td) {
+			continue
+		}
+		task := resubmittedTask{podIdentifier: podIdentifier, jd: s.jobIDToJD[td.GetJobId()], bound: !isTaskPending(td.GetUid())}
+		copied := *td
+		task.td = &copied
+		if task.bound {
+			nodeName, ok := PodNodeName(podIdentifier)
+			if hostname := nodeHostname(s.nodeToRTND[nodeName]); ok && hostname != "" {
+				task.td, task.pinned = pinTask(td, hostname), true
+			} else {
+				glog.Warningf("Cannot keep pod %v on its node %q when resyncing Firmament", podIdentifier, nodeName)
+			}
+		}
+		tasks = append(tasks, task)
+	}
+	return nodes, tasks
+}
+
+// firmamentLostState probes Firmament with a node, or a task if there is no
+// node, it must know.
+func firmamentLostState(fc firmament.FirmamentSchedulerClient, nodes []resubmittedNode, tasks []resubmittedTask) (bool, error) {
+	if len(nodes) > 0 {
+		resp, err := fc.NodeUpdated(context.Background(), nodes[0].rtnd)
+		if err != nil {
+			return false, err
+		}
+		return resp.GetType() == firmament.NodeReplyType_NODE_NOT_FOUND, nil
+	}
+	for _, task := range tasks {
+		if task.pinned {
+			// Updating it would pin the task Firmament knows.
+			continue
+		}
+		resp, err := fc.TaskUpdated(context.Background(), &firmament.TaskDescription{
+			TaskDescriptor: task.td,
+			JobDescriptor:  task.jd,
+		})
+		if err != nil {
+			return false, err
+		}
+		return resp.GetType() == firmament.TaskReplyType_TASK_NOT_FOUND ||
+			resp.GetType() == firmament.TaskReplyType_TASK_JOB_NOT_FOUND, nil
+	}
+	// There is nothing to resync.
+	return false, nil
+}
+
+// IsResyncedPlacement returns true if the task was already bound when it was
+// submitted again to a restarted Firmament, and the placement must be
+// ignored. The placement is consumed.
+func IsResyncedPlacement(taskID uint64) bool {
+	resyncMux.Lock()
+	defer resyncMux.Unlock()
+	if !resyncedTasks[taskID] {
+		return false
+	}
+	delete(resyncedTasks, taskID)
+	return true
+}
+
+// forgetResyncedTask drops the resync state of a removed task.
+func forgetResyncedTask(taskID uint64) {
+	resyncMux.Lock()
+	delete(resyncedTasks, taskID)
+	resyncMux.Unlock()
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+
+	"github.com/golang/mock/gomock"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestResyncFirmament(t *testing.T) {
+	var testData = []struct {
+		name            string
+		nodeReply       firmament.NodeReplyType
+		expectedResync  bool
+		expectedIgnored bool
+	}{
+		{
+			name:      "firmament kept its state",
+			nodeReply: firmament.NodeReplyType_NODE_UPDATED_OK,
+		},
+		{
+			name:            "firmament restarted",
+			nodeReply:       firmament.NodeReplyType_NODE_NOT_FOUND,
+			expectedResync:  true,
+			expectedIgnored: true,
+		},
+	}
+	for _, tc := range testData {
+		mockCtrl := gomock.NewController(t)
+		fc := firmament.NewMockFirmamentSchedulerClient(mockCtrl)
+		rtnd := &firmament.ResourceTopologyNodeDescriptor{ResourceDesc: &firmament.ResourceDescriptor{
+			Uuid:   "res0",
+			Labels: []*firmament.Label{{Key: nodeHostnameLabel, Value: "host0"}},
+		}}
+		state = &memoryState{}
+		state.nodeToRTND = map[string]*firmament.ResourceTopologyNodeDescriptor{"node0": rtnd}
+		jd := &firmament.JobDescriptor{Uuid: "job0"}
+		state.jobIDToJD = map[string]*firmament.JobDescriptor{"job0": jd}
+		bound := &firmament.TaskDescriptor{Uid: 1, JobId: "job0"}
+		state.podToTD = map[PodIdentifier]*firmament.TaskDescriptor{
+			{Name: "bound", Namespace: "ns"}:   bound,
+			{Name: "pending", Namespace: "ns"}: {Uid: 2, JobId: "job0"},
+		}
+		state.taskIDToPod = map[uint64]PodIdentifier{1: {Name: "bound", Namespace: "ns"}, 2: {Name: "pending", Namespace: "ns"}}
+		markTaskPending(2)
+		podsByNode = newPodIndex()
+		podsByNode.update(&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "bound", Namespace: "ns"},
+			Spec:       v1.PodSpec{NodeName: "node0"},
+			Status:     v1.PodStatus{Phase: v1.PodRunning},
+		}, 0, 0)
+
+		fc.EXPECT().NodeUpdated(gomock.Any(), rtnd).Return(&firmament.NodeUpdatedResponse{Type: tc.nodeReply}, nil)
+		if tc.expectedResync {
+			fc.EXPECT().NodeAdded(gomock.Any(), rtnd).Return(
+				&firmament.NodeAddedResponse{Type: firmament.NodeReplyType_NODE_ADDED_OK}, nil)
+			fc.EXPECT().TaskSubmitted(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, td *firmament.TaskDescription, _ ...grpc.CallOption) (*firmament.TaskSubmittedResponse, error) {
+					selectors := td.GetTaskDescriptor().GetLabelSelectors()
+					pinned := len(selectors) == 1 && selectors[0].GetKey() == nodeHostnameLabel &&
+						reflect.DeepEqual(selectors[0].GetValues(), []string{"host0"})
+					if bound := td.GetTaskDescriptor().GetUid() == 1; pinned != bound {
+						t.Errorf("%s: resubmitted task %d with selectors %v, expected it pinned to its node only if bound",
+							tc.name, td.GetTaskDescriptor().GetUid(), selectors)
+					}
+					return &firmament.TaskSubmittedResponse{Type: firmament.TaskReplyType_TASK_SUBMITTED_OK}, nil
+				}).Times(2)
+		}
+		resynced, err := ResyncFirmament(fc, nil)
+		if err != nil || resynced != tc.expectedResync {
+			t.Errorf("%s: ResyncFirmament() = %v, %v, expected %v", tc.name, resynced, err, tc.expectedResync)
+		}
+		if len(bound.LabelSelectors) != 0 {
+			t.Errorf("%s: ResyncFirmament() pinned the task descriptor of the pod, expected a copy", tc.name)
+		}
+		if got := IsResyncedPlacement(1); got != tc.expectedIgnored {
+			t.Errorf("%s: IsResyncedPlacement(bound) = %v, expected %v", tc.name, got, tc.expectedIgnored)
+		}
+		if IsResyncedPlacement(2) {
+			t.Errorf("%s: IsResyncedPlacement(pending) = true, expected its placement to be applied", tc.name)
+		}
+		if tc.expectedResync {
+			// Once placed back on its node, the task gets its constraints
+			// back.
+			fc.EXPECT().TaskUpdated(gomock.Any(), &firmament.TaskDescription{TaskDescriptor: bound, JobDescriptor: jd}).Return(
+				&firmament.TaskUpdatedResponse{Type: firmament.TaskReplyType_TASK_UPDATED_OK}, nil)
+		}
+		UnpinTask(fc, 1)
+		MarkTaskPlaced(2)
+		podsByNode = newPodIndex()
+		mockCtrl.Finish()
+	}
+}
+
+func TestForceResyncFirmament(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	fc := firmament.NewMockFirmamentSchedulerClient(mockCtrl)
+	rtnd := &firmament.ResourceTopologyNodeDescriptor{ResourceDesc: &firmament.ResourceDescriptor{Uuid: "res0"}}
+	state = &memoryState{}
+	state.nodeToRTND = map[string]*firmament.ResourceTopologyNodeDescriptor{"node0": rtnd}
+	state.jobIDToJD = map[string]*firmament.JobDescriptor{"job0": {Uuid: "job0"}}
+	state.podToTD = map[PodIdentifier]*firmament.TaskDescriptor{
+		{Name: "bound", Namespace: "ns"}:  {Uid: 1, JobId: "job0"},
+		{Name: "missed", Namespace: "ns"}: {Uid: 2, JobId: "job0"},
+	}
+	markTaskPending(2)
+	defer MarkTaskPlaced(2)
+
+	// The submission of the pending task failed transiently, so Firmament
+	// only misses it.
+	fc.EXPECT().TaskSubmitted(gomock.Any(), gomock.Any()).Return(nil, grpc.Errorf(codes.Unavailable, "connection refused"))
+	err := firmament.TaskSubmitted(fc, &firmament.TaskDescription{TaskDescriptor: state.podToTD[PodIdentifier{Name: "missed", Namespace: "ns"}]})
+	if !firmament.IsRetryable(err) {
+		t.Fatalf("TaskSubmitted() = %v, expected a retryable error", err)
+	}
+	if firmamentErr, ok := err.(*firmament.Error); !ok || firmamentErr.Op != "TaskSubmitted" {
+		t.Errorf("TaskSubmitted() = %v, expected an error of the request", err)
+	}
+	seen := RecoverableErrors()
+	handleError(err)
+	if RecoverableErrors() != seen+1 {
+		t.Errorf("RecoverableErrors() = %d after a retryable error, expected %d", RecoverableErrors(), seen+1)
+	}
+
+	fc.EXPECT().NodeUpdated(gomock.Any(), rtnd).Return(&firmament.NodeUpdatedResponse{Type: firmament.NodeReplyType_NODE_UPDATED_OK}, nil)
+	fc.EXPECT().NodeAdded(gomock.Any(), rtnd).Return(&firmament.NodeAddedResponse{Type: firmament.NodeReplyType_NODE_ALREADY_EXISTS}, nil)
+	fc.EXPECT().TaskSubmitted(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, td *firmament.TaskDescription, _ ...grpc.CallOption) (*firmament.TaskSubmittedResponse, error) {
+			if td.GetTaskDescriptor().GetUid() == 1 {
+				return &firmament.TaskSubmittedResponse{Type: firmament.TaskReplyType_TASK_ALREADY_SUBMITTED}, nil
+			}
+			return &firmament.TaskSubmittedResponse{Type: firmament.TaskReplyType_TASK_SUBMITTED_OK}, nil
+		}).Times(2)
+	resynced, err := ForceResyncFirmament(fc, nil)
+	if err != nil || resynced {
+		t.Errorf("ForceResyncFirmament() = %v, %v, expected false as Firmament kept its state", resynced, err)
+	}
+	// The placement of the task Firmament knew is applied.
+	if IsResyncedPlacement(1) {
+		t.Error("IsResyncedPlacement() = true for a task Firmament knew")
+	}
+
+	// The unexpected replies are not recovered from.
+	fc.EXPECT().NodeUpdated(gomock.Any(), rtnd).Return(&firmament.NodeUpdatedResponse{Type: firmament.NodeReplyType_NODE_UPDATED_OK}, nil)
+	fc.EXPECT().NodeAdded(gomock.Any(), rtnd).Return(&firmament.NodeAddedResponse{Type: firmament.NodeReplyType_NODE_FAILED_OK}, nil)
+	if _, err := ForceResyncFirmament(fc, nil); err == nil || firmament.KindOf(err) != firmament.Fatal {
+		t.Errorf("ForceResyncFirmament() = %v on an unexpected reply, expected a fatal error", err)
+	}
+}
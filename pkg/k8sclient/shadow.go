@@ -0,0 +1,98 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"sync"
+
+	"github.com/golang/glog"
+	"github.com/kubernetes-sigs/poseidon/pkg/metrics"
+)
+
+// shadowMode is set when Poseidon computes placements for the pods of
+// another scheduler without binding them.
+var shadowMode bool
+
+// shadowPlacement holds the intended and actual node of a shadowed pod.
+type shadowPlacement struct {
+	intended string
+	actual   string
+}
+
+// shadowMux is used to guard access to shadowPlacements.
+var shadowMux sync.Mutex
+
+// shadowPlacements maps shadowed pods to their placements until both the
+// intended and the actual node are known.
+var shadowPlacements = make(map[PodIdentifier]*shadowPlacement)
+
+// IsShadowMode returns true if Poseidon must not act on its placements.
+func IsShadowMode() bool {
+	return shadowMode
+}
+
+// RecordShadowPlacement records the node Poseidon would have placed the pod on.
+func RecordShadowPlacement(podIdentifier PodIdentifier, nodeName string) {
+	shadowMux.Lock()
+	defer shadowMux.Unlock()
+	placement := getShadowPlacement(podIdentifier)
+	placement.intended = nodeName
+	compareShadowPlacement(podIdentifier, placement)
+}
+
+// recordActualPlacement records the node the other scheduler bound the pod to.
+func recordActualPlacement(podIdentifier PodIdentifier, nodeName string) {
+	shadowMux.Lock()
+	defer shadowMux.Unlock()
+	placement := getShadowPlacement(podIdentifier)
+	placement.actual = nodeName
+	compareShadowPlacement(podIdentifier, placement)
+}
+
+// forgetShadowPlacement drops the placements of a deleted pod.
+func forgetShadowPlacement(podIdentifier PodIdentifier) {
+	shadowMux.Lock()
+	delete(shadowPlacements, podIdentifier)
+	shadowMux.Unlock()
+}
+
+func getShadowPlacement(podIdentifier PodIdentifier) *shadowPlacement {
+	placement, ok := shadowPlacements[podIdentifier]
+	if !ok {
+		placement = &shadowPlacement{}
+		shadowPlacements[podIdentifier] = placement
+	}
+	return placement
+}
+
+// compareShadowPlacement accounts the placement once both nodes are known.
+// The caller must hold shadowMux.
+func compareShadowPlacement(podIdentifier PodIdentifier, placement *shadowPlacement) {
+	if placement.intended == "" || placement.actual == "" {
+		return
+	}
+	delete(shadowPlacements, podIdentifier)
+	if placement.intended == placement.actual {
+		metrics.ShadowDecisions.Inc("agree")
+	} else {
+		glog.V(2).Infof("Shadow placement of pod %v diverges: intended %s, actual %s",
+			podIdentifier, placement.intended, placement.actual)
+		metrics.ShadowDecisions.Inc("diverge")
+	}
+	agreed := metrics.ShadowDecisions.Get("agree")
+	metrics.ShadowAgreementRatio.Set(agreed / (agreed + metrics.ShadowDecisions.Get("diverge")))
+}
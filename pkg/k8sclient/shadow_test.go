@@ -0,0 +1,51 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"testing"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/metrics"
+)
+
+func TestShadowPlacement(t *testing.T) {
+	agreed := metrics.ShadowDecisions.Get("agree")
+	diverged := metrics.ShadowDecisions.Get("diverge")
+
+	// Poseidon places before the shadowed scheduler binds.
+	pod0 := PodIdentifier{Name: "pod0", Namespace: "default"}
+	RecordShadowPlacement(pod0, "node0")
+	recordActualPlacement(pod0, "node0")
+	// The shadowed scheduler binds before Poseidon places.
+	pod1 := PodIdentifier{Name: "pod1", Namespace: "default"}
+	recordActualPlacement(pod1, "node1")
+	RecordShadowPlacement(pod1, "node2")
+	// The pod is deleted before Poseidon places it.
+	pod2 := PodIdentifier{Name: "pod2", Namespace: "default"}
+	recordActualPlacement(pod2, "node1")
+	forgetShadowPlacement(pod2)
+
+	if got := metrics.ShadowDecisions.Get("agree") - agreed; got != 1 {
+		t.Errorf("Agreeing placements = %v, expected 1", got)
+	}
+	if got := metrics.ShadowDecisions.Get("diverge") - diverged; got != 1 {
+		t.Errorf("Diverging placements = %v, expected 1", got)
+	}
+	if len(shadowPlacements) != 0 {
+		t.Errorf("Expected no placements left, got %v", shadowPlacements)
+	}
+}
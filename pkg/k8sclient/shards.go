@@ -0,0 +1,48 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+)
+
+// ShardScope restricts the bookkeeping of a scheduling cycle to the nodes
+// and tasks of a shard of a sharded Firmament, whose cycles run concurrently
+// with those of the other shards. A nil scope is the whole cluster.
+type ShardScope struct {
+	Shards *firmament.ShardedClient
+	Name   string
+}
+
+// hasNode returns whether the node is in the scope.
+func (s *ShardScope) hasNode(rtnd *firmament.ResourceTopologyNodeDescriptor) bool {
+	return s == nil || s.Shards.NodeShard(rtnd) == s.Name
+}
+
+// hasTask returns whether the task is in the scope.
+func (s *ShardScope) hasTask(td *firmament.TaskDescriptor) bool {
+	return s == nil || s.Shards.TaskShard(td) == s.Name
+}
+
+// hasTaskID returns whether the submitted task is in the scope.
+func (s *ShardScope) hasTaskID(taskID uint64) bool {
+	if s == nil {
+		return true
+	}
+	shard, _ := s.Shards.ShardOfTask(taskID)
+	return shard == s.Name
+}
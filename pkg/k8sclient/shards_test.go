@@ -0,0 +1,95 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+	"github.com/kubernetes-sigs/poseidon/pkg/metrics"
+	"golang.org/x/net/context"
+)
+
+func TestShardScope(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	defaultShard, shardA := firmament.NewMockFirmamentSchedulerClient(mockCtrl), firmament.NewMockFirmamentSchedulerClient(mockCtrl)
+	shards, err := firmament.NewShardedClient("pool", map[string]firmament.FirmamentSchedulerClient{"": defaultShard, "a": shardA})
+	if err != nil {
+		t.Fatal(err)
+	}
+	inPool := &firmament.TaskDescriptor{Uid: 1, LabelSelectors: []*firmament.LabelSelector{
+		{Type: firmament.LabelSelector_IN_SET, Key: "pool", Values: []string{"a"}},
+	}}
+	anywhere := &firmament.TaskDescriptor{Uid: 2}
+	shardA.EXPECT().TaskSubmitted(gomock.Any(), gomock.Any()).Return(&firmament.TaskSubmittedResponse{}, nil)
+	defaultShard.EXPECT().TaskSubmitted(gomock.Any(), gomock.Any()).Return(&firmament.TaskSubmittedResponse{}, nil)
+	for _, td := range []*firmament.TaskDescriptor{inPool, anywhere} {
+		shards.TaskSubmitted(context.Background(), &firmament.TaskDescription{TaskDescriptor: td})
+	}
+	scopeA, defaultScope := &ShardScope{Shards: shards, Name: "a"}, &ShardScope{Shards: shards}
+	var nilScope *ShardScope
+	if !scopeA.hasTask(inPool) || scopeA.hasTask(anywhere) || !defaultScope.hasTask(anywhere) || !nilScope.hasTask(inPool) {
+		t.Error("hasTask() did not select the tasks of the shard")
+	}
+
+	// The submissions wake up the cycles of their own shard.
+	defer func() {
+		pendingMux.Lock()
+		submissionNotifiers, submissionShards = map[string]*submissionNotifier{"": newSubmissionNotifier()}, nil
+		pendingMux.Unlock()
+	}()
+	submittedA, submittedDefault := TaskSubmittedNotify(scopeA), TaskSubmittedNotify(defaultScope)
+	criticalA, criticalDefault := CriticalTaskSubmittedNotify(scopeA), CriticalTaskSubmittedNotify(defaultScope)
+	markCriticalTaskPending(1)
+	markTaskPending(2)
+	defer MarkTaskPlaced(1)
+	defer MarkTaskPlaced(2)
+	for name, notify := range map[string]<-chan struct{}{"submission of shard a": submittedA, "critical submission of shard a": criticalA,
+		"submission of the default shard": submittedDefault} {
+		select {
+		case <-notify:
+		default:
+			t.Errorf("The %s was not notified", name)
+		}
+	}
+	select {
+	case <-criticalDefault:
+		t.Error("The critical submission of shard a was notified to the default shard")
+	default:
+	}
+	if got := NumShardPendingTasks(scopeA); got != 1 {
+		t.Errorf("NumShardPendingTasks(a) = %d, expected 1", got)
+	}
+
+	// The cycles of a shard only account its own tasks.
+	cycleStart := time.Now()
+	RecordSchedulingCycle(scopeA, cycleStart)
+	RecordSchedulingCycle(scopeA, cycleStart)
+	RecordSchedulingCycle(defaultScope, cycleStart)
+	if got := metrics.FirmamentBacklogMaxCycles.Get(); got != 2 {
+		t.Errorf("Max cycles = %v, expected 2 cycles of shard a", got)
+	}
+	pendingMux.Lock()
+	cycles := pendingTasks[2].cycles
+	pendingMux.Unlock()
+	if cycles != 1 {
+		t.Errorf("Task of the default shard left unplaced by %d cycles, expected 1", cycles)
+	}
+}
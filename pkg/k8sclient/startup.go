@@ -0,0 +1,104 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/kubernetes-sigs/poseidon/pkg/metrics"
+)
+
+var (
+	synchronizedMux sync.Mutex
+	// synchronizedWatchers are the watchers whose initial synchronization
+	// completed.
+	synchronizedWatchers = make(map[string]bool)
+)
+
+// watcherSynchronized returns whether the initial synchronization of the
+// watcher completed.
+func watcherSynchronized(watcher string) bool {
+	synchronizedMux.Lock()
+	defer synchronizedMux.Unlock()
+	return synchronizedWatchers[watcher]
+}
+
+// WaitForStartupSync blocks until the nodes and pods listed at startup are
+// synchronized with Firmament.
+func WaitForStartupSync() {
+	for !watcherSynchronized("node") || !watcherSynchronized("pod") {
+		time.Sleep(time.Second)
+	}
+}
+
+// startupSync tracks the initial synchronization of the nodes or pods listed
+// when the caches of a watcher synced, i.e. the keys queued before its
+// workers started, until all of them are processed.
+type startupSync struct {
+	watcher string
+	start   time.Time
+	mu      sync.Mutex
+	pending map[interface{}]bool
+}
+
+// newStartupSync returns the tracker of the initial synchronization of the
+// keys of the watcher, which started at start.
+func newStartupSync(watcher string, keys []interface{}, start time.Time) *startupSync {
+	s := &startupSync{
+		watcher: watcher,
+		start:   start,
+		pending: make(map[interface{}]bool, len(keys)),
+	}
+	for _, key := range keys {
+		s.pending[key] = true
+	}
+	glog.Infof("Synchronizing %d %ss with Firmament", len(s.pending), watcher)
+	metrics.StartupSyncPending.Set(float64(len(s.pending)), watcher)
+	if len(s.pending) == 0 {
+		s.complete()
+	}
+	return s
+}
+
+// processed marks the key as synchronized.
+func (s *startupSync) processed(key interface{}) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.pending[key] {
+		return
+	}
+	delete(s.pending, key)
+	metrics.StartupSyncPending.Set(float64(len(s.pending)), s.watcher)
+	if len(s.pending) == 0 {
+		s.complete()
+	}
+}
+
+// complete records the duration of the synchronization.
+func (s *startupSync) complete() {
+	elapsed := time.Since(s.start)
+	glog.Infof("Synchronized the %ss with Firmament in %v", s.watcher, elapsed)
+	metrics.StartupSyncSeconds.Set(elapsed.Seconds(), s.watcher)
+	synchronizedMux.Lock()
+	synchronizedWatchers[s.watcher] = true
+	synchronizedMux.Unlock()
+}
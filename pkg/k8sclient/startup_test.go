@@ -0,0 +1,66 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/metrics"
+)
+
+func TestStartupSync(t *testing.T) {
+	metrics.StartupSyncSeconds.Reset()
+	queue := NewKeyedQueue()
+	queue.Add("node0", &Node{Hostname: "node0"})
+	queue.Add("node1", &Node{Hostname: "node1"})
+	queue.Add("node0", &Node{Hostname: "node0"})
+	startup := newStartupSync("test", queue.Keys(), time.Now().Add(-time.Minute))
+	if pending := metrics.StartupSyncPending.Get("test"); pending != 2 {
+		t.Fatalf("pending = %v, expected 2", pending)
+	}
+
+	startup.processed("node0")
+	// Keys queued after the startup are not tracked.
+	startup.processed("node2")
+	startup.processed("node0")
+	if pending := metrics.StartupSyncPending.Get("test"); pending != 1 {
+		t.Errorf("pending = %v, expected 1", pending)
+	}
+	if seconds := metrics.StartupSyncSeconds.Get("test"); seconds != 0 {
+		t.Errorf("startup sync seconds = %v before the sync completed", seconds)
+	}
+
+	startup.processed("node1")
+	if pending := metrics.StartupSyncPending.Get("test"); pending != 0 {
+		t.Errorf("pending = %v, expected 0", pending)
+	}
+	if seconds := metrics.StartupSyncSeconds.Get("test"); seconds < 60 {
+		t.Errorf("startup sync seconds = %v, expected at least 60", seconds)
+	}
+
+	var disabled *startupSync
+	disabled.processed("node0")
+}
+
+func TestStartupSyncWithoutKeys(t *testing.T) {
+	metrics.StartupSyncSeconds.Reset()
+	newStartupSync("empty", nil, time.Now().Add(-time.Second))
+	if seconds := metrics.StartupSyncSeconds.Get("empty"); seconds < 1 {
+		t.Errorf("startup sync seconds = %v, expected the sync to be complete", seconds)
+	}
+}
@@ -0,0 +1,322 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"sync"
+	"time"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+	"github.com/kubernetes-sigs/poseidon/pkg/metrics"
+)
+
+// StateStore is the state shared by the watchers, the scheduling loop and
+// the stats server: the pods and nodes submitted to Firmament. Its readers
+// do not depend on how and where it is kept.
+type StateStore interface {
+	// PodOfTask returns the pod of the Firmament task.
+	PodOfTask(taskID uint64) (PodIdentifier, bool)
+	// TaskOfPod returns the Firmament task descriptor of the pod.
+	TaskOfPod(podIdentifier PodIdentifier) (*firmament.TaskDescriptor, bool)
+	// NodeOfResource returns the node of the Firmament resource, i.e. of
+	// the node itself or of one of its processing units.
+	NodeOfResource(resourceID string) (string, bool)
+	// NodeTopology returns the Firmament resource topology of the node.
+	NodeTopology(nodeName string) (*firmament.ResourceTopologyNodeDescriptor, bool)
+	// Snapshot returns a consistent copy of the pods and nodes.
+	Snapshot() *StateSnapshot
+
+	// taskOf returns the pod and the task descriptor of the task.
+	taskOf(taskID uint64) (PodIdentifier, *firmament.TaskDescriptor, bool)
+	// taskDescription returns the pod of the task and the task and job
+	// descriptors to send to Firmament, false if the pod or its job is not
+	// known.
+	taskDescription(taskID uint64) (PodIdentifier, *firmament.TaskDescription, bool)
+	// jobOf returns the job descriptor of the job.
+	jobOf(jobID string) (*firmament.JobDescriptor, bool)
+	// submitTask records the task newTask adds to the job of the pod, the
+	// job being created by newJob if it has no task yet.
+	submitTask(podIdentifier PodIdentifier, jobID string, newJob func() *firmament.JobDescriptor,
+		newTask func(jd *firmament.JobDescriptor) *firmament.TaskDescriptor) *firmament.TaskDescription
+	// removeTask forgets the task of the pod, and its job once it has no
+	// task left.
+	removeTask(podIdentifier PodIdentifier, taskID uint64, jobID string)
+	// updateTasks applies update to the descriptors of the known tasks, and
+	// returns their updated descriptions.
+	updateTasks(taskIDs []uint64, update func(td *firmament.TaskDescriptor)) []*firmament.TaskDescription
+	// resyncDescriptors copies the nodes and tasks of the scope to submit
+	// again to Firmament.
+	resyncDescriptors(scope *ShardScope) ([]resubmittedNode, []resubmittedTask)
+}
+
+// StateSnapshot is a copy of the pods and nodes submitted to Firmament.
+type StateSnapshot struct {
+	Time time.Time `json:"time"`
+	// Tasks maps the pods (namespace/name) to the ID of their task.
+	Tasks map[string]uint64 `json:"tasks"`
+	// Nodes maps the nodes to the ID of their resource.
+	Nodes map[string]string `json:"nodes"`
+}
+
+// memoryState is the StateStore kept in memory.
+type memoryState struct {
+	// podMux guards the pod, task and job related maps.
+	podMux sync.RWMutex
+	// podToTD maps the pods to their task descriptor.
+	podToTD map[PodIdentifier]*firmament.TaskDescriptor
+	// taskIDToPod maps the task IDs to their pod.
+	taskIDToPod map[uint64]PodIdentifier
+	// jobIDToJD maps the job IDs to their job descriptor.
+	jobIDToJD map[string]*firmament.JobDescriptor
+	// jobNumTasksToRemove maps the job IDs to the number of their tasks.
+	jobNumTasksToRemove map[string]int
+	// nodeMux guards the node and resource related maps.
+	nodeMux sync.RWMutex
+	// nodeToRTND maps the node names to their resource topology.
+	nodeToRTND map[string]*firmament.ResourceTopologyNodeDescriptor
+	// resIDToNode maps the resource IDs to their node name.
+	resIDToNode map[string]string
+}
+
+// state is the state of the running scheduler. Its maps are created when
+// the watchers start.
+var state = &memoryState{}
+
+// State returns the state of the running scheduler.
+func State() StateStore {
+	return state
+}
+
+// resetPods clears the pods and tasks.
+func (s *memoryState) resetPods() {
+	s.podMux.Lock()
+	defer s.podMux.Unlock()
+	s.podToTD = make(map[PodIdentifier]*firmament.TaskDescriptor)
+	s.taskIDToPod = make(map[uint64]PodIdentifier)
+	s.jobIDToJD = make(map[string]*firmament.JobDescriptor)
+	s.jobNumTasksToRemove = make(map[string]int)
+	s.updatePodMetrics()
+}
+
+// resetNodes clears the nodes and resources.
+func (s *memoryState) resetNodes() {
+	s.nodeMux.Lock()
+	defer s.nodeMux.Unlock()
+	s.nodeToRTND = make(map[string]*firmament.ResourceTopologyNodeDescriptor)
+	s.resIDToNode = make(map[string]string)
+	s.updateNodeMetrics()
+}
+
+// watched returns whether both the pod and the node watchers started.
+func (s *memoryState) watched() bool {
+	s.podMux.RLock()
+	defer s.podMux.RUnlock()
+	s.nodeMux.RLock()
+	defer s.nodeMux.RUnlock()
+	return s.podToTD != nil && s.nodeToRTND != nil
+}
+
+// setTask records the task of the pod. podMux must be held.
+func (s *memoryState) setTask(podIdentifier PodIdentifier, td *firmament.TaskDescriptor) {
+	s.podToTD[podIdentifier] = td
+	s.taskIDToPod[td.GetUid()] = podIdentifier
+	s.updatePodMetrics()
+}
+
+// deleteTask forgets the task of the pod. podMux must be held.
+func (s *memoryState) deleteTask(podIdentifier PodIdentifier, taskID uint64) {
+	delete(s.podToTD, podIdentifier)
+	delete(s.taskIDToPod, taskID)
+	s.updatePodMetrics()
+}
+
+// setNode records the resource topology of the node. nodeMux must be held.
+func (s *memoryState) setNode(nodeName string, rtnd *firmament.ResourceTopologyNodeDescriptor) {
+	s.nodeToRTND[nodeName] = rtnd
+	s.resIDToNode[rtnd.GetResourceDesc().GetUuid()] = nodeName
+	s.updateNodeMetrics()
+}
+
+// deleteNode forgets the node and its resource. nodeMux must be held.
+func (s *memoryState) deleteNode(nodeName, resourceID string) {
+	delete(s.nodeToRTND, nodeName)
+	delete(s.resIDToNode, resourceID)
+	s.updateNodeMetrics()
+}
+
+// setResource records the node of a resource. nodeMux must be held.
+func (s *memoryState) setResource(resourceID, nodeName string) {
+	s.resIDToNode[resourceID] = nodeName
+	s.updateNodeMetrics()
+}
+
+// deleteResource forgets a resource. nodeMux must be held.
+func (s *memoryState) deleteResource(resourceID string) {
+	delete(s.resIDToNode, resourceID)
+	s.updateNodeMetrics()
+}
+
+func (s *memoryState) updatePodMetrics() {
+	metrics.StateEntries.Set(float64(len(s.podToTD)), "pods")
+	metrics.StateEntries.Set(float64(len(s.taskIDToPod)), "tasks")
+}
+
+func (s *memoryState) updateNodeMetrics() {
+	metrics.StateEntries.Set(float64(len(s.nodeToRTND)), "nodes")
+	metrics.StateEntries.Set(float64(len(s.resIDToNode)), "resources")
+}
+
+// PodOfTask implements StateStore.
+func (s *memoryState) PodOfTask(taskID uint64) (PodIdentifier, bool) {
+	s.podMux.RLock()
+	defer s.podMux.RUnlock()
+	podIdentifier, ok := s.taskIDToPod[taskID]
+	return podIdentifier, ok
+}
+
+// TaskOfPod implements StateStore.
+func (s *memoryState) TaskOfPod(podIdentifier PodIdentifier) (*firmament.TaskDescriptor, bool) {
+	s.podMux.RLock()
+	defer s.podMux.RUnlock()
+	td, ok := s.podToTD[podIdentifier]
+	return td, ok
+}
+
+// NodeOfResource implements StateStore.
+func (s *memoryState) NodeOfResource(resourceID string) (string, bool) {
+	s.nodeMux.RLock()
+	defer s.nodeMux.RUnlock()
+	nodeName, ok := s.resIDToNode[resourceID]
+	return nodeName, ok
+}
+
+// NodeTopology implements StateStore.
+func (s *memoryState) NodeTopology(nodeName string) (*firmament.ResourceTopologyNodeDescriptor, bool) {
+	s.nodeMux.RLock()
+	defer s.nodeMux.RUnlock()
+	rtnd, ok := s.nodeToRTND[nodeName]
+	return rtnd, ok
+}
+
+// taskOf implements StateStore.
+func (s *memoryState) taskOf(taskID uint64) (PodIdentifier, *firmament.TaskDescriptor, bool) {
+	s.podMux.RLock()
+	defer s.podMux.RUnlock()
+	podIdentifier, ok := s.taskIDToPod[taskID]
+	if !ok {
+		return podIdentifier, nil, false
+	}
+	return podIdentifier, s.podToTD[podIdentifier], true
+}
+
+// taskDescription implements StateStore.
+func (s *memoryState) taskDescription(taskID uint64) (PodIdentifier, *firmament.TaskDescription, bool) {
+	s.podMux.RLock()
+	defer s.podMux.RUnlock()
+	podIdentifier, ok := s.taskIDToPod[taskID]
+	if !ok {
+		return podIdentifier, nil, false
+	}
+	td := s.podToTD[podIdentifier]
+	jd, ok := s.jobIDToJD[td.GetJobId()]
+	if !ok {
+		return podIdentifier, nil, false
+	}
+	return podIdentifier, &firmament.TaskDescription{TaskDescriptor: td, JobDescriptor: jd}, true
+}
+
+// jobOf implements StateStore.
+func (s *memoryState) jobOf(jobID string) (*firmament.JobDescriptor, bool) {
+	s.podMux.RLock()
+	defer s.podMux.RUnlock()
+	jd, ok := s.jobIDToJD[jobID]
+	return jd, ok
+}
+
+// submitTask implements StateStore.
+func (s *memoryState) submitTask(podIdentifier PodIdentifier, jobID string, newJob func() *firmament.JobDescriptor,
+	newTask func(jd *firmament.JobDescriptor) *firmament.TaskDescriptor) *firmament.TaskDescription {
+	s.podMux.Lock()
+	defer s.podMux.Unlock()
+	jd, ok := s.jobIDToJD[jobID]
+	if !ok {
+		jd = newJob()
+		s.jobIDToJD[jobID] = jd
+		s.jobNumTasksToRemove[jobID] = 0
+	}
+	td := newTask(jd)
+	s.jobNumTasksToRemove[jobID]++
+	s.setTask(podIdentifier, td)
+	return &firmament.TaskDescription{TaskDescriptor: td, JobDescriptor: jd}
+}
+
+// removeTask implements StateStore.
+func (s *memoryState) removeTask(podIdentifier PodIdentifier, taskID uint64, jobID string) {
+	s.podMux.Lock()
+	defer s.podMux.Unlock()
+	s.deleteTask(podIdentifier, taskID)
+	// TODO(ionel): Should we delete the task from JD's spawned field?
+	s.jobNumTasksToRemove[jobID]--
+	if s.jobNumTasksToRemove[jobID] == 0 {
+		// Clean state because the job doesn't have any tasks left.
+		delete(s.jobNumTasksToRemove, jobID)
+		delete(s.jobIDToJD, jobID)
+	}
+}
+
+// updateTasks implements StateStore. The tasks whose job is not known are
+// not updated.
+func (s *memoryState) updateTasks(taskIDs []uint64, update func(td *firmament.TaskDescriptor)) []*firmament.TaskDescription {
+	s.podMux.Lock()
+	defer s.podMux.Unlock()
+	var updated []*firmament.TaskDescription
+	for _, taskID := range taskIDs {
+		podIdentifier, ok := s.taskIDToPod[taskID]
+		if !ok {
+			continue
+		}
+		td := s.podToTD[podIdentifier]
+		jd, ok := s.jobIDToJD[td.GetJobId()]
+		if !ok {
+			continue
+		}
+		update(td)
+		updated = append(updated, &firmament.TaskDescription{TaskDescriptor: td, JobDescriptor: jd})
+	}
+	return updated
+}
+
+// Snapshot implements StateStore. The nodes are locked before the pods, as
+// everywhere else.
+func (s *memoryState) Snapshot() *StateSnapshot {
+	s.nodeMux.RLock()
+	defer s.nodeMux.RUnlock()
+	s.podMux.RLock()
+	defer s.podMux.RUnlock()
+	snapshot := &StateSnapshot{
+		Time:  time.Now(),
+		Tasks: make(map[string]uint64, len(s.podToTD)),
+		Nodes: make(map[string]string, len(s.nodeToRTND)),
+	}
+	for podIdentifier, td := range s.podToTD {
+		snapshot.Tasks[podIdentifier.UniqueName()] = td.GetUid()
+	}
+	for nodeName, rtnd := range s.nodeToRTND {
+		snapshot.Nodes[nodeName] = rtnd.GetResourceDesc().GetUuid()
+	}
+	return snapshot
+}
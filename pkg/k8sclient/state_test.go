@@ -0,0 +1,77 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+	"github.com/kubernetes-sigs/poseidon/pkg/metrics"
+)
+
+func TestMemoryState(t *testing.T) {
+	store := &memoryState{}
+	if store.watched() {
+		t.Error("watched() = true before the watchers started")
+	}
+	store.resetPods()
+	store.resetNodes()
+	if !store.watched() {
+		t.Error("watched() = false after the watchers started")
+	}
+
+	pod := PodIdentifier{Name: "pod0", Namespace: "ns"}
+	store.setTask(pod, &firmament.TaskDescriptor{Uid: 1})
+	store.setNode("node0", &firmament.ResourceTopologyNodeDescriptor{ResourceDesc: &firmament.ResourceDescriptor{Uuid: "res0"}})
+	store.setResource("pu0", "node0")
+	if entries := metrics.StateEntries.Get("resources"); entries != 2 {
+		t.Errorf("resources = %v, expected 2", entries)
+	}
+
+	if got, ok := store.PodOfTask(1); !ok || got != pod {
+		t.Errorf("PodOfTask(1) = %v, %v, expected %v", got, ok, pod)
+	}
+	if td, ok := store.TaskOfPod(pod); !ok || td.GetUid() != 1 {
+		t.Errorf("TaskOfPod(%v) = %v, %v, expected task 1", pod, td, ok)
+	}
+	if nodeName, ok := store.NodeOfResource("pu0"); !ok || nodeName != "node0" {
+		t.Errorf("NodeOfResource(pu0) = %s, %v, expected node0", nodeName, ok)
+	}
+	if _, ok := store.NodeTopology("node1"); ok {
+		t.Error("NodeTopology(node1) found an unknown node")
+	}
+	snapshot := store.Snapshot()
+	if !reflect.DeepEqual(snapshot.Tasks, map[string]uint64{"ns/pod0": 1}) ||
+		!reflect.DeepEqual(snapshot.Nodes, map[string]string{"node0": "res0"}) {
+		t.Errorf("Snapshot() = %+v", snapshot)
+	}
+
+	store.deleteTask(pod, 1)
+	store.deleteResource("pu0")
+	store.deleteNode("node0", "res0")
+	if _, ok := store.PodOfTask(1); ok {
+		t.Error("PodOfTask(1) found a deleted task")
+	}
+	if entries := metrics.StateEntries.Get("nodes") + metrics.StateEntries.Get("resources"); entries != 0 {
+		t.Errorf("nodes and resources = %v, expected 0", entries)
+	}
+	// The snapshot is not changed by later updates.
+	if len(snapshot.Tasks) != 1 || len(snapshot.Nodes) != 1 {
+		t.Errorf("Snapshot() changed to %+v", snapshot)
+	}
+}
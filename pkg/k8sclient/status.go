@@ -0,0 +1,156 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Firmament health reported in the status.
+const (
+	FirmamentServing     = "Serving"
+	FirmamentUnavailable = "Unavailable"
+)
+
+// Status is the state of the scheduler published after each cycle.
+type Status struct {
+	// Leader identifies the Poseidon instance scheduling the pods.
+	Leader string
+	RunID  string
+	// PendingTasks is the number of tasks submitted to Firmament and not
+	// yet placed.
+	PendingTasks int
+	// DeferredDeltas is the number of deltas deferred by the node caps.
+	DeferredDeltas   int
+	LastCycleTime    time.Time
+	LastCycleLatency time.Duration
+	FirmamentHealth  string
+	// Backpressure is why the task submissions are delayed, empty if they
+	// are not.
+	Backpressure string
+}
+
+// data returns the status as ConfigMap data.
+func (s Status) data() map[string]string {
+	return map[string]string{
+		"leader":           s.Leader,
+		"runID":            s.RunID,
+		"pendingTasks":     strconv.Itoa(s.PendingTasks),
+		"deferredDeltas":   strconv.Itoa(s.DeferredDeltas),
+		"lastCycleTime":    s.LastCycleTime.UTC().Format(time.RFC3339),
+		"lastCycleLatency": s.LastCycleLatency.String(),
+		"firmamentHealth":  s.FirmamentHealth,
+		"backpressure":     s.Backpressure,
+	}
+}
+
+// StatusPublisher publishes the scheduler status in a well-known ConfigMap,
+// so that cluster tooling can watch the scheduler health declaratively.
+// The ConfigMap is written asynchronously so that a slow API server does
+// not delay the scheduling cycles, and only the latest status is written.
+type StatusPublisher struct {
+	client    kubernetes.Interface
+	namespace string
+	name      string
+	updates   chan Status
+}
+
+// ParseStatusConfigMap splits the namespace/name of the status ConfigMap.
+func ParseStatusConfigMap(configMap string) (string, string, error) {
+	parts := strings.Split(configMap, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("status ConfigMap %q is not in the namespace/name format", configMap)
+	}
+	return parts[0], parts[1], nil
+}
+
+// NewStatusPublisher starts publishing the status in the ConfigMap with the
+// given namespace/name.
+func NewStatusPublisher(kubeConfig, configMap string) (*StatusPublisher, error) {
+	namespace, name, err := ParseStatusConfigMap(configMap)
+	if err != nil {
+		return nil, err
+	}
+	config, err := GetClientConfig(kubeConfig)
+	if err != nil {
+		return nil, err
+	}
+	enableCredentialRotation(config, kubeConfig)
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	p := newStatusPublisher(client, namespace, name)
+	go p.run()
+	return p, nil
+}
+
+func newStatusPublisher(client kubernetes.Interface, namespace, name string) *StatusPublisher {
+	return &StatusPublisher{
+		client:    client,
+		namespace: namespace,
+		name:      name,
+		updates:   make(chan Status, 1),
+	}
+}
+
+// Publish queues the status, replacing the queued status not yet written.
+// It must not be called concurrently.
+func (p *StatusPublisher) Publish(status Status) {
+	select {
+	case <-p.updates:
+	default:
+	}
+	p.updates <- status
+}
+
+func (p *StatusPublisher) run() {
+	for status := range p.updates {
+		if err := p.write(status); err != nil {
+			glog.Errorf("Failed to publish the scheduler status in ConfigMap %s/%s: %v", p.namespace, p.name, err)
+		}
+	}
+}
+
+// write creates or updates the status ConfigMap.
+func (p *StatusPublisher) write(status Status) error {
+	configMaps := p.client.CoreV1().ConfigMaps(p.namespace)
+	configMap, err := configMaps.Get(p.name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		_, err = configMaps.Create(&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: p.name, Namespace: p.namespace},
+			Data:       status.data(),
+		})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	configMap.Data = status.data()
+	_, err = configMaps.Update(configMap)
+	return err
+}
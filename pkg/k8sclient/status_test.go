@@ -0,0 +1,89 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestParseStatusConfigMap(t *testing.T) {
+	var testData = []struct {
+		configMap string
+		namespace string
+		name      string
+		valid     bool
+	}{
+		{configMap: "kube-system/poseidon-status", namespace: "kube-system", name: "poseidon-status", valid: true},
+		{configMap: "poseidon-status"},
+		{configMap: "kube-system/"},
+		{configMap: "a/b/c"},
+	}
+	for _, tc := range testData {
+		namespace, name, err := ParseStatusConfigMap(tc.configMap)
+		if (err == nil) != tc.valid {
+			t.Errorf("ParseStatusConfigMap(%q) error = %v, expected valid %v", tc.configMap, err, tc.valid)
+			continue
+		}
+		if namespace != tc.namespace || name != tc.name {
+			t.Errorf("ParseStatusConfigMap(%q) = %s, %s, expected %s, %s", tc.configMap, namespace, name, tc.namespace, tc.name)
+		}
+	}
+}
+
+func TestStatusPublisherWrite(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	p := newStatusPublisher(client, "kube-system", "poseidon-status")
+	cycle := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	statuses := []Status{
+		{Leader: "poseidon-0", RunID: "run", PendingTasks: 3, LastCycleTime: cycle, LastCycleLatency: time.Second, FirmamentHealth: FirmamentServing},
+		{Leader: "poseidon-0", RunID: "run", DeferredDeltas: 2, LastCycleTime: cycle, LastCycleLatency: time.Second, FirmamentHealth: FirmamentUnavailable},
+	}
+	// The ConfigMap is created, then updated.
+	for _, status := range statuses {
+		if err := p.write(status); err != nil {
+			t.Fatalf("write(%+v) = %v, expected no error", status, err)
+		}
+		configMap, err := client.CoreV1().ConfigMaps("kube-system").Get("poseidon-status", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Failed to get the status ConfigMap: %v", err)
+		}
+		expected := map[string]string{
+			"leader":           "poseidon-0",
+			"runID":            "run",
+			"pendingTasks":     "0",
+			"deferredDeltas":   "0",
+			"lastCycleTime":    "2018-06-01T12:00:00Z",
+			"lastCycleLatency": "1s",
+			"firmamentHealth":  status.FirmamentHealth,
+		}
+		if status.PendingTasks > 0 {
+			expected["pendingTasks"] = "3"
+		}
+		if status.DeferredDeltas > 0 {
+			expected["deferredDeltas"] = "2"
+		}
+		for key, value := range expected {
+			if configMap.Data[key] != value {
+				t.Errorf("Status %s = %q, expected %q", key, configMap.Data[key], value)
+			}
+		}
+	}
+}
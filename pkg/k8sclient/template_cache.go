@@ -0,0 +1,115 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"sync"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+	"github.com/kubernetes-sigs/poseidon/pkg/metrics"
+
+	"k8s.io/api/core/v1"
+)
+
+const (
+	// podTemplateHashLabel is set by the Deployment controller on the pods
+	// of a ReplicaSet to the hash of their template.
+	podTemplateHashLabel = "pod-template-hash"
+	// maxCachedTemplates bounds the number of pod templates cached.
+	maxCachedTemplates = 10000
+)
+
+// templateConstraints are the requests and placement constraints computed
+// from the spec of a pod, shared by the replicas of its template.
+type templateConstraints struct {
+	cpuRequest int64
+	memRequest int64
+	// hostPathVolumes are the hostPath volumes which must exist on the node.
+	hostPathVolumes []string
+	// nodeSelectors are the Firmament label selectors of the node selector.
+	nodeSelectors []*firmament.LabelSelector
+}
+
+// templateCache caches the constraints of the pods by pod template, so that
+// the replicas of large Deployments do not recompute them. The constraints
+// computed from the pod metadata, e.g. labels or annotations, are not
+// cached as they may differ between replicas.
+type templateCache struct {
+	mu        sync.Mutex
+	templates map[string]*templateConstraints
+}
+
+// podTemplates caches the pod constraints by template, nil if disabled.
+var podTemplates *templateCache
+
+func newTemplateCache() *templateCache {
+	return &templateCache{templates: make(map[string]*templateConstraints)}
+}
+
+// templateKey returns the key of the template of a pod with the given owner
+// and labels, empty if the pod has no template hash.
+func templateKey(ownerRef string, labels map[string]string) string {
+	hash, ok := labels[podTemplateHashLabel]
+	if !ok || hash == "" || ownerRef == "" {
+		return ""
+	}
+	// The template hash is only unique per owner.
+	return ownerRef + "/" + hash
+}
+
+// constraints returns the constraints of the pod, computing them if its
+// template is not cached yet.
+func (c *templateCache) constraints(pw *PodWatcher, pod *v1.Pod) *templateConstraints {
+	key := ""
+	if c != nil {
+		key = templateKey(GetOwnerReference(pod), pod.Labels)
+	}
+	if key == "" {
+		return computeTemplateConstraints(pw, pod)
+	}
+	c.mu.Lock()
+	constraints, ok := c.templates[key]
+	c.mu.Unlock()
+	if ok {
+		metrics.TemplateConstraintCache.Inc("hit")
+		return constraints
+	}
+	metrics.TemplateConstraintCache.Inc("miss")
+	constraints = computeTemplateConstraints(pw, pod)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.templates) >= maxCachedTemplates {
+		// Evict an arbitrary template, most likely one of an old revision.
+		for evicted := range c.templates {
+			delete(c.templates, evicted)
+			break
+		}
+	}
+	c.templates[key] = constraints
+	return constraints
+}
+
+func computeTemplateConstraints(pw *PodWatcher, pod *v1.Pod) *templateConstraints {
+	cpuReq, memReq := pw.getCPUMemRequest(pod)
+	nodeSelector := NodeSelectors(pod.Spec.NodeSelector)
+	return &templateConstraints{
+		cpuRequest:      cpuReq,
+		memRequest:      memReq,
+		hostPathVolumes: hostPathVolumes(pod),
+		nodeSelectors:   getFirmamentLabelSelectorFromNodeSelectorMap(nodeSelector, SortNodeSelectorsKey(nodeSelector)),
+	}
+}
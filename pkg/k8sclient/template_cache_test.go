@@ -0,0 +1,81 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestTemplateCache(t *testing.T) {
+	controller := true
+	replica := func(name, hash, cpu string, annotations map[string]string) *v1.Pod {
+		labels := map[string]string{"app": "web"}
+		if hash != "" {
+			labels[podTemplateHashLabel] = hash
+		}
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Namespace:       "default",
+				Labels:          labels,
+				Annotations:     annotations,
+				OwnerReferences: []metav1.OwnerReference{{UID: "replicaset", Controller: &controller}},
+			},
+			Spec: v1.PodSpec{
+				NodeSelector: map[string]string{"disk": "ssd"},
+				Containers: []v1.Container{{
+					Resources: v1.ResourceRequirements{
+						Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse(cpu)},
+					},
+				}},
+			},
+		}
+	}
+	defer func() { podTemplates = nil }()
+	podTemplates = newTemplateCache()
+	pw := &PodWatcher{}
+
+	first := pw.parsePod(replica("web-1", "abc", "1", nil))
+	if first.CPURequest != 1000 || len(first.nodeSelectors) != 1 {
+		t.Fatalf("parsePod() = %+v, expected 1000 millicores and a node selector", first)
+	}
+	// The requests are not recomputed for the replicas of the template,
+	// unlike the constraints of the pod metadata.
+	second := pw.parsePod(replica("web-2", "abc", "2", map[string]string{RequiredHostPathsAnnotation: "/data"}))
+	if second.CPURequest != 1000 {
+		t.Errorf("parsePod() of a replica = %d millicores, expected the cached 1000", second.CPURequest)
+	}
+	if !reflect.DeepEqual(second.HostPaths, []string{"/data"}) {
+		t.Errorf("parsePod() of a replica has host paths %v, expected [/data]", second.HostPaths)
+	}
+	newRevision := pw.parsePod(replica("web-3", "def", "2", nil))
+	if newRevision.CPURequest != 2000 {
+		t.Errorf("parsePod() of a new template = %d millicores, expected 2000", newRevision.CPURequest)
+	}
+	withoutHash := pw.parsePod(replica("web-4", "", "3", nil))
+	if withoutHash.CPURequest != 3000 {
+		t.Errorf("parsePod() of a pod without template hash = %d millicores, expected 3000", withoutHash.CPURequest)
+	}
+	if len(podTemplates.templates) != 2 {
+		t.Errorf("%d templates cached, expected 2", len(podTemplates.templates))
+	}
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"sync"
+	"time"
+)
+
+// activeBorrowerWindow is the time during which a tenant which asked for
+// surplus capacity competes for it. Rate limited pods are retried every
+// deferredSubmissionDelay.
+const activeBorrowerWindow = 2 * deferredSubmissionDelay
+
+// TenantLimit is the rate at which the pods of a tenant are submitted to
+// Firmament, which bounds the rate of their placements.
+type TenantLimit struct {
+	// Rate is the number of pods per second the tenant is guaranteed.
+	Rate float64 `json:"rate"`
+	// Burst is the number of pods submitted at once after an idle period,
+	// Rate and at least 1 by default. A tenant with a zero rate only gets
+	// surplus capacity.
+	Burst float64 `json:"burst,omitempty"`
+	// Weight is the share of the surplus capacity the tenant gets once it
+	// exceeds its rate, 1 by default.
+	Weight float64 `json:"weight,omitempty"`
+}
+
+// TenantLimits are the submission rate limits of the tenants, which are the
+// namespaces. The pods of the tenants exceeding their rate share the
+// surplus rate by weighted fair queuing.
+type TenantLimits struct {
+	Tenants map[string]TenantLimit `json:"tenants,omitempty"`
+	// Default is the limit of the namespaces not in Tenants, which are not
+	// limited if it is nil.
+	Default *TenantLimit `json:"default,omitempty"`
+	// SurplusRate is the number of pods per second submitted on top of the
+	// tenant rates.
+	SurplusRate float64 `json:"surplusRate,omitempty"`
+	// SurplusBurst is the burst of the surplus rate, SurplusRate and at
+	// least 1 by default. There is no surplus if SurplusRate is zero.
+	SurplusBurst float64 `json:"surplusBurst,omitempty"`
+}
+
+// LoadTenantLimits reads a JSON tenant limits file.
+func LoadTenantLimits(path string) (*TenantLimits, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	limits := &TenantLimits{}
+	if err := json.Unmarshal(data, limits); err != nil {
+		return nil, fmt.Errorf("invalid tenant limits %s: %v", path, err)
+	}
+	for tenant, limit := range limits.Tenants {
+		if limit.Rate < 0 || limit.Weight < 0 {
+			return nil, fmt.Errorf("invalid tenant limits %s: negative rate or weight for tenant %s", path, tenant)
+		}
+	}
+	if limits.SurplusRate < 0 {
+		return nil, fmt.Errorf("invalid tenant limits %s: negative surplus rate", path)
+	}
+	return limits, nil
+}
+
+// tokenBucket allows events at a given rate, up to burst at once.
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate, burst float64, now time.Time) *tokenBucket {
+	if burst <= 0 && rate > 0 {
+		burst = math.Max(rate, 1)
+	}
+	return &tokenBucket{rate: rate, burst: burst, tokens: burst, last: now}
+}
+
+// take consumes a token if one is available.
+func (b *tokenBucket) take(now time.Time) bool {
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// tenantState is the rate limiting state of a tenant.
+type tenantState struct {
+	bucket *tokenBucket
+	weight float64
+	// virtualTime is the surplus consumed by the tenant, divided by its
+	// weight.
+	virtualTime float64
+	lastBorrow  time.Time
+}
+
+func (s *tenantState) isBorrowing(now time.Time) bool {
+	return !s.lastBorrow.IsZero() && now.Sub(s.lastBorrow) < activeBorrowerWindow
+}
+
+// tenantLimiter admits the submission of the pods at the rate of their
+// tenant. The surplus is shared by start-time fair queuing: a tenant may
+// borrow a surplus token as long as it did not consume more than one token
+// per weight unit ahead of the other borrowing tenants.
+type tenantLimiter struct {
+	mu      sync.Mutex
+	limits  *TenantLimits
+	tenants map[string]*tenantState
+	surplus *tokenBucket
+	now     func() time.Time
+}
+
+func newTenantLimiter(limits *TenantLimits, now func() time.Time) *tenantLimiter {
+	return &tenantLimiter{
+		limits:  limits,
+		tenants: make(map[string]*tenantState),
+		surplus: newTokenBucket(limits.SurplusRate, limits.SurplusBurst, now()),
+		now:     now,
+	}
+}
+
+// tenantRateLimiter limits the submission rate of the tenants, nil if they
+// are not limited.
+var tenantRateLimiter *tenantLimiter
+
+// admit returns true if a pod of the namespace can be submitted now.
+func (l *tenantLimiter) admit(namespace string) bool {
+	limit, ok := l.limits.Tenants[namespace]
+	if !ok {
+		if l.limits.Default == nil {
+			return true
+		}
+		limit = *l.limits.Default
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	state, ok := l.tenants[namespace]
+	if !ok {
+		weight := limit.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		state = &tenantState{bucket: newTokenBucket(limit.Rate, limit.Burst, now), weight: weight}
+		l.tenants[namespace] = state
+	}
+	if state.bucket.take(now) {
+		return true
+	}
+	minVirtualTime := math.Inf(1)
+	for tenant, other := range l.tenants {
+		if tenant != namespace && other.isBorrowing(now) {
+			minVirtualTime = math.Min(minVirtualTime, other.virtualTime)
+		}
+	}
+	if !state.isBorrowing(now) && !math.IsInf(minVirtualTime, 1) {
+		// The tenant does not get credit for the time it did not borrow.
+		state.virtualTime = math.Max(state.virtualTime, minVirtualTime)
+	}
+	state.lastBorrow = now
+	if state.virtualTime > minVirtualTime+1 {
+		// Leave the surplus to the tenants which consumed less of it.
+		return false
+	}
+	if !l.surplus.take(now) {
+		return false
+	}
+	state.virtualTime += 1 / state.weight
+	return true
+}
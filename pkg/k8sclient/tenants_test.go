@@ -0,0 +1,134 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced clock.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func TestLoadTenantLimits(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tenants")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	var testData = []struct {
+		name  string
+		data  string
+		valid bool
+	}{
+		{
+			name:  "valid",
+			data:  `{"tenants": {"team-a": {"rate": 10, "weight": 2}}, "default": {"rate": 1}, "surplusRate": 50}`,
+			valid: true,
+		},
+		{
+			name: "negative rate",
+			data: `{"tenants": {"team-a": {"rate": -1}}}`,
+		},
+		{
+			name: "negative surplus rate",
+			data: `{"surplusRate": -1}`,
+		},
+		{
+			name: "not JSON",
+			data: `tenants: {}`,
+		},
+	}
+	for _, tc := range testData {
+		path := filepath.Join(dir, "limits.json")
+		if err := ioutil.WriteFile(path, []byte(tc.data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := LoadTenantLimits(path)
+		if (err == nil) != tc.valid {
+			t.Errorf("%s: LoadTenantLimits() = %v, expected valid %v", tc.name, err, tc.valid)
+		}
+	}
+}
+
+func TestTenantLimiterRate(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	limiter := newTenantLimiter(&TenantLimits{
+		Tenants: map[string]TenantLimit{"limited": {Rate: 2}},
+	}, clock.Now)
+	for i := 0; i < 2; i++ {
+		if !limiter.admit("limited") {
+			t.Fatalf("admit() of burst pod %d = false, expected true", i)
+		}
+	}
+	if limiter.admit("limited") {
+		t.Errorf("admit() above the rate = true, expected false")
+	}
+	for i := 0; i < 100; i++ {
+		if !limiter.admit("unlimited") {
+			t.Fatalf("admit() of a namespace without limit = false, expected true")
+		}
+	}
+	clock.now = clock.now.Add(500 * time.Millisecond)
+	if !limiter.admit("limited") {
+		t.Errorf("admit() after a token was refilled = false, expected true")
+	}
+	if limiter.admit("limited") {
+		t.Errorf("admit() above the rate = true, expected false")
+	}
+}
+
+func TestTenantLimiterSurplusSharing(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	limiter := newTenantLimiter(&TenantLimits{
+		Tenants: map[string]TenantLimit{
+			"heavy": {Rate: 0, Weight: 2},
+			"light": {Rate: 0, Weight: 1},
+		},
+		SurplusRate: 10,
+	}, clock.Now)
+	admitted := map[string]int{}
+	order := [][]string{{"heavy", "light"}, {"light", "heavy"}}
+	for step := 0; step < 3000; step++ {
+		clock.now = clock.now.Add(100 * time.Millisecond)
+		// Both tenants have more pods than the surplus allows.
+		for _, tenant := range order[step%2] {
+			for i := 0; i < 3; i++ {
+				if limiter.admit(tenant) {
+					admitted[tenant]++
+				}
+			}
+		}
+	}
+	total := admitted["heavy"] + admitted["light"]
+	if total < 2990 || total > 3020 {
+		t.Errorf("Admitted %d pods in 300s at a surplus rate of 10/s, expected all the surplus to be used", total)
+	}
+	ratio := float64(admitted["heavy"]) / float64(admitted["light"])
+	if ratio < 1.9 || ratio > 2.1 {
+		t.Errorf("Admitted %d heavy and %d light pods, expected the surplus to be shared 2:1", admitted["heavy"], admitted["light"])
+	}
+}
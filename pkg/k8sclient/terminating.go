@@ -0,0 +1,164 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"sync"
+
+	"github.com/golang/glog"
+	"github.com/kubernetes-sigs/poseidon/pkg/features"
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+
+	"k8s.io/api/core/v1"
+)
+
+// nodeTerminating is an internal phase for the nodes about to be removed from
+// the cluster.
+const nodeTerminating NodePhase = "Terminating"
+
+// terminationTaints are the taints announcing that a node is about to be
+// removed, by the cluster autoscaler or the cloud provider.
+var terminationTaints = map[string]bool{
+	"ToBeDeletedByClusterAutoscaler":            true,
+	"node.cloudprovider.kubernetes.io/shutdown": true,
+}
+
+// migrateFromTerminatingNodes enables the migration of the running pods off
+// the terminating nodes.
+var migrateFromTerminatingNodes bool
+
+var (
+	terminationMux sync.Mutex
+	// terminationMigrations are the migration deltas not yet applied.
+	terminationMigrations []*firmament.SchedulingDelta
+	// terminatingResources maps the resource ID of the terminating nodes to
+	// their name, as they are no longer in state.resIDToNode.
+	terminatingResources = make(map[string]string)
+)
+
+// isTerminating returns true if the node is being deleted or is tainted for
+// removal.
+func isTerminating(node *v1.Node) bool {
+	if node.DeletionTimestamp != nil {
+		return true
+	}
+	for _, taint := range node.Spec.Taints {
+		if terminationTaints[taint.Key] {
+			return true
+		}
+	}
+	return false
+}
+
+// isSchedulable returns true if pods can be placed on the node.
+func isSchedulable(node *v1.Node) bool {
+	return !node.Spec.Unschedulable && !isTerminating(node)
+}
+
+// terminate removes the node from Firmament ahead of its deletion, so that
+// the tasks waiting to be bound to it are placed on other nodes, and queues
+// the migration of the running pods scheduled by Poseidon.
+func (nw *NodeWatcher) terminate(hostname string, rtnd *firmament.ResourceTopologyNodeDescriptor) {
+	resID := rtnd.GetResourceDesc().GetUuid()
+	handleError(firmament.NodeRemoved(nw.fc, &firmament.ResourceUID{ResourceUid: resID}))
+	state.nodeMux.Lock()
+	delete(warmingNodes, hostname)
+	state.deleteNode(hostname, resID)
+	state.nodeMux.Unlock()
+	if !migrateFromTerminatingNodes || !features.Enabled(features.Migrations) {
+		return
+	}
+	var migrations []*firmament.SchedulingDelta
+	for _, pod := range podsByNode.podsOnNode(hostname) {
+		if pod.Phase != v1.PodRunning || pod.Deleting || pod.DaemonSet {
+			continue
+		}
+		td, ok := state.TaskOfPod(pod.Identifier)
+		if !ok {
+			// The pod is not scheduled by Poseidon.
+			continue
+		}
+		migrations = append(migrations, &firmament.SchedulingDelta{
+			Type:       firmament.SchedulingDelta_MIGRATE,
+			TaskId:     td.GetUid(),
+			ResourceId: resID,
+		})
+	}
+	glog.Infof("Node %s is terminating, migrating its %d pods", hostname, len(migrations))
+	terminationMux.Lock()
+	terminatingResources[resID] = hostname
+	terminationMigrations = append(terminationMigrations, migrations...)
+	terminationMux.Unlock()
+}
+
+// forgetTerminatingNode drops the state of a terminating node once removed.
+func forgetTerminatingNode(hostname string) {
+	terminationMux.Lock()
+	defer terminationMux.Unlock()
+	for resID, name := range terminatingResources {
+		if name == hostname {
+			delete(terminatingResources, resID)
+		}
+	}
+}
+
+func isDaemonSetPod(pod *v1.Pod) bool {
+	for _, ref := range pod.OwnerReferences {
+		if ref.Kind == "DaemonSet" {
+			return true
+		}
+	}
+	return false
+}
+
+// TerminationMigrations returns and clears the migration deltas of the pods
+// of the scope running on terminating nodes. Those of the other shards are
+// left for their own cycles.
+func TerminationMigrations(scope *ShardScope) []*firmament.SchedulingDelta {
+	terminationMux.Lock()
+	var queued []*firmament.SchedulingDelta
+	var others []*firmament.SchedulingDelta
+	for _, delta := range terminationMigrations {
+		if scope.hasTaskID(delta.GetTaskId()) {
+			queued = append(queued, delta)
+		} else {
+			others = append(others, delta)
+		}
+	}
+	terminationMigrations = others
+	terminationMux.Unlock()
+	if len(queued) == 0 {
+		return nil
+	}
+	var migrations []*firmament.SchedulingDelta
+	for _, delta := range queued {
+		// Skip the pods deleted in the meantime.
+		if _, ok := state.PodOfTask(delta.GetTaskId()); ok {
+			migrations = append(migrations, delta)
+		}
+	}
+	return migrations
+}
+
+// TerminatingNodeName returns the name of the terminating node of the given
+// resource ID, or false if the resource is not a terminating node.
+func TerminatingNodeName(resID string) (string, bool) {
+	terminationMux.Lock()
+	defer terminationMux.Unlock()
+	hostname, ok := terminatingResources[resID]
+	return hostname, ok
+}
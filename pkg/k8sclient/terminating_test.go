@@ -0,0 +1,110 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"testing"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+
+	"github.com/golang/mock/gomock"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestIsTerminating(t *testing.T) {
+	now := metav1.Now()
+	var testData = []struct {
+		node     *v1.Node
+		expected bool
+	}{
+		{node: &v1.Node{}, expected: false},
+		{node: &v1.Node{ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &now}}, expected: true},
+		{
+			node: &v1.Node{Spec: v1.NodeSpec{Taints: []v1.Taint{
+				{Key: "ToBeDeletedByClusterAutoscaler", Effect: v1.TaintEffectNoSchedule},
+			}}},
+			expected: true,
+		},
+		{
+			node: &v1.Node{Spec: v1.NodeSpec{Taints: []v1.Taint{
+				{Key: "dedicated", Effect: v1.TaintEffectNoSchedule},
+			}}},
+			expected: false,
+		},
+	}
+	for i, tc := range testData {
+		if got := isTerminating(tc.node); got != tc.expected {
+			t.Errorf("case %d: isTerminating() = %v, expected %v", i, got, tc.expected)
+		}
+	}
+}
+
+func TestNodeWatcher_terminate(t *testing.T) {
+	defer func(migrate bool) { migrateFromTerminatingNodes = migrate }(migrateFromTerminatingNodes)
+	migrateFromTerminatingNodes = true
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	fc := firmament.NewMockFirmamentSchedulerClient(mockCtrl)
+	fc.EXPECT().NodeRemoved(gomock.Any(), &firmament.ResourceUID{ResourceUid: "res0"}).Return(
+		&firmament.NodeRemovedResponse{Type: firmament.NodeReplyType_NODE_REMOVED_OK}, nil)
+
+	running := func(name string, kind string) *v1.Pod {
+		pod := &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"},
+			Spec:       v1.PodSpec{NodeName: "node0"},
+			Status:     v1.PodStatus{Phase: v1.PodRunning},
+		}
+		if kind != "" {
+			pod.OwnerReferences = []metav1.OwnerReference{{Kind: kind, Name: "owner"}}
+		}
+		return pod
+	}
+	defer func() { podsByNode = newPodIndex() }()
+	for _, pod := range []*v1.Pod{running("pod0", "ReplicaSet"), running("daemon", "DaemonSet"), running("other", "")} {
+		podsByNode.update(pod, 0, 0)
+	}
+	state = &memoryState{}
+	rtnd := &firmament.ResourceTopologyNodeDescriptor{ResourceDesc: &firmament.ResourceDescriptor{Uuid: "res0"}}
+	state.nodeToRTND = map[string]*firmament.ResourceTopologyNodeDescriptor{"node0": rtnd}
+	state.resIDToNode = map[string]string{"res0": "node0"}
+	state.podToTD = map[PodIdentifier]*firmament.TaskDescriptor{
+		{Name: "pod0", Namespace: "ns"}:   {Uid: 1},
+		{Name: "daemon", Namespace: "ns"}: {Uid: 2},
+	}
+	state.taskIDToPod = map[uint64]PodIdentifier{
+		1: {Name: "pod0", Namespace: "ns"},
+		2: {Name: "daemon", Namespace: "ns"},
+	}
+
+	nw := &NodeWatcher{fc: fc}
+	nw.terminate("node0", rtnd)
+	if _, ok := state.resIDToNode["res0"]; ok {
+		t.Error("terminating node still known after terminate()")
+	}
+	migrations := TerminationMigrations(nil)
+	if len(migrations) != 1 || migrations[0].GetTaskId() != 1 || migrations[0].GetType() != firmament.SchedulingDelta_MIGRATE {
+		t.Errorf("TerminationMigrations() = %v, expected the migration of task 1", migrations)
+	}
+	if name, ok := TerminatingNodeName("res0"); !ok || name != "node0" {
+		t.Errorf("TerminatingNodeName(res0) = %s, %v, expected node0", name, ok)
+	}
+	forgetTerminatingNode("node0")
+	if _, ok := TerminatingNodeName("res0"); ok {
+		t.Error("TerminatingNodeName(res0) found after the node removal")
+	}
+}
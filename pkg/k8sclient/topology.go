@@ -0,0 +1,91 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+	"github.com/kubernetes-sigs/poseidon/pkg/runinfo"
+)
+
+// TopologyZoneAnnotation is set on the bound pods to the zone of their node,
+// as a hint for topology aware routing.
+const TopologyZoneAnnotation = "poseidon.k8s.io/topology-zone"
+
+// zoneLabels are the node labels holding the zone of the node, by order of
+// preference.
+var zoneLabels = []string{"topology.kubernetes.io/zone", "failure-domain.beta.kubernetes.io/zone"}
+
+// annotateTopologyZone enables the annotation of the bound pods with the
+// zone of their node.
+var annotateTopologyZone bool
+
+// nodeZone returns the zone of the node, empty if it is unknown.
+func nodeZone(nodeName string) string {
+	state.nodeMux.RLock()
+	defer state.nodeMux.RUnlock()
+	rtnd, ok := state.nodeToRTND[nodeName]
+	if !ok {
+		return ""
+	}
+	return labelsZone(rtnd.GetResourceDesc().GetLabels())
+}
+
+// labelsZone returns the zone of the node with the given labels, empty if
+// it has none.
+func labelsZone(nodeLabels []*firmament.Label) string {
+	labels := make(map[string]string)
+	for _, label := range nodeLabels {
+		labels[label.GetKey()] = label.GetValue()
+	}
+	for _, key := range zoneLabels {
+		if zone := labels[key]; zone != "" {
+			return zone
+		}
+	}
+	return ""
+}
+
+// bindingAnnotations returns the annotations set on a pod when it is bound
+// to the node, so that they are set atomically with its placement.
+func bindingAnnotations(nodeName string) map[string]string {
+	annotations := map[string]string{runinfo.Annotation: runinfo.ID}
+	if annotateTopologyZone {
+		if zone := nodeZone(nodeName); zone != "" {
+			annotations[TopologyZoneAnnotation] = zone
+		}
+	}
+	return annotations
+}
+
+// sameAnnotations returns whether the pod annotations are equal, but for
+// those set when the pod is bound, which change nothing to its task.
+func sameAnnotations(a, b map[string]string) bool {
+	isBinding := func(key string) bool {
+		return key == runinfo.Annotation || key == TopologyZoneAnnotation
+	}
+	for key, value := range a {
+		if other, ok := b[key]; !isBinding(key) && (!ok || other != value) {
+			return false
+		}
+	}
+	for key := range b {
+		if _, ok := a[key]; !isBinding(key) && !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"testing"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+	"github.com/kubernetes-sigs/poseidon/pkg/runinfo"
+)
+
+func TestBindingAnnotations(t *testing.T) {
+	state = &memoryState{}
+	state.nodeToRTND = map[string]*firmament.ResourceTopologyNodeDescriptor{
+		"zoned": {ResourceDesc: &firmament.ResourceDescriptor{Labels: []*firmament.Label{
+			{Key: "failure-domain.beta.kubernetes.io/zone", Value: "us-east-1a"},
+			{Key: "topology.kubernetes.io/zone", Value: "us-east-1b"},
+		}}},
+		"unzoned": {ResourceDesc: &firmament.ResourceDescriptor{}},
+	}
+	defer func() { annotateTopologyZone = false }()
+	var testData = []struct {
+		node     string
+		annotate bool
+		expected string
+	}{
+		{node: "zoned", annotate: true, expected: "us-east-1b"},
+		{node: "zoned"},
+		{node: "unzoned", annotate: true},
+		{node: "removed", annotate: true},
+	}
+	for _, tc := range testData {
+		annotateTopologyZone = tc.annotate
+		annotations := bindingAnnotations(tc.node)
+		if annotations[runinfo.Annotation] != runinfo.ID {
+			t.Errorf("bindingAnnotations(%s) = %v, expected the run ID", tc.node, annotations)
+		}
+		if zone, ok := annotations[TopologyZoneAnnotation]; zone != tc.expected || ok != (tc.expected != "") {
+			t.Errorf("bindingAnnotations(%s) with annotation %v = %v, expected zone %q", tc.node, tc.annotate, annotations, tc.expected)
+		}
+	}
+}
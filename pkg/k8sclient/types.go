@@ -27,25 +27,11 @@ import (
 
 const bytesToKb = 1024
 
-// PodMux is used to guard access to the pod, task and job related maps.
-var PodMux *sync.RWMutex
+// deferredMux guards deferredPods and pausedPods.
+var deferredMux sync.Mutex
 
-// PodToTD maps Kubernetes pod identifier(namespace + name) to firmament task descriptor.
-var PodToTD map[PodIdentifier]*firmament.TaskDescriptor
-
-// TaskIDToPod maps firmament task ID to Kubernetes pod identifier(namespace + name).
-var TaskIDToPod map[uint64]PodIdentifier
-var jobIDToJD map[string]*firmament.JobDescriptor
-var jobNumTasksToRemove map[string]int
-
-// NodeMux is used to guard access to the node and resource related maps.
-var NodeMux *sync.RWMutex
-
-// NodeToRTND maps node name to firmament resource topology node descriptor.
-var NodeToRTND map[string]*firmament.ResourceTopologyNodeDescriptor
-
-// ResIDToNode maps resource ID to node name.
-var ResIDToNode map[string]string
+// deferredPods holds the pending pods whose submission to Firmament is deferred because of the backlog.
+var deferredPods = make(map[PodIdentifier]*Pod)
 
 // NodePhase represents a node phase.
 type NodePhase string
@@ -73,6 +59,8 @@ type Node struct {
 	MemAllocatableKb int64
 	Labels           map[string]string
 	Annotations      map[string]string
+	// Devices are the devices of the node by resource.
+	Devices map[string]DeviceCount
 }
 
 // PodPhase represents a pod phase.
@@ -116,6 +104,24 @@ type Pod struct {
 	Annotations  map[string]string
 	NodeSelector map[string]string
 	OwnerRef     string
+	// Priority is the pod priority value, nil if the pod has none.
+	Priority          *int32
+	PriorityClassName string
+	// HostPaths are the host paths which must exist on the node of the pod.
+	HostPaths []string
+	// DeviceRequests are the device resources requested by the pod.
+	DeviceRequests []string
+	// ReplicaSet is the UID of the ReplicaSet controlling the pod, empty if
+	// it has none.
+	ReplicaSet string
+	// NodeName is the node the pod is bound to, empty if it is not bound.
+	NodeName string
+	// Owner is the owner the churn of the pod is accounted to, e.g.
+	// Deployment/web, empty if the pod has no controller.
+	Owner string
+	// nodeSelectors are the Firmament label selectors of NodeSelector, nil
+	// if they are not computed yet.
+	nodeSelectors []*firmament.LabelSelector
 }
 
 // NodeWatcher is a Kubernetes node watcher.
@@ -124,7 +130,13 @@ type NodeWatcher struct {
 	clientset     kubernetes.Interface
 	nodeWorkQueue Queue
 	controller    cache.Controller
+	nodeStore     cache.Store
 	fc            firmament.FirmamentSchedulerClient
+	// daemonSetController tracks the DaemonSets if their overhead is
+	// discounted from the node capacity.
+	daemonSetController cache.Controller
+	// startup tracks the synchronization of the nodes listed at startup.
+	startup *startupSync
 }
 
 // PodWatcher is a Kubernetes pod watcher.
@@ -134,4 +146,11 @@ type PodWatcher struct {
 	podWorkQueue Queue
 	controller   cache.Controller
 	fc           firmament.FirmamentSchedulerClient
+	// schedulerName is the name of the scheduler the pods are claimed for.
+	schedulerName string
+	// namespaceController tracks the namespaces if their node selectors
+	// are honored.
+	namespaceController cache.Controller
+	// startup tracks the synchronization of the pods listed at startup.
+	startup *startupSync
 }
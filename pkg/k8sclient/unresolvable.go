@@ -0,0 +1,143 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/golang/glog"
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+	"github.com/kubernetes-sigs/poseidon/pkg/metrics"
+	"k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// rejectUnresolvableConstraints enables marking the pending pods whose
+// constraints no node satisfies unschedulable instead of submitting them to
+// Firmament, which would fail to place them every cycle.
+var rejectUnresolvableConstraints bool
+
+var (
+	unschedulableMux sync.Mutex
+	// unschedulablePods maps the pending pods reported unschedulable by
+	// Poseidon itself to the message last reported on them.
+	unschedulablePods = make(map[PodIdentifier]string)
+)
+
+// unresolvableConstraints returns why no node satisfies the constraints of
+// the pod, empty if a node does. The constraints are only resolved once the
+// nodes listed at startup are known.
+func unresolvableConstraints(pod *Pod) string {
+	if !watcherSynchronized("node") {
+		return ""
+	}
+	td := &firmament.TaskDescriptor{ResourceRequest: &firmament.ResourceVector{}}
+	compileConstraints(pod, td)
+	selectors := td.GetLabelSelectors()
+	if len(selectors) == 0 {
+		return ""
+	}
+	state.nodeMux.RLock()
+	for _, rtnd := range state.nodeToRTND {
+		if matchesSelectors(rtnd.GetResourceDesc().GetLabels(), selectors) {
+			state.nodeMux.RUnlock()
+			return ""
+		}
+	}
+	state.nodeMux.RUnlock()
+	constraints := make([]string, 0, len(selectors))
+	for _, selector := range selectors {
+		constraints = append(constraints, describeSelector(selector))
+	}
+	return fmt.Sprintf("no node matches the constraints %s", strings.Join(constraints, ", "))
+}
+
+// reportUnresolvable marks a pod whose constraints no node satisfies
+// unschedulable, unless it was already for the same reason.
+func (pw *PodWatcher) reportUnresolvable(pod *Pod, reason string) {
+	if pw.reportUnschedulable(pod, "FailedScheduling", reason, unschedulableCondition(reason)) {
+		metrics.UnresolvablePods.Inc()
+	}
+}
+
+// unschedulableCondition returns the PodScheduled condition set to false
+// with the Unschedulable reason, as the default scheduler does, on which
+// the cluster autoscaler acts.
+func unschedulableCondition(message string) v1.PodCondition {
+	return v1.PodCondition{
+		Type:               v1.PodScheduled,
+		Status:             v1.ConditionFalse,
+		Reason:             v1.PodReasonUnschedulable,
+		Message:            message,
+		LastTransitionTime: meta_v1.Now(),
+	}
+}
+
+// reportUnschedulable reports why Poseidon can not place a pending pod with
+// an event of the given reason and sets the conditions on the pod, unless
+// it already reported the same message. It returns whether it reported it.
+func (pw *PodWatcher) reportUnschedulable(pod *Pod, eventReason, message string, conditions ...v1.PodCondition) bool {
+	unschedulableMux.Lock()
+	previous, reported := unschedulablePods[pod.Identifier]
+	unschedulablePods[pod.Identifier] = message
+	unschedulableMux.Unlock()
+	if reported && previous == message {
+		return false
+	}
+	glog.Warningf("Pod %v can not be placed: %s", pod.Identifier, message)
+	pw.recordPodEvent(pod.Identifier, v1.EventTypeWarning, eventReason, message)
+	if len(conditions) == 0 {
+		return true
+	}
+	if err := setPodConditions(pw.clientset, pod.Identifier, conditions); err != nil {
+		glog.Errorf("Failed to set the conditions of pod %v: %v", pod.Identifier, err)
+	}
+	return true
+}
+
+// setPodConditions sets the conditions on the pod, replacing the ones of
+// the same types.
+func setPodConditions(client kubernetes.Interface, podIdentifier PodIdentifier, conditions []v1.PodCondition) error {
+	pod, err := client.CoreV1().Pods(podIdentifier.Namespace).Get(podIdentifier.Name, meta_v1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	for _, condition := range conditions {
+		updated := false
+		for i := range pod.Status.Conditions {
+			if pod.Status.Conditions[i].Type == condition.Type {
+				pod.Status.Conditions[i] = condition
+				updated = true
+			}
+		}
+		if !updated {
+			pod.Status.Conditions = append(pod.Status.Conditions, condition)
+		}
+	}
+	_, err = client.CoreV1().Pods(podIdentifier.Namespace).UpdateStatus(pod)
+	return err
+}
+
+// forgetUnschedulable drops a pod once it is submitted or deleted.
+func forgetUnschedulable(podIdentifier PodIdentifier) {
+	unschedulableMux.Lock()
+	defer unschedulableMux.Unlock()
+	delete(unschedulablePods, podIdentifier)
+}
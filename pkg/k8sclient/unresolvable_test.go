@@ -0,0 +1,75 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestUnresolvableConstraints(t *testing.T) {
+	state = &memoryState{}
+	state.nodeToRTND = map[string]*firmament.ResourceTopologyNodeDescriptor{
+		"node0": {ResourceDesc: &firmament.ResourceDescriptor{Labels: []*firmament.Label{{Key: "disk", Value: "hdd"}}}},
+	}
+	podIdentifier := PodIdentifier{Name: "pod0", Namespace: "ns"}
+	pod := &Pod{Identifier: podIdentifier, NodeSelector: NodeSelectors{"disk": "ssd"}}
+	defer func() {
+		synchronizedMux.Lock()
+		delete(synchronizedWatchers, "node")
+		synchronizedMux.Unlock()
+	}()
+	if reason := unresolvableConstraints(pod); reason != "" {
+		t.Errorf("unresolvableConstraints() = %q before the nodes are synchronized, expected none", reason)
+	}
+	newStartupSync("node", nil, time.Now())
+	reason := unresolvableConstraints(pod)
+	if expected := "no node matches the constraints disk in (ssd)"; reason != expected {
+		t.Errorf("unresolvableConstraints() = %q, expected %q", reason, expected)
+	}
+	if reason := unresolvableConstraints(&Pod{Identifier: podIdentifier, NodeSelector: NodeSelectors{"disk": "hdd"}}); reason != "" {
+		t.Errorf("unresolvableConstraints() = %q for a matching node, expected none", reason)
+	}
+
+	// The pod is marked unschedulable once per reason.
+	client := fake.NewSimpleClientset(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod0", Namespace: "ns"}})
+	pw := &PodWatcher{clientset: client, schedulerName: "poseidon"}
+	defer forgetUnschedulable(podIdentifier)
+	pw.reportUnresolvable(pod, reason)
+	pw.reportUnresolvable(pod, reason)
+	events, err := client.CoreV1().Events("ns").List(metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events.Items) != 1 {
+		t.Errorf("reportUnresolvable() created %d events, expected 1", len(events.Items))
+	}
+	updated, err := client.CoreV1().Pods("ns").Get("pod0", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conditions := updated.Status.Conditions
+	if len(conditions) != 1 || conditions[0].Type != v1.PodScheduled || conditions[0].Status != v1.ConditionFalse ||
+		conditions[0].Reason != v1.PodReasonUnschedulable || conditions[0].Message != reason {
+		t.Errorf("reportUnresolvable() set the conditions %v, expected PodScheduled false as unschedulable", conditions)
+	}
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/kubernetes-sigs/poseidon/pkg/metrics"
+	"k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// UnsupportedPodFeatures returns why Poseidon cannot schedule the pod as
+// specified: the required scheduling constraints of the pod spec it does
+// not honor and the invalid Poseidon annotations, which would otherwise
+// leave the pod pending. The preferences it does not honor are allowed.
+func UnsupportedPodFeatures(pod *v1.Pod) []string {
+	var reasons []string
+	if affinity := pod.Spec.Affinity; affinity != nil {
+		if affinity.NodeAffinity != nil && affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution != nil {
+			reasons = append(reasons, fmt.Sprintf("spec.affinity.nodeAffinity.requiredDuringSchedulingIgnoredDuringExecution is not supported, use spec.nodeSelector or the %s annotation",
+				LabelSelectorsAnnotation))
+		}
+		if affinity.PodAffinity != nil && len(affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution) > 0 {
+			reasons = append(reasons, "spec.affinity.podAffinity.requiredDuringSchedulingIgnoredDuringExecution is not supported")
+		}
+		if affinity.PodAntiAffinity != nil && len(affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution) > 0 {
+			reasons = append(reasons, "spec.affinity.podAntiAffinity.requiredDuringSchedulingIgnoredDuringExecution is not supported")
+		}
+	}
+	annotated := &Pod{
+		Identifier:  PodIdentifier{Namespace: pod.Namespace, Name: pod.Name},
+		Annotations: pod.Annotations,
+	}
+	for _, check := range []func(*Pod) error{checkLabelSelectorsAnnotation, checkPodGroupAnnotations, checkNodePreferencesAnnotation} {
+		if err := check(annotated); err != nil {
+			reasons = append(reasons, err.Error())
+		}
+	}
+	return reasons
+}
+
+// admissionReview is the subset of the AdmissionReview of the
+// admission.k8s.io API, v1 or v1beta1, read and written by the validating
+// webhook.
+type admissionReview struct {
+	APIVersion string             `json:"apiVersion,omitempty"`
+	Kind       string             `json:"kind,omitempty"`
+	Request    *admissionRequest  `json:"request,omitempty"`
+	Response   *admissionResponse `json:"response,omitempty"`
+}
+
+type admissionRequest struct {
+	UID    types.UID `json:"uid"`
+	DryRun *bool     `json:"dryRun,omitempty"`
+	Object v1.Pod    `json:"object"`
+}
+
+type admissionResponse struct {
+	UID     types.UID       `json:"uid"`
+	Allowed bool            `json:"allowed"`
+	Result  *meta_v1.Status `json:"status,omitempty"`
+}
+
+// ValidatingWebhook is the handler of a validating admission webhook
+// rejecting the pods of the scheduler whose spec Poseidon cannot schedule,
+// with the reasons as message, so that they are not left pending. The
+// webhook must only be registered for the creation of pods.
+type ValidatingWebhook struct {
+	SchedulerName string
+}
+
+func (h *ValidatingWebhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	review := &admissionReview{}
+	if err := json.NewDecoder(r.Body).Decode(review); err != nil || review.Request == nil {
+		http.Error(w, fmt.Sprintf("invalid admission review: %v", err), http.StatusBadRequest)
+		return
+	}
+	pod := &review.Request.Object
+	response := &admissionResponse{UID: review.Request.UID, Allowed: true}
+	if pod.Spec.SchedulerName == h.SchedulerName {
+		if reasons := UnsupportedPodFeatures(pod); len(reasons) > 0 {
+			dryRun := review.Request.DryRun != nil && *review.Request.DryRun
+			glog.V(2).Infof("Rejecting pod %s/%s (dry run: %v): %s", pod.Namespace, pod.GenerateName+pod.Name, dryRun,
+				strings.Join(reasons, "; "))
+			metrics.WebhookRejectedPods.Inc(pod.Namespace)
+			response.Allowed = false
+			response.Result = &meta_v1.Status{
+				Status:  meta_v1.StatusFailure,
+				Code:    http.StatusForbidden,
+				Reason:  meta_v1.StatusReasonForbidden,
+				Message: fmt.Sprintf("pod cannot be scheduled by %s: %s", h.SchedulerName, strings.Join(reasons, "; ")),
+			}
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&admissionReview{APIVersion: review.APIVersion, Kind: review.Kind, Response: response})
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"k8s.io/api/core/v1"
+)
+
+func TestUnsupportedPodFeatures(t *testing.T) {
+	var testData = []struct {
+		name    string
+		spec    v1.PodSpec
+		annots  map[string]string
+		reasons int
+	}{
+		{name: "supported", spec: v1.PodSpec{NodeSelector: map[string]string{"zone": "a"}}},
+		{name: "preferred affinity", spec: v1.PodSpec{Affinity: &v1.Affinity{NodeAffinity: &v1.NodeAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: []v1.PreferredSchedulingTerm{{Weight: 1}},
+		}}}},
+		{name: "required affinities", spec: v1.PodSpec{Affinity: &v1.Affinity{
+			NodeAffinity:    &v1.NodeAffinity{RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{}},
+			PodAntiAffinity: &v1.PodAntiAffinity{RequiredDuringSchedulingIgnoredDuringExecution: []v1.PodAffinityTerm{{TopologyKey: "zone"}}},
+		}}, reasons: 2},
+		{name: "invalid annotations", annots: map[string]string{
+			LabelSelectorsAnnotation:  "zone>a",
+			NodePreferencesAnnotation: "high:zone=a",
+		}, reasons: 2},
+	}
+	for _, tc := range testData {
+		pod := &v1.Pod{Spec: tc.spec}
+		pod.Annotations = tc.annots
+		if reasons := UnsupportedPodFeatures(pod); len(reasons) != tc.reasons {
+			t.Errorf("%s: UnsupportedPodFeatures() = %v, expected %d reasons", tc.name, reasons, tc.reasons)
+		}
+	}
+}
+
+func TestValidatingWebhook(t *testing.T) {
+	webhook := &ValidatingWebhook{SchedulerName: "poseidon"}
+	unsupported := v1.PodSpec{Affinity: &v1.Affinity{PodAffinity: &v1.PodAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: []v1.PodAffinityTerm{{TopologyKey: "zone"}},
+	}}}
+	var testData = []struct {
+		schedulerName string
+		spec          v1.PodSpec
+		allowed       bool
+	}{
+		{schedulerName: "poseidon", spec: unsupported, allowed: false},
+		{schedulerName: "poseidon", allowed: true},
+		// The pods of the other schedulers are not validated.
+		{schedulerName: "default-scheduler", spec: unsupported, allowed: true},
+	}
+	for _, tc := range testData {
+		review := &admissionReview{APIVersion: "admission.k8s.io/v1", Kind: "AdmissionReview", Request: &admissionRequest{UID: "uid"}}
+		review.Request.Object.Spec = tc.spec
+		review.Request.Object.Spec.SchedulerName = tc.schedulerName
+		body, err := json.Marshal(review)
+		if err != nil {
+			t.Fatal(err)
+		}
+		recorder := httptest.NewRecorder()
+		webhook.ServeHTTP(recorder, httptest.NewRequest("POST", "/validate", bytes.NewReader(body)))
+		response := &admissionReview{}
+		if err := json.NewDecoder(recorder.Body).Decode(response); err != nil {
+			t.Fatal(err)
+		}
+		if response.APIVersion != review.APIVersion || response.Response == nil || response.Response.UID != "uid" {
+			t.Fatalf("ServeHTTP() = %+v, expected the response to the review", response)
+		}
+		if response.Response.Allowed != tc.allowed {
+			t.Errorf("Pod of %s allowed = %v, expected %v", tc.schedulerName, response.Response.Allowed, tc.allowed)
+		}
+		if !tc.allowed && (response.Response.Result == nil || !strings.Contains(response.Response.Result.Message, "podAffinity")) {
+			t.Errorf("Rejection %+v does not tell the unsupported feature", response.Response.Result)
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	webhook.ServeHTTP(recorder, httptest.NewRequest("POST", "/validate", strings.NewReader("{}")))
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("ServeHTTP() = %d without a request, expected %d", recorder.Code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+)
+
+// nodeWarmUpSteps is the number of capacity increases over the warm-up period.
+const nodeWarmUpSteps = 10
+
+// nodeWarmingUp is an internal phase used to increase the capacity of a
+// warming up node.
+const nodeWarmingUp NodePhase = "WarmingUp"
+
+// nodeWarmUpPeriod is the time over which the capacity advertised to
+// Firmament for a newly added node ramps up to its full capacity. 0 disables
+// the ramp.
+var nodeWarmUpPeriod time.Duration
+
+// nodeWarmUpCompleteLabel is the node label which, once set to "true", ends
+// the warm-up of the node, e.g. when its CNI and image cache are ready.
+var nodeWarmUpCompleteLabel string
+
+// warmingNode holds the full capacity of a warming up node.
+type warmingNode struct {
+	added       time.Time
+	cpuCapacity float32
+	ramCapacity uint64
+}
+
+// warmingNodes maps the names of the warming up nodes to their state.
+// Guarded by state.nodeMux.
+var warmingNodes = make(map[string]*warmingNode)
+
+// isWarmUpComplete returns true if the node signals it is ready for full load.
+func isWarmUpComplete(node *Node) bool {
+	return nodeWarmUpCompleteLabel != "" && node.Labels[nodeWarmUpCompleteLabel] == "true"
+}
+
+// warmUpFraction returns the fraction of the capacity advertised for a node
+// added at the given time.
+func warmUpFraction(added, now time.Time) float64 {
+	fraction := float64(now.Sub(added)) / float64(nodeWarmUpPeriod)
+	if fraction < 1.0/nodeWarmUpSteps {
+		return 1.0 / nodeWarmUpSteps
+	}
+	if fraction > 1 {
+		return 1
+	}
+	return fraction
+}
+
+// setCapacity sets the capacity of the node and its PUs.
+func setCapacity(rtnd *firmament.ResourceTopologyNodeDescriptor, cpuCapacity float32, ramCapacity uint64) {
+	rtnd.ResourceDesc.ResourceCapacity.CpuCores = cpuCapacity
+	rtnd.ResourceDesc.ResourceCapacity.RamCap = ramCapacity
+	for _, childRTND := range rtnd.GetChildren() {
+		setCapacity(childRTND, cpuCapacity, ramCapacity)
+	}
+}
+
+// startWarmUp reduces the capacity of a newly added node and schedules its
+// ramp up. The caller must hold state.nodeMux.
+func (nw *NodeWatcher) startWarmUp(key interface{}, node *Node, rtnd *firmament.ResourceTopologyNodeDescriptor) {
+	if nodeWarmUpPeriod <= 0 || isWarmUpComplete(node) {
+		return
+	}
+	capacity := rtnd.GetResourceDesc().GetResourceCapacity()
+	warming := &warmingNode{
+		added:       time.Now(),
+		cpuCapacity: capacity.GetCpuCores(),
+		ramCapacity: capacity.GetRamCap(),
+	}
+	warmingNodes[node.Hostname] = warming
+	nw.rampUp(key, node.Hostname, warming)
+}
+
+// rampUp advertises the current share of the full capacity of a warming up
+// node and schedules the next increase. The caller must hold state.nodeMux.
+func (nw *NodeWatcher) rampUp(key interface{}, hostname string, warming *warmingNode) {
+	rtnd := state.nodeToRTND[hostname]
+	fraction := warmUpFraction(warming.added, time.Now())
+	if fraction >= 1 {
+		nw.stopWarmUp(hostname)
+		return
+	}
+	setCapacity(rtnd, float32(fraction*float64(warming.cpuCapacity)), uint64(fraction*float64(warming.ramCapacity)))
+	glog.V(2).Infof("Node %s warming up at %.0f%% of its capacity", hostname, fraction*100)
+	time.AfterFunc(nodeWarmUpPeriod/nodeWarmUpSteps, func() {
+		state.nodeMux.RLock()
+		current := warmingNodes[hostname]
+		state.nodeMux.RUnlock()
+		if current != warming {
+			// The node was removed or added again in the meantime.
+			return
+		}
+		nw.nodeWorkQueue.Add(key, &Node{Hostname: hostname, Phase: nodeWarmingUp})
+	})
+}
+
+// stopWarmUp restores the full capacity of a warming up node. The caller
+// must hold state.nodeMux.
+func (nw *NodeWatcher) stopWarmUp(hostname string) {
+	warming, ok := warmingNodes[hostname]
+	if !ok {
+		return
+	}
+	delete(warmingNodes, hostname)
+	setCapacity(state.nodeToRTND[hostname], warming.cpuCapacity, warming.ramCapacity)
+	glog.Infof("Node %s warmed up", hostname)
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+
+	"github.com/golang/mock/gomock"
+	"golang.org/x/net/context"
+	"k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestWarmUpFraction(t *testing.T) {
+	defer func(period time.Duration) { nodeWarmUpPeriod = period }(nodeWarmUpPeriod)
+	nodeWarmUpPeriod = 100 * time.Second
+	added := time.Now()
+	var testData = []struct {
+		elapsed  time.Duration
+		expected float64
+	}{
+		{elapsed: 0, expected: 0.1},
+		{elapsed: 50 * time.Second, expected: 0.5},
+		{elapsed: 200 * time.Second, expected: 1},
+	}
+	for _, tc := range testData {
+		if got := warmUpFraction(added, added.Add(tc.elapsed)); got != tc.expected {
+			t.Errorf("warmUpFraction(%v) = %v, expected %v", tc.elapsed, got, tc.expected)
+		}
+	}
+}
+
+func TestIsWarmUpComplete(t *testing.T) {
+	defer func(label string) { nodeWarmUpCompleteLabel = label }(nodeWarmUpCompleteLabel)
+	var testData = []struct {
+		label    string
+		labels   map[string]string
+		expected bool
+	}{
+		{label: "", labels: map[string]string{"warm": "true"}, expected: false},
+		{label: "warm", labels: nil, expected: false},
+		{label: "warm", labels: map[string]string{"warm": "false"}, expected: false},
+		{label: "warm", labels: map[string]string{"warm": "true"}, expected: true},
+	}
+	for _, tc := range testData {
+		nodeWarmUpCompleteLabel = tc.label
+		if got := isWarmUpComplete(&Node{Labels: tc.labels}); got != tc.expected {
+			t.Errorf("isWarmUpComplete(%v) with label %q = %v, expected %v", tc.labels, tc.label, got, tc.expected)
+		}
+	}
+}
+
+func TestNodeWatcher_warmUp(t *testing.T) {
+	defer func(period time.Duration) { nodeWarmUpPeriod = period }(nodeWarmUpPeriod)
+	nodeWarmUpPeriod = 200 * time.Millisecond
+	testObj := initializeNodeObj(t)
+	defer testObj.mockCtrl.Finish()
+
+	testObj.firmamentClient.EXPECT().NodeAdded(gomock.Any(), gomock.Any()).Do(
+		func(ctx context.Context, rtnd *firmament.ResourceTopologyNodeDescriptor) {
+			if cpu := rtnd.GetResourceDesc().GetResourceCapacity().GetCpuCores(); cpu != 100 {
+				t.Errorf("NodeAdded() with %v cpu, expected 100 during warm-up", cpu)
+			}
+		}).Return(&firmament.NodeAddedResponse{Type: firmament.NodeReplyType_NODE_ADDED_OK}, nil)
+	testObj.firmamentClient.EXPECT().NodeUpdated(gomock.Any(), gomock.Any()).Return(
+		&firmament.NodeUpdatedResponse{Type: firmament.NodeReplyType_NODE_UPDATED_OK}, nil).MinTimes(1)
+
+	nodeWatch := NewNodeWatcher(testObj.kubeClient, testObj.firmamentClient)
+	node := BuildNode("node0", "1", "10000000000", nil, []v1.NodeCondition{
+		{
+			Type:   v1.NodeReady,
+			Status: v1.ConditionTrue,
+		},
+	}, false)
+	key, err := cache.MetaNamespaceKeyFunc(node)
+	if err != nil {
+		t.Fatal("error getting key ", err)
+	}
+	nodeWatch.enqueueNodeAddition(key, node)
+	go nodeWatch.nodeWorker()
+	time.Sleep(time.Second)
+	nodeWatch.nodeWorkQueue.ShutDown()
+
+	state.nodeMux.RLock()
+	defer state.nodeMux.RUnlock()
+	if _, ok := warmingNodes["node0"]; ok {
+		t.Error("node0 still warming up after the warm-up period")
+	}
+	if cpu := state.nodeToRTND["node0"].GetResourceDesc().GetResourceCapacity().GetCpuCores(); cpu != 1000 {
+		t.Errorf("node0 has %v cpu after the warm-up, expected 1000", cpu)
+	}
+}
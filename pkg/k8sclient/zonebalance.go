@@ -0,0 +1,296 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/golang/glog"
+	"github.com/kubernetes-sigs/poseidon/pkg/features"
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ZoneBalancePolicy keeps the replicas of each ReplicaSet, i.e. of each
+// Deployment revision, balanced across the zones of the nodes.
+type ZoneBalancePolicy struct {
+	// MaxSkew is the largest difference between the number of replicas of
+	// two zones, at least 1.
+	MaxSkew int
+	// Migrate enables the deletion of a replica of the most loaded zone of
+	// the ReplicaSets exceeding the skew, so that their controller replaces
+	// it in another zone.
+	Migrate bool
+}
+
+// zoneBalancePolicy is the zone balancing policy, nil if the replicas are
+// placed regardless of their zone.
+var zoneBalancePolicy *ZoneBalancePolicy
+
+// ZoneMigration is a replica to delete to balance its ReplicaSet.
+type ZoneMigration struct {
+	Pod    PodIdentifier
+	TaskID uint64
+	Node   string
+	Zone   string
+}
+
+var (
+	zoneBalanceMux sync.Mutex
+	// balancedTasks maps the tasks of the balanced ReplicaSets to their
+	// ReplicaSet.
+	balancedTasks = make(map[uint64]string)
+	// zoneSelectors are the label selectors excluding zones last set on the
+	// balanced tasks.
+	zoneSelectors = make(map[uint64][]*firmament.LabelSelector)
+)
+
+// replicaSetOf returns the UID of the ReplicaSet controlling the pod, empty
+// if it has none.
+func replicaSetOf(pod *v1.Pod) string {
+	if ref := metav1.GetControllerOf(pod); ref != nil && ref.Kind == "ReplicaSet" {
+		return string(ref.UID)
+	}
+	return ""
+}
+
+// registerZoneBalancedTask records the task of a replica of a ReplicaSet.
+func registerZoneBalancedTask(pod *Pod, taskID uint64) {
+	if zoneBalancePolicy == nil || pod.ReplicaSet == "" {
+		return
+	}
+	zoneBalanceMux.Lock()
+	defer zoneBalanceMux.Unlock()
+	balancedTasks[taskID] = pod.ReplicaSet
+}
+
+// forgetZoneBalancedTask drops a removed task.
+func forgetZoneBalancedTask(taskID uint64) {
+	zoneBalanceMux.Lock()
+	defer zoneBalanceMux.Unlock()
+	delete(balancedTasks, taskID)
+	delete(zoneSelectors, taskID)
+}
+
+// replicasByZone returns the number of replicas of the ReplicaSets bound to
+// each zone, including the zones without replica, and the sorted zones.
+func replicasByZone() (map[string]map[string]int, []string) {
+	state.nodeMux.RLock()
+	nodeZones := make(map[string]string, len(state.nodeToRTND))
+	for nodeName := range state.nodeToRTND {
+		nodeZones[nodeName] = ""
+	}
+	state.nodeMux.RUnlock()
+	zoneSet := make(map[string]bool)
+	for nodeName := range nodeZones {
+		if zone := nodeZone(nodeName); zone != "" {
+			nodeZones[nodeName] = zone
+			zoneSet[zone] = true
+		}
+	}
+	replicas := make(map[string]map[string]int)
+	for nodeName, zone := range nodeZones {
+		if zone == "" {
+			continue
+		}
+		for _, pod := range podsByNode.podsOnNode(nodeName) {
+			if pod.ReplicaSet == "" || pod.Deleting {
+				continue
+			}
+			if replicas[pod.ReplicaSet] == nil {
+				replicas[pod.ReplicaSet] = make(map[string]int)
+			}
+			replicas[pod.ReplicaSet][zone]++
+		}
+	}
+	zones := make([]string, 0, len(zoneSet))
+	for zone := range zoneSet {
+		zones = append(zones, zone)
+	}
+	sort.Strings(zones)
+	return replicas, zones
+}
+
+// skewedZones returns the zones holding at least MaxSkew replicas more than
+// the least loaded zone, in which a new replica would exceed the skew.
+func (p *ZoneBalancePolicy) skewedZones(replicas map[string]int, zones []string) []string {
+	if len(zones) == 0 {
+		return nil
+	}
+	least := replicas[zones[0]]
+	for _, zone := range zones {
+		if replicas[zone] < least {
+			least = replicas[zone]
+		}
+	}
+	var skewed []string
+	for _, zone := range zones {
+		if replicas[zone]-least >= p.MaxSkew {
+			skewed = append(skewed, zone)
+		}
+	}
+	return skewed
+}
+
+// zoneExclusionSelectors returns the label selectors keeping a task off the
+// nodes of the zones.
+func zoneExclusionSelectors(zones []string) []*firmament.LabelSelector {
+	if len(zones) == 0 {
+		return nil
+	}
+	var selectors []*firmament.LabelSelector
+	for _, key := range zoneLabels {
+		selectors = append(selectors, &firmament.LabelSelector{
+			Type:   firmament.LabelSelector_NOT_IN_SET,
+			Key:    key,
+			Values: zones,
+		})
+	}
+	return selectors
+}
+
+// BalanceZones recomputes the zones the pending replicas of each ReplicaSet
+// must avoid not to exceed the skew, and updates their tasks whose excluded
+// zones changed. The replicas placed in a single cycle may still exceed the
+// skew. If migrations are enabled, it returns a replica to delete from the
+// most loaded zone of each ReplicaSet exceeding the skew without pending
+// replica.
+func BalanceZones(fc firmament.FirmamentSchedulerClient) []ZoneMigration {
+	if zoneBalancePolicy == nil {
+		return nil
+	}
+	replicas, zones := replicasByZone()
+	zoneBalanceMux.Lock()
+	pending := make(map[string]bool)
+	changed := make(map[uint64][]*firmament.LabelSelector)
+	var taskIDs []uint64
+	for taskID, replicaSet := range balancedTasks {
+		if !isTaskPending(taskID) {
+			continue
+		}
+		pending[replicaSet] = true
+		selectors := zoneExclusionSelectors(zoneBalancePolicy.skewedZones(replicas[replicaSet], zones))
+		if !reflect.DeepEqual(selectors, zoneSelectors[taskID]) {
+			changed[taskID] = selectors
+			taskIDs = append(taskIDs, taskID)
+		}
+	}
+	updates := state.updateTasks(taskIDs, func(td *firmament.TaskDescriptor) {
+		selectors := changed[td.GetUid()]
+		td.LabelSelectors = append(withoutSelectors(td.LabelSelectors, zoneSelectors[td.GetUid()]), selectors...)
+		zoneSelectors[td.GetUid()] = selectors
+	})
+	zoneBalanceMux.Unlock()
+	for _, update := range updates {
+		glog.V(2).Infof("Updating the excluded zones of task %d", update.TaskDescriptor.GetUid())
+		handleError(firmament.TaskUpdated(fc, update))
+	}
+	if !zoneBalancePolicy.Migrate || !features.Enabled(features.Migrations) {
+		return nil
+	}
+	return zoneMigrations(replicas, zones, pending)
+}
+
+// zoneMigrations returns a replica of the most loaded zone of each
+// ReplicaSet exceeding the skew, skipping the ReplicaSets with pending
+// replicas which may restore the balance.
+func zoneMigrations(replicas map[string]map[string]int, zones []string, pending map[string]bool) []ZoneMigration {
+	var migrations []ZoneMigration
+	replicaSets := make([]string, 0, len(replicas))
+	for replicaSet := range replicas {
+		replicaSets = append(replicaSets, replicaSet)
+	}
+	sort.Strings(replicaSets)
+	for _, replicaSet := range replicaSets {
+		if pending[replicaSet] || len(zones) < 2 {
+			continue
+		}
+		most, least := zones[0], zones[0]
+		for _, zone := range zones {
+			if replicas[replicaSet][zone] > replicas[replicaSet][most] {
+				most = zone
+			}
+			if replicas[replicaSet][zone] < replicas[replicaSet][least] {
+				least = zone
+			}
+		}
+		if replicas[replicaSet][most]-replicas[replicaSet][least] <= zoneBalancePolicy.MaxSkew {
+			continue
+		}
+		if migration, ok := replicaInZone(replicaSet, most); ok {
+			migrations = append(migrations, migration)
+		}
+	}
+	return migrations
+}
+
+// replicaInZone returns a running replica of the ReplicaSet bound to a node
+// of the zone, the first one by name.
+func replicaInZone(replicaSet, zone string) (ZoneMigration, bool) {
+	state.nodeMux.RLock()
+	nodeNames := make([]string, 0, len(state.nodeToRTND))
+	for nodeName := range state.nodeToRTND {
+		nodeNames = append(nodeNames, nodeName)
+	}
+	state.nodeMux.RUnlock()
+	sort.Strings(nodeNames)
+	var migration ZoneMigration
+	found := false
+	for _, nodeName := range nodeNames {
+		if nodeZone(nodeName) != zone {
+			continue
+		}
+		for _, pod := range podsByNode.podsOnNode(nodeName) {
+			if pod.ReplicaSet != replicaSet || pod.Deleting || pod.Phase != v1.PodRunning {
+				continue
+			}
+			if !found || pod.Identifier.UniqueName() < migration.Pod.UniqueName() {
+				migration = ZoneMigration{Pod: pod.Identifier, Node: nodeName, Zone: zone}
+				if td, ok := state.TaskOfPod(pod.Identifier); ok {
+					migration.TaskID = td.GetUid()
+				}
+				found = true
+			}
+		}
+	}
+	return migration, found
+}
+
+// withoutSelectors returns the selectors except the removed ones.
+func withoutSelectors(selectors, removed []*firmament.LabelSelector) []*firmament.LabelSelector {
+	if len(removed) == 0 {
+		return selectors
+	}
+	kept := make([]*firmament.LabelSelector, 0, len(selectors))
+	for _, selector := range selectors {
+		keep := true
+		for _, r := range removed {
+			if selector == r {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			kept = append(kept, selector)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sclient
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestSkewedZones(t *testing.T) {
+	zones := []string{"a", "b", "c"}
+	var testData = []struct {
+		maxSkew  int
+		replicas map[string]int
+		expected []string
+	}{
+		{maxSkew: 1, replicas: map[string]int{"a": 1, "b": 1, "c": 1}, expected: nil},
+		{maxSkew: 1, replicas: map[string]int{"a": 2, "b": 1, "c": 1}, expected: []string{"a"}},
+		{maxSkew: 2, replicas: map[string]int{"a": 2, "b": 1, "c": 1}, expected: nil},
+		{maxSkew: 2, replicas: map[string]int{"a": 3, "b": 2}, expected: []string{"a", "b"}},
+	}
+	for _, tc := range testData {
+		policy := &ZoneBalancePolicy{MaxSkew: tc.maxSkew}
+		if skewed := policy.skewedZones(tc.replicas, zones); !reflect.DeepEqual(skewed, tc.expected) {
+			t.Errorf("skewedZones(%v) = %v with a skew of %d, expected %v", tc.replicas, skewed, tc.maxSkew, tc.expected)
+		}
+	}
+
+	selectors := zoneExclusionSelectors([]string{"a"})
+	if len(selectors) != len(zoneLabels) {
+		t.Fatalf("zoneExclusionSelectors() = %v, expected a selector per zone label", selectors)
+	}
+	if matchesSelectors([]*firmament.Label{{Key: zoneLabels[0], Value: "a"}}, selectors) {
+		t.Error("Node of an excluded zone matches the exclusion selectors")
+	}
+	if !matchesSelectors([]*firmament.Label{{Key: zoneLabels[0], Value: "b"}}, selectors) {
+		t.Error("Node of another zone does not match the exclusion selectors")
+	}
+}
+
+func TestBalanceZones(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	fc := firmament.NewMockFirmamentSchedulerClient(mockCtrl)
+	defer func() { zoneBalancePolicy = nil }()
+	zoneBalancePolicy = &ZoneBalancePolicy{MaxSkew: 1, Migrate: true}
+	state = &memoryState{}
+	state.nodeToRTND = make(map[string]*firmament.ResourceTopologyNodeDescriptor)
+	for _, zone := range []string{"a", "b", "c"} {
+		state.nodeToRTND["node-"+zone] = &firmament.ResourceTopologyNodeDescriptor{ResourceDesc: &firmament.ResourceDescriptor{
+			Labels: []*firmament.Label{{Key: zoneLabels[0], Value: zone}},
+		}}
+	}
+	podsByNode = newPodIndex()
+	defer func() { podsByNode = newPodIndex() }()
+	controller := true
+	replica := func(name, nodeName string) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns", OwnerReferences: []metav1.OwnerReference{
+				{Kind: "ReplicaSet", Name: "web", UID: types.UID("rs0"), Controller: &controller},
+			}},
+			Spec:   v1.PodSpec{NodeName: nodeName},
+			Status: v1.PodStatus{Phase: v1.PodRunning},
+		}
+	}
+	for i, nodeName := range []string{"node-a", "node-a", "node-a", "node-b"} {
+		podsByNode.update(replica(fmt.Sprintf("web-%d", i), nodeName), 100, 1000)
+	}
+	replicas, zones := replicasByZone()
+	if expected := map[string]map[string]int{"rs0": {"a": 3, "b": 1}}; !reflect.DeepEqual(replicas, expected) {
+		t.Errorf("replicasByZone() = %v, expected %v", replicas, expected)
+	}
+	if !reflect.DeepEqual(zones, []string{"a", "b", "c"}) {
+		t.Errorf("replicasByZone() zones = %v, expected [a b c]", zones)
+	}
+
+	// The pending replica is kept off the zones which already have more
+	// replicas than zone c, and no replica is migrated while it is pending.
+	state.jobIDToJD = map[string]*firmament.JobDescriptor{"job0": {Uuid: "job0"}}
+	podIdentifier := PodIdentifier{Name: "web-4", Namespace: "ns"}
+	state.podToTD = map[PodIdentifier]*firmament.TaskDescriptor{podIdentifier: {Uid: 1, JobId: "job0"}}
+	state.taskIDToPod = map[uint64]PodIdentifier{1: podIdentifier}
+	registerZoneBalancedTask(&Pod{Identifier: podIdentifier, ReplicaSet: "rs0"}, 1)
+	defer forgetZoneBalancedTask(1)
+	markTaskPending(1)
+	defer MarkTaskPlaced(1)
+	fc.EXPECT().TaskUpdated(gomock.Any(), gomock.Any()).Return(
+		&firmament.TaskUpdatedResponse{Type: firmament.TaskReplyType_TASK_UPDATED_OK}, nil)
+	if migrations := BalanceZones(fc); len(migrations) != 0 {
+		t.Errorf("BalanceZones() = %v with a pending replica, expected no migration", migrations)
+	}
+	expected := zoneExclusionSelectors([]string{"a", "b"})
+	if selectors := state.podToTD[podIdentifier].LabelSelectors; !reflect.DeepEqual(selectors, expected) {
+		t.Errorf("BalanceZones() set the selectors %v, expected %v", selectors, expected)
+	}
+	// The task is only updated when its excluded zones change.
+	BalanceZones(fc)
+
+	// Once placed, the most loaded zone gives up a replica.
+	MarkTaskPlaced(1)
+	migrations := BalanceZones(fc)
+	expectedMigrations := []ZoneMigration{{Pod: PodIdentifier{Name: "web-0", Namespace: "ns"}, Node: "node-a", Zone: "a"}}
+	if !reflect.DeepEqual(migrations, expectedMigrations) {
+		t.Errorf("BalanceZones() = %v, expected %v", migrations, expectedMigrations)
+	}
+}
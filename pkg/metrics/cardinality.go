@@ -0,0 +1,135 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// OverflowLabelValue replaces the values of the high cardinality labels of
+// the samples aggregated once a metric reaches its series limit.
+const OverflowLabelValue = "__other__"
+
+// OverflowPolicy is what happens to new series once a metric reaches its
+// series limit.
+type OverflowPolicy string
+
+const (
+	// OverflowAggregate aggregates the new series into the series whose high
+	// cardinality labels are OverflowLabelValue, which takes one of the
+	// series of the limit. The gauges are not aggregated, their new series
+	// are dropped.
+	OverflowAggregate OverflowPolicy = "aggregate"
+	// OverflowDrop drops the new series.
+	OverflowDrop OverflowPolicy = "drop"
+)
+
+// CardinalityLimits bounds the number of series of the metrics partitioned
+// by high cardinality labels, e.g. namespace or pod, so that the metrics
+// endpoint remains scrapable on large clusters.
+type CardinalityLimits struct {
+	// MaxSeries is the max number of series per metric, 0 means no limit.
+	MaxSeries int
+	// Labels are the high cardinality label names. Only the metrics
+	// partitioned by at least one of them are limited.
+	Labels []string
+	// Policy applies to the series above the limit.
+	Policy OverflowPolicy
+}
+
+var limits struct {
+	sync.RWMutex
+	CardinalityLimits
+	labels map[string]bool
+}
+
+// SetCardinalityLimits configures the limits applied to new series.
+func SetCardinalityLimits(cardinalityLimits CardinalityLimits) error {
+	switch cardinalityLimits.Policy {
+	case OverflowAggregate, OverflowDrop:
+	default:
+		return fmt.Errorf("unknown metrics overflow policy %q", cardinalityLimits.Policy)
+	}
+	limits.Lock()
+	defer limits.Unlock()
+	limits.CardinalityLimits = cardinalityLimits
+	limits.labels = make(map[string]bool, len(cardinalityLimits.Labels))
+	for _, label := range cardinalityLimits.Labels {
+		limits.labels[label] = true
+	}
+	return nil
+}
+
+// overflowLabelValues returns the label values under which a new series of
+// the metric is accounted, or false if the series is dropped. The caller
+// must hold v.mu.
+func (v *vec) overflowLabelValues(labelValues []string) ([]string, bool) {
+	limits.RLock()
+	defer limits.RUnlock()
+	if limits.MaxSeries <= 0 {
+		return labelValues, true
+	}
+	var overflow []string
+	for i, name := range v.labelNames {
+		if limits.labels[name] {
+			if overflow == nil {
+				overflow = append([]string(nil), labelValues...)
+			}
+			overflow[i] = OverflowLabelValue
+		}
+	}
+	if overflow == nil {
+		// The metric has no high cardinality label.
+		return labelValues, true
+	}
+	// The sum of the values of gauges is meaningless.
+	aggregate := limits.Policy == OverflowAggregate && v.metricType != "gauge"
+	maxSeries := limits.MaxSeries
+	if aggregate {
+		// One of the series is kept for the aggregated samples.
+		maxSeries--
+	}
+	if len(v.samples) < maxSeries {
+		return labelValues, true
+	}
+	if !aggregate {
+		return nil, false
+	}
+	if _, ok := v.samples[strings.Join(overflow, "\xff")]; !ok && len(v.samples) >= limits.MaxSeries {
+		return nil, false
+	}
+	return overflow, true
+}
+
+// writeDropped writes the number of updates dropped by the cardinality
+// limits per metric.
+func writeDropped(w io.Writer, vecs []*vec) {
+	name := namespace + "_metrics_dropped_series_updates_total"
+	fmt.Fprintf(w, "# HELP %s Number of metric updates dropped because the metric reached its series limit.\n", name)
+	fmt.Fprintf(w, "# TYPE %s counter\n", name)
+	for _, v := range vecs {
+		v.mu.Lock()
+		dropped := v.dropped
+		v.mu.Unlock()
+		if dropped > 0 {
+			fmt.Fprintf(w, "%s%s %d\n", name, formatLabels([]string{"metric"}, []string{v.name}), dropped)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCardinalityLimits(t *testing.T) {
+	defer SetCardinalityLimits(CardinalityLimits{Policy: OverflowAggregate})
+	var testData = []struct {
+		policy           OverflowPolicy
+		expectedOverflow float64
+		expectedDropped  string
+	}{
+		{
+			policy:           OverflowAggregate,
+			expectedOverflow: 3,
+		},
+		{
+			policy:           OverflowDrop,
+			expectedOverflow: 0,
+			expectedDropped:  `poseidon_metrics_dropped_series_updates_total{metric="test_cardinality_drop"} 3`,
+		},
+	}
+	for _, tc := range testData {
+		if err := SetCardinalityLimits(CardinalityLimits{
+			MaxSeries: 2,
+			Labels:    []string{"namespace"},
+			Policy:    tc.policy,
+		}); err != nil {
+			t.Fatalf("SetCardinalityLimits() failed: %v", err)
+		}
+		limited := NewCounter("test_cardinality_"+string(tc.policy), "Test limited counter.", "namespace", "result")
+		unlimited := NewCounter("test_cardinality_unlimited_"+string(tc.policy), "Test unlimited counter.", "result")
+		for _, ns := range []string{"ns0", "ns1", "ns2", "ns3"} {
+			limited.Inc(ns, "ok")
+			unlimited.Inc(ns)
+		}
+		limited.Inc("ns0", "ok")
+		if got := limited.Get("ns0", "ok"); got != 2 {
+			t.Errorf("%s: Get(ns0) = %v, expected 2", tc.policy, got)
+		}
+		if got := limited.Get(OverflowLabelValue, "ok"); got != tc.expectedOverflow {
+			t.Errorf("%s: Get(%s) = %v, expected %v", tc.policy, OverflowLabelValue, got, tc.expectedOverflow)
+		}
+		limited.Inc("ns4", "failed")
+		limited.mu.Lock()
+		series := len(limited.samples)
+		limited.mu.Unlock()
+		if series != 2 {
+			t.Errorf("%s: metric with a limit of 2 series has %d series", tc.policy, series)
+		}
+		if got := unlimited.Get("ns3"); got != 1 {
+			t.Errorf("%s: Get(ns3) of a metric without high cardinality labels = %v, expected 1", tc.policy, got)
+		}
+		recorder := httptest.NewRecorder()
+		Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+		if !strings.Contains(recorder.Body.String(), tc.expectedDropped) {
+			t.Errorf("%s: Handler() output:\n%s\nexpected to contain:\n%s", tc.policy, recorder.Body.String(), tc.expectedDropped)
+		}
+	}
+}
+
+func TestSetCardinalityLimitsInvalidPolicy(t *testing.T) {
+	if err := SetCardinalityLimits(CardinalityLimits{Policy: "sample"}); err == nil {
+		t.Error("SetCardinalityLimits() accepted an unknown policy")
+	}
+}
+
+func TestCardinalityLimitsGauge(t *testing.T) {
+	defer SetCardinalityLimits(CardinalityLimits{Policy: OverflowAggregate})
+	if err := SetCardinalityLimits(CardinalityLimits{
+		MaxSeries: 2,
+		Labels:    []string{"namespace"},
+		Policy:    OverflowAggregate,
+	}); err != nil {
+		t.Fatalf("SetCardinalityLimits() failed: %v", err)
+	}
+	gauge := NewGauge("test_cardinality_gauge", "Test limited gauge.", "namespace")
+	for _, ns := range []string{"ns0", "ns1", "ns2"} {
+		gauge.Set(1, ns)
+	}
+	if got := gauge.Get("ns1"); got != 1 {
+		t.Errorf("Get(ns1) = %v, expected 1", got)
+	}
+	if got := gauge.Get(OverflowLabelValue); got != 0 {
+		t.Errorf("Get(%s) = %v, expected the gauge not to be aggregated", OverflowLabelValue, got)
+	}
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+const namespace = "poseidon"
+
+var (
+	// FirmamentBacklog is the number of tasks submitted to Firmament which have not been placed yet.
+	FirmamentBacklog = NewGauge(namespace+"_firmament_backlog_tasks",
+		"Number of tasks submitted to Firmament which have not been placed yet.")
+	// FirmamentBacklogCarriedOver is the number of pending tasks which were left unplaced by at least one scheduling cycle.
+	FirmamentBacklogCarriedOver = NewGauge(namespace+"_firmament_backlog_carried_over_tasks",
+		"Number of pending tasks which were left unplaced by at least one scheduling cycle.")
+	// FirmamentBacklogMaxCycles is the largest number of scheduling cycles a pending task was left unplaced by.
+	FirmamentBacklogMaxCycles = NewGauge(namespace+"_firmament_backlog_max_cycles",
+		"Largest number of scheduling cycles a pending task was left unplaced by.")
+	// FirmamentBacklogOldestAge is the age of the oldest pending task.
+	FirmamentBacklogOldestAge = NewGauge(namespace+"_firmament_backlog_oldest_task_age_seconds",
+		"Time since the oldest pending task was submitted to Firmament.")
+	// DeferredTaskSubmissions counts the task submissions deferred because the backlog exceeded its limit.
+	DeferredTaskSubmissions = NewCounter(namespace+"_deferred_task_submissions_total",
+		"Number of task submissions deferred because the Firmament backlog exceeded its limit.")
+	// RateLimitedTaskSubmissions counts the task submissions deferred because their namespace exceeded its rate.
+	RateLimitedTaskSubmissions = NewCounter(namespace+"_rate_limited_task_submissions_total",
+		"Number of task submissions deferred because their namespace exceeded its submission rate.", "namespace")
+	// TemplateConstraintCache counts the lookups of the pod constraints cached by pod template per result (hit or miss).
+	TemplateConstraintCache = NewCounter(namespace+"_template_constraint_cache_lookups_total",
+		"Number of lookups of the pod constraints cached by pod template, by result: hit or miss.", "result")
+	// CanaryPods counts the eligible pods by claim decision (claimed or skipped).
+	CanaryPods = NewCounter(namespace+"_canary_pods_total",
+		"Number of eligible pods by claim decision.", "decision")
+	// ShadowDecisions counts the shadow placements which agree or diverge with the actual placements.
+	ShadowDecisions = NewCounter(namespace+"_shadow_decisions_total",
+		"Number of shadow placements by comparison result (agree or diverge) with the placement of the shadowed scheduler.", "result")
+	// ShadowAgreementRatio is the fraction of shadow placements which agree with the actual placements.
+	ShadowAgreementRatio = NewGauge(namespace+"_shadow_agreement_ratio",
+		"Fraction of shadow placements which agree with the placement of the shadowed scheduler.")
+	// DeferredDeltas is the number of scheduling deltas deferred to the next cycle by the per node caps.
+	DeferredDeltas = NewGauge(namespace+"_deferred_scheduling_deltas",
+		"Number of scheduling deltas deferred to the next cycle because their node reached its placement or preemption cap.")
+	// PodsBound counts the pods bound to a node per namespace.
+	PodsBound = NewCounter(namespace+"_pods_bound_total",
+		"Number of pods bound to a node.", "namespace")
+	// DeltaResults counts the scheduling deltas applied per delta type and result.
+	DeltaResults = NewCounter(namespace+"_scheduling_delta_results_total",
+		"Number of scheduling deltas by type and result of their application: applied, failed or superseded.", "type", "result")
+	// BindFailures counts the failed binds per class of failure.
+	BindFailures = NewCounter(namespace+"_bind_failures_total",
+		"Number of failed binds by class: conflict, pod_not_found, node_not_found, forbidden, timeout or error.", "class")
+	// NodeQuarantines counts the nodes quarantined per source of the failures (bind, kubelet or removed).
+	NodeQuarantines = NewCounter(namespace+"_node_quarantines_total",
+		"Number of nodes quarantined after repeated failed binds, by source of the last failure: bind or kubelet, or "+
+			"because a bind found the node removed.", "source")
+	// QuarantinedNodes is the number of nodes currently quarantined.
+	QuarantinedNodes = NewGauge(namespace+"_quarantined_nodes",
+		"Number of nodes on which no pod is placed because of repeated failed binds.")
+	// AbandonedSchedulerRuns counts the scheduler runs given up on per reason (timeout or cancelled).
+	AbandonedSchedulerRuns = NewCounter(namespace+"_abandoned_scheduler_runs_total",
+		"Number of scheduler runs given up on before Firmament returned their deltas, by reason: timeout, unavailable or cancelled.", "reason")
+	// RejectedStats counts the node and pod stats rejected per reason (sender or invalid).
+	RejectedStats = NewCounter(namespace+"_rejected_stats_total",
+		"Number of node and pod stats rejected, by kind (node or pod) and reason: sent by another node (sender) or invalid.",
+		"kind", "reason")
+	// StartupSyncPending is the number of nodes or pods listed at startup not yet synchronized with Firmament, per watcher.
+	StartupSyncPending = NewGauge(namespace+"_startup_sync_pending",
+		"Number of nodes or pods listed at startup which are not synchronized with Firmament yet, by watcher: node or pod.",
+		"watcher")
+	// StartupSyncSeconds is the duration of the startup synchronization per watcher.
+	StartupSyncSeconds = NewGauge(namespace+"_startup_sync_seconds",
+		"Seconds from the start of a watcher until the nodes or pods listed at startup were synchronized with Firmament, "+
+			"by watcher: node or pod.", "watcher")
+	// StateEntries is the number of entries of the maps of the scheduler state.
+	StateEntries = NewGauge(namespace+"_state_entries",
+		"Number of entries of the scheduler state, by map: pods, tasks, nodes or resources.", "map")
+	// PodGroupTimeouts counts the pod groups whose held placements were released per namespace.
+	PodGroupTimeouts = NewCounter(namespace+"_pod_group_timeouts_total",
+		"Number of pod groups whose placements were released after waiting too long for their minAvailable pods to be placed, "+
+			"by namespace.", "namespace")
+	// HeldPodGroupPlacements is the number of placements held until their pod group reaches its minAvailable.
+	HeldPodGroupPlacements = NewGauge(namespace+"_held_pod_group_placements",
+		"Number of placements not bound until their pod group reaches its minAvailable.")
+	// FallbackPlacements counts the placements of the fallback scheduler per result (applied or failed).
+	FallbackPlacements = NewCounter(namespace+"_fallback_placements_total",
+		"Number of placements computed by the fallback scheduler while Firmament was unavailable, by result of their bind: applied or failed.",
+		"result")
+	// FirmamentHealth is 1 for the current state of the connectivity to Firmament.
+	FirmamentHealth = NewGauge(namespace+"_firmament_health",
+		"1 for the current state of the connectivity to Firmament, 0 for the others, by state: Connecting, Ready, Degraded or Down.",
+		"state")
+	// UnresolvablePods counts the pending pods marked unschedulable because no node satisfies their constraints.
+	UnresolvablePods = NewCounter(namespace+"_unresolvable_pods_total",
+		"Number of pending pods marked unschedulable because no node satisfies their constraints.")
+	// OversizedPods counts the pending pods whose requests exceed the allocatable of every node.
+	OversizedPods = NewCounter(namespace+"_oversized_pods_total",
+		"Number of pending pods whose requests exceed the allocatable of every node.")
+	// PlacementDecisions counts the PlacementDecision custom resources per result (created, failed, dropped or expired).
+	PlacementDecisions = NewCounter(namespace+"_placement_decisions_total",
+		"Number of PlacementDecision custom resources per result: created, failed, dropped or expired.", "result")
+	// PausedPods is the number of pending pods whose scheduling is paused by their annotation.
+	PausedPods = NewGauge(namespace+"_paused_pods",
+		"Number of pending pods whose scheduling is paused by their annotation.")
+	// ZoneAllocatable is the allocatable capacity of the nodes per zone, node pool and resource.
+	ZoneAllocatable = NewGauge(namespace+"_zone_allocatable",
+		"Allocatable capacity of the nodes known to the scheduler per zone, node pool and resource: cpu in millicores or memory in KB.",
+		"zone", "pool", "resource")
+	// ZoneAllocated is the capacity requested by the pods bound to the nodes per zone, node pool and resource.
+	ZoneAllocated = NewGauge(namespace+"_zone_allocated",
+		"Capacity requested by the pods bound to the nodes per zone, node pool and resource: cpu in millicores or memory in KB.",
+		"zone", "pool", "resource")
+	// ZonePendingRequests is the capacity requested by the pending tasks per zone, node pool and resource they are restricted to.
+	ZonePendingRequests = NewGauge(namespace+"_zone_pending_requests",
+		"Capacity requested by the tasks submitted to Firmament which are not placed yet per zone, node pool and resource they are restricted to, "+
+			"empty if unrestricted: cpu in millicores or memory in KB.",
+		"zone", "pool", "resource")
+	// BackpressuredTaskSubmissions counts the task submissions deferred because the scheduler is saturated.
+	BackpressuredTaskSubmissions = NewCounter(namespace+"_backpressured_task_submissions_total",
+		"Number of task submissions deferred because the solver or the bind path is saturated.")
+	// SubmissionRateLimit is the allowed number of task submissions per second while the scheduler is saturated.
+	SubmissionRateLimit = NewGauge(namespace+"_task_submission_rate_limit",
+		"Allowed number of task submissions per second while the solver or the bind path is saturated, 0 without backpressure.")
+	// WebhookRejectedPods counts the pods rejected by the validating webhook per namespace.
+	WebhookRejectedPods = NewCounter(namespace+"_webhook_rejected_pods_total",
+		"Number of pods rejected by the validating webhook because Poseidon cannot schedule their spec per namespace.",
+		"namespace")
+	// FairShareDeferredTaskSubmissions counts the task submissions deferred because their namespace exceeded its fair share.
+	FairShareDeferredTaskSubmissions = NewCounter(namespace+"_fair_share_deferred_task_submissions_total",
+		"Number of task submissions deferred because their namespace exceeded its fair share of the capacity.", "namespace")
+	// TenantDominantShare is the dominant share of the capacity requested by the submitted batch pods per namespace.
+	TenantDominantShare = NewGauge(namespace+"_tenant_dominant_share",
+		"Largest fraction of the CPU or memory capacity requested by the submitted batch pods per namespace.", "namespace")
+	// NodeNotReadyFlaps counts the nodes ready again within their not ready grace period.
+	NodeNotReadyFlaps = NewCounter(namespace+"_node_not_ready_flaps_total",
+		"Number of nodes ready again within their not ready grace period, which were not failed.")
+	// PodChurn counts the preemptions and migrations of the pods per owner.
+	PodChurn = NewCounter(namespace+"_pod_churn_total",
+		"Number of pods preempted or migrated per owner, e.g. Deployment/web, and type.", "namespace", "owner", "type")
+	// OwnerChurn is the number of preemptions and migrations of the pods of each owner within the churn window.
+	OwnerChurn = NewGauge(namespace+"_owner_churn",
+		"Number of pods preempted or migrated per owner within the churn window.", "namespace", "owner")
+	// SuppressedMigrations counts the migrations suppressed because the owner of the pod exhausted its churn budget.
+	SuppressedMigrations = NewCounter(namespace+"_suppressed_migrations_total",
+		"Number of pod migrations suppressed because their owner exhausted its churn budget.", "namespace", "owner")
+	// SchedulerErrors counts the errors of the requests to Firmament and of the scheduler state per kind: retryable, state_mismatch or fatal.
+	SchedulerErrors = NewCounter(namespace+"_errors_total",
+		"Number of errors of the requests to Firmament and of the scheduler state, by kind: retryable, state_mismatch or fatal.", "kind")
+	// RunInfo is 1 for the ID of the current run.
+	RunInfo = NewGauge(namespace+"_run_info",
+		"Always 1, labeled with the ID of the current Poseidon run.", "run_id")
+)
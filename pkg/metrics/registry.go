@@ -0,0 +1,217 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// registry holds all the metrics exposed by Handler.
+var registry struct {
+	sync.Mutex
+	vecs []*vec
+}
+
+// sample is the value of a metric for a set of label values.
+type sample struct {
+	labelValues []string
+	value       float64
+}
+
+// vec is a metric partitioned by label values.
+type vec struct {
+	name       string
+	help       string
+	metricType string
+	labelNames []string
+
+	mu      sync.Mutex
+	samples map[string]*sample
+	// dropped is the number of updates of series dropped by the cardinality limits.
+	dropped int
+}
+
+func newVec(name, help, metricType string, labelNames []string) *vec {
+	v := &vec{
+		name:       name,
+		help:       help,
+		metricType: metricType,
+		labelNames: labelNames,
+		samples:    make(map[string]*sample),
+	}
+	registry.Lock()
+	registry.vecs = append(registry.vecs, v)
+	registry.Unlock()
+	return v
+}
+
+func (v *vec) add(delta float64, set bool, labelValues []string) {
+	if len(labelValues) != len(v.labelNames) {
+		panic(fmt.Sprintf("metric %s expects %d label values, got %d", v.name, len(v.labelNames), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	s, ok := v.samples[key]
+	if !ok {
+		overflow, keep := v.overflowLabelValues(labelValues)
+		if !keep {
+			v.dropped++
+			return
+		}
+		key = strings.Join(overflow, "\xff")
+		if s, ok = v.samples[key]; !ok {
+			s = &sample{labelValues: append([]string(nil), overflow...)}
+			v.samples[key] = s
+		}
+	}
+	if set {
+		s.value = delta
+	} else {
+		s.value += delta
+	}
+}
+
+func (v *vec) get(labelValues []string) float64 {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if s, ok := v.samples[strings.Join(labelValues, "\xff")]; ok {
+		return s.value
+	}
+	return 0
+}
+
+func (v *vec) reset() {
+	v.mu.Lock()
+	v.samples = make(map[string]*sample)
+	v.mu.Unlock()
+}
+
+func (v *vec) write(w io.Writer) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	fmt.Fprintf(w, "# HELP %s %s\n", v.name, v.help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", v.name, v.metricType)
+	keys := make([]string, 0, len(v.samples))
+	for key := range v.samples {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s := v.samples[key]
+		fmt.Fprintf(w, "%s%s %s\n", v.name, formatLabels(v.labelNames, s.labelValues),
+			strconv.FormatFloat(s.value, 'g', -1, 64))
+	}
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatLabels(labelNames, labelValues []string) string {
+	if len(labelNames) == 0 {
+		return ""
+	}
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, name := range labelNames {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		fmt.Fprintf(&buf, "%s=\"%s\"", name, labelValueEscaper.Replace(labelValues[i]))
+	}
+	buf.WriteByte('}')
+	return buf.String()
+}
+
+// Gauge is a metric whose value can go up and down.
+type Gauge struct {
+	*vec
+}
+
+// NewGauge creates and registers a gauge partitioned by the given label names.
+func NewGauge(name, help string, labelNames ...string) *Gauge {
+	return &Gauge{newVec(name, help, "gauge", labelNames)}
+}
+
+// Set sets the gauge for the given label values.
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	g.add(value, true, labelValues)
+}
+
+// Add adds the given delta to the gauge for the given label values.
+func (g *Gauge) Add(delta float64, labelValues ...string) {
+	g.add(delta, false, labelValues)
+}
+
+// Get returns the value of the gauge for the given label values.
+func (g *Gauge) Get(labelValues ...string) float64 {
+	return g.get(labelValues)
+}
+
+// Reset removes the values of the gauge for all the label values.
+func (g *Gauge) Reset() {
+	g.reset()
+}
+
+// Counter is a metric whose value only goes up.
+type Counter struct {
+	*vec
+}
+
+// NewCounter creates and registers a counter partitioned by the given label names.
+func NewCounter(name, help string, labelNames ...string) *Counter {
+	return &Counter{newVec(name, help, "counter", labelNames)}
+}
+
+// Inc increments the counter for the given label values.
+func (c *Counter) Inc(labelValues ...string) {
+	c.add(1, false, labelValues)
+}
+
+// Add adds the given non-negative delta to the counter for the given label values.
+func (c *Counter) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		panic(fmt.Sprintf("counter %s can not decrease", c.name))
+	}
+	c.add(delta, false, labelValues)
+}
+
+// Get returns the value of the counter for the given label values.
+func (c *Counter) Get(labelValues ...string) float64 {
+	return c.get(labelValues)
+}
+
+// Handler returns an HTTP handler exposing all the metrics in the Prometheus
+// text format.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		registry.Lock()
+		vecs := append([]*vec(nil), registry.vecs...)
+		registry.Unlock()
+		for _, v := range vecs {
+			v.write(w)
+		}
+		writeDropped(w, vecs)
+	})
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGauge(t *testing.T) {
+	gauge := NewGauge("test_gauge", "Test gauge.", "node")
+	gauge.Set(3, "node0")
+	gauge.Add(-1, "node0")
+	gauge.Add(1, "node1")
+	if got := gauge.Get("node0"); got != 2 {
+		t.Errorf("Get(node0) = %v, expected 2", got)
+	}
+	gauge.Reset()
+	if got := gauge.Get("node1"); got != 0 {
+		t.Errorf("Get(node1) after Reset() = %v, expected 0", got)
+	}
+}
+
+func TestCounter(t *testing.T) {
+	counter := NewCounter("test_counter", "Test counter.")
+	counter.Inc()
+	counter.Add(2)
+	if got := counter.Get(); got != 3 {
+		t.Errorf("Get() = %v, expected 3", got)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("Add() with a negative delta did not panic")
+		}
+	}()
+	counter.Add(-1)
+}
+
+func TestHandler(t *testing.T) {
+	gauge := NewGauge("test_handler_gauge", "Test handler gauge.", "namespace", "name")
+	gauge.Set(1.5, "default", "pod\"1\"")
+	gauge.Set(2, "default", "pod0")
+	recorder := httptest.NewRecorder()
+	Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	expected := `# HELP test_handler_gauge Test handler gauge.
+# TYPE test_handler_gauge gauge
+test_handler_gauge{namespace="default",name="pod\"1\""} 1.5
+test_handler_gauge{namespace="default",name="pod0"} 2
+`
+	if !strings.Contains(recorder.Body.String(), expected) {
+		t.Errorf("Handler() output:\n%s\nexpected to contain:\n%s", recorder.Body.String(), expected)
+	}
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runinfo
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Annotation is the annotation holding the ID of the run which made a
+// scheduling decision, e.g. on the bound pods and the scheduler events.
+const Annotation = "poseidon.k8s.io/run-id"
+
+// ID uniquely identifies the current Poseidon process. It is attached to the
+// scheduling decisions to correlate them with the run which made them across
+// restarts.
+var ID = uuid.New().String()
+
+// StartTime is the time at which the current process started.
+var StartTime = time.Now()
+
+// Report describes the state of Poseidon at startup.
+type Report struct {
+	RunID     string    `json:"runId"`
+	StartTime time.Time `json:"startTime"`
+	// ConfigHash identifies the effective configuration.
+	ConfigHash       string `json:"configHash"`
+	FirmamentAddress string `json:"firmamentAddress"`
+	// FirmamentVersion is empty as long as Firmament does not report it.
+	FirmamentVersion string `json:"firmamentVersion,omitempty"`
+	FirmamentStatus  string `json:"firmamentStatus"`
+	Nodes            int    `json:"nodes"`
+	Pods             int    `json:"pods"`
+}
+
+// NewReport returns the report of the current run.
+func NewReport() *Report {
+	return &Report{RunID: ID, StartTime: StartTime}
+}
+
+// String returns the report as a single line of JSON, easy to extract from
+// the logs.
+func (r *Report) String() string {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err.Error()
+	}
+	return string(data)
+}
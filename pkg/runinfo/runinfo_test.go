@@ -0,0 +1,38 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runinfo
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestReport(t *testing.T) {
+	report := NewReport()
+	report.ConfigHash = "abc"
+	report.Nodes = 3
+	var decoded Report
+	if err := json.Unmarshal([]byte(report.String()), &decoded); err != nil {
+		t.Fatalf("report %s is not JSON: %v", report, err)
+	}
+	if decoded.RunID != ID || decoded.RunID == "" {
+		t.Errorf("report run ID = %q, expected %q", decoded.RunID, ID)
+	}
+	if decoded.ConfigHash != "abc" || decoded.Nodes != 3 {
+		t.Errorf("report = %+v, expected config hash abc and 3 nodes", decoded)
+	}
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sampling
+
+import (
+	"crypto/hmac"
+	crand "crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+)
+
+// Outcome is the outcome of a scheduling decision.
+type Outcome string
+
+const (
+	// Placed represents a pod bound to the node chosen by Firmament.
+	Placed Outcome = "placed"
+	// BindFailed represents a placement the bind of which failed.
+	BindFailed Outcome = "bind_failed"
+	// Preempted represents a pod deleted to free resources.
+	Preempted Outcome = "preempted"
+	// Migrated represents a pod deleted to be placed on another node.
+	Migrated Outcome = "migrated"
+)
+
+// Features is the feature vector of a scheduling decision. The
+// utilizations are the ratios of the capacity requested by the pods
+// Poseidon schedules, other than the pod of the decision.
+type Features struct {
+	// CPURequest is in millicores and MemRequestKb in KB.
+	CPURequest   int64  `json:"cpuRequest"`
+	MemRequestKb int64  `json:"memRequestKb"`
+	Priority     uint32 `json:"priority"`
+	// Constraints is the number of Firmament label selectors of the pod.
+	Constraints int `json:"constraints"`
+	// PendingCycles is the number of scheduling cycles which did not place
+	// the pod, and PendingSeconds the time since its submission.
+	PendingCycles  int     `json:"pendingCycles"`
+	PendingSeconds float64 `json:"pendingSeconds"`
+	Nodes          int     `json:"nodes"`
+	NodeCPUUtil    float64 `json:"nodeCpuUtil"`
+	NodeMemUtil    float64 `json:"nodeMemUtil"`
+	ClusterCPUUtil float64 `json:"clusterCpuUtil"`
+	ClusterMemUtil float64 `json:"clusterMemUtil"`
+}
+
+// Sample is a sampled scheduling decision. The pod and node names are
+// anonymized.
+type Sample struct {
+	Time     time.Time `json:"time"`
+	RunID    string    `json:"runId,omitempty"`
+	Pod      string    `json:"pod"`
+	Node     string    `json:"node,omitempty"`
+	Outcome  Outcome   `json:"outcome"`
+	Features Features  `json:"features"`
+}
+
+// Sampler records a random sample of the scheduling decisions, one JSON
+// object per line, for the offline analysis of the decisions and the
+// training of cost models.
+type Sampler struct {
+	mu         sync.Mutex
+	writer     io.Writer
+	closer     io.Closer
+	percentage int
+	salt       []byte
+	rand       *rand.Rand
+}
+
+// New returns a sampler writing percentage percent of the decisions to
+// writer. The names are anonymized with a keyed hash of the salt, so that
+// they can be joined across runs sharing the salt. A random salt is used if
+// it is empty.
+func New(writer io.Writer, percentage int, salt string) (*Sampler, error) {
+	if percentage <= 0 || percentage > 100 {
+		return nil, fmt.Errorf("invalid sampling percentage %d", percentage)
+	}
+	s := &Sampler{
+		writer:     writer,
+		percentage: percentage,
+		salt:       []byte(salt),
+		rand:       rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	if salt == "" {
+		s.salt = make([]byte, 32)
+		if _, err := crand.Read(s.salt); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Open returns a sampler appending the samples to the file at path.
+func Open(path string, percentage int, salt string) (*Sampler, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	s, err := New(file, percentage, salt)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	s.closer = file
+	return s, nil
+}
+
+// Sample returns whether the next decision is sampled.
+func (s *Sampler) Sample() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rand.Intn(100) < s.percentage
+}
+
+// Anonymize returns the keyed hash of a name.
+func (s *Sampler) Anonymize(name string) string {
+	mac := hmac.New(sha256.New, s.salt)
+	mac.Write([]byte(name))
+	return hex.EncodeToString(mac.Sum(nil)[:8])
+}
+
+// Record writes a sample.
+func (s *Sampler) Record(sample Sample) error {
+	data, err := json.Marshal(sample)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.writer.Write(append(data, '\n'))
+	return err
+}
+
+// Close closes the file of the sampler, if any.
+func (s *Sampler) Close() error {
+	if s.closer == nil {
+		return nil
+	}
+	return s.closer.Close()
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sampling
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSampler(t *testing.T) {
+	if _, err := New(&bytes.Buffer{}, 0, ""); err == nil {
+		t.Error("New() with a 0 percentage succeeded")
+	}
+	var buffer bytes.Buffer
+	sampler, err := New(&buffer, 100, "salt")
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if !sampler.Sample() {
+		t.Error("Sample() = false with a 100 percentage")
+	}
+	pod := sampler.Anonymize("default/pod0")
+	if pod == "default/pod0" || pod != sampler.Anonymize("default/pod0") {
+		t.Errorf("Anonymize() = %s, expected a stable hash", pod)
+	}
+	if pod == sampler.Anonymize("default/pod1") {
+		t.Error("Anonymize() returned the same hash for two names")
+	}
+	other, err := New(&bytes.Buffer{}, 100, "other")
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if pod == other.Anonymize("default/pod0") {
+		t.Error("Anonymize() returned the same hash for two salts")
+	}
+
+	samples := []Sample{
+		{
+			Time:    time.Now().UTC().Truncate(time.Second),
+			Pod:     pod,
+			Node:    sampler.Anonymize("node0"),
+			Outcome: Placed,
+			Features: Features{
+				CPURequest:    100,
+				MemRequestKb:  1024,
+				Constraints:   2,
+				PendingCycles: 1,
+				Nodes:         3,
+				NodeCPUUtil:   0.5,
+			},
+		},
+		{
+			Time:    time.Now().UTC().Truncate(time.Second),
+			Pod:     pod,
+			Outcome: BindFailed,
+		},
+	}
+	for _, sample := range samples {
+		if err := sampler.Record(sample); err != nil {
+			t.Fatalf("Record() failed: %v", err)
+		}
+	}
+	var recorded []Sample
+	scanner := bufio.NewScanner(&buffer)
+	for scanner.Scan() {
+		var sample Sample
+		if err := json.Unmarshal(scanner.Bytes(), &sample); err != nil {
+			t.Fatalf("Invalid sample %q: %v", scanner.Text(), err)
+		}
+		recorded = append(recorded, sample)
+	}
+	if !reflect.DeepEqual(recorded, samples) {
+		t.Errorf("Recorded %+v, expected %+v", recorded, samples)
+	}
+}
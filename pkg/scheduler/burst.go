@@ -0,0 +1,72 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"time"
+)
+
+// Burst decides when a scheduling cycle runs ahead of the batch interval.
+// When only a few tasks are pending, a submission triggers an immediate
+// cycle so that interactive workloads are placed with low latency, while
+// larger backlogs keep waiting for the batch interval to amortize the
+// solver cost. The submission of a critical task always triggers an
+// immediate cycle.
+type Burst struct {
+	maxPending int
+	submitted  <-chan struct{}
+	critical   <-chan struct{}
+	numPending func() int
+}
+
+// NewBurst returns a Burst which triggers immediate cycles when at most
+// maxPending tasks are pending, or when critical receives a value. A
+// maxPending of 0 disables the burst cycles of the other tasks.
+func NewBurst(maxPending int, submitted, critical <-chan struct{}, numPending func() int) *Burst {
+	return &Burst{
+		maxPending: maxPending,
+		submitted:  submitted,
+		critical:   critical,
+		numPending: numPending,
+	}
+}
+
+// Wait blocks until the interval elapses, stopCh is closed or a burst cycle
+// should run, in which case it returns true.
+func (b *Burst) Wait(interval time.Duration, stopCh <-chan struct{}) bool {
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	submitted := b.submitted
+	if b.maxPending <= 0 {
+		// A nil channel never receives, burst cycles are disabled.
+		submitted = nil
+	}
+	for {
+		select {
+		case <-timer.C:
+			return false
+		case <-stopCh:
+			return false
+		case <-b.critical:
+			return true
+		case <-submitted:
+			if pending := b.numPending(); pending > 0 && pending <= b.maxPending {
+				return true
+			}
+		}
+	}
+}
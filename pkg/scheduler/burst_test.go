@@ -0,0 +1,95 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBurstWait(t *testing.T) {
+	var testData = []struct {
+		name       string
+		maxPending int
+		pending    int
+		submit     bool
+		expected   bool
+	}{
+		{
+			name:       "disabled",
+			maxPending: 0,
+			pending:    1,
+			submit:     true,
+			expected:   false,
+		},
+		{
+			name:       "no submission",
+			maxPending: 5,
+			pending:    1,
+			submit:     false,
+			expected:   false,
+		},
+		{
+			name:       "few pending tasks",
+			maxPending: 5,
+			pending:    5,
+			submit:     true,
+			expected:   true,
+		},
+		{
+			name:       "too many pending tasks",
+			maxPending: 5,
+			pending:    6,
+			submit:     true,
+			expected:   false,
+		},
+	}
+	for _, tc := range testData {
+		submitted := make(chan struct{}, 1)
+		pending := tc.pending
+		burst := NewBurst(tc.maxPending, submitted, nil, func() int { return pending })
+		if tc.submit {
+			submitted <- struct{}{}
+		}
+		if got := burst.Wait(50*time.Millisecond, nil); got != tc.expected {
+			t.Errorf("%s: Wait() = %v, expected %v", tc.name, got, tc.expected)
+		}
+	}
+}
+
+func TestBurstWaitStop(t *testing.T) {
+	burst := NewBurst(5, make(chan struct{}), nil, func() int { return 1 })
+	stopCh := make(chan struct{})
+	close(stopCh)
+	start := time.Now()
+	if burst.Wait(time.Minute, stopCh) {
+		t.Error("Wait() = true after stop")
+	}
+	if time.Since(start) > time.Second {
+		t.Error("Wait() did not return when stopped")
+	}
+}
+
+func TestBurstWaitCritical(t *testing.T) {
+	critical := make(chan struct{}, 1)
+	// Burst cycles are disabled and too many tasks are pending.
+	burst := NewBurst(0, make(chan struct{}), critical, func() int { return 100 })
+	critical <- struct{}{}
+	if !burst.Wait(time.Minute, nil) {
+		t.Error("Wait() = false after a critical submission")
+	}
+}
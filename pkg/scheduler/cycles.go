@@ -0,0 +1,158 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DeltaRecord is a scheduling delta of a cycle and the result of its
+// application.
+type DeltaRecord struct {
+	Type   string `json:"type"`
+	TaskID uint64 `json:"taskId"`
+	// Pod is the pod namespace/name.
+	Pod    string `json:"pod,omitempty"`
+	Node   string `json:"node,omitempty"`
+	Result string `json:"result"`
+}
+
+// Cycle is a scheduling cycle and the deltas it handled.
+type Cycle struct {
+	Start time.Time `json:"start"`
+	// Shard is the Firmament shard of the cycle, empty for the default shard.
+	Shard        string        `json:"shard,omitempty"`
+	Burst        bool          `json:"burst"`
+	SolveSeconds float64       `json:"solveSeconds"`
+	ApplySeconds float64       `json:"applySeconds"`
+	Deltas       []DeltaRecord `json:"deltas"`
+}
+
+// CycleLog keeps the deltas of the most recent scheduling cycles, so that
+// operators can see what the scheduler just did. The methods of a nil
+// CycleLog do nothing.
+type CycleLog struct {
+	// log holds the completed cycles. It is the CycleLog itself, or the log
+	// shared by the shards if the CycleLog records those of a shard.
+	log       *CycleLog
+	mu        sync.Mutex
+	cycles    []Cycle
+	maxCycles int
+	shard     string
+	// current is the cycle in progress, nil between cycles. Guarded by
+	// log.mu.
+	current *Cycle
+}
+
+// NewCycleLog returns a log of the last maxCycles cycles, nil if maxCycles
+// is not positive.
+func NewCycleLog(maxCycles int) *CycleLog {
+	if maxCycles <= 0 {
+		return nil
+	}
+	l := &CycleLog{maxCycles: maxCycles}
+	l.log = l
+	return l
+}
+
+// ForShard returns a CycleLog recording the cycles of a Firmament shard,
+// which run concurrently with those of the other shards, in the same log.
+func (l *CycleLog) ForShard(shard string) *CycleLog {
+	if l == nil {
+		return nil
+	}
+	return &CycleLog{log: l.log, shard: shard}
+}
+
+// Begin starts recording a cycle whose solver run started at start.
+func (l *CycleLog) Begin(start time.Time, burst bool) {
+	if l == nil {
+		return
+	}
+	l.log.mu.Lock()
+	defer l.log.mu.Unlock()
+	l.current = &Cycle{Start: start, Shard: l.shard, Burst: burst}
+}
+
+// Record records a delta of the cycle in progress.
+func (l *CycleLog) Record(record DeltaRecord) {
+	if l == nil {
+		return
+	}
+	l.log.mu.Lock()
+	defer l.log.mu.Unlock()
+	if l.current != nil {
+		l.current.Deltas = append(l.current.Deltas, record)
+	}
+}
+
+// End completes the cycle in progress, which spent solve in the solver and
+// apply applying the deltas.
+func (l *CycleLog) End(solve, apply time.Duration) {
+	if l == nil {
+		return
+	}
+	log := l.log
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	if l.current == nil {
+		return
+	}
+	l.current.SolveSeconds = solve.Seconds()
+	l.current.ApplySeconds = apply.Seconds()
+	log.cycles = append(log.cycles, *l.current)
+	if len(log.cycles) > log.maxCycles {
+		log.cycles = append([]Cycle(nil), log.cycles[len(log.cycles)-log.maxCycles:]...)
+	}
+	l.current = nil
+}
+
+// Cycles returns the last n completed cycles, or all of them if n is 0,
+// most recent first.
+func (l *CycleLog) Cycles(n int) []Cycle {
+	l = l.log
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if n <= 0 || n > len(l.cycles) {
+		n = len(l.cycles)
+	}
+	cycles := make([]Cycle, 0, n)
+	for i := len(l.cycles) - 1; i >= len(l.cycles)-n; i-- {
+		cycles = append(cycles, l.cycles[i])
+	}
+	return cycles
+}
+
+// ServeHTTP returns the last cycles, as many as the cycles query parameter
+// or all of the kept ones.
+func (l *CycleLog) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var n int
+	if cycles := r.URL.Query().Get("cycles"); cycles != "" {
+		var err error
+		if n, err = strconv.Atoi(cycles); err != nil {
+			http.Error(w, fmt.Sprintf("invalid cycles parameter: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(l.Cycles(n))
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCycleLog(t *testing.T) {
+	var disabled *CycleLog
+	disabled.Begin(time.Now(), false)
+	disabled.Record(DeltaRecord{})
+	disabled.End(0, 0)
+	if NewCycleLog(0) != nil {
+		t.Error("NewCycleLog(0) != nil, expected the log to be disabled")
+	}
+
+	log := NewCycleLog(2)
+	// A delta recorded between cycles is dropped.
+	log.Record(DeltaRecord{Type: "place", TaskID: 100})
+	start := time.Now().UTC().Truncate(time.Second)
+	for i := 0; i < 3; i++ {
+		log.Begin(start.Add(time.Duration(i)*time.Second), i == 2)
+		log.Record(DeltaRecord{Type: "place", TaskID: uint64(i), Pod: fmt.Sprintf("ns/pod%d", i), Node: "node0", Result: "applied"})
+		log.End(time.Second, 2*time.Second)
+	}
+	cycles := log.Cycles(0)
+	if len(cycles) != 2 || cycles[0].Deltas[0].TaskID != 2 || cycles[1].Deltas[0].TaskID != 1 {
+		t.Fatalf("Cycles(0) = %+v, expected the cycles of tasks 2 and 1", cycles)
+	}
+	if !cycles[0].Burst || cycles[0].SolveSeconds != 1 || cycles[0].ApplySeconds != 2 || len(cycles[0].Deltas) != 1 {
+		t.Errorf("Cycles(0)[0] = %+v, expected a burst cycle with 1 delta", cycles[0])
+	}
+
+	recorder := httptest.NewRecorder()
+	log.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/cycles?cycles=1", nil))
+	var served []Cycle
+	if err := json.NewDecoder(recorder.Body).Decode(&served); err != nil {
+		t.Fatalf("Failed to decode the cycles: %v", err)
+	}
+	if len(served) != 1 || served[0].Deltas[0].Pod != "ns/pod2" {
+		t.Errorf("GET /cycles?cycles=1 = %+v, expected the last cycle", served)
+	}
+	recorder = httptest.NewRecorder()
+	log.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/cycles?cycles=x", nil))
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("GET /cycles?cycles=x: code %d, expected %d", recorder.Code, http.StatusBadRequest)
+	}
+
+	// The cycles of the shards run concurrently, each recorded as its own.
+	if disabled.ForShard("a") != nil {
+		t.Error("ForShard(a) of a disabled log != nil")
+	}
+	shardA, shardB := log.ForShard("a"), log.ForShard("b")
+	shardA.Begin(start, false)
+	shardB.Begin(start, true)
+	shardA.Record(DeltaRecord{Type: "place", TaskID: 10, Result: "applied"})
+	shardB.Record(DeltaRecord{Type: "place", TaskID: 20, Result: "applied"})
+	shardB.End(time.Second, time.Second)
+	shardA.End(time.Second, time.Second)
+	cycles = log.Cycles(0)
+	if len(cycles) != 2 || cycles[0].Shard != "a" || cycles[0].Deltas[0].TaskID != 10 || cycles[1].Shard != "b" || len(cycles[1].Deltas) != 1 {
+		t.Errorf("Cycles(0) = %+v, expected the cycles of shards a and b", cycles)
+	}
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+)
+
+// DrainStatus is the drain state reported by the drain endpoint.
+type DrainStatus struct {
+	// Draining is true once a drain has been requested.
+	Draining bool `json:"draining"`
+	// Drained is true once the deltas of the last scheduling cycle are
+	// applied and no further cycle will run. It is then safe to terminate.
+	Drained bool `json:"drained"`
+}
+
+// Drain quiesces the scheduler before shutdown or upgrade. Once requested,
+// the scheduling loop finishes applying the current deltas and stops running
+// cycles.
+type Drain struct {
+	mu        sync.Mutex
+	status    DrainStatus
+	requested chan struct{}
+	// onRequest is called once when the drain is requested.
+	onRequest func()
+}
+
+// NewDrain returns a Drain which calls onRequest when a drain is requested.
+func NewDrain(onRequest func()) *Drain {
+	return &Drain{
+		requested: make(chan struct{}),
+		onRequest: onRequest,
+	}
+}
+
+// Request starts draining the scheduler. It is safe to call it several times.
+func (d *Drain) Request() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.status.Draining {
+		return
+	}
+	d.status.Draining = true
+	if d.onRequest != nil {
+		d.onRequest()
+	}
+	close(d.requested)
+}
+
+// Requested returns a channel which is closed when a drain is requested.
+func (d *Drain) Requested() <-chan struct{} {
+	return d.requested
+}
+
+// IsRequested returns true if a drain has been requested.
+func (d *Drain) IsRequested() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.status.Draining
+}
+
+// MarkDrained records that the scheduling loop has stopped.
+func (d *Drain) MarkDrained() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.status.Drained = true
+}
+
+// Status returns the current drain status.
+func (d *Drain) Status() DrainStatus {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.status
+}
+
+// ServeHTTP requests a drain on POST and reports the drain status. The
+// status code is 200 once the scheduler is drained and 503 otherwise, so
+// that it can be polled before terminating the scheduler.
+func (d *Drain) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		d.Request()
+	case http.MethodGet:
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	status := d.Status()
+	w.Header().Set("Content-Type", "application/json")
+	if status.Drained {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(status)
+}
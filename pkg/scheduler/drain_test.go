@@ -0,0 +1,59 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDrain(t *testing.T) {
+	requests := 0
+	drain := NewDrain(func() { requests++ })
+
+	recorder := httptest.NewRecorder()
+	drain.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/drain", nil))
+	if recorder.Code != http.StatusServiceUnavailable || drain.IsRequested() {
+		t.Errorf("GET before drain: code %d, requested %v", recorder.Code, drain.IsRequested())
+	}
+
+	for i := 0; i < 2; i++ {
+		recorder = httptest.NewRecorder()
+		drain.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/drain", nil))
+	}
+	if requests != 1 {
+		t.Errorf("onRequest called %d times, expected 1", requests)
+	}
+	select {
+	case <-drain.Requested():
+	default:
+		t.Error("Requested() channel is not closed")
+	}
+
+	drain.MarkDrained()
+	recorder = httptest.NewRecorder()
+	drain.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/drain", nil))
+	var status DrainStatus
+	if err := json.NewDecoder(recorder.Body).Decode(&status); err != nil {
+		t.Fatalf("Failed to decode drain status: %v", err)
+	}
+	if recorder.Code != http.StatusOK || !status.Draining || !status.Drained {
+		t.Errorf("GET after drain: code %d, status %+v", recorder.Code, status)
+	}
+}
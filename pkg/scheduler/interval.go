@@ -0,0 +1,93 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"time"
+)
+
+const (
+	// cycleWeight is the weight given to the latest cycle duration when
+	// updating the moving average.
+	cycleWeight = 0.3
+	// intervalFactor is the ratio between the time spent waiting and the
+	// time spent in a scheduling cycle. A factor of 2 keeps the scheduler
+	// busy for at most a third of the time.
+	intervalFactor = 2
+)
+
+// Interval computes the time to wait between two scheduling cycles.
+type Interval struct {
+	adaptive bool
+	current  time.Duration
+	min      time.Duration
+	max      time.Duration
+	// avgCycle is the exponentially weighted moving average of the
+	// solver plus bind durations.
+	avgCycle time.Duration
+}
+
+// NewFixedInterval returns an Interval that always waits for the given duration.
+func NewFixedInterval(interval time.Duration) *Interval {
+	return &Interval{
+		current: interval,
+		min:     interval,
+		max:     interval,
+	}
+}
+
+// NewAdaptiveInterval returns an Interval that starts at the given duration
+// and adapts to the measured cycle durations within [min, max].
+func NewAdaptiveInterval(initial, min, max time.Duration) *Interval {
+	interval := &Interval{
+		adaptive: true,
+		current:  initial,
+		min:      min,
+		max:      max,
+	}
+	interval.current = interval.clamp(initial)
+	return interval
+}
+
+// Observe records the durations of the solver run and of applying its deltas.
+func (i *Interval) Observe(solve, bind time.Duration) {
+	if !i.adaptive {
+		return
+	}
+	cycle := solve + bind
+	if i.avgCycle == 0 {
+		i.avgCycle = cycle
+	} else {
+		i.avgCycle = time.Duration(cycleWeight*float64(cycle) + (1-cycleWeight)*float64(i.avgCycle))
+	}
+	i.current = i.clamp(intervalFactor * i.avgCycle)
+}
+
+// Next returns the time to wait before the next scheduling cycle.
+func (i *Interval) Next() time.Duration {
+	return i.current
+}
+
+func (i *Interval) clamp(interval time.Duration) time.Duration {
+	if interval < i.min {
+		return i.min
+	}
+	if interval > i.max {
+		return i.max
+	}
+	return interval
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFixedInterval(t *testing.T) {
+	interval := NewFixedInterval(10 * time.Second)
+	interval.Observe(time.Minute, time.Minute)
+	if got := interval.Next(); got != 10*time.Second {
+		t.Errorf("Next() = %v, expected %v", got, 10*time.Second)
+	}
+}
+
+func TestAdaptiveInterval(t *testing.T) {
+	var testData = []struct {
+		name     string
+		initial  time.Duration
+		cycles   [][2]time.Duration
+		expected time.Duration
+	}{
+		{
+			name:     "initial interval is used before any cycle",
+			initial:  10 * time.Second,
+			expected: 10 * time.Second,
+		},
+		{
+			name:     "initial interval is clamped",
+			initial:  time.Hour,
+			expected: time.Minute,
+		},
+		{
+			name:     "fast cycles shrink the interval to the minimum",
+			initial:  10 * time.Second,
+			cycles:   [][2]time.Duration{{10 * time.Millisecond, 10 * time.Millisecond}},
+			expected: time.Second,
+		},
+		{
+			name:     "first cycle sets the average",
+			initial:  10 * time.Second,
+			cycles:   [][2]time.Duration{{2 * time.Second, time.Second}},
+			expected: 6 * time.Second,
+		},
+		{
+			name:    "later cycles are averaged",
+			initial: 10 * time.Second,
+			cycles: [][2]time.Duration{
+				{2 * time.Second, 0},
+				{12 * time.Second, 0},
+			},
+			expected: 10 * time.Second,
+		},
+		{
+			name:     "slow cycles grow the interval to the maximum",
+			initial:  10 * time.Second,
+			cycles:   [][2]time.Duration{{time.Minute, time.Minute}},
+			expected: time.Minute,
+		},
+	}
+	for _, tc := range testData {
+		interval := NewAdaptiveInterval(tc.initial, time.Second, time.Minute)
+		for _, cycle := range tc.cycles {
+			interval.Observe(cycle[0], cycle[1])
+		}
+		if got := interval.Next(); got != tc.expected {
+			t.Errorf("%s: Next() = %v, expected %v", tc.name, got, tc.expected)
+		}
+	}
+}
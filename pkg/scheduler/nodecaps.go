@@ -0,0 +1,95 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+	"github.com/kubernetes-sigs/poseidon/pkg/metrics"
+)
+
+// NodeCaps limits the number of placements and preemptions applied to a
+// single node per scheduling cycle, so that a freshly added node does not
+// receive a stampede of pods to admit and images to pull. The deltas above
+// the caps are carried over and applied first in the following cycles.
+// Firmament already accounts them, so they are delayed rather than dropped.
+type NodeCaps struct {
+	maxPlacements  int
+	maxPreemptions int
+	placements     map[string]int
+	preemptions    map[string]int
+	carriedOver    map[*firmament.SchedulingDelta]bool
+	deferred       []*firmament.SchedulingDelta
+}
+
+// NewNodeCaps returns NodeCaps allowing at most maxPlacements placements and
+// maxPreemptions preemptions or migrations per node and cycle. 0 disables
+// the corresponding cap.
+func NewNodeCaps(maxPlacements, maxPreemptions int) *NodeCaps {
+	return &NodeCaps{
+		maxPlacements:  maxPlacements,
+		maxPreemptions: maxPreemptions,
+	}
+}
+
+// Start starts a scheduling cycle. It returns the deltas carried over from
+// the previous cycles followed by the given deltas.
+func (c *NodeCaps) Start(deltas []*firmament.SchedulingDelta) []*firmament.SchedulingDelta {
+	c.placements = make(map[string]int)
+	c.preemptions = make(map[string]int)
+	c.carriedOver = make(map[*firmament.SchedulingDelta]bool, len(c.deferred))
+	for _, delta := range c.deferred {
+		c.carriedOver[delta] = true
+	}
+	all := append(c.deferred, deltas...)
+	c.deferred = nil
+	metrics.DeferredDeltas.Set(0)
+	return all
+}
+
+// IsCarriedOver returns true if the delta was deferred by a previous cycle.
+// Its task may have been removed in the meantime.
+func (c *NodeCaps) IsCarriedOver(delta *firmament.SchedulingDelta) bool {
+	return c.carriedOver[delta]
+}
+
+// Admit returns true if the delta targeting the node can be applied in the
+// current cycle. Otherwise the delta is deferred to the next cycle.
+func (c *NodeCaps) Admit(delta *firmament.SchedulingDelta, nodeName string) bool {
+	counts, max := c.placements, c.maxPlacements
+	if delta.GetType() != firmament.SchedulingDelta_PLACE {
+		counts, max = c.preemptions, c.maxPreemptions
+	}
+	if max > 0 && counts[nodeName] >= max {
+		c.deferred = append(c.deferred, delta)
+		metrics.DeferredDeltas.Set(float64(len(c.deferred)))
+		return false
+	}
+	counts[nodeName]++
+	return true
+}
+
+// NumDeferred returns the number of deltas deferred to the next cycle.
+func (c *NodeCaps) NumDeferred() int {
+	return len(c.deferred)
+}
+
+// Reset drops the deferred deltas, e.g. when Firmament lost the state they
+// were computed from.
+func (c *NodeCaps) Reset() {
+	c.deferred = nil
+	metrics.DeferredDeltas.Set(0)
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+)
+
+func TestNodeCaps(t *testing.T) {
+	place := func(taskID uint64) *firmament.SchedulingDelta {
+		return &firmament.SchedulingDelta{TaskId: taskID, Type: firmament.SchedulingDelta_PLACE}
+	}
+	preempt := func(taskID uint64) *firmament.SchedulingDelta {
+		return &firmament.SchedulingDelta{TaskId: taskID, Type: firmament.SchedulingDelta_PREEMPT}
+	}
+	var testData = []struct {
+		name           string
+		maxPlacements  int
+		maxPreemptions int
+		deltas         []*firmament.SchedulingDelta
+		nodes          []string
+		expected       []bool
+	}{
+		{
+			name:     "no caps",
+			deltas:   []*firmament.SchedulingDelta{place(1), place(2), preempt(3)},
+			nodes:    []string{"node1", "node1", "node1"},
+			expected: []bool{true, true, true},
+		},
+		{
+			name:           "placement cap per node",
+			maxPlacements:  1,
+			maxPreemptions: 0,
+			deltas:         []*firmament.SchedulingDelta{place(1), place(2), place(3), preempt(4)},
+			nodes:          []string{"node1", "node1", "node2", "node1"},
+			expected:       []bool{true, false, true, true},
+		},
+		{
+			name:           "preemption cap per node",
+			maxPlacements:  0,
+			maxPreemptions: 1,
+			deltas:         []*firmament.SchedulingDelta{preempt(1), place(2), preempt(3)},
+			nodes:          []string{"node1", "node1", "node1"},
+			expected:       []bool{true, true, false},
+		},
+	}
+	for _, tc := range testData {
+		caps := NewNodeCaps(tc.maxPlacements, tc.maxPreemptions)
+		deltas := caps.Start(tc.deltas)
+		numDeferred := 0
+		for i, delta := range deltas {
+			if caps.IsCarriedOver(delta) {
+				t.Errorf("%s: delta %d carried over in the first cycle", tc.name, i)
+			}
+			if got := caps.Admit(delta, tc.nodes[i]); got != tc.expected[i] {
+				t.Errorf("%s: Admit(delta %d) = %v, expected %v", tc.name, i, got, tc.expected[i])
+			}
+			if !tc.expected[i] {
+				numDeferred++
+			}
+		}
+		if caps.NumDeferred() != numDeferred {
+			t.Errorf("%s: NumDeferred() = %d, expected %d", tc.name, caps.NumDeferred(), numDeferred)
+		}
+	}
+}
+
+func TestNodeCapsCarryOver(t *testing.T) {
+	caps := NewNodeCaps(1, 0)
+	first := &firmament.SchedulingDelta{TaskId: 1, Type: firmament.SchedulingDelta_PLACE}
+	second := &firmament.SchedulingDelta{TaskId: 2, Type: firmament.SchedulingDelta_PLACE}
+	for _, delta := range caps.Start([]*firmament.SchedulingDelta{first, second}) {
+		caps.Admit(delta, "node1")
+	}
+	third := &firmament.SchedulingDelta{TaskId: 3, Type: firmament.SchedulingDelta_PLACE}
+	deltas := caps.Start([]*firmament.SchedulingDelta{third})
+	if len(deltas) != 2 || deltas[0] != second || deltas[1] != third {
+		t.Fatalf("Start() = %v, expected the deferred delta followed by the new one", deltas)
+	}
+	if !caps.IsCarriedOver(second) || caps.IsCarriedOver(third) {
+		t.Error("IsCarriedOver() does not report the deferred delta only")
+	}
+	if !caps.Admit(second, "node1") {
+		t.Error("Admit() deferred the carried over delta again")
+	}
+	if caps.Admit(third, "node1") {
+		t.Error("Admit() exceeded the placement cap")
+	}
+}
+
+func TestNodeCapsReset(t *testing.T) {
+	caps := NewNodeCaps(1, 0)
+	for _, delta := range caps.Start([]*firmament.SchedulingDelta{
+		{TaskId: 1, Type: firmament.SchedulingDelta_PLACE},
+		{TaskId: 2, Type: firmament.SchedulingDelta_PLACE},
+	}) {
+		caps.Admit(delta, "node1")
+	}
+	caps.Reset()
+	if deltas := caps.Start(nil); len(deltas) != 0 {
+		t.Errorf("Start() = %v after Reset(), expected no carried over delta", deltas)
+	}
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// appliedResult is the result of the recorded deltas which changed the
+// cluster.
+const appliedResult = "applied"
+
+// ReplayTarget is the cluster the recorded deltas are replayed against.
+type ReplayTarget interface {
+	// Place binds the pod, given as namespace/name, to the node.
+	Place(pod, node string) error
+	// Evict deletes the preempted or migrated pod.
+	Evict(pod string) error
+}
+
+// ReplayResult is a replayed delta and the error of its application.
+type ReplayResult struct {
+	Delta DeltaRecord
+	Err   error
+}
+
+// LoadCycles reads the cycles as served on /cycles.
+func LoadCycles(r io.Reader) ([]Cycle, error) {
+	var cycles []Cycle
+	if err := json.NewDecoder(r).Decode(&cycles); err != nil {
+		return nil, fmt.Errorf("invalid cycle log: %v", err)
+	}
+	return cycles, nil
+}
+
+// ReplayDeltas returns the deltas of the cycles which were applied to the
+// cluster, oldest cycle first and in their order within a cycle, so that a
+// replay pins the pods to the nodes they were recorded on regardless of
+// the order the cycles were saved in. Only the first maxDeltas deltas are
+// returned if maxDeltas is positive, to bisect the deltas leading to an
+// anomaly.
+func ReplayDeltas(cycles []Cycle, maxDeltas int) []DeltaRecord {
+	ordered := append([]Cycle(nil), cycles...)
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].Start.Before(ordered[j].Start) })
+	var deltas []DeltaRecord
+	for _, cycle := range ordered {
+		for _, delta := range cycle.Deltas {
+			if delta.Result != appliedResult || delta.Pod == "" {
+				continue
+			}
+			if maxDeltas > 0 && len(deltas) == maxDeltas {
+				return deltas
+			}
+			deltas = append(deltas, delta)
+		}
+	}
+	return deltas
+}
+
+// Replay applies the deltas to the target in order. A failed delta does not
+// stop the replay, as the following deltas may not depend on it.
+func Replay(target ReplayTarget, deltas []DeltaRecord) []ReplayResult {
+	results := make([]ReplayResult, 0, len(deltas))
+	for _, delta := range deltas {
+		var err error
+		switch delta.Type {
+		case "place":
+			err = target.Place(delta.Pod, delta.Node)
+		case "preempt", "migrate":
+			err = target.Evict(delta.Pod)
+		default:
+			err = fmt.Errorf("unexpected delta type %q", delta.Type)
+		}
+		results = append(results, ReplayResult{Delta: delta, Err: err})
+	}
+	return results
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeReplayTarget records the replayed operations.
+type fakeReplayTarget struct {
+	operations []string
+	failPod    string
+}
+
+func (t *fakeReplayTarget) Place(pod, node string) error {
+	t.operations = append(t.operations, "place "+pod+" "+node)
+	if pod == t.failPod {
+		return errors.New("bind failed")
+	}
+	return nil
+}
+
+func (t *fakeReplayTarget) Evict(pod string) error {
+	t.operations = append(t.operations, "evict "+pod)
+	return nil
+}
+
+func TestReplay(t *testing.T) {
+	// The cycles are saved most recent first.
+	served := `[
+		{"start": "2018-06-01T10:00:10Z", "deltas": [
+			{"type": "preempt", "taskId": 3, "pod": "ns/pod3", "node": "node1", "result": "applied"},
+			{"type": "place", "taskId": 4, "pod": "ns/pod4", "node": "node1", "result": "applied"}
+		]},
+		{"start": "2018-06-01T10:00:00Z", "deltas": [
+			{"type": "place", "taskId": 1, "pod": "ns/pod1", "node": "node0", "result": "applied"},
+			{"type": "place", "taskId": 2, "pod": "ns/pod2", "node": "node0", "result": "deferred"},
+			{"type": "place", "taskId": 5, "pod": "ns/pod5", "node": "node0", "result": "applied"}
+		]}
+	]`
+	cycles, err := LoadCycles(strings.NewReader(served))
+	if err != nil {
+		t.Fatalf("LoadCycles() failed: %v", err)
+	}
+	if !cycles[1].Start.Equal(time.Date(2018, 6, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("LoadCycles() = %+v", cycles)
+	}
+	if _, err := LoadCycles(strings.NewReader("{")); err == nil {
+		t.Error("LoadCycles() of an invalid log succeeded")
+	}
+
+	if deltas := ReplayDeltas(cycles, 2); len(deltas) != 2 || deltas[1].TaskID != 5 {
+		t.Errorf("ReplayDeltas(2) = %+v, expected the deltas of tasks 1 and 5", deltas)
+	}
+	target := &fakeReplayTarget{failPod: "ns/pod5"}
+	results := Replay(target, ReplayDeltas(cycles, 0))
+	expected := []string{"place ns/pod1 node0", "place ns/pod5 node0", "evict ns/pod3", "place ns/pod4 node1"}
+	if !reflect.DeepEqual(target.operations, expected) {
+		t.Errorf("Replay() applied %v, expected %v", target.operations, expected)
+	}
+	for i, result := range results {
+		if (result.Err != nil) != (i == 1) {
+			t.Errorf("Replay() result %d = %+v", i, result)
+		}
+	}
+}
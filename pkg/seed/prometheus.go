@@ -0,0 +1,155 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package seed
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/k8sclient"
+)
+
+// PrometheusQueries are the PromQL queries of the historical usage. The
+// node queries must return an instant vector labeled with node, and the pod
+// queries one labeled with namespace and pod.
+type PrometheusQueries struct {
+	// NodeCPUUtilization and NodeMemUtilization are in fractions of the
+	// capacity.
+	NodeCPUUtilization string
+	NodeMemUtilization string
+	// PodCPUUsage is in millicores and PodMemUsage in KB.
+	PodCPUUsage string
+	PodMemUsage string
+}
+
+// DefaultPrometheusQueries average the usage over the last day, from the
+// node exporter metrics labeled with their node and the cAdvisor metrics of
+// the kubelets.
+var DefaultPrometheusQueries = PrometheusQueries{
+	NodeCPUUtilization: `1 - avg by (node) (rate(node_cpu_seconds_total{mode="idle"}[1d]))`,
+	NodeMemUtilization: `1 - avg by (node) (avg_over_time(node_memory_MemAvailable_bytes[1d]) / node_memory_MemTotal_bytes)`,
+	PodCPUUsage:        `sum by (namespace, pod) (rate(container_cpu_usage_seconds_total{container!=""}[1d])) * 1000`,
+	PodMemUsage:        `sum by (namespace, pod) (avg_over_time(container_memory_working_set_bytes{container!=""}[1d])) / 1024`,
+}
+
+// prometheusResponse is the response of the Prometheus instant query API.
+type prometheusResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Metric map[string]string `json:"metric"`
+			// Value is the timestamp and the value as a string.
+			Value [2]interface{} `json:"value"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// QueryPrometheus reads a seed from the Prometheus server at address. The
+// nodes and pods missing from one of their two queries are skipped.
+func QueryPrometheus(address string, queries PrometheusQueries, timeout time.Duration) (*Seed, error) {
+	client := &http.Client{Timeout: timeout}
+	query := func(promQL string, labels ...string) (map[string]float64, error) {
+		values, err := queryPrometheus(client, address, promQL, labels)
+		if err != nil {
+			return nil, fmt.Errorf("query %q: %v", promQL, err)
+		}
+		return values, nil
+	}
+	nodeCPU, err := query(queries.NodeCPUUtilization, "node")
+	if err != nil {
+		return nil, err
+	}
+	nodeMem, err := query(queries.NodeMemUtilization, "node")
+	if err != nil {
+		return nil, err
+	}
+	podCPU, err := query(queries.PodCPUUsage, "namespace", "pod")
+	if err != nil {
+		return nil, err
+	}
+	podMem, err := query(queries.PodMemUsage, "namespace", "pod")
+	if err != nil {
+		return nil, err
+	}
+	seed := &Seed{}
+	for node, cpu := range nodeCPU {
+		if mem, ok := nodeMem[node]; ok {
+			seed.Nodes = append(seed.Nodes, NodeUsage{Node: node, CPUUtilization: cpu, MemUtilization: mem})
+		}
+	}
+	for pod, cpu := range podCPU {
+		mem, ok := podMem[pod]
+		if !ok {
+			continue
+		}
+		parts := strings.SplitN(pod, "/", 2)
+		seed.Pods = append(seed.Pods, PodUsage{
+			Pod:        k8sclient.PodIdentifier{Namespace: parts[0], Name: parts[1]},
+			CPUUsage:   int64(cpu),
+			MemUsageKb: int64(mem),
+		})
+	}
+	return seed, nil
+}
+
+// queryPrometheus returns the values of the instant query by the values of
+// the given labels, joined with slashes.
+func queryPrometheus(client *http.Client, address, promQL string, labels []string) (map[string]float64, error) {
+	resp, err := client.Get(strings.TrimSuffix(address, "/") + "/api/v1/query?query=" + url.QueryEscape(promQL))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	response := &prometheusResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return nil, fmt.Errorf("invalid response with status %s: %v", resp.Status, err)
+	}
+	if response.Status != "success" {
+		return nil, fmt.Errorf("%s: %s", response.Status, response.Error)
+	}
+	if response.Data.ResultType != "vector" {
+		return nil, fmt.Errorf("result of type %s, expected a vector", response.Data.ResultType)
+	}
+	values := make(map[string]float64, len(response.Data.Result))
+	for _, sample := range response.Data.Result {
+		keys := make([]string, 0, len(labels))
+		for _, label := range labels {
+			if sample.Metric[label] == "" {
+				return nil, fmt.Errorf("sample %v has no %s label", sample.Metric, label)
+			}
+			keys = append(keys, sample.Metric[label])
+		}
+		text, _ := sample.Value[1].(string)
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of sample %v: %v", sample.Metric, err)
+		}
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			continue
+		}
+		values[strings.Join(keys, "/")] = value
+	}
+	return values, nil
+}
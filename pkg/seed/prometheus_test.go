@@ -0,0 +1,67 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package seed
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/k8sclient"
+)
+
+func TestQueryPrometheus(t *testing.T) {
+	queries := PrometheusQueries{NodeCPUUtilization: "node_cpu", NodeMemUtilization: "node_mem", PodCPUUsage: "pod_cpu", PodMemUsage: "pod_mem"}
+	results := map[string]string{
+		"node_cpu": `{"metric":{"node":"node0"},"value":[1,"0.5"]},{"metric":{"node":"node1"},"value":[1,"0.75"]}`,
+		// node1 has no memory utilization and node2 no CPU one.
+		"node_mem": `{"metric":{"node":"node0"},"value":[1,"0.25"]},{"metric":{"node":"node2"},"value":[1,"0.25"]}`,
+		"pod_cpu":  `{"metric":{"namespace":"default","pod":"pod0"},"value":[1,"250.4"]},{"metric":{"namespace":"default","pod":"pod1"},"value":[1,"NaN"]}`,
+		"pod_mem":  `{"metric":{"namespace":"default","pod":"pod0"},"value":[1,"1024"]},{"metric":{"namespace":"default","pod":"pod1"},"value":[1,"1024"]}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result, ok := results[r.URL.Query().Get("query")]
+		if r.URL.Path != "/api/v1/query" || !ok {
+			fmt.Fprint(w, `{"status":"error","error":"unknown query"}`)
+			return
+		}
+		fmt.Fprintf(w, `{"status":"success","data":{"resultType":"vector","result":[%s]}}`, result)
+	}))
+	defer server.Close()
+
+	seed, err := QueryPrometheus(server.URL+"/", queries, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(seed.Pods, func(i, j int) bool { return seed.Pods[i].Pod.Name < seed.Pods[j].Pod.Name })
+	expected := &Seed{
+		Nodes: []NodeUsage{{Node: "node0", CPUUtilization: 0.5, MemUtilization: 0.25}},
+		Pods:  []PodUsage{{Pod: k8sclient.PodIdentifier{Namespace: "default", Name: "pod0"}, CPUUsage: 250, MemUsageKb: 1024}},
+	}
+	if !reflect.DeepEqual(seed, expected) {
+		t.Errorf("QueryPrometheus() = %+v, expected %+v", seed, expected)
+	}
+
+	queries.PodMemUsage = "unknown"
+	if _, err := QueryPrometheus(server.URL, queries, time.Second); err == nil {
+		t.Error("QueryPrometheus() succeeded with a failing query")
+	}
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package seed
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+	"github.com/kubernetes-sigs/poseidon/pkg/k8sclient"
+	"golang.org/x/net/context"
+)
+
+// NodeUsage is the historical utilization of a node, in fractions of its
+// capacity.
+type NodeUsage struct {
+	Node           string
+	CPUUtilization float64
+	MemUtilization float64
+}
+
+// PodUsage is the historical usage of a pod, CPU in millicores and memory in
+// KB.
+type PodUsage struct {
+	Pod        k8sclient.PodIdentifier
+	CPUUsage   int64
+	MemUsageKb int64
+}
+
+// Seed is the historical usage loaded into the knowledge base of Firmament
+// at startup, so that its cost models do not start cold.
+type Seed struct {
+	Nodes []NodeUsage
+	Pods  []PodUsage
+}
+
+// Merge appends the usages of another seed.
+func (s *Seed) Merge(other *Seed) {
+	s.Nodes = append(s.Nodes, other.Nodes...)
+	s.Pods = append(s.Pods, other.Pods...)
+}
+
+// LoadCSV reads a seed from a CSV file whose records are either
+// node,<name>,<CPU utilization>,<memory utilization> with the utilizations
+// in fractions of the capacity, or pod,<namespace>/<name>,<CPU usage in
+// millicores>,<memory usage in KB>. The lines starting with # are ignored.
+func LoadCSV(path string) (*Seed, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return readCSV(file)
+}
+
+func readCSV(r io.Reader) (*Seed, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = 4
+	reader.TrimLeadingSpace = true
+	seed := &Seed{}
+	for n := 1; ; n++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return seed, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch record[0] {
+		case "node":
+			cpu, cpuErr := strconv.ParseFloat(record[2], 64)
+			mem, memErr := strconv.ParseFloat(record[3], 64)
+			if cpuErr != nil || memErr != nil || cpu < 0 || cpu > 1 || mem < 0 || mem > 1 {
+				return nil, fmt.Errorf("record %d: invalid utilizations %q and %q, expected fractions of the capacity", n, record[2], record[3])
+			}
+			seed.Nodes = append(seed.Nodes, NodeUsage{Node: record[1], CPUUtilization: cpu, MemUtilization: mem})
+		case "pod":
+			parts := strings.SplitN(record[1], "/", 2)
+			if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+				return nil, fmt.Errorf("record %d: invalid pod %q, expected <namespace>/<name>", n, record[1])
+			}
+			cpu, cpuErr := strconv.ParseInt(record[2], 10, 64)
+			mem, memErr := strconv.ParseInt(record[3], 10, 64)
+			if cpuErr != nil || memErr != nil || cpu < 0 || mem < 0 {
+				return nil, fmt.Errorf("record %d: invalid usages %q and %q, expected millicores and KB", n, record[2], record[3])
+			}
+			seed.Pods = append(seed.Pods, PodUsage{
+				Pod:        k8sclient.PodIdentifier{Namespace: parts[0], Name: parts[1]},
+				CPUUsage:   cpu,
+				MemUsageKb: mem,
+			})
+		default:
+			return nil, fmt.Errorf("record %d: unknown record type %q, expected node or pod", n, record[0])
+		}
+	}
+}
+
+// Apply sends the usages of the nodes and pods known to Firmament as their
+// stats, and returns the number of nodes and pods seeded. The usages of the
+// unknown nodes and pods are skipped.
+func Apply(fc firmament.FirmamentSchedulerClient, state k8sclient.StateStore, seed *Seed) (int, int) {
+	timestamp := uint64(time.Now().UnixNano() / int64(time.Microsecond))
+	var nodes, pods int
+	for _, usage := range seed.Nodes {
+		rtnd, ok := state.NodeTopology(usage.Node)
+		if !ok {
+			glog.V(2).Infof("Not seeding the usage of unknown node %s", usage.Node)
+			continue
+		}
+		capacity := rtnd.GetResourceDesc().GetResourceCapacity()
+		_, err := fc.AddNodeStats(context.Background(), &firmament.ResourceStats{
+			ResourceId: rtnd.GetResourceDesc().GetUuid(),
+			Timestamp:  timestamp,
+			CpusStats: []*firmament.CpuStats{{
+				CpuCapacity:    int64(capacity.GetCpuCores()),
+				CpuUtilization: usage.CPUUtilization,
+			}},
+			MemCapacity:    int64(capacity.GetRamCap()),
+			MemUtilization: usage.MemUtilization,
+		})
+		if err != nil {
+			glog.Warningf("Failed to seed the usage of node %s: %v", usage.Node, err)
+			continue
+		}
+		nodes++
+	}
+	for _, usage := range seed.Pods {
+		td, ok := state.TaskOfPod(usage.Pod)
+		if !ok {
+			glog.V(2).Infof("Not seeding the usage of unknown pod %v", usage.Pod)
+			continue
+		}
+		request := td.GetResourceRequest()
+		_, err := fc.AddTaskStats(context.Background(), &firmament.TaskStats{
+			TaskId:     td.GetUid(),
+			Timestamp:  timestamp,
+			CpuRequest: int64(request.GetCpuCores()),
+			CpuUsage:   usage.CPUUsage,
+			MemRequest: int64(request.GetRamCap()),
+			MemUsage:   usage.MemUsageKb,
+		})
+		if err != nil {
+			glog.Warningf("Failed to seed the usage of pod %v: %v", usage.Pod, err)
+			continue
+		}
+		pods++
+	}
+	return nodes, pods
+}
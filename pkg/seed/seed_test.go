@@ -0,0 +1,122 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package seed
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+	"github.com/kubernetes-sigs/poseidon/pkg/k8sclient"
+)
+
+func TestReadCSV(t *testing.T) {
+	seed, err := readCSV(strings.NewReader(`# kind,name,cpu,memory
+node,node0,0.5,0.25
+pod,default/pod0,250,1024
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &Seed{
+		Nodes: []NodeUsage{{Node: "node0", CPUUtilization: 0.5, MemUtilization: 0.25}},
+		Pods:  []PodUsage{{Pod: k8sclient.PodIdentifier{Namespace: "default", Name: "pod0"}, CPUUsage: 250, MemUsageKb: 1024}},
+	}
+	if !reflect.DeepEqual(seed, expected) {
+		t.Errorf("readCSV() = %+v, expected %+v", seed, expected)
+	}
+	for _, invalid := range []string{
+		"node,node0,1.5,0.25",
+		"node,node0,high,0.25",
+		"pod,pod0,250,1024",
+		"pod,default/pod0,-1,1024",
+		"job,job0,1,1",
+		"node,node0,0.5",
+	} {
+		if _, err := readCSV(strings.NewReader(invalid)); err == nil {
+			t.Errorf("readCSV(%q) accepted an invalid record", invalid)
+		}
+	}
+}
+
+// fakeState is a StateStore of the given nodes and tasks.
+type fakeState struct {
+	k8sclient.StateStore
+	nodes map[string]*firmament.ResourceTopologyNodeDescriptor
+	tasks map[k8sclient.PodIdentifier]*firmament.TaskDescriptor
+}
+
+func (s *fakeState) NodeTopology(nodeName string) (*firmament.ResourceTopologyNodeDescriptor, bool) {
+	rtnd, ok := s.nodes[nodeName]
+	return rtnd, ok
+}
+
+func (s *fakeState) TaskOfPod(podIdentifier k8sclient.PodIdentifier) (*firmament.TaskDescriptor, bool) {
+	td, ok := s.tasks[podIdentifier]
+	return td, ok
+}
+
+func TestApply(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	fc := firmament.NewMockFirmamentSchedulerClient(mockCtrl)
+	pod := k8sclient.PodIdentifier{Namespace: "default", Name: "pod0"}
+	state := &fakeState{
+		nodes: map[string]*firmament.ResourceTopologyNodeDescriptor{
+			"node0": {ResourceDesc: &firmament.ResourceDescriptor{
+				Uuid:             "uuid0",
+				ResourceCapacity: &firmament.ResourceVector{CpuCores: 4000, RamCap: 8192},
+			}},
+			"node1": {ResourceDesc: &firmament.ResourceDescriptor{Uuid: "uuid1"}},
+		},
+		tasks: map[k8sclient.PodIdentifier]*firmament.TaskDescriptor{
+			pod: {Uid: 1, ResourceRequest: &firmament.ResourceVector{CpuCores: 500, RamCap: 2048}},
+		},
+	}
+	seed := &Seed{
+		Nodes: []NodeUsage{
+			{Node: "node0", CPUUtilization: 0.5, MemUtilization: 0.25},
+			{Node: "node1", CPUUtilization: 0.5, MemUtilization: 0.25},
+			{Node: "unknown", CPUUtilization: 0.5, MemUtilization: 0.25},
+		},
+		Pods: []PodUsage{
+			{Pod: pod, CPUUsage: 250, MemUsageKb: 1024},
+			{Pod: k8sclient.PodIdentifier{Namespace: "default", Name: "unknown"}, CPUUsage: 250, MemUsageKb: 1024},
+		},
+	}
+	gomock.InOrder(
+		fc.EXPECT().AddNodeStats(gomock.Any(), gomock.Any()).Do(func(_ interface{}, stats *firmament.ResourceStats) {
+			if stats.GetResourceId() != "uuid0" || stats.GetMemCapacity() != 8192 || stats.GetMemUtilization() != 0.25 ||
+				stats.GetCpusStats()[0].GetCpuUtilization() != 0.5 {
+				t.Errorf("AddNodeStats(%v), expected the usage of node0", stats)
+			}
+		}).Return(&firmament.ResourceStatsResponse{}, nil),
+		// The nodes whose stats Firmament fails to add are not seeded.
+		fc.EXPECT().AddNodeStats(gomock.Any(), gomock.Any()).Return(nil, errors.New("unavailable")),
+		fc.EXPECT().AddTaskStats(gomock.Any(), gomock.Any()).Do(func(_ interface{}, stats *firmament.TaskStats) {
+			if stats.GetTaskId() != 1 || stats.GetCpuUsage() != 250 || stats.GetMemUsage() != 1024 || stats.GetCpuRequest() != 500 {
+				t.Errorf("AddTaskStats(%v), expected the usage of pod0", stats)
+			}
+		}).Return(&firmament.TaskStatsResponse{}, nil),
+	)
+	if nodes, pods := Apply(fc, state, seed); nodes != 1 || pods != 1 {
+		t.Errorf("Apply() = %d, %d, expected 1 node and 1 pod seeded", nodes, pods)
+	}
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Authentication is the way the senders of stats are authenticated.
+type Authentication string
+
+const (
+	// NoAuthentication accepts the stats of any sender.
+	NoAuthentication Authentication = ""
+	// TLSAuthentication authenticates the senders by their client
+	// certificate, verified against the client CA of the stats server.
+	TLSAuthentication Authentication = "tls"
+	// TokenAuthentication authenticates the senders by the bearer token of
+	// their requests, reviewed by the API server.
+	TokenAuthentication Authentication = "token"
+)
+
+// nodeUserPrefix is the prefix of the user names of the nodes.
+const nodeUserPrefix = "system:node:"
+
+// authenticator returns the identity of the sender of a stream.
+type authenticator interface {
+	authenticate(ctx context.Context) (string, error)
+}
+
+// tlsAuthenticator authenticates the senders by the common name of their
+// verified client certificate, e.g. system:node:<node name> for the
+// kubelet client certificates.
+type tlsAuthenticator struct{}
+
+func (tlsAuthenticator) authenticate(ctx context.Context) (string, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", fmt.Errorf("no peer")
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return "", fmt.Errorf("no verified client certificate")
+	}
+	return tlsInfo.State.VerifiedChains[0][0].Subject.CommonName, nil
+}
+
+// tokenAuthenticator authenticates the senders by their bearer token, with
+// a TokenReview.
+type tokenAuthenticator struct {
+	client kubernetes.Interface
+}
+
+func (a *tokenAuthenticator) authenticate(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok || len(md["authorization"]) == 0 {
+		return "", fmt.Errorf("no bearer token")
+	}
+	token := strings.TrimPrefix(md["authorization"][0], "Bearer ")
+	review, err := a.client.AuthenticationV1().TokenReviews().Create(&authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token},
+	})
+	if err != nil {
+		return "", fmt.Errorf("token review failed: %v", err)
+	}
+	if !review.Status.Authenticated {
+		return "", fmt.Errorf("invalid bearer token: %s", review.Status.Error)
+	}
+	return review.Status.User.Username, nil
+}
+
+// sender is an authenticated sender of stats.
+type sender struct {
+	identity string
+	// node is the name of the node the sender is, empty if it is trusted
+	// to send the stats of any node.
+	node string
+}
+
+// mayReport returns whether the sender may report the stats of the node.
+func (s *sender) mayReport(nodeName string) bool {
+	return s == nil || s.node == "" || s.node == nodeName
+}
+
+// authenticateSender authenticates the sender of a stream. The nodes may
+// only report their own stats, and the trusted identities, e.g. the service
+// account of a cluster wide metrics pipeline, the stats of any node. It
+// returns a nil sender if the senders are not authenticated.
+func (s *poseidonStatsServer) authenticateSender(ctx context.Context) (*sender, error) {
+	if s.authenticator == nil {
+		return nil, nil
+	}
+	identity, err := s.authenticator.authenticate(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "%v", err)
+	}
+	if s.trustedIdentities[identity] {
+		return &sender{identity: identity}, nil
+	}
+	if strings.HasPrefix(identity, nodeUserPrefix) && len(identity) > len(nodeUserPrefix) {
+		return &sender{identity: identity, node: strings.TrimPrefix(identity, nodeUserPrefix)}, nil
+	}
+	return nil, status.Errorf(codes.PermissionDenied, "%s is neither a node nor a trusted identity", identity)
+}
+
+// serverTLSConfig returns the TLS config of the stats server, which
+// requires and verifies the client certificates if clientCAFile is set.
+func serverTLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{Certificates: []tls.Certificate{cert}}
+	if clientCAFile != "" {
+		data, err := ioutil.ReadFile(clientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		config.ClientCAs = x509.NewCertPool()
+		if !config.ClientCAs.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificate in %s", clientCAFile)
+		}
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return config, nil
+}
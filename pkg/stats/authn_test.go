@@ -0,0 +1,152 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+	"github.com/kubernetes-sigs/poseidon/pkg/k8sclient"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+)
+
+// fixedAuthenticator authenticates every sender as identity, or fails.
+type fixedAuthenticator struct {
+	identity string
+	err      error
+}
+
+func (a fixedAuthenticator) authenticate(ctx context.Context) (string, error) {
+	return a.identity, a.err
+}
+
+func TestAuthenticateSender(t *testing.T) {
+	var testData = []struct {
+		name     string
+		auth     authenticator
+		code     codes.Code
+		mayNode0 bool
+		mayNode1 bool
+	}{
+		{name: "not authenticated", mayNode0: true, mayNode1: true},
+		{name: "node", auth: fixedAuthenticator{identity: "system:node:node0"}, mayNode0: true},
+		{name: "trusted", auth: fixedAuthenticator{identity: "system:serviceaccount:kube-system:heapster"}, mayNode0: true, mayNode1: true},
+		{name: "untrusted", auth: fixedAuthenticator{identity: "system:serviceaccount:default:default"}, code: codes.PermissionDenied},
+		{name: "failed", auth: fixedAuthenticator{err: fmt.Errorf("no bearer token")}, code: codes.Unauthenticated},
+	}
+	for _, tc := range testData {
+		server := &poseidonStatsServer{
+			authenticator:     tc.auth,
+			trustedIdentities: map[string]bool{"system:serviceaccount:kube-system:heapster": true},
+		}
+		sender, err := server.authenticateSender(context.Background())
+		if status.Code(err) != tc.code {
+			t.Errorf("%s: authenticateSender() = %v, expected code %v", tc.name, err, tc.code)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if sender.mayReport("node0") != tc.mayNode0 || sender.mayReport("node1") != tc.mayNode1 {
+			t.Errorf("%s: sender %+v may report node0 %v and node1 %v, expected %v and %v", tc.name, sender,
+				sender.mayReport("node0"), sender.mayReport("node1"), tc.mayNode0, tc.mayNode1)
+		}
+	}
+}
+
+func TestTLSAuthenticator(t *testing.T) {
+	if _, err := (tlsAuthenticator{}).authenticate(context.Background()); err == nil {
+		t.Error("authenticate() without peer succeeded")
+	}
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "system:node:node0", Organization: []string{"system:nodes"}}}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}},
+	})
+	if identity, err := (tlsAuthenticator{}).authenticate(ctx); err != nil || identity != "system:node:node0" {
+		t.Errorf("authenticate() = %s, %v, expected system:node:node0", identity, err)
+	}
+}
+
+func TestTokenAuthenticator(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "tokenreviews", func(action core.Action) (bool, runtime.Object, error) {
+		review := action.(core.CreateAction).GetObject().(*authenticationv1.TokenReview)
+		if review.Spec.Token == "node0-token" {
+			review.Status.Authenticated = true
+			review.Status.User.Username = "system:node:node0"
+		}
+		return true, review, nil
+	})
+	authenticator := &tokenAuthenticator{client: client}
+	var testData = []struct {
+		name     string
+		md       metadata.MD
+		identity string
+	}{
+		{name: "valid token", md: metadata.Pairs("authorization", "Bearer node0-token"), identity: "system:node:node0"},
+		{name: "invalid token", md: metadata.Pairs("authorization", "Bearer spoofed")},
+		{name: "no token", md: metadata.MD{}},
+	}
+	for _, tc := range testData {
+		identity, err := authenticator.authenticate(metadata.NewIncomingContext(context.Background(), tc.md))
+		if identity != tc.identity || (err == nil) != (tc.identity != "") {
+			t.Errorf("%s: authenticate() = %q, %v, expected %q", tc.name, identity, err, tc.identity)
+		}
+	}
+}
+
+// fakeState is a StateStore knowing only the given nodes.
+type fakeState struct {
+	k8sclient.StateStore
+	nodes map[string]*firmament.ResourceTopologyNodeDescriptor
+}
+
+func (s fakeState) NodeTopology(nodeName string) (*firmament.ResourceTopologyNodeDescriptor, bool) {
+	rtnd, ok := s.nodes[nodeName]
+	return rtnd, ok
+}
+
+func TestReceiveNodeStatsRejectsOtherNodes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	state := fakeState{nodes: map[string]*firmament.ResourceTopologyNodeDescriptor{
+		"node1": BuildFirmamentResourceDescriptor("uuid1", "node1", 1000, 1024, "pu1", "node1_PU #0"),
+	}}
+	stream := NewMockPoseidonStats_ReceiveNodeStatsServer(ctrl)
+	stream.EXPECT().Context().Return(context.Background())
+	gomock.InOrder(
+		stream.EXPECT().Recv().Return(&NodeStats{Hostname: "node1"}, nil),
+		stream.EXPECT().Recv().Return(nil, fmt.Errorf("closed")),
+	)
+	stream.EXPECT().Send(&NodeStatsResponse{Type: NodeStatsResponseType_NODE_STATS_REJECTED, Hostname: "node1"})
+	server := &poseidonStatsServer{state: state, authenticator: fixedAuthenticator{identity: "system:node:node0"}}
+	server.ReceiveNodeStats(stream)
+}
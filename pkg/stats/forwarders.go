@@ -0,0 +1,126 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"hash/fnv"
+	"sync"
+
+	"github.com/golang/glog"
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+)
+
+// forwarderQueueLength is the number of stats buffered per forwarder.
+// Receiving streams block once the forwarder owning their stats is full.
+const forwarderQueueLength = 1024
+
+// forwarding is the stats of a node or pod waiting for the forwarder owning
+// them to forward them to Firmament.
+type forwarding struct {
+	stats interface{}
+	// done is closed once the stats are forwarded.
+	done chan struct{}
+}
+
+// statsForwarders shard the ingestion of the stats server within the
+// Poseidon process. Each forwarder sends the stats it owns to Firmament
+// through its own connection. The nodes and pods are owned by a forwarder by
+// hash, so that the stats of a node or pod reach Firmament in order whichever
+// listener of the stats server the sender is connected to.
+type statsForwarders struct {
+	// mu is held for writing once closed, and for reading by the stats
+	// being handed over.
+	mu     sync.RWMutex
+	closed bool
+	queues []chan forwarding
+	// forwarders is the number of forwarders still running.
+	forwarders sync.WaitGroup
+}
+
+// newStatsForwarders starts a forwarder per Firmament client.
+func newStatsForwarders(clients []firmament.FirmamentSchedulerClient) *statsForwarders {
+	r := &statsForwarders{}
+	for _, client := range clients {
+		queue := make(chan forwarding, forwarderQueueLength)
+		r.queues = append(r.queues, queue)
+		r.forwarders.Add(1)
+		go func(client firmament.FirmamentSchedulerClient) {
+			defer r.forwarders.Done()
+			forwardStats(client, queue)
+		}(client)
+	}
+	return r
+}
+
+func forwardStats(client firmament.FirmamentSchedulerClient, queue <-chan forwarding) {
+	for f := range queue {
+		switch stats := f.stats.(type) {
+		case *firmament.ResourceStats:
+			if err := firmament.AddNodeStats(client, stats); err != nil {
+				glog.Warningf("Failed to forward the stats of resource %s: %v", stats.GetResourceId(), err)
+			}
+		case *firmament.TaskStats:
+			if err := firmament.AddTaskStats(client, stats); err != nil {
+				glog.Warningf("Failed to forward the stats of task %d: %v", stats.GetTaskId(), err)
+			}
+		}
+		close(f.done)
+	}
+}
+
+// owner returns the index of the forwarder owning the key.
+func (r *statsForwarders) owner(key string) int {
+	hash := fnv.New32a()
+	hash.Write([]byte(key))
+	return int(hash.Sum32() % uint32(len(r.queues)))
+}
+
+// forward hands the stats over to the forwarder owning the key, and returns
+// once they are forwarded to Firmament. The stats received once the
+// forwarders are closed are dropped.
+func (r *statsForwarders) forward(key string, stats interface{}) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.closed {
+		return
+	}
+	f := forwarding{stats: stats, done: make(chan struct{})}
+	r.queues[r.owner(key)] <- f
+	<-f.done
+}
+
+// addNodeStats forwards the stats of the node with the given hostname.
+func (r *statsForwarders) addNodeStats(hostname string, resourceStats *firmament.ResourceStats) {
+	r.forward(hostname, resourceStats)
+}
+
+// addTaskStats forwards the stats of the pod with the given namespace/name.
+func (r *statsForwarders) addTaskStats(pod string, taskStats *firmament.TaskStats) {
+	r.forward(pod, taskStats)
+}
+
+// close stops the forwarders once the stats being handed over are
+// forwarded.
+func (r *statsForwarders) close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	for _, queue := range r.queues {
+		close(queue)
+	}
+	r.forwarders.Wait()
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// recordingClient records the stats it receives.
+type recordingClient struct {
+	firmament.FirmamentSchedulerClient
+	mu        sync.Mutex
+	resources []string
+	tasks     []uint64
+}
+
+func (c *recordingClient) AddNodeStats(ctx context.Context, in *firmament.ResourceStats, opts ...grpc.CallOption) (*firmament.ResourceStatsResponse, error) {
+	c.mu.Lock()
+	c.resources = append(c.resources, in.GetResourceId())
+	c.mu.Unlock()
+	return &firmament.ResourceStatsResponse{}, nil
+}
+
+func (c *recordingClient) AddTaskStats(ctx context.Context, in *firmament.TaskStats, opts ...grpc.CallOption) (*firmament.TaskStatsResponse, error) {
+	c.mu.Lock()
+	c.tasks = append(c.tasks, in.GetTaskId())
+	c.mu.Unlock()
+	return &firmament.TaskStatsResponse{}, nil
+}
+
+func (c *recordingClient) forwarded() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.resources) + len(c.tasks)
+}
+
+func TestStatsForwarders(t *testing.T) {
+	clients := []*recordingClient{{}, {}, {}}
+	forwarders := newStatsForwarders([]firmament.FirmamentSchedulerClient{clients[0], clients[1], clients[2]})
+	hostnames := []string{"node0", "node1", "node2", "node3", "node4"}
+	const rounds = 3
+	var senders sync.WaitGroup
+	for _, hostname := range hostnames {
+		senders.Add(1)
+		go func(hostname string) {
+			defer senders.Done()
+			for round := 0; round < rounds; round++ {
+				forwarded := clients[forwarders.owner(hostname)].forwarded()
+				forwarders.addNodeStats(hostname, &firmament.ResourceStats{ResourceId: hostname})
+				if clients[forwarders.owner(hostname)].forwarded() <= forwarded {
+					t.Errorf("addNodeStats(%s) returned before the stats were forwarded", hostname)
+				}
+				forwarders.addTaskStats("default/"+hostname, &firmament.TaskStats{TaskId: uint64(round)})
+			}
+		}(hostname)
+	}
+	senders.Wait()
+	forwarders.close()
+	// The stats of a node or pod must always go through the same forwarder.
+	owners := make(map[string]int)
+	total := 0
+	for index, client := range clients {
+		for _, resourceID := range client.resources {
+			if owner, ok := owners[resourceID]; ok && owner != index {
+				t.Errorf("stats of %s forwarded by forwarders %d and %d", resourceID, owner, index)
+			}
+			owners[resourceID] = index
+		}
+		total += client.forwarded()
+	}
+	if total != 2*rounds*len(hostnames) {
+		t.Errorf("%d stats forwarded, expected %d", total, 2*rounds*len(hostnames))
+	}
+	// The stats received once the forwarders are closed are dropped.
+	forwarders.addNodeStats("node0", &firmament.ResourceStats{ResourceId: "node0"})
+	if got := clients[0].forwarded() + clients[1].forwarded() + clients[2].forwarded(); got != total {
+		t.Errorf("%d stats forwarded after the forwarders were closed, expected %d", got, total)
+	}
+}
@@ -55,17 +55,20 @@ const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 type NodeStatsResponseType int32
 
 const (
-	NodeStatsResponseType_NODE_STATS_OK  NodeStatsResponseType = 0
-	NodeStatsResponseType_NODE_NOT_FOUND NodeStatsResponseType = 1
+	NodeStatsResponseType_NODE_STATS_OK       NodeStatsResponseType = 0
+	NodeStatsResponseType_NODE_NOT_FOUND      NodeStatsResponseType = 1
+	NodeStatsResponseType_NODE_STATS_REJECTED NodeStatsResponseType = 2
 )
 
 var NodeStatsResponseType_name = map[int32]string{
 	0: "NODE_STATS_OK",
 	1: "NODE_NOT_FOUND",
+	2: "NODE_STATS_REJECTED",
 }
 var NodeStatsResponseType_value = map[string]int32{
-	"NODE_STATS_OK":  0,
-	"NODE_NOT_FOUND": 1,
+	"NODE_STATS_OK":       0,
+	"NODE_NOT_FOUND":      1,
+	"NODE_STATS_REJECTED": 2,
 }
 
 func (x NodeStatsResponseType) String() string {
@@ -76,17 +79,20 @@ func (NodeStatsResponseType) EnumDescriptor() ([]byte, []int) { return fileDescr
 type PodStatsResponseType int32
 
 const (
-	PodStatsResponseType_POD_STATS_OK  PodStatsResponseType = 0
-	PodStatsResponseType_POD_NOT_FOUND PodStatsResponseType = 1
+	PodStatsResponseType_POD_STATS_OK       PodStatsResponseType = 0
+	PodStatsResponseType_POD_NOT_FOUND      PodStatsResponseType = 1
+	PodStatsResponseType_POD_STATS_REJECTED PodStatsResponseType = 2
 )
 
 var PodStatsResponseType_name = map[int32]string{
 	0: "POD_STATS_OK",
 	1: "POD_NOT_FOUND",
+	2: "POD_STATS_REJECTED",
 }
 var PodStatsResponseType_value = map[string]int32{
-	"POD_STATS_OK":  0,
-	"POD_NOT_FOUND": 1,
+	"POD_STATS_OK":       0,
+	"POD_NOT_FOUND":      1,
+	"POD_STATS_REJECTED": 2,
 }
 
 func (x PodStatsResponseType) String() string {
@@ -627,51 +633,51 @@ func init() { proto.RegisterFile("poseidonstats.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
 	// 744 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x74, 0x95, 0xdf, 0x4e, 0xe3, 0x46,
-	0x14, 0xc6, 0x31, 0x09, 0x21, 0x39, 0x21, 0xff, 0x86, 0x7f, 0x16, 0xa0, 0x36, 0xcd, 0x45, 0x1b,
-	0x51, 0x09, 0x10, 0x5c, 0x56, 0xaa, 0x84, 0x08, 0xdc, 0xb4, 0x4a, 0x22, 0xc7, 0xa8, 0x97, 0xd6,
-	0x60, 0x4e, 0x83, 0xdb, 0x4c, 0xec, 0xf5, 0x8c, 0x59, 0xd8, 0xbb, 0x7d, 0x8b, 0x7d, 0x92, 0x7d,
-	0xbe, 0x95, 0xcf, 0xc4, 0x13, 0xdb, 0xc0, 0x55, 0x34, 0xc7, 0xbf, 0xf3, 0xcd, 0xa7, 0xf9, 0x72,
-	0x66, 0x60, 0x37, 0x0a, 0x25, 0x06, 0x8f, 0xe1, 0x52, 0x2a, 0xae, 0xe4, 0x59, 0x14, 0x87, 0x2a,
-	0x64, 0x5b, 0xb4, 0x18, 0x7c, 0xad, 0x40, 0x63, 0x1c, 0x3e, 0xe2, 0x2c, 0x5d, 0xb1, 0x23, 0xa8,
-	0x3f, 0x85, 0x52, 0x2d, 0xb9, 0x40, 0xdb, 0xea, 0x5b, 0xc3, 0x86, 0x63, 0xd6, 0xec, 0x04, 0x1a,
-	0x2a, 0x10, 0x28, 0x15, 0x17, 0x91, 0xbd, 0xd9, 0xb7, 0x86, 0x55, 0x67, 0x5d, 0x60, 0xbf, 0x41,
-	0xc7, 0x8f, 0x12, 0x8f, 0x2f, 0x16, 0xa1, 0xcf, 0x15, 0x7f, 0x58, 0xa0, 0x5d, 0xe9, 0x5b, 0xc3,
-	0x8a, 0xd3, 0xf6, 0xa3, 0xe4, 0x7a, 0x5d, 0x65, 0xbf, 0xc0, 0x4e, 0x0a, 0xfa, 0x3c, 0xe2, 0x7e,
-	0xa0, 0x5e, 0xed, 0x2a, 0x51, 0x4d, 0x3f, 0x4a, 0x6e, 0x56, 0xa5, 0x4c, 0x2b, 0x46, 0x89, 0xf1,
-	0x33, 0x57, 0x41, 0xb8, 0xb4, 0xb7, 0xfa, 0xd6, 0xd0, 0x22, 0x2d, 0x67, 0x5d, 0xcd, 0xc0, 0x44,
-	0x05, 0x8b, 0xe0, 0x8b, 0x06, 0x6b, 0x06, 0xbc, 0x5f, 0x57, 0x53, 0x50, 0xa0, 0x28, 0xb8, 0xdb,
-	0xd6, 0xee, 0x04, 0x8a, 0x92, 0xbb, 0x14, 0x34, 0xee, 0xea, 0xda, 0x9d, 0x40, 0x91, 0x77, 0x97,
-	0x22, 0x79, 0x77, 0x0d, 0xbd, 0xa9, 0x40, 0x51, 0x72, 0x97, 0x82, 0x79, 0x77, 0x60, 0xc0, 0x9c,
-	0xbb, 0x01, 0x87, 0x9e, 0x89, 0xc0, 0x41, 0x19, 0x85, 0x4b, 0x89, 0xec, 0x02, 0xaa, 0xea, 0x35,
-	0xd2, 0x31, 0xb4, 0x2f, 0x4f, 0xce, 0x74, 0x76, 0x6f, 0x38, 0xf7, 0x35, 0x42, 0x87, 0xc8, 0x42,
-	0x78, 0x9b, 0xc5, 0xf0, 0x06, 0xdf, 0x6b, 0x50, 0x9f, 0x86, 0x8f, 0x3a, 0x65, 0x06, 0xd5, 0x5c,
-	0xc2, 0xd5, 0x2c, 0xdd, 0xf4, 0x57, 0x46, 0xdc, 0xcf, 0xba, 0xd7, 0x85, 0x82, 0x74, 0xa5, 0xf4,
-	0xbf, 0x38, 0x86, 0x46, 0x1a, 0xc2, 0x22, 0x10, 0x81, 0x5a, 0xa5, 0x59, 0xf7, 0xa3, 0xe4, 0xef,
-	0x74, 0xcd, 0x7e, 0x86, 0xa6, 0x8e, 0xf2, 0x53, 0x82, 0x52, 0x51, 0x8c, 0x15, 0x07, 0x28, 0x46,
-	0xaa, 0x64, 0xdd, 0x89, 0xe4, 0x73, 0xa4, 0xf0, 0x74, 0xf7, 0x7d, 0xba, 0x4e, 0x3f, 0xa6, 0x27,
-	0xa8, 0xa5, 0x75, 0x60, 0x75, 0x81, 0xc2, 0x48, 0xeb, 0x1c, 0xb4, 0xb4, 0x4e, 0x0a, 0x28, 0x03,
-	0x23, 0x4d, 0xe7, 0x4f, 0xd2, 0x0d, 0xd3, 0xad, 0xa5, 0x0f, 0x61, 0x9b, 0xba, 0xa5, 0xa4, 0x50,
-	0x2a, 0x4e, 0x2d, 0xed, 0x94, 0x32, 0xeb, 0xf2, 0xb9, 0xff, 0x84, 0x76, 0xd3, 0x74, 0xdd, 0xa4,
-	0x6b, 0xf6, 0xab, 0x8e, 0xf4, 0x73, 0x18, 0xff, 0x1f, 0x2c, 0xe7, 0x9e, 0x44, 0x65, 0xef, 0x10,
-	0xd2, 0x12, 0x28, 0xfe, 0xd1, 0xd5, 0x19, 0xaa, 0x8c, 0x8b, 0xf8, 0x1c, 0xbd, 0x7f, 0x79, 0xb2,
-	0x50, 0xd2, 0x6e, 0x19, 0x6e, 0xca, 0xe7, 0x78, 0x47, 0x45, 0x76, 0x0e, 0x7b, 0x25, 0xce, 0x8b,
-	0xb9, 0x42, 0xbb, 0x4d, 0xff, 0x93, 0x5e, 0x01, 0x76, 0xb8, 0x42, 0x76, 0x0a, 0x3d, 0xc1, 0xff,
-	0x0b, 0xe3, 0x82, 0x74, 0x87, 0xa4, 0x3b, 0xf4, 0x21, 0x27, 0x7e, 0x05, 0x07, 0x6f, 0x58, 0x2d,
-	0xdf, 0x25, 0xf9, 0xdd, 0x52, 0x03, 0x6d, 0xb0, 0x0f, 0xb5, 0x25, 0x2a, 0x2f, 0x7e, 0xb1, 0x7b,
-	0xa4, 0xba, 0xb5, 0x44, 0xe5, 0xbc, 0xb0, 0x01, 0xb4, 0x74, 0xd9, 0xc3, 0x38, 0x0e, 0x63, 0x69,
-	0x33, 0x3d, 0x18, 0xf4, 0xf5, 0x96, 0x4a, 0xec, 0x77, 0x60, 0x05, 0x46, 0xef, 0xb5, 0x4b, 0x7b,
-	0x75, 0x72, 0x20, 0xed, 0xf3, 0x13, 0x34, 0x57, 0x30, 0x51, 0x7b, 0x44, 0x35, 0x88, 0xca, 0xfb,
-	0x50, 0x2f, 0xf6, 0xbe, 0xf1, 0xe1, 0x1a, 0x1f, 0xca, 0xf8, 0x38, 0x30, 0x3e, 0xdc, 0x92, 0x0f,
-	0x55, 0xf4, 0x71, 0x68, 0x7c, 0xb8, 0xef, 0xf8, 0x50, 0x2b, 0x1f, 0xb6, 0xf1, 0xe1, 0x92, 0x8f,
-	0x41, 0x02, 0xdd, 0x6c, 0x6e, 0xcc, 0x68, 0x9e, 0x17, 0x46, 0xf3, 0x78, 0x35, 0x9a, 0x65, 0x2c,
-	0x37, 0x99, 0xd9, 0xc0, 0x6d, 0x7e, 0x34, 0x70, 0x95, 0xd2, 0xc0, 0x9d, 0xfe, 0x09, 0xfb, 0xef,
-	0x8e, 0x3a, 0xeb, 0x41, 0x6b, 0x3c, 0x19, 0xdd, 0x7a, 0x33, 0xf7, 0xda, 0x9d, 0x79, 0x93, 0xbf,
-	0xba, 0x1b, 0x8c, 0x41, 0x9b, 0x4a, 0xe3, 0x89, 0xeb, 0xdd, 0x4d, 0xee, 0xc7, 0xa3, 0xae, 0x75,
-	0xfa, 0x07, 0xec, 0xbd, 0xe7, 0x87, 0x75, 0x61, 0x67, 0x3a, 0x19, 0xe5, 0xbb, 0x7b, 0xd0, 0x4a,
-	0x2b, 0xb9, 0xe6, 0xcb, 0x6f, 0x16, 0xb4, 0xa6, 0xab, 0x27, 0x43, 0xdf, 0x18, 0x23, 0xe8, 0x3a,
-	0xe8, 0x63, 0xf0, 0x8c, 0xeb, 0xb7, 0xa2, 0x5b, 0xbe, 0x92, 0x8e, 0xec, 0x8f, 0x2e, 0xa9, 0xc1,
-	0xc6, 0xd0, 0xba, 0xb0, 0xd8, 0x35, 0x74, 0x56, 0x2a, 0xe6, 0x2a, 0xea, 0x94, 0x0e, 0xef, 0xe8,
-	0xf0, 0x83, 0xd3, 0xd4, 0x12, 0x0f, 0x35, 0x7a, 0xbc, 0xae, 0x7e, 0x04, 0x00, 0x00, 0xff, 0xff,
-	0x6f, 0x9f, 0x80, 0xbe, 0xd3, 0x06, 0x00, 0x00,
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x03, 0x75, 0x55, 0xdb, 0x52, 0xdb, 0x30,
+	0x14, 0xc4, 0x71, 0x08, 0xf1, 0x09, 0x49, 0x1c, 0x85, 0x8b, 0x07, 0x98, 0x5e, 0xf2, 0xd0, 0x32,
+	0x74, 0x06, 0x18, 0xf8, 0x02, 0x86, 0x84, 0x87, 0xb6, 0x93, 0x30, 0x8e, 0x19, 0x1e, 0x3d, 0x26,
+	0xa8, 0xe0, 0x36, 0x8e, 0x5d, 0x5b, 0xa1, 0xd0, 0xb7, 0xfe, 0x45, 0xbf, 0xa4, 0xdf, 0x57, 0xe9,
+	0xc8, 0x96, 0x2f, 0xc0, 0x53, 0x46, 0xab, 0x3d, 0x7b, 0x36, 0x5a, 0x1d, 0x19, 0xfa, 0x51, 0x98,
+	0x50, 0xff, 0x36, 0x5c, 0x24, 0xcc, 0x63, 0xc9, 0x61, 0x14, 0x87, 0x2c, 0x24, 0xab, 0xb8, 0x18,
+	0xfc, 0xd1, 0xc1, 0x18, 0x87, 0xb7, 0x74, 0x2a, 0x56, 0x64, 0x07, 0x9a, 0xf7, 0x61, 0xc2, 0x16,
+	0x5e, 0x40, 0x2d, 0xed, 0x9d, 0xb6, 0x6f, 0xd8, 0x6a, 0x4d, 0xf6, 0xc0, 0x60, 0x7e, 0x40, 0x79,
+	0x59, 0x10, 0x59, 0x35, 0xbe, 0x59, 0xb7, 0x73, 0x80, 0x7c, 0x84, 0xee, 0x2c, 0x5a, 0xba, 0xde,
+	0x7c, 0x1e, 0xce, 0x3c, 0xe6, 0xdd, 0xcc, 0xa9, 0xa5, 0x73, 0x8e, 0x6e, 0x77, 0x38, 0x7c, 0x96,
+	0xa3, 0xe4, 0x3d, 0xac, 0x0b, 0xe2, 0xcc, 0x8b, 0xbc, 0x99, 0xcf, 0x9e, 0xac, 0x3a, 0xb2, 0x5a,
+	0x1c, 0x3b, 0x4f, 0xa1, 0x4c, 0x2b, 0xa6, 0x09, 0x8d, 0x1f, 0x3c, 0xe6, 0x87, 0x0b, 0x6b, 0x95,
+	0xb3, 0x34, 0xd4, 0xb2, 0x73, 0x34, 0x23, 0x2e, 0x99, 0x3f, 0xf7, 0x7f, 0x4b, 0x62, 0x43, 0x11,
+	0xaf, 0x72, 0x54, 0x10, 0x03, 0x1a, 0x94, 0xdc, 0xad, 0x49, 0x77, 0x1c, 0xae, 0xb8, 0x13, 0x44,
+	0xe5, 0xae, 0x29, 0xdd, 0x71, 0xac, 0xe8, 0x4e, 0x50, 0x8a, 0xee, 0x0c, 0xd9, 0x94, 0xc3, 0x15,
+	0x77, 0x82, 0x58, 0x74, 0x07, 0x8a, 0x58, 0x70, 0x37, 0xf0, 0xa0, 0xa7, 0x22, 0xe0, 0x02, 0x11,
+	0x0f, 0x8a, 0x92, 0x63, 0xa8, 0xb3, 0xa7, 0x48, 0xc6, 0xd0, 0x39, 0xd9, 0x3b, 0x94, 0xd9, 0x3d,
+	0xe3, 0x39, 0x9c, 0x63, 0x23, 0xb3, 0x14, 0x5e, 0xad, 0x1c, 0xde, 0xe0, 0x5f, 0x03, 0x9a, 0x97,
+	0xe1, 0xad, 0x4c, 0x99, 0x40, 0xbd, 0x90, 0x70, 0x3d, 0x4b, 0x57, 0xfc, 0x26, 0xfc, 0x4f, 0x66,
+	0xd5, 0x39, 0x50, 0x92, 0xd6, 0x2b, 0xf7, 0x62, 0x17, 0x0c, 0x11, 0xc2, 0xdc, 0x0f, 0x7c, 0x96,
+	0xa6, 0xd9, 0xe4, 0xc0, 0x57, 0xb1, 0x26, 0x6f, 0xa1, 0x25, 0xa3, 0xfc, 0xb9, 0xe4, 0x17, 0x05,
+	0x63, 0xd4, 0x6d, 0xc0, 0x18, 0x11, 0xc9, 0xaa, 0x97, 0x89, 0x77, 0x47, 0x31, 0x3c, 0x59, 0x7d,
+	0x25, 0xd6, 0x62, 0x53, 0x9c, 0xa0, 0x94, 0x96, 0x81, 0x35, 0x39, 0xa0, 0xa4, 0x65, 0x0e, 0x52,
+	0x5a, 0x26, 0x05, 0x98, 0x81, 0x92, 0xc6, 0xf3, 0x47, 0x69, 0x43, 0x55, 0x4b, 0xe9, 0x6d, 0x58,
+	0xc3, 0xea, 0x24, 0xc1, 0x50, 0x74, 0xbb, 0x21, 0x2a, 0x93, 0x24, 0xab, 0x9a, 0x79, 0xb3, 0x7b,
+	0x6a, 0xb5, 0x54, 0xd5, 0xb9, 0x58, 0x93, 0x0f, 0x32, 0xd2, 0x5f, 0x61, 0xfc, 0xc3, 0x5f, 0xdc,
+	0xb9, 0x09, 0x65, 0xd6, 0x3a, 0x52, 0xda, 0x1c, 0xbe, 0x96, 0xe8, 0x94, 0xb2, 0x8c, 0x17, 0xf1,
+	0x4e, 0xee, 0x37, 0x6f, 0x39, 0x67, 0x89, 0xd5, 0x56, 0xbc, 0x4b, 0x8e, 0x5e, 0x20, 0x48, 0x8e,
+	0x60, 0xa3, 0xc2, 0x73, 0x63, 0x8f, 0x51, 0xab, 0x83, 0xf7, 0xa4, 0x57, 0x22, 0xdb, 0x7c, 0x83,
+	0x1c, 0x40, 0x2f, 0xf0, 0xbe, 0x87, 0x71, 0x49, 0xba, 0x8b, 0xd2, 0x5d, 0xdc, 0x28, 0x88, 0x9f,
+	0xc2, 0xd6, 0x33, 0xae, 0x94, 0x37, 0x51, 0xbe, 0x5f, 0x29, 0xc0, 0x06, 0x9b, 0xd0, 0x58, 0x50,
+	0xe6, 0xc6, 0x8f, 0x56, 0x0f, 0x55, 0x57, 0xf9, 0xca, 0x7e, 0x24, 0x03, 0x68, 0x4b, 0xd8, 0xa5,
+	0x71, 0x1c, 0xc6, 0x89, 0x45, 0xe4, 0x60, 0xe0, 0xee, 0x08, 0x21, 0xf2, 0x09, 0x48, 0x89, 0x23,
+	0x7b, 0xf5, 0xb1, 0x57, 0xb7, 0x40, 0xc4, 0x3e, 0x6f, 0xa0, 0x95, 0x92, 0x91, 0xb5, 0x81, 0x2c,
+	0x03, 0x59, 0x45, 0x1f, 0xec, 0xd1, 0xda, 0x54, 0x3e, 0x1c, 0xe5, 0x83, 0x29, 0x1f, 0x5b, 0xca,
+	0x87, 0x53, 0xf1, 0xc1, 0xca, 0x3e, 0xb6, 0x95, 0x0f, 0xe7, 0x05, 0x1f, 0x2c, 0xf5, 0x61, 0x29,
+	0x1f, 0x0e, 0xfa, 0x18, 0x2c, 0xc1, 0xcc, 0xe6, 0x46, 0x8d, 0xe6, 0x51, 0x69, 0x34, 0x77, 0xd3,
+	0xd1, 0xac, 0xd2, 0x0a, 0x93, 0x99, 0x0d, 0x5c, 0xed, 0xb5, 0x81, 0xd3, 0x2b, 0x03, 0x77, 0x70,
+	0x0d, 0x9b, 0x2f, 0x8e, 0x3a, 0xe9, 0x41, 0x7b, 0x3c, 0x19, 0x8e, 0xdc, 0xa9, 0x73, 0xe6, 0x4c,
+	0xdd, 0xc9, 0x17, 0x73, 0x85, 0xab, 0x77, 0x10, 0x1a, 0x4f, 0x1c, 0xf7, 0x62, 0x72, 0x35, 0x1e,
+	0x9a, 0x1a, 0xbf, 0xde, 0xfd, 0x02, 0xcd, 0x1e, 0x7d, 0x1e, 0x9d, 0x3b, 0xa3, 0xa1, 0x59, 0x3b,
+	0x98, 0xc2, 0xc6, 0x4b, 0x46, 0x89, 0x09, 0xeb, 0x97, 0x93, 0x61, 0x51, 0x96, 0x77, 0x12, 0x48,
+	0x51, 0x75, 0x0b, 0x48, 0x4e, 0xca, 0x45, 0x4f, 0xfe, 0x6a, 0x9c, 0x9b, 0x7e, 0x63, 0xe4, 0x13,
+	0x33, 0x04, 0xd3, 0xa6, 0x33, 0xea, 0x3f, 0xd0, 0xfc, 0xe3, 0x62, 0x56, 0xdf, 0xb0, 0x1d, 0xeb,
+	0xb5, 0x57, 0x6d, 0xb0, 0xb2, 0xaf, 0x1d, 0x6b, 0xe4, 0x0c, 0xba, 0xa9, 0x8a, 0x7a, 0xbb, 0xba,
+	0x95, 0xd3, 0xde, 0xd9, 0x7e, 0xe5, 0xf8, 0xa5, 0xc4, 0x4d, 0x03, 0xbf, 0x76, 0xa7, 0xff, 0x01,
+	0x5e, 0x5a, 0xb1, 0x29, 0x04, 0x07, 0x00, 0x00,
 }
@@ -17,17 +17,98 @@ limitations under the License.
 package stats
 
 import (
+	"fmt"
 	"io"
 	"net"
+	"time"
 
 	"github.com/golang/glog"
 	"github.com/kubernetes-sigs/poseidon/pkg/firmament"
 	"github.com/kubernetes-sigs/poseidon/pkg/k8sclient"
+	"github.com/kubernetes-sigs/poseidon/pkg/metrics"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"k8s.io/client-go/kubernetes"
 )
 
 type poseidonStatsServer struct {
 	firmamentClient firmament.FirmamentSchedulerClient
+	// state resolves the reported nodes and pods.
+	state k8sclient.StateStore
+	// forwarders forward the stats to Firmament if set, otherwise the stats
+	// are forwarded by the receiving stream through firmamentClient.
+	forwarders *statsForwarders
+	// validate enables the validation of the stats.
+	validate bool
+	// authenticator authenticates the senders if set.
+	authenticator authenticator
+	// trustedIdentities may send the stats of any node.
+	trustedIdentities map[string]bool
+}
+
+// ServerOptions configures the stats server.
+type ServerOptions struct {
+	// Validate rejects the inconsistent stats, and the stats of pods not
+	// reported by the node they are bound to.
+	Validate bool
+	// TLSCertFile and TLSKeyFile enable TLS. ClientCAFile verifies the
+	// client certificates.
+	TLSCertFile  string
+	TLSKeyFile   string
+	ClientCAFile string
+	// Authentication is the way the senders are authenticated. The nodes
+	// may only send their own stats.
+	Authentication Authentication
+	// TrustedIdentities may send the stats of any node.
+	TrustedIdentities []string
+	// KubeConfig is used to review the bearer tokens.
+	KubeConfig string
+	// Shards routes the stats to the shards of a sharded Firmament. The
+	// forwarders share it instead of connecting to Firmament.
+	Shards *firmament.ShardedClient
+}
+
+// nodeStatsRejected returns true if the node stats are not sent by the node
+// or a trusted identity, or are invalid.
+func (s *poseidonStatsServer) nodeStatsRejected(sender *sender, nodeStats *NodeStats) bool {
+	if !sender.mayReport(nodeStats.GetHostname()) {
+		rejectStats("node", nodeStats.GetHostname(), "sender", fmt.Errorf("sent by %s", sender.identity))
+		return true
+	}
+	if !s.validate {
+		return false
+	}
+	if err := validateNodeStats(nodeStats, time.Now()); err != nil {
+		rejectStats("node", nodeStats.GetHostname(), "invalid", err)
+		return true
+	}
+	return false
+}
+
+// podStatsRejected returns true if the pod stats are not sent by the node of
+// the pod or a trusted identity, or are invalid.
+func (s *poseidonStatsServer) podStatsRejected(sender *sender, podStats *PodStats) bool {
+	podName := podStats.GetNamespace() + "/" + podStats.GetName()
+	if !sender.mayReport(podStats.GetHostname()) {
+		rejectStats("pod", podName, "sender", fmt.Errorf("sent by %s", sender.identity))
+		return true
+	}
+	// The node of the pod is checked if the senders are authenticated, so
+	// that the nodes only report their own pods.
+	if !s.validate && s.authenticator == nil {
+		return false
+	}
+	if err := validatePodStats(podStats, k8sclient.PodNodeName); err != nil {
+		rejectStats("pod", podName, "invalid", err)
+		return true
+	}
+	return false
+}
+
+// rejectStats counts and logs the rejected stats of a node or pod.
+func rejectStats(kind, name, reason string, err error) {
+	metrics.RejectedStats.Inc(kind, reason)
+	glog.V(2).Infof("Rejecting the stats of %s %s: %v", kind, name, err)
 }
 
 func convertPodStatsToTaskStats(podStats *PodStats) *firmament.TaskStats {
@@ -75,6 +156,11 @@ func convertNodeStatsToResourceStats(nodeStats *NodeStats) *firmament.ResourceSt
 }
 
 func (s *poseidonStatsServer) ReceiveNodeStats(stream PoseidonStats_ReceiveNodeStatsServer) error {
+	sender, err := s.authenticateSender(stream.Context())
+	if err != nil {
+		glog.Warningf("Refusing node stats stream: %v", err)
+		return err
+	}
 	for {
 		nodeStats, err := stream.Recv()
 		if err == io.EOF {
@@ -86,9 +172,7 @@ func (s *poseidonStatsServer) ReceiveNodeStats(stream PoseidonStats_ReceiveNodeS
 			return err
 		}
 		resourceStats := convertNodeStatsToResourceStats(nodeStats)
-		k8sclient.NodeMux.RLock()
-		rtnd, ok := k8sclient.NodeToRTND[nodeStats.GetHostname()]
-		k8sclient.NodeMux.RUnlock()
+		rtnd, ok := s.state.NodeTopology(nodeStats.GetHostname())
 		if !ok {
 			sendErr := stream.Send(&NodeStatsResponse{
 				Type:     NodeStatsResponseType_NODE_NOT_FOUND,
@@ -100,8 +184,26 @@ func (s *poseidonStatsServer) ReceiveNodeStats(stream PoseidonStats_ReceiveNodeS
 			}
 			continue
 		}
+		if s.nodeStatsRejected(sender, nodeStats) {
+			sendErr := stream.Send(&NodeStatsResponse{
+				Type:     NodeStatsResponseType_NODE_STATS_REJECTED,
+				Hostname: nodeStats.GetHostname(),
+			})
+			if sendErr != nil {
+				glog.Error("Stream send error in node stats receive ", sendErr)
+				return sendErr
+			}
+			continue
+		}
 		resourceStats.ResourceId = rtnd.GetResourceDesc().GetUuid()
-		firmament.AddNodeStats(s.firmamentClient, resourceStats)
+		k8sclient.RecordNodeUsage(nodeStats.GetHostname(), nodeStats.GetCpuUtilization(), nodeStats.GetMemUtilization())
+		if s.forwarders != nil {
+			s.forwarders.addNodeStats(nodeStats.GetHostname(), resourceStats)
+		} else {
+			if err := firmament.AddNodeStats(s.firmamentClient, resourceStats); err != nil {
+				glog.Warningf("Failed to forward the stats of node %s: %v", nodeStats.GetHostname(), err)
+			}
+		}
 		sendErr := stream.Send(&NodeStatsResponse{
 			Type:     NodeStatsResponseType_NODE_STATS_OK,
 			Hostname: nodeStats.GetHostname(),
@@ -114,6 +216,11 @@ func (s *poseidonStatsServer) ReceiveNodeStats(stream PoseidonStats_ReceiveNodeS
 }
 
 func (s *poseidonStatsServer) ReceivePodStats(stream PoseidonStats_ReceivePodStatsServer) error {
+	sender, err := s.authenticateSender(stream.Context())
+	if err != nil {
+		glog.Warningf("Refusing pod stats stream: %v", err)
+		return err
+	}
 	for {
 		podStats, err := stream.Recv()
 		if err == io.EOF {
@@ -129,9 +236,7 @@ func (s *poseidonStatsServer) ReceivePodStats(stream PoseidonStats_ReceivePodSta
 			Name:      podStats.Name,
 			Namespace: podStats.Namespace,
 		}
-		k8sclient.PodMux.RLock()
-		td, ok := k8sclient.PodToTD[podIdentifier]
-		k8sclient.PodMux.RUnlock()
+		td, ok := s.state.TaskOfPod(podIdentifier)
 		if !ok {
 			sendErr := stream.Send(&PodStatsResponse{
 				Type:      PodStatsResponseType_POD_NOT_FOUND,
@@ -144,8 +249,27 @@ func (s *poseidonStatsServer) ReceivePodStats(stream PoseidonStats_ReceivePodSta
 			}
 			continue
 		}
+		if s.podStatsRejected(sender, podStats) {
+			sendErr := stream.Send(&PodStatsResponse{
+				Type:      PodStatsResponseType_POD_STATS_REJECTED,
+				Name:      podStats.GetName(),
+				Namespace: podStats.GetNamespace(),
+			})
+			if sendErr != nil {
+				glog.Error("Stream send error in pod stats receive ", sendErr)
+				return sendErr
+			}
+			continue
+		}
 		taskStats.TaskId = td.GetUid()
-		firmament.AddTaskStats(s.firmamentClient, taskStats)
+		k8sclient.RecordPodUsage(podIdentifier, podStats.GetCpuUsage(), podStats.GetMemUsage())
+		if s.forwarders != nil {
+			s.forwarders.addTaskStats(podIdentifier.UniqueName(), taskStats)
+		} else {
+			if err := firmament.AddTaskStats(s.firmamentClient, taskStats); err != nil {
+				glog.Warningf("Failed to forward the stats of pod %v: %v", podIdentifier, err)
+			}
+		}
 		sendErr := stream.Send(&PodStatsResponse{
 			Type:      PodStatsResponseType_POD_STATS_OK,
 			Name:      podStats.GetName(),
@@ -159,20 +283,95 @@ func (s *poseidonStatsServer) ReceivePodStats(stream PoseidonStats_ReceivePodSta
 }
 
 // StartgRPCStatsServer starts a gRPC server to serve poseidon status.
-// Currently, it receives node and pod status.
-func StartgRPCStatsServer(statsServerAddress, firmamentAddress string) {
+// Currently, it receives node and pod status. The server listens on each of
+// the given addresses. With several addresses, the stats are sharded by node
+// and pod over as many forwarders within this process, each forwarding the
+// stats it owns through its own connection to Firmament.
+func StartgRPCStatsServer(statsServerAddresses []string, firmamentAddress string, options ServerOptions) {
 	glog.Info("Starting stats server...")
-	listen, err := net.Listen("tcp", statsServerAddress)
-	if err != nil {
-		glog.Fatalf("failed to listen: %v", err)
+	if len(statsServerAddresses) == 0 {
+		glog.Fatal("No stats server address to listen on")
 	}
-	grpcServer := grpc.NewServer()
-	fc, conn, err := firmament.New(firmamentAddress)
-	if err != nil {
-		glog.Fatalln("Unable to initialze Firmament client", err)
+	var listeners []net.Listener
+	for _, address := range statsServerAddresses {
+		listen, err := net.Listen("tcp", address)
+		if err != nil {
+			glog.Fatalf("failed to listen: %v", err)
+		}
+		listeners = append(listeners, listen)
+	}
+	var serverOptions []grpc.ServerOption
+	if options.TLSCertFile != "" {
+		tlsConfig, err := serverTLSConfig(options.TLSCertFile, options.TLSKeyFile, options.ClientCAFile)
+		if err != nil {
+			glog.Fatalf("Invalid stats server TLS config: %v", err)
+		}
+		serverOptions = append(serverOptions, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	var clients []firmament.FirmamentSchedulerClient
+	for range listeners {
+		if options.Shards != nil {
+			clients = append(clients, options.Shards)
+			continue
+		}
+		client, conn, err := firmament.New(firmamentAddress)
+		if err != nil {
+			glog.Fatalln("Unable to initialze Firmament client", err)
 
+		}
+		defer conn.Close()
+		clients = append(clients, client)
+	}
+	server := &poseidonStatsServer{firmamentClient: clients[0], state: k8sclient.State(), validate: options.Validate}
+	switch options.Authentication {
+	case NoAuthentication:
+	case TLSAuthentication:
+		if options.ClientCAFile == "" {
+			glog.Fatal("Authenticating the stats senders by TLS requires a client CA")
+		}
+		server.authenticator = tlsAuthenticator{}
+	case TokenAuthentication:
+		config, err := k8sclient.GetClientConfig(options.KubeConfig)
+		if err != nil {
+			glog.Fatalf("Failed to build the token review client: %v", err)
+		}
+		client, err := kubernetes.NewForConfig(config)
+		if err != nil {
+			glog.Fatalf("Failed to build the token review client: %v", err)
+		}
+		server.authenticator = &tokenAuthenticator{client: client}
+	default:
+		glog.Fatalf("Unknown stats authentication %q", options.Authentication)
+	}
+	if server.authenticator != nil {
+		server.trustedIdentities = make(map[string]bool)
+		for _, identity := range options.TrustedIdentities {
+			server.trustedIdentities[identity] = true
+		}
+		glog.Infof("Authenticating the stats senders by %s, trusted identities: %v", options.Authentication, options.TrustedIdentities)
+	}
+	if len(listeners) > 1 {
+		server.forwarders = newStatsForwarders(clients)
+		glog.Infof("Stats sharded over %d forwarders, listening on %v", len(listeners), statsServerAddresses)
+	}
+	var grpcServers []*grpc.Server
+	served := make(chan error, len(listeners))
+	for _, listen := range listeners {
+		grpcServer := grpc.NewServer(serverOptions...)
+		RegisterPoseidonStatsServer(grpcServer, server)
+		grpcServers = append(grpcServers, grpcServer)
+		go func(grpcServer *grpc.Server, listen net.Listener) {
+			served <- grpcServer.Serve(listen)
+		}(grpcServer, listen)
+	}
+	err := <-served
+	glog.Errorf("Stats server stopped: %v", err)
+	// The forwarders forward the stats they received before the connections
+	// to Firmament are closed.
+	for _, grpcServer := range grpcServers {
+		grpcServer.Stop()
+	}
+	if server.forwarders != nil {
+		server.forwarders.close()
 	}
-	defer conn.Close()
-	RegisterPoseidonStatsServer(grpcServer, &poseidonStatsServer{firmamentClient: fc})
-	grpcServer.Serve(listen)
 }
@@ -0,0 +1,66 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/k8sclient"
+)
+
+// maxClockSkew bounds how far in the future the timestamp of node stats
+// may be.
+const maxClockSkew = time.Minute
+
+// validateNodeStats returns an error if the node stats are inconsistent,
+// e.g. a usage above the capacity of the node.
+func validateNodeStats(nodeStats *NodeStats, now time.Time) error {
+	switch {
+	case nodeStats.GetCpuCapacity() < 0 || nodeStats.GetCpuAllocatable() < 0 ||
+		nodeStats.GetMemCapacity() < 0 || nodeStats.GetMemAllocatable() < 0:
+		return fmt.Errorf("negative capacity")
+	case nodeStats.GetCpuAllocatable() > nodeStats.GetCpuCapacity() || nodeStats.GetMemAllocatable() > nodeStats.GetMemCapacity():
+		return fmt.Errorf("allocatable above capacity")
+	case nodeStats.GetCpuUtilization() < 0 || nodeStats.GetCpuReservation() < 0 ||
+		nodeStats.GetMemUtilization() < 0 || nodeStats.GetMemReservation() < 0:
+		return fmt.Errorf("negative usage")
+	case nodeStats.GetCpuUtilization() > 1 || nodeStats.GetMemUtilization() > 1:
+		// The utilizations are fractions of the capacity.
+		return fmt.Errorf("utilization above capacity")
+	case nodeStats.GetTimestamp() > uint64(now.Add(maxClockSkew).UnixNano()):
+		return fmt.Errorf("timestamp in the future")
+	}
+	return nil
+}
+
+// validatePodStats returns an error if the pod stats are inconsistent, or
+// are not reported by the node the pod is bound to according to nodeOf.
+func validatePodStats(podStats *PodStats, nodeOf func(k8sclient.PodIdentifier) (string, bool)) error {
+	if podStats.GetCpuUsage() < 0 || podStats.GetMemUsage() < 0 || podStats.GetMemRss() < 0 ||
+		podStats.GetMemCache() < 0 || podStats.GetMemWorkingSet() < 0 || podStats.GetNetRx() < 0 || podStats.GetNetTx() < 0 {
+		return fmt.Errorf("negative usage")
+	}
+	nodeName, ok := nodeOf(k8sclient.PodIdentifier{Name: podStats.GetName(), Namespace: podStats.GetNamespace()})
+	if !ok {
+		return fmt.Errorf("pod not bound")
+	}
+	if nodeName != podStats.GetHostname() {
+		return fmt.Errorf("pod bound to node %s", nodeName)
+	}
+	return nil
+}
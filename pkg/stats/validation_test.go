@@ -0,0 +1,85 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kubernetes-sigs/poseidon/pkg/k8sclient"
+)
+
+func TestValidateNodeStats(t *testing.T) {
+	now := time.Now()
+	valid := func() *NodeStats {
+		return &NodeStats{
+			Hostname:       "node0",
+			Timestamp:      uint64(now.UnixNano()),
+			CpuAllocatable: 7000,
+			CpuCapacity:    10000,
+			CpuReservation: 0.5,
+			CpuUtilization: 0.2,
+			MemAllocatable: 700000,
+			MemCapacity:    1000000,
+			MemReservation: 0.4,
+			MemUtilization: 0.1,
+		}
+	}
+	var testData = []struct {
+		name    string
+		mutate  func(*NodeStats)
+		invalid bool
+	}{
+		{name: "valid", mutate: func(*NodeStats) {}},
+		{name: "negative capacity", mutate: func(s *NodeStats) { s.MemCapacity = -1 }, invalid: true},
+		{name: "allocatable above capacity", mutate: func(s *NodeStats) { s.CpuAllocatable = 20000 }, invalid: true},
+		{name: "negative usage", mutate: func(s *NodeStats) { s.CpuReservation = -0.1 }, invalid: true},
+		{name: "utilization above capacity", mutate: func(s *NodeStats) { s.MemUtilization = 1.5 }, invalid: true},
+		{name: "timestamp in the future", mutate: func(s *NodeStats) { s.Timestamp = uint64(now.Add(time.Hour).UnixNano()) }, invalid: true},
+	}
+	for _, tc := range testData {
+		nodeStats := valid()
+		tc.mutate(nodeStats)
+		if err := validateNodeStats(nodeStats, now); (err != nil) != tc.invalid {
+			t.Errorf("%s: validateNodeStats() = %v, expected invalid %v", tc.name, err, tc.invalid)
+		}
+	}
+}
+
+func TestValidatePodStats(t *testing.T) {
+	nodeOf := func(podIdentifier k8sclient.PodIdentifier) (string, bool) {
+		if podIdentifier.Name == "bound" {
+			return "node0", true
+		}
+		return "", false
+	}
+	var testData = []struct {
+		name    string
+		stats   *PodStats
+		invalid bool
+	}{
+		{name: "valid", stats: &PodStats{Name: "bound", Namespace: "ns", Hostname: "node0", CpuUsage: 10}},
+		{name: "other node", stats: &PodStats{Name: "bound", Namespace: "ns", Hostname: "node1"}, invalid: true},
+		{name: "not bound", stats: &PodStats{Name: "pending", Namespace: "ns", Hostname: "node0"}, invalid: true},
+		{name: "negative usage", stats: &PodStats{Name: "bound", Namespace: "ns", Hostname: "node0", MemUsage: -1}, invalid: true},
+	}
+	for _, tc := range testData {
+		if err := validatePodStats(tc.stats, nodeOf); (err != nil) != tc.invalid {
+			t.Errorf("%s: validatePodStats() = %v, expected invalid %v", tc.name, err, tc.invalid)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package version
+
+// The build information of the binary, set by hack/lib/version.sh through
+// -ldflags. The in-tree values are those of the ad-hoc builds, e.g. go build.
+var (
+	gitMajor     string = ""
+	gitMinor     string = ""
+	gitVersion   string = "v0.0.0-master"
+	gitCommit    string = ""
+	gitTreeState string = ""
+	buildDate    string = "1970-01-01T00:00:00Z"
+)